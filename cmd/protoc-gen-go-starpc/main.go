@@ -5,34 +5,59 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"runtime/debug"
 	"strconv"
 	"strings"
 
 	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
 const SRPCPackage = "github.com/aperturerobotics/starpc/srpc"
 
+// deprecationComment is the comment for methods marked deprecated in the proto.
+const deprecationComment = "// Deprecated: Do not use."
+
+var (
+	flags = flag.FlagSet{}
+	// typePrefix is the prefix for generated type names.
+	typePrefix = flags.String("type_prefix", "SRPC", "prefix for generated type names")
+	// typeSuffix is the suffix added after the service name in generated type names.
+	typeSuffix = flags.String("type_suffix", "", "suffix added after the service name in generated type names")
+	// serverStreamCloseSend controls calling CloseSend in server-streaming clients.
+	serverStreamCloseSend = flags.Bool(
+		"server_stream_close_send",
+		true,
+		"call CloseSend after opening server-streaming calls (if false the caller must call CloseSend)",
+	)
+)
+
 func main() {
-	opts := protogen.Options{}
+	opts := protogen.Options{ParamFunc: flags.Set}
 	opts.Run(func(plugin *protogen.Plugin) error {
 		for _, f := range plugin.Files {
 			if !f.Generate || len(f.Services) == 0 {
 				continue
 			}
-			generatePluginFile(plugin, f)
+			generatePluginFile(plugin, f, *typePrefix, *typeSuffix, *serverStreamCloseSend)
 		}
 		plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
 		return nil
 	})
 }
 
-func generatePluginFile(plugin *protogen.Plugin, file *protogen.File) {
+func generatePluginFile(plugin *protogen.Plugin, file *protogen.File, prefix, suffix string, serverStreamCloseSend bool) {
 	gf := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_srpc.pb.go", file.GoImportPath)
-	s := &srpc{gf, file}
+	s := &srpc{
+		GeneratedFile:         gf,
+		file:                  file,
+		prefix:                prefix,
+		suffix:                suffix,
+		serverStreamCloseSend: serverStreamCloseSend,
+	}
 
 	s.P("// Code generated by protoc-gen-srpc. DO NOT EDIT.")
 	if bi, ok := debug.ReadBuildInfo(); ok {
@@ -51,12 +76,45 @@ func generatePluginFile(plugin *protogen.Plugin, file *protogen.File) {
 type srpc struct {
 	*protogen.GeneratedFile
 	file *protogen.File
+	// prefix is the prefix for generated type names.
+	prefix string
+	// suffix is the suffix added after the service name.
+	suffix string
+	// serverStreamCloseSend calls CloseSend after opening a server-streaming call.
+	serverStreamCloseSend bool
+}
+
+// Exported returns an exported identifier with the configured prefix & suffix.
+func (s *srpc) Exported(name, kind string) string {
+	return s.prefix + name + s.suffix + kind
+}
+
+// Unexported returns an unexported identifier with the configured prefix & suffix.
+func (s *srpc) Unexported(name, kind string) string {
+	if s.prefix != "" {
+		return strings.ToLower(s.prefix) + name + s.suffix + kind
+	}
+	return strings.ToLower(name[:1]) + name[1:] + s.suffix + kind
+}
+
+// StreamName returns the base name for a method stream type.
+func (s *srpc) StreamName(method *protogen.Method) string {
+	return strings.ReplaceAll(method.Parent.GoName, "_", "__") + "_" +
+		strings.ReplaceAll(method.GoName, "_", "__")
 }
 
 func (s *srpc) Ident(path, ident string) string {
 	return s.QualifiedGoIdent(protogen.GoImportPath(path).Ident(ident))
 }
 
+// asMessage wraps the message variable with srpc.AsMessage.
+//
+// Selects the encoding of the message when it is sent or received: messages
+// without the vtprotobuf functions are encoded with the proto package.
+func (s *srpc) asMessage(name string) string {
+	return s.Ident(SRPCPackage, "AsMessage") + "(" + name + ")"
+}
+
 // GetServiceID returns the service id for the srpc.
 func (s *srpc) GetServiceID(p *protogen.Service) (service string) {
 	return string(p.Desc.FullName())
@@ -67,6 +125,15 @@ func (s *srpc) GetServiceAndMethodID(p *protogen.Method) (service, method string
 	return string(p.Parent.Desc.FullName()), string(p.Desc.Name())
 }
 
+// IsDeprecated checks if the method or its service is marked deprecated.
+func (s *srpc) IsDeprecated(p *protogen.Method) bool {
+	if opts, ok := p.Desc.Options().(*descriptorpb.MethodOptions); ok && opts.GetDeprecated() {
+		return true
+	}
+	opts, ok := p.Parent.Desc.Options().(*descriptorpb.ServiceOptions)
+	return ok && opts.GetDeprecated()
+}
+
 /*
 func (s *srpc) ServiceMethodString(method *protogen.Method) string {
 	return strconv.Quote(fmt.Sprintf("/%s/%s", method.Parent.Desc.FullName(), method.Desc.Name()))
@@ -82,59 +149,51 @@ func (s *srpc) OutputType(method *protogen.Method) string {
 }
 
 func (s *srpc) ClientIface(service *protogen.Service) string {
-	return "SRPC" + service.GoName + "Client"
+	return s.Exported(service.GoName, "Client")
 }
 
 func (s *srpc) ClientImpl(service *protogen.Service) string {
-	return "srpc" + service.GoName + "Client"
+	return s.Unexported(service.GoName, "Client")
 }
 
 func (s *srpc) ServerIface(service *protogen.Service) string {
-	return "SRPC" + service.GoName + "Server"
+	return s.Exported(service.GoName, "Server")
 }
 
 func (s *srpc) ServerServiceID(service *protogen.Service) string {
-	return "SRPC" + service.GoName + "ServiceID"
+	return s.Exported(service.GoName, "ServiceID")
 }
 
 func (s *srpc) ServerImpl(service *protogen.Service) string {
-	return "srpc" + service.GoName + "Server"
+	return s.Unexported(service.GoName, "Server")
 }
 
 func (s *srpc) ServerUnimpl(service *protogen.Service) string {
-	return "SRPC" + service.GoName + "UnimplementedServer"
+	return s.Exported(service.GoName, "UnimplementedServer")
 }
 
 func (s *srpc) ServerHandler(service *protogen.Service) string {
-	return "SRPC" + service.GoName + "Handler"
+	return s.Exported(service.GoName, "Handler")
+}
+
+func (s *srpc) ServerRegister(service *protogen.Service) string {
+	return s.prefix + "Register" + service.GoName + s.suffix
 }
 
 func (s *srpc) ClientStreamIface(method *protogen.Method) string {
-	return "SRPC" +
-		strings.ReplaceAll(method.Parent.GoName, "_", "__") + "_" +
-		strings.ReplaceAll(method.GoName, "_", "__") +
-		"Client"
+	return s.Exported(s.StreamName(method), "Client")
 }
 
 func (s *srpc) ClientStreamImpl(method *protogen.Method) string {
-	return "srpc" +
-		strings.ReplaceAll(method.Parent.GoName, "_", "__") + "_" +
-		strings.ReplaceAll(method.GoName, "_", "__") +
-		"Client"
+	return s.Unexported(s.StreamName(method), "Client")
 }
 
 func (s *srpc) ServerStreamIface(method *protogen.Method) string {
-	return "SRPC" +
-		strings.ReplaceAll(method.Parent.GoName, "_", "__") + "_" +
-		strings.ReplaceAll(method.GoName, "_", "__") +
-		"Stream"
+	return s.Exported(s.StreamName(method), "Stream")
 }
 
 func (s *srpc) ServerStreamImpl(method *protogen.Method) string {
-	return "srpc" +
-		strings.ReplaceAll(method.Parent.GoName, "_", "__") + "_" +
-		strings.ReplaceAll(method.GoName, "_", "__") +
-		"Stream"
+	return s.Unexported(s.StreamName(method), "Stream")
 }
 
 // service generation
@@ -144,6 +203,9 @@ func (s *srpc) generateService(service *protogen.Service) {
 	s.P("SRPCClient() ", s.Ident(SRPCPackage, "Client"))
 	s.P()
 	for _, method := range service.Methods {
+		if s.IsDeprecated(method) {
+			s.P(deprecationComment)
+		}
 		s.P(s.generateClientSignature(method))
 	}
 	s.P("}")
@@ -204,6 +266,7 @@ func (s *srpc) generateService(service *protogen.Service) {
 	s.P()
 	// Constructor helper
 	s.P("// New", s.ServerHandler(service), " constructs a new RPC handler.")
+	s.P("// The handler can be wrapped or inspected before registering with a Mux.")
 	s.P("// serviceID: if empty, uses default: ", serviceID)
 	s.P("func New", s.ServerHandler(service), "(impl ", s.ServerIface(service), ", serviceID string) srpc.Handler {")
 	s.P("if serviceID == \"\" { serviceID = ", s.ServerServiceID(service), " }")
@@ -212,9 +275,9 @@ func (s *srpc) generateService(service *protogen.Service) {
 	s.P()
 
 	// Registration helper
-	s.P("// SRPCRegister", service.GoName, " registers the implementation with the mux.")
+	s.P("// ", s.ServerRegister(service), " registers the implementation with the mux.")
 	s.P("// Uses the default serviceID: ", serviceID)
-	s.P("func SRPCRegister", service.GoName, "(mux ", s.Ident(SRPCPackage, "Mux"), ", impl ", s.ServerIface(service), ") error {")
+	s.P("func ", s.ServerRegister(service), "(mux ", s.Ident(SRPCPackage, "Mux"), ", impl ", s.ServerIface(service), ") error {")
 	s.P("return mux.Register(New", s.ServerHandler(service), "(impl, \"\"))")
 	s.P("}")
 	s.P()
@@ -247,6 +310,9 @@ func (s *srpc) generateService(service *protogen.Service) {
 	for _, method := range service.Methods {
 		_, methodID := s.GetServiceAndMethodID(method)
 		s.P("case ", strconv.Quote(methodID), ":")
+		if s.IsDeprecated(method) {
+			s.P(s.Ident(SRPCPackage, "WarnDeprecated"), "(strm.Context(), d.GetServiceID(), ", strconv.Quote(methodID), ")")
+		}
 		s.P("return true, d.InvokeMethod_", method.GoName, "(d.impl, strm)")
 	}
 	s.P("default:")
@@ -276,11 +342,11 @@ func (s *srpc) generateService(service *protogen.Service) {
 				// streaming client, non-streaming server.
 				s.P("out, err := impl.", method.GoName, "(clientStrm)")
 				s.P("if err != nil { return err }")
-				s.P("return strm.MsgSend(out)")
+				s.P("return ", s.Ident(SRPCPackage, "SendResponse"), "(strm, ", s.asMessage("out"), ")")
 			}
 		} else {
 			s.P("req := new(", inType, ")")
-			s.P("if err := strm.MsgRecv(req); err != nil { return err }")
+			s.P("if err := strm.MsgRecv(", s.asMessage("req"), "); err != nil { return err }")
 
 			if method.Desc.IsStreamingServer() {
 				// non-streaming client, streaming server
@@ -290,7 +356,7 @@ func (s *srpc) generateService(service *protogen.Service) {
 				// non-streaming client, non-streaming server
 				s.P("out, err := impl.", method.GoName, "(strm.Context(), req)")
 				s.P("if err != nil { return err }")
-				s.P("return strm.MsgSend(out)")
+				s.P("return ", s.Ident(SRPCPackage, "SendResponse"), "(strm, ", s.asMessage("out"), ")")
 			}
 		}
 
@@ -332,7 +398,7 @@ func (s *srpc) generateClientMethod(p *protogen.Method) {
 	s.P("func (c *", recvType, ") ", s.generateClientSignature(p), "{")
 	if !p.Desc.IsStreamingServer() && !p.Desc.IsStreamingClient() {
 		s.P("out := new(", outType, ")")
-		s.P("err := c.cc.ExecCall(ctx, c.serviceID, ", methodQuote, ", ", "in, out)")
+		s.P("err := c.cc.ExecCall(ctx, c.serviceID, ", methodQuote, ", ", s.asMessage("in"), ", ", s.asMessage("out"), ")")
 		s.P("if err != nil { return nil, err }")
 		s.P("return out, nil")
 		s.P("}")
@@ -342,13 +408,13 @@ func (s *srpc) generateClientMethod(p *protogen.Method) {
 
 	firstMsgRef := "nil"
 	if !p.Desc.IsStreamingClient() {
-		firstMsgRef = "in"
+		firstMsgRef = s.asMessage("in")
 	}
 
 	s.P("stream, err := c.cc.NewStream(ctx, c.serviceID, ", methodQuote, ", ", firstMsgRef, ")")
 	s.P("if err != nil { return nil, err }")
 	s.P("strm := &", s.ClientStreamImpl(p), "{stream}")
-	if !p.Desc.IsStreamingClient() {
+	if !p.Desc.IsStreamingClient() && s.serverStreamCloseSend {
 		s.P("if err := strm.CloseSend(); err != nil { return nil, err }")
 	}
 	s.P("return strm, nil")
@@ -382,35 +448,33 @@ func (s *srpc) generateClientMethod(p *protogen.Method) {
 
 	if genSend {
 		s.P("func (x *", s.ClientStreamImpl(p), ") Send(m *", inType, ") error {")
-		s.P("return x.MsgSend(m)")
+		s.P("return x.MsgSend(", s.asMessage("m"), ")")
 		s.P("}")
 		s.P()
 	}
 	if genRecv {
 		s.P("func (x *", s.ClientStreamImpl(p), ") Recv() (*", outType, ", error) {")
 		s.P("m := new(", outType, ")")
-		s.P("if err := x.MsgRecv(m); err != nil { return nil, err }")
+		s.P("if err := x.MsgRecv(", s.asMessage("m"), "); err != nil { return nil, err }")
 		s.P("return m, nil")
 		s.P("}")
 		s.P()
 
 		s.P("func (x *", s.ClientStreamImpl(p), ") RecvTo(m *", outType, ") error {")
-		s.P("return x.MsgRecv(m)")
+		s.P("return x.MsgRecv(", s.asMessage("m"), ")")
 		s.P("}")
 		s.P()
 	}
 	if genCloseAndRecv {
 		s.P("func (x *", s.ClientStreamImpl(p), ") CloseAndRecv() (*", outType, ", error) {")
-		s.P("if err := x.CloseSend(); err != nil { return nil, err }")
 		s.P("m := new(", outType, ")")
-		s.P("if err := x.MsgRecv(m); err != nil { return nil, err }")
+		s.P("if err := x.CloseAndMsgRecv(m); err != nil { return nil, err }")
 		s.P("return m, nil")
 		s.P("}")
 		s.P()
 
 		s.P("func (x *", s.ClientStreamImpl(p), ") CloseAndMsgRecv(m *", outType, ") error {")
-		s.P("if err := x.CloseSend(); err != nil { return err }")
-		s.P("return x.MsgRecv(m)")
+		s.P("return ", s.Ident(SRPCPackage, "CloseAndMsgRecv"), "(x.Stream, ", s.asMessage("m"), ")")
 		s.P("}")
 		s.P()
 	}
@@ -497,6 +561,7 @@ func (s *srpc) generateServerMethod(method *protogen.Method) {
 	}
 	if genRecv {
 		s.P("Recv() (*", s.InputType(method), ", error)")
+		s.P("RecvTo(*", s.InputType(method), ") error")
 	}
 	s.P("}")
 	s.P()
@@ -508,15 +573,14 @@ func (s *srpc) generateServerMethod(method *protogen.Method) {
 
 	if genSend {
 		s.P("func (x *", s.ServerStreamImpl(method), ") Send(m *", s.OutputType(method), ") error {")
-		s.P("return x.MsgSend(m)")
+		s.P("return x.MsgSend(", s.asMessage("m"), ")")
 		s.P("}")
 		s.P()
 	}
 
 	if genSendAndClose {
 		s.P("func (x *", s.ServerStreamImpl(method), ") SendAndClose(m *", s.OutputType(method), ") error {")
-		s.P("if err := x.MsgSend(m); err != nil { return err }")
-		s.P("return x.CloseSend()")
+		s.P("return ", s.Ident(SRPCPackage, "SendClose"), "(x.Stream, ", s.asMessage("m"), ")")
 		s.P("}")
 		s.P()
 	}
@@ -524,13 +588,13 @@ func (s *srpc) generateServerMethod(method *protogen.Method) {
 	if genRecv {
 		s.P("func (x *", s.ServerStreamImpl(method), ") Recv() (*", s.InputType(method), ", error) {")
 		s.P("m := new(", s.InputType(method), ")")
-		s.P("if err := x.MsgRecv(m); err != nil { return nil, err }")
+		s.P("if err := x.MsgRecv(", s.asMessage("m"), "); err != nil { return nil, err }")
 		s.P("return m, nil")
 		s.P("}")
 		s.P()
 
 		s.P("func (x *", s.ServerStreamImpl(method), ") RecvTo(m *", s.InputType(method), ") error {")
-		s.P("return x.MsgRecv(m)")
+		s.P("return x.MsgRecv(", s.asMessage("m"), ")")
 		s.P("}")
 		s.P()
 	}
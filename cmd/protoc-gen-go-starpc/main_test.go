@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// TestGenerateDeprecated tests generating a service with a deprecated method.
+func TestGenerateDeprecated(t *testing.T) {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{
+			GoPackage: proto.String("example.com/test;test"),
+		},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Msg")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Tester"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Current"),
+				InputType:  proto.String(".test.Msg"),
+				OutputType: proto.String(".test.Msg"),
+			}, {
+				Name:       proto.String("Old"),
+				InputType:  proto.String(".test.Msg"),
+				OutputType: proto.String(".test.Msg"),
+				Options:    &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)},
+			}},
+		}},
+	}
+	plugin, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{fd.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{fd},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, f := range plugin.Files {
+		if f.Generate {
+			generatePluginFile(plugin, f, "SRPC", "", true)
+		}
+	}
+
+	resp := plugin.Response()
+	if resp.GetError() != "" {
+		t.Fatal(resp.GetError())
+	}
+	if len(resp.GetFile()) != 1 {
+		t.Fatalf("expected one generated file but got %d", len(resp.GetFile()))
+	}
+	out := resp.GetFile()[0].GetContent()
+	if n := strings.Count(out, `srpc.WarnDeprecated(strm.Context(), d.GetServiceID(), "Old")`); n != 1 {
+		t.Fatalf("expected one deprecation warning for Old but got %d:\n%s", n, out)
+	}
+	if strings.Count(out, "srpc.WarnDeprecated(") != 1 {
+		t.Fatalf("expected no deprecation warning for Current:\n%s", out)
+	}
+	if !strings.Contains(out, "c.cc.ExecCall(ctx, c.serviceID, \"Old\", srpc.AsMessage(in), srpc.AsMessage(out))") {
+		t.Fatalf("expected messages wrapped with AsMessage:\n%s", out)
+	}
+	if !strings.Contains(out, deprecationComment+"\n\tOld(") {
+		t.Fatalf("expected deprecated client method:\n%s", out)
+	}
+}
@@ -0,0 +1,155 @@
+package e2e
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aperturerobotics/starpc/echo"
+	"github.com/aperturerobotics/starpc/srpc"
+	"nhooyr.io/websocket"
+)
+
+// benchServerStreamCount is the number of messages sent per server stream call.
+const benchServerStreamCount = 10
+
+// benchEchoServer is an EchoServer which streams without delay.
+type benchEchoServer struct {
+	*echo.EchoServer
+}
+
+// EchoServerStream sends benchServerStreamCount copies of the message.
+func (s *benchEchoServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	for i := 0; i < benchServerStreamCount; i++ {
+		if err := strm.MsgSend(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newBenchMux constructs a mux with the benchmark echo server.
+func newBenchMux(b *testing.B) srpc.Mux {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, &benchEchoServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+		b.Fatal(err.Error())
+	}
+	return mux
+}
+
+// benchTransports are the transports to run the benchmarks with.
+var benchTransports = []struct {
+	name  string
+	setup func(b *testing.B, mux srpc.Mux) srpc.Client
+}{
+	{name: "Pipe", setup: setupBenchPipe},
+	{name: "WebSocket", setup: setupBenchWebSocket},
+}
+
+// setupBenchPipe constructs a client with in-memory pipe streams.
+func setupBenchPipe(b *testing.B, mux srpc.Mux) srpc.Client {
+	return srpc.NewClient(srpc.NewServerPipe(srpc.NewServer(mux)))
+}
+
+// setupBenchWebSocket constructs a client with a WebSocket to a HTTP server.
+func setupBenchWebSocket(b *testing.B, mux srpc.Mux) srpc.Client {
+	srv, err := srpc.NewHTTPServer(mux, "")
+	if err != nil {
+		b.Fatal(err.Error())
+	}
+	hsrv := httptest.NewServer(srv)
+	b.Cleanup(hsrv.Close)
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	b.Cleanup(ctxCancel)
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hsrv.URL, "http"), nil)
+	if err != nil {
+		b.Fatal(err.Error())
+	}
+	mc, err := srpc.NewWebSocketConn(ctx, conn, false, nil)
+	if err != nil {
+		b.Fatal(err.Error())
+	}
+	client := srpc.NewClientWithMuxedConn(mc)
+	b.Cleanup(func() { _ = srpc.CloseClient(client) })
+	return client
+}
+
+// runBench runs the benchmark with each transport.
+func runBench(b *testing.B, fn func(b *testing.B, client echo.SRPCEchoerClient)) {
+	for _, tpt := range benchTransports {
+		b.Run(tpt.name, func(b *testing.B) {
+			client := echo.NewSRPCEchoerClient(tpt.setup(b, newBenchMux(b)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			fn(b, client)
+		})
+	}
+}
+
+func BenchmarkE2E_Unary(b *testing.B) {
+	ctx := context.Background()
+	req := &echo.EchoMsg{Body: bodyTxt}
+	runBench(b, func(b *testing.B, client echo.SRPCEchoerClient) {
+		for i := 0; i < b.N; i++ {
+			if _, err := client.Echo(ctx, req); err != nil {
+				b.Fatal(err.Error())
+			}
+		}
+	})
+}
+
+func BenchmarkE2E_ServerStream(b *testing.B) {
+	ctx := context.Background()
+	req := &echo.EchoMsg{Body: bodyTxt}
+	runBench(b, func(b *testing.B, client echo.SRPCEchoerClient) {
+		msg := &echo.EchoMsg{}
+		for i := 0; i < b.N; i++ {
+			strm, err := client.EchoServerStream(ctx, req)
+			if err != nil {
+				b.Fatal(err.Error())
+			}
+			var count int
+			for {
+				if err := strm.RecvTo(msg); err != nil {
+					if err == io.EOF {
+						break
+					}
+					b.Fatal(err.Error())
+				}
+				count++
+			}
+			if count != benchServerStreamCount {
+				b.Fatalf("expected %d messages but got %d", benchServerStreamCount, count)
+			}
+			_ = strm.Close()
+		}
+	})
+}
+
+func BenchmarkE2E_BidiStream(b *testing.B) {
+	ctx := context.Background()
+	req := &echo.EchoMsg{Body: bodyTxt}
+	runBench(b, func(b *testing.B, client echo.SRPCEchoerClient) {
+		strm, err := client.EchoBidiStream(ctx)
+		if err != nil {
+			b.Fatal(err.Error())
+		}
+		defer strm.Close()
+		msg := &echo.EchoMsg{}
+		// the server sends an initial message
+		if err := strm.RecvTo(msg); err != nil {
+			b.Fatal(err.Error())
+		}
+		for i := 0; i < b.N; i++ {
+			if err := strm.Send(req); err != nil {
+				b.Fatal(err.Error())
+			}
+			if err := strm.RecvTo(msg); err != nil {
+				b.Fatal(err.Error())
+			}
+		}
+	})
+}
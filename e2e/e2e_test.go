@@ -1,9 +1,17 @@
 package e2e
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,6 +20,9 @@ import (
 	"github.com/aperturerobotics/starpc/rpcstream"
 	"github.com/aperturerobotics/starpc/srpc"
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
 )
 
 const bodyTxt = "hello world via starpc e2e test"
@@ -33,6 +44,15 @@ func RunE2E(t *testing.T, cb func(client echo.SRPCEchoerClient) error) {
 
 // RunE2E_Setup sets up the client and server and calls the callback.
 func RunE2E_Setup(t *testing.T, cb func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error) {
+	RunE2E_SetupWithOpts(t, nil, cb)
+}
+
+// RunE2E_SetupWithOpts sets up the client and server with options and calls the callback.
+func RunE2E_SetupWithOpts(
+	t *testing.T,
+	opts []srpc.ServerOption,
+	cb func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error,
+) {
 	// Alternatively:
 	// openStream := srpc.NewServerPipe(server)
 	// client := srpc.NewClient(openStream)
@@ -46,7 +66,7 @@ func RunE2E_Setup(t *testing.T, cb func(server *srpc.Server, mux srpc.Mux, clien
 	client := srpc.NewClientWithMuxedConn(clientMp)
 
 	mux := srpc.NewMux()
-	server := srpc.NewServer(mux)
+	server := srpc.NewServer(mux, opts...)
 
 	ctx := context.Background()
 	// outbound=false
@@ -121,6 +141,251 @@ func TestE2E_ServerStream(t *testing.T) {
 	})
 }
 
+func TestE2E_ServerStreamSendQueue(t *testing.T) {
+	ctx := context.Background()
+	opts := []srpc.ServerOption{srpc.WithSendQueueDepth(4)}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+			return err
+		}
+		req := &echo.EchoMsg{Body: bodyTxt}
+		out, err := echo.NewSRPCEchoerClient(client).EchoServerStream(ctx, req)
+		if err != nil {
+			return err
+		}
+		return CheckServerStream(t, out, req)
+	})
+}
+
+func TestE2E_ServerStreamHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	opts := []srpc.ServerOption{srpc.WithHeartbeatInterval(time.Millisecond * 10)}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+			return err
+		}
+		req := &echo.EchoMsg{Body: bodyTxt}
+		out, err := echo.NewSRPCEchoerClient(client).EchoServerStream(ctx, req)
+		if err != nil {
+			return err
+		}
+		// heartbeats must not surface as messages
+		return CheckServerStream(t, out, req)
+	})
+}
+
+// trailerServer sets a trailer and fails the server stream.
+type trailerServer struct {
+	*echo.EchoServer
+}
+
+// EchoServerStream sends the message once then fails with a trailer.
+func (s *trailerServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	if err := strm.Send(msg); err != nil {
+		return err
+	}
+	srpc.SetTrailer(strm.Context(), srpc.Metadata{"retry-after": "5"})
+	return errors.New("stream failed")
+}
+
+func TestE2E_TrailerOnError(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &trailerServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+			return err
+		}
+		strm, err := echo.NewSRPCEchoerClient(client).EchoServerStream(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		_, err = strm.Recv()
+		if err == nil || err.Error() != "stream failed" {
+			return errors.Errorf("expected stream error but got %v", err)
+		}
+		if val := srpc.StreamTrailer(strm).Get("retry-after"); val != "5" {
+			return errors.Errorf("expected trailer but got %v", srpc.StreamTrailer(strm))
+		}
+		return nil
+	})
+}
+
+// deprecatedServer warns that Echo is deprecated, as generated for deprecated methods.
+type deprecatedServer struct {
+	*echo.EchoServer
+}
+
+// Echo warns that the method is deprecated then echoes the message.
+func (s *deprecatedServer) Echo(ctx context.Context, msg *echo.EchoMsg) (*echo.EchoMsg, error) {
+	srpc.WarnDeprecated(ctx, echo.SRPCEchoerServiceID, "Echo")
+	return msg, nil
+}
+
+func TestE2E_DeprecationWarnings(t *testing.T) {
+	ctx := context.Background()
+	for _, enable := range []bool{false, true} {
+		mux := srpc.NewMux()
+		if err := echo.SRPCRegisterEchoer(mux, &deprecatedServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+			t.Fatal(err.Error())
+		}
+		server := srpc.NewServer(mux, srpc.WithDeprecationWarnings(enable))
+		var warnings []string
+		client := srpc.NewClient(srpc.NewServerPipe(server), srpc.WithWarningHandler(func(serviceID, methodID, warning string) {
+			warnings = append(warnings, serviceID+"/"+methodID+": "+warning)
+		}))
+
+		resp, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if resp.GetBody() != bodyTxt {
+			t.Fatalf("response body incorrect: %q", resp.GetBody())
+		}
+		if !enable {
+			if len(warnings) != 0 {
+				t.Fatalf("expected no warnings but got %v", warnings)
+			}
+			continue
+		}
+		expected := echo.SRPCEchoerServiceID + "/Echo: method " + echo.SRPCEchoerServiceID + "/Echo is deprecated"
+		if len(warnings) != 1 || warnings[0] != expected {
+			t.Fatalf("unexpected warnings: %v", warnings)
+		}
+	}
+}
+
+// panicServer panics in the unary handler.
+type panicServer struct {
+	*echo.EchoServer
+}
+
+// Echo panics.
+func (s *panicServer) Echo(ctx context.Context, msg *echo.EchoMsg) (*echo.EchoMsg, error) {
+	panic("echo failed")
+}
+
+func TestE2E_HandlerPanic(t *testing.T) {
+	ctx := context.Background()
+	for _, debugStacks := range []bool{false, true} {
+		opts := []srpc.ServerOption{srpc.WithDebugStacks(debugStacks)}
+		RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+			if err := echo.SRPCRegisterEchoer(mux, &panicServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+				return err
+			}
+			_, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+			if !errors.Is(err, srpc.ErrHandlerPanic) || err.Error() != srpc.ErrHandlerPanic.Error() {
+				return errors.Errorf("expected panic error but got %v", err)
+			}
+			st, ok := srpc.StatusFromError(err)
+			if !ok || st.Code != srpc.StatusInternal {
+				return errors.Errorf("expected internal status but got %v", st)
+			}
+			if value, ok := st.Details[srpc.PanicValueDetail]; ok != debugStacks || (ok && value != "echo failed") {
+				return errors.Errorf("expected panic value %v but got %q", debugStacks, value)
+			}
+			if hasStack := strings.Contains(st.Details[srpc.PanicStackDetail], "goroutine"); hasStack != debugStacks {
+				return errors.Errorf("expected stack trace %v but got %v", debugStacks, st.Details)
+			}
+			return nil
+		})
+	}
+}
+
+// progressServer sends progress updates from the unary handler.
+type progressServer struct {
+	*echo.EchoServer
+}
+
+// Echo sends two progress updates then echoes the message.
+func (s *progressServer) Echo(ctx context.Context, msg *echo.EchoMsg) (*echo.EchoMsg, error) {
+	for _, progress := range []string{"50%", "100%"} {
+		if err := srpc.EmitProgress(ctx, []byte(progress)); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+func TestE2E_Progress(t *testing.T) {
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &progressServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+			return err
+		}
+		echoClient := echo.NewSRPCEchoerClient(client)
+
+		var progress []string
+		ctx := srpc.WithProgressHandler(context.Background(), func(data []byte) {
+			progress = append(progress, string(data))
+		})
+		resp, err := echoClient.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != bodyTxt {
+			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+		}
+		if strings.Join(progress, ",") != "50%,100%" {
+			return errors.Errorf("unexpected progress updates: %v", progress)
+		}
+
+		// progress updates are ignored without a handler
+		resp, err = echoClient.Echo(context.Background(), &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != bodyTxt {
+			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+		}
+		return nil
+	})
+}
+
+// debugLogServer logs to the debug logger from the unary handler.
+type debugLogServer struct {
+	*echo.EchoServer
+}
+
+// Echo logs the message then echoes it.
+func (s *debugLogServer) Echo(ctx context.Context, msg *echo.EchoMsg) (*echo.EchoMsg, error) {
+	srpc.DebugLogger(ctx).With("service", "echo").Info("echo called", "body", msg.GetBody())
+	return msg, nil
+}
+
+func TestE2E_DebugLogs(t *testing.T) {
+	for _, enable := range []bool{false, true} {
+		opts := []srpc.ServerOption{srpc.WithDebugLogs(enable)}
+		RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+			if err := echo.SRPCRegisterEchoer(mux, &debugLogServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+				return err
+			}
+
+			var entries []*srpc.DebugLogEntry
+			ctx := srpc.WithDebugLogHandler(context.Background(), func(entry *srpc.DebugLogEntry) {
+				entries = append(entries, entry)
+			})
+			if _, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt}); err != nil {
+				return err
+			}
+			if !enable {
+				if len(entries) != 0 {
+					return errors.Errorf("expected no debug logs but got %v", entries)
+				}
+				return nil
+			}
+			if len(entries) != 1 {
+				return errors.Errorf("expected 1 debug log but got %v", entries)
+			}
+			entry := entries[0]
+			if entry.GetMessage() != "echo called" || entry.GetAttrs()["body"] != bodyTxt || entry.GetAttrs()["service"] != "echo" {
+				return errors.Errorf("unexpected debug log: %v", entry)
+			}
+			return nil
+		})
+	}
+}
+
 func TestE2E_Cancel(t *testing.T) {
 	rctx := context.Background()
 	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
@@ -164,82 +429,2566 @@ func TestE2E_Cancel(t *testing.T) {
 	})
 }
 
-// CheckClientStream checks the server stream portion of the Echo test.
-func CheckClientStream(t *testing.T, out echo.SRPCEchoer_EchoClientStreamClient, req *echo.EchoMsg) error {
-	// send request
-	if err := out.MsgSend(req); err != nil {
-		return err
-	}
-	// expect 1 response
-	ret := &echo.EchoMsg{}
-	if err := out.MsgRecv(ret); err != nil {
-		return err
-	}
-	// check response
-	if ret.GetBody() != req.GetBody() {
-		return errors.Errorf("expected %q got %q", req.GetBody(), ret.GetBody())
-	}
-	_ = out.Close()
-	return nil
+func TestE2E_CancelUnary(t *testing.T) {
+	rctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		startedCh := make(chan struct{})
+		observedCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				close(startedCh)
+				select {
+				case <-ctx.Done():
+					close(observedCh)
+					return nil, context.Canceled
+				case <-time.After(time.Second * 5):
+					return msg, nil
+				}
+			},
+		}
+		_ = msrv.Register(mux)
+
+		ctx, ctxCancel := context.WithCancel(rctx)
+		defer ctxCancel()
+		go func() {
+			<-startedCh
+			ctxCancel()
+		}()
+
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		if err != context.Canceled {
+			t.Fatalf("expected context canceled but got %v", err)
+		}
+
+		select {
+		case <-observedCh:
+		case <-time.After(time.Millisecond * 500):
+			t.Fatal("server did not observe cancellation from client")
+		}
+		return nil
+	})
 }
 
-func TestE2E_ClientStream(t *testing.T) {
+func TestE2E_MethodFromContext(t *testing.T) {
 	ctx := context.Background()
-	RunE2E(t, func(client echo.SRPCEchoerClient) error {
-		bodyTxt := "hello world"
-		req := &echo.EchoMsg{
-			Body: bodyTxt,
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				serviceID, methodID, ok := srpc.MethodFromContext(ctx)
+				if !ok {
+					return nil, errors.New("expected method in context")
+				}
+				return &e2e_mock.MockMsg{Body: serviceID + "/" + methodID}, nil
+			},
 		}
-		out, err := client.EchoClientStream(ctx)
+		_ = msrv.Register(mux)
+
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		resp, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
 		if err != nil {
-			t.Fatal(err.Error())
+			return err
 		}
-		return CheckClientStream(t, out, req)
+		if expected := e2e_mock.SRPCMockServiceID + "/MockRequest"; resp.GetBody() != expected {
+			return errors.Errorf("expected %q got %q", expected, resp.GetBody())
+		}
+		return nil
 	})
 }
 
-func TestE2E_BidiStream(t *testing.T) {
+func TestE2E_ConnDone(t *testing.T) {
 	ctx := context.Background()
-	RunE2E(t, func(client echo.SRPCEchoerClient) error {
-		strm, err := client.EchoBidiStream(ctx)
-		if err != nil {
-			t.Fatal(err.Error())
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		doneCh := make(chan (<-chan struct{}), 1)
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				doneCh <- srpc.ConnDoneFromContext(ctx)
+				return msg, nil
+			},
 		}
-		clientExpected := "hello from client"
-		if err := strm.MsgSend(&echo.EchoMsg{Body: clientExpected}); err != nil {
-			t.Fatal(err.Error())
+		if err := msrv.Register(mux); err != nil {
+			return err
 		}
-		msg, err := strm.Recv()
-		if err != nil {
-			t.Fatal(err.Error())
+		if _, err := e2e_mock.NewSRPCMockClient(client).MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt}); err != nil {
+			return err
 		}
-		expected := "hello from server"
-		if msg.GetBody() != expected {
-			t.Fatalf("expected %q got %q", expected, msg.GetBody())
+		done := <-doneCh
+		if done == nil {
+			return errors.New("expected conn done channel in call context")
+		}
+
+		// closing the client closes the conn
+		_ = srpc.CloseClient(client)
+		select {
+		case <-done:
+			return nil
+		case <-time.After(time.Second * 5):
+			return errors.New("expected conn done channel to be closed")
 		}
-		// expect no error closing
-		return strm.Close()
 	})
 }
 
-func TestE2E_RpcStream(t *testing.T) {
+func TestE2E_ActiveCalls(t *testing.T) {
 	ctx := context.Background()
-	RunE2E(t, func(client echo.SRPCEchoerClient) error {
-		openStreamFn := rpcstream.NewRpcStreamOpenStream(func(ctx context.Context) (rpcstream.RpcStream, error) {
-			return client.RpcStream(ctx)
-		}, "test", false)
-		proxiedClient := srpc.NewClient(openStreamFn)
-		proxiedSvc := echo.NewSRPCEchoerClient(proxiedClient)
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		startedCh := make(chan struct{})
+		releaseCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				close(startedCh)
+				<-releaseCh
+				return msg, nil
+			},
+		}
+		_ = msrv.Register(mux)
 
-		// run a RPC proxied over another RPC
-		resp, err := proxiedSvc.Echo(ctx, &echo.EchoMsg{Body: "hello world"})
-		if err != nil {
+		errCh := make(chan error, 1)
+		go func() {
+			mclient := e2e_mock.NewSRPCMockClient(client)
+			_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+			errCh <- err
+		}()
+
+		<-startedCh
+		calls := server.ActiveCalls()
+		if len(calls) != 1 {
+			return errors.Errorf("expected 1 active call but got %d", len(calls))
+		}
+		if calls[0].ServiceID != e2e_mock.SRPCMockServiceID || calls[0].MethodID != "MockRequest" || calls[0].StreamID == 0 {
+			return errors.Errorf("unexpected active call: %v", calls[0])
+		}
+		close(releaseCh)
+		if err := <-errCh; err != nil {
 			return err
 		}
-		if resp.GetBody() != "hello world" {
-			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+
+		// the call is removed on completion
+		for i := 0; len(server.ActiveCalls()) != 0; i++ {
+			if i > 100 {
+				return errors.New("expected active call to be removed")
+			}
+			<-time.After(time.Millisecond * 10)
+		}
+		return nil
+	})
+}
+
+func TestE2E_CancelCall(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		startedCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				close(startedCh)
+				<-ctx.Done()
+				return nil, context.Cause(ctx)
+			},
 		}
+		_ = msrv.Register(mux)
+
+		errCh := make(chan error, 1)
+		go func() {
+			mclient := e2e_mock.NewSRPCMockClient(client)
+			_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+			errCh <- err
+		}()
 
+		<-startedCh
+		calls := server.ActiveCalls()
+		if len(calls) != 1 || calls[0].ID == 0 {
+			return errors.Errorf("expected 1 active call with an id but got %v", calls)
+		}
+		if server.CancelCall(calls[0].ID+1, nil) {
+			return errors.New("expected cancel of unknown call to return false")
+		}
+		if !server.CancelCall(calls[0].ID, nil) {
+			return errors.New("expected cancel of active call to return true")
+		}
+		err := <-errCh
+		var remoteErr *srpc.RemoteError
+		if !errors.As(err, &remoteErr) || remoteErr.Message != srpc.ErrCallCanceled.Error() {
+			return errors.Errorf("expected call canceled error but got %v", err)
+		}
 		return nil
 	})
 }
+
+func TestE2E_SequenceNumbers(t *testing.T) {
+	ctx := context.Background()
+	opts := []srpc.ServerOption{srpc.WithSequenceNumbers()}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, _ srpc.Client) error {
+		echoServer := echo.NewEchoServer(mux)
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+
+		client := srpc.NewClient(srpc.NewServerPipe(server), srpc.WithClientSequenceNumbers())
+		echoClient := echo.NewSRPCEchoerClient(client)
+		strm, err := echoClient.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		for i := 0; i < 10; i++ {
+			if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+				return err
+			}
+			msg, err := strm.Recv()
+			if err != nil {
+				return err
+			}
+			if msg.GetBody() != bodyTxt {
+				return errors.Errorf("expected %q got %q", bodyTxt, msg.GetBody())
+			}
+		}
+		return nil
+	})
+}
+
+func TestE2E_Checksums(t *testing.T) {
+	ctx := context.Background()
+	opts := []srpc.ServerOption{srpc.WithRequiredChecksums()}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		echoServer := echo.NewEchoServer(mux)
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+
+		// the default client does not enable checksums
+		_, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err == nil || err.Error() != srpc.ErrChecksumRequired.Error() {
+			return errors.Errorf("expected checksums required error but got %v", err)
+		}
+
+		checksumClient := srpc.NewClient(srpc.NewServerPipe(server), srpc.WithClientChecksums())
+		echoClient := echo.NewSRPCEchoerClient(checksumClient)
+		out, err := echoClient.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if out.GetBody() != bodyTxt {
+			return errors.Errorf("expected %q got %q", bodyTxt, out.GetBody())
+		}
+
+		strm, err := echoClient.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		for i := 0; i < 10; i++ {
+			if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+				return err
+			}
+			msg, err := strm.Recv()
+			if err != nil {
+				return err
+			}
+			if msg.GetBody() != bodyTxt {
+				return errors.Errorf("expected %q got %q", bodyTxt, msg.GetBody())
+			}
+		}
+		return nil
+	})
+}
+
+// countingCodec is a JSON codec which counts the encoded messages.
+type countingCodec struct {
+	srpc.JSONCodec
+	count *atomic.Int32
+}
+
+// Name returns the name of the codec.
+func (countingCodec) Name() string {
+	return "counting-json"
+}
+
+// Marshal encodes the message.
+func (c countingCodec) Marshal(msg any) ([]byte, error) {
+	c.count.Add(1)
+	return c.JSONCodec.Marshal(msg)
+}
+
+func TestE2E_CodecNegotiation(t *testing.T) {
+	ctx := context.Background()
+	var count atomic.Int32
+	srpc.RegisterCodec(countingCodec{count: &count})
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, _ srpc.Client) error {
+		echoServer := echo.NewEchoServer(mux)
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+
+		// the server selects the first codec it supports
+		client := srpc.NewClient(srpc.NewServerPipe(server), srpc.WithPreferredCodecs("unknown", "counting-json", "proto"))
+		echoClient := echo.NewSRPCEchoerClient(client)
+		out, err := echoClient.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if out.GetBody() != bodyTxt {
+			return errors.Errorf("expected %q got %q", bodyTxt, out.GetBody())
+		}
+		// the request and the response
+		if n := count.Load(); n != 2 {
+			return errors.Errorf("expected 2 messages encoded with the codec but got %d", n)
+		}
+
+		strm, err := echoClient.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+			return err
+		}
+		msg, err := strm.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.GetBody() != bodyTxt {
+			return errors.Errorf("expected %q got %q", bodyTxt, msg.GetBody())
+		}
+
+		// no common codec
+		client = srpc.NewClient(srpc.NewServerPipe(server), srpc.WithPreferredCodecs("unknown"))
+		_, err = echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if code := srpc.StatusCodeOf(err); code != srpc.StatusUnimplemented {
+			return errors.Errorf("expected status unimplemented but got %v: %v", code, err)
+		}
+		return nil
+	})
+}
+
+func TestE2E_StreamCompression(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, _ srpc.Client) error {
+		echoServer := echo.NewEchoServer(mux)
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+
+		client := srpc.NewClient(srpc.NewServerPipe(server), srpc.WithStreamCompression("deflate"))
+		echoClient := echo.NewSRPCEchoerClient(client)
+		resp, err := echoClient.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != bodyTxt {
+			return errors.Errorf("expected %q got %q", bodyTxt, resp.GetBody())
+		}
+
+		strm, err := echoClient.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		for i := 0; i < 10; i++ {
+			if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+				return err
+			}
+			msg, err := strm.Recv()
+			if err != nil {
+				return err
+			}
+			if msg.GetBody() != bodyTxt {
+				return errors.Errorf("expected %q got %q", bodyTxt, msg.GetBody())
+			}
+		}
+		return nil
+	})
+}
+
+func TestE2E_MuxSelector(t *testing.T) {
+	ctx := context.Background()
+	tenantMuxes := make(map[string]srpc.Mux)
+	for _, tenantID := range []string{"tenant-a", "tenant-b"} {
+		tenantID := tenantID
+		tenantMux := srpc.NewMux()
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				return &e2e_mock.MockMsg{Body: tenantID}, nil
+			},
+		}
+		if err := msrv.Register(tenantMux); err != nil {
+			t.Fatal(err.Error())
+		}
+		tenantMuxes[tenantID] = tenantMux
+	}
+	opts := []srpc.ServerOption{srpc.WithMuxSelector(func(serviceID string, md srpc.Metadata) srpc.Mux {
+		return tenantMuxes[md.Get("tenant-id")]
+	})}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		for tenantID := range tenantMuxes {
+			tenantCtx := srpc.WithOutgoingMetadata(ctx, srpc.Metadata{"tenant-id": tenantID})
+			resp, err := mclient.MockRequest(tenantCtx, &e2e_mock.MockMsg{Body: bodyTxt})
+			if err != nil {
+				return err
+			}
+			if resp.GetBody() != tenantID {
+				return errors.Errorf("expected response from %s but got %s", tenantID, resp.GetBody())
+			}
+		}
+
+		// unknown tenants use the server mux which is empty
+		_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		if !errors.Is(err, srpc.ErrUnimplemented) {
+			return errors.Errorf("expected unimplemented error but got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestE2E_StreamWorkersReject(t *testing.T) {
+	ctx := context.Background()
+	opts := []srpc.ServerOption{
+		srpc.WithStreamWorkers(1, 0, srpc.StreamQueueReject),
+		srpc.WithBusyRetryAfter(time.Second),
+	}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		defer server.Close()
+		startedCh := make(chan struct{})
+		releaseCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				select {
+				case <-startedCh:
+				default:
+					close(startedCh)
+					<-releaseCh
+				}
+				return msg, nil
+			},
+		}
+		_ = msrv.Register(mux)
+
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+			errCh <- err
+		}()
+
+		// the only worker is busy: the second call is rejected
+		<-startedCh
+		_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		close(releaseCh)
+		if !errors.Is(err, srpc.ErrServerBusy) {
+			return errors.Errorf("expected server busy error but got %v", err)
+		}
+		if code := srpc.StatusCodeOf(err); code != srpc.StatusUnavailable {
+			return errors.Errorf("expected status unavailable but got %v", code)
+		}
+		if retryAfter := srpc.RetryAfterOf(err); retryAfter != time.Second {
+			return errors.Errorf("expected retry after 1s but got %v", retryAfter)
+		}
+		return <-errCh
+	})
+}
+
+func TestE2E_ServiceCodec(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				return &e2e_mock.MockMsg{Body: msg.GetBody()}, nil
+			},
+		}
+		if err := srpc.RegisterHandler(mux, e2e_mock.NewSRPCMockHandler(msrv, ""), srpc.WithServiceCodec(srpc.JSONCodec{})); err != nil {
+			return err
+		}
+
+		strm, err := client.NewStream(ctx, e2e_mock.SRPCMockServiceID, "MockRequest", nil)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		cstrm := srpc.NewCodecStream(strm, srpc.JSONCodec{})
+		if err := cstrm.MsgSend(&e2e_mock.MockMsg{Body: bodyTxt}); err != nil {
+			return err
+		}
+		if err := cstrm.CloseSend(); err != nil {
+			return err
+		}
+
+		// the response is encoded with the pinned codec
+		raw := srpc.NewRawMessage(nil, true)
+		if err := cstrm.MsgRecv(raw); err != nil {
+			return err
+		}
+		resp := &e2e_mock.MockMsg{}
+		if err := protojson.Unmarshal(raw.GetData(), resp); err != nil {
+			return errors.Wrapf(err, "expected json response but got %q", raw.GetData())
+		}
+		if resp.GetBody() != bodyTxt {
+			return errors.Errorf("unexpected response body: %q", resp.GetBody())
+		}
+		return nil
+	})
+}
+
+func TestE2E_MuxUnregister(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		startedCh := make(chan struct{})
+		releaseCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				close(startedCh)
+				<-releaseCh
+				return msg, nil
+			},
+		}
+		if err := msrv.Register(mux); err != nil {
+			return err
+		}
+
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+			errCh <- err
+		}()
+		<-startedCh
+
+		// unregister while the call is in-flight
+		if err := srpc.UnregisterService(mux, e2e_mock.SRPCMockServiceID); err != nil {
+			return err
+		}
+		_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		if !errors.Is(err, srpc.ErrUnimplemented) {
+			return errors.Errorf("expected unimplemented error but got %v", err)
+		}
+
+		// the in-flight call completes
+		close(releaseCh)
+		if err := <-errCh; err != nil {
+			return err
+		}
+
+		// the service can be registered again
+		msrv = &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				return msg, nil
+			},
+		}
+		if err := msrv.Register(mux); err != nil {
+			return err
+		}
+		_, err = mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		return err
+	})
+}
+
+func TestE2E_Unimplemented(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		if !errors.Is(err, srpc.ErrUnimplemented) {
+			return errors.Errorf("expected unimplemented error but got %v", err)
+		}
+		var uerr *srpc.UnimplementedError
+		if !errors.As(err, &uerr) {
+			return errors.Errorf("expected UnimplementedError but got %T", err)
+		}
+		if uerr.Service != e2e_mock.SRPCMockServiceID || uerr.Method != "MockRequest" {
+			return errors.Errorf("unexpected service or method: %v", err)
+		}
+		return nil
+	})
+}
+
+// CheckClientStream checks the server stream portion of the Echo test.
+func CheckClientStream(t *testing.T, out echo.SRPCEchoer_EchoClientStreamClient, req *echo.EchoMsg) error {
+	// send request
+	if err := out.MsgSend(req); err != nil {
+		return err
+	}
+	// expect 1 response
+	ret := &echo.EchoMsg{}
+	if err := out.MsgRecv(ret); err != nil {
+		return err
+	}
+	// check response
+	if ret.GetBody() != req.GetBody() {
+		return errors.Errorf("expected %q got %q", req.GetBody(), ret.GetBody())
+	}
+	_ = out.Close()
+	return nil
+}
+
+func TestE2E_ClientStream(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		bodyTxt := "hello world"
+		req := &echo.EchoMsg{
+			Body: bodyTxt,
+		}
+		out, err := client.EchoClientStream(ctx)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return CheckClientStream(t, out, req)
+	})
+}
+
+// rejectClientStreamServer returns an error after the client finishes sending.
+type rejectClientStreamServer struct {
+	*echo.EchoServer
+}
+
+// EchoClientStream receives all messages and rejects them.
+func (s *rejectClientStreamServer) EchoClientStream(strm echo.SRPCEchoer_EchoClientStreamStream) (*echo.EchoMsg, error) {
+	var count int
+	msg := &echo.EchoMsg{}
+	for {
+		err := strm.RecvTo(msg)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		count++
+	}
+	return nil, errors.Errorf("rejected %d messages", count)
+}
+
+func TestE2E_ClientStreamCloseAndRecvError(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		echoServer := &rejectClientStreamServer{EchoServer: echo.NewEchoServer(mux)}
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		strm, err := echo.NewSRPCEchoerClient(client).EchoClientStream(ctx)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 3; i++ {
+			if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+				return err
+			}
+		}
+		_, err = strm.CloseAndRecv()
+		var remoteErr *srpc.RemoteError
+		if !errors.As(err, &remoteErr) {
+			return errors.Errorf("expected remote error but got %v", err)
+		}
+		if remoteErr.Message != "rejected 3 messages" {
+			return errors.Errorf("unexpected remote error: %v", remoteErr.Message)
+		}
+		var closeErr *srpc.CloseSendError
+		if errors.As(err, &closeErr) {
+			return errors.Errorf("expected remote error but got close send error: %v", err)
+		}
+
+		// the stream is closed after CloseAndRecv
+		if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != srpc.ErrStreamClosed {
+			return errors.Errorf("expected stream closed error but got %v", err)
+		}
+		return nil
+	})
+}
+
+// earlyReturnClientStreamServer returns after the third message.
+type earlyReturnClientStreamServer struct {
+	*echo.EchoServer
+}
+
+// EchoClientStream rejects the third message without reading the rest.
+func (s *earlyReturnClientStreamServer) EchoClientStream(strm echo.SRPCEchoer_EchoClientStreamStream) (*echo.EchoMsg, error) {
+	for i := 1; ; i++ {
+		if _, err := strm.Recv(); err != nil {
+			return nil, err
+		}
+		if i == 3 {
+			return nil, errors.Errorf("validation failed on message %d", i)
+		}
+	}
+}
+
+func TestE2E_ClientStreamEarlyReturn(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &earlyReturnClientStreamServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+			return err
+		}
+		strm, err := echo.NewSRPCEchoerClient(client).EchoClientStream(ctx)
+		if err != nil {
+			return err
+		}
+
+		// send until the client observes the handler returned
+		var sendErr error
+		for i := 0; i < 1000 && sendErr == nil; i++ {
+			sendErr = strm.Send(&echo.EchoMsg{Body: bodyTxt})
+			if i >= 3 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		if sendErr != io.EOF {
+			return errors.Errorf("expected io.EOF from send but got %v", sendErr)
+		}
+
+		_, err = strm.CloseAndRecv()
+		var remoteErr *srpc.RemoteError
+		if !errors.As(err, &remoteErr) || remoteErr.Message != "validation failed on message 3" {
+			return errors.Errorf("expected remote error but got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestE2E_MessageTransform(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	// default empty request bodies and upper-case the responses
+	inv := srpc.NewMessageTransformInvoker(
+		mux,
+		func(ctx context.Context, serviceID, methodID string, msg srpc.Message) error {
+			if m, ok := msg.(*echo.EchoMsg); ok && m.GetBody() == "" {
+				m.Body = bodyTxt
+			}
+			return nil
+		},
+		func(ctx context.Context, serviceID, methodID string, msg srpc.Message) error {
+			if m, ok := msg.(*echo.EchoMsg); ok {
+				m.Body = strings.ToUpper(m.GetBody())
+			}
+			return nil
+		},
+	)
+	client := echo.NewSRPCEchoerClient(srpc.NewClient(srpc.NewServerPipe(srpc.NewServer(inv))))
+
+	out, err := client.Echo(ctx, &echo.EchoMsg{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := strings.ToUpper(bodyTxt); out.GetBody() != expected {
+		t.Fatalf("expected %q got %q", expected, out.GetBody())
+	}
+
+	// each message of a stream is transformed
+	strm, err := client.EchoServerStream(ctx, &echo.EchoMsg{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var count int
+	for {
+		msg, err := strm.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if expected := strings.ToUpper(bodyTxt); msg.GetBody() != expected {
+			t.Fatalf("expected %q got %q", expected, msg.GetBody())
+		}
+		count++
+	}
+	if count == 0 {
+		t.Fatal("expected messages from server stream")
+	}
+}
+
+func TestE2E_RequireMetadata(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	inv := srpc.NewRequireMetadataInvoker(mux, "api-version").
+		RequireMethod(echo.SRPCEchoerServiceID, "Echo", "tenant-id")
+	client := echo.NewSRPCEchoerClient(srpc.NewClient(srpc.NewServerPipe(srpc.NewServer(inv))))
+
+	for _, md := range []srpc.Metadata{
+		nil,
+		{"api-version": "1"},
+		{"tenant-id": "a"},
+	} {
+		_, err := client.Echo(srpc.WithOutgoingMetadata(ctx, md), &echo.EchoMsg{Body: bodyTxt})
+		if code := srpc.StatusCodeOf(err); code != srpc.StatusInvalidArgument {
+			t.Fatalf("expected invalid argument with %v but got %v: %v", md, code, err)
+		}
+	}
+	md := srpc.Metadata{"api-version": "1", "tenant-id": "a"}
+	if _, err := client.Echo(srpc.WithOutgoingMetadata(ctx, md), &echo.EchoMsg{Body: bodyTxt}); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestE2E_ByteQuota(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	// reads all messages before returning
+	echoServer := &rejectClientStreamServer{EchoServer: echo.NewEchoServer(mux)}
+	if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+		t.Fatal(err.Error())
+	}
+	inv := srpc.NewByteQuotaInvoker(mux, func(ctx context.Context, serviceID, methodID string) uint64 {
+		if srpc.MetadataFromContext(ctx).Get("tenant-id") == "free" {
+			return 64
+		}
+		return 0
+	})
+	server := srpc.NewServer(inv)
+
+	clientPipe, serverPipe := net.Pipe()
+	clientMp, err := srpc.NewMuxedConn(clientPipe, true, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	serverMp, err := srpc.NewMuxedConn(serverPipe, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	go func() {
+		_ = server.AcceptMuxedConn(ctx, serverMp)
+	}()
+	client := echo.NewSRPCEchoerClient(srpc.NewClientWithMuxedConn(clientMp))
+
+	for _, tenantID := range []string{"paid", "free"} {
+		strm, err := client.EchoClientStream(srpc.WithOutgoingMetadata(ctx, srpc.Metadata{"tenant-id": tenantID}))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for i := 0; i < 10; i++ {
+			if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+				break
+			}
+		}
+		_, err = strm.CloseAndRecv()
+		if tenantID == "paid" {
+			var remoteErr *srpc.RemoteError
+			if !errors.As(err, &remoteErr) || remoteErr.Message != "rejected 10 messages" {
+				t.Fatalf("expected all messages to be received but got %v", err)
+			}
+			continue
+		}
+		if code := srpc.StatusCodeOf(err); code != srpc.StatusResourceExhausted {
+			t.Fatalf("expected resource exhausted but got %v: %v", code, err)
+		}
+	}
+}
+
+// batchClientStreamServer counts the messages sent by the client.
+type batchClientStreamServer struct {
+	*echo.EchoServer
+	// sendClosed is set by the client before it closes the stream for sending
+	sendClosed atomic.Bool
+	// started receives the value of sendClosed when the handler is invoked
+	started chan bool
+}
+
+// EchoClientStream returns the number of messages sent by the client.
+func (s *batchClientStreamServer) EchoClientStream(strm echo.SRPCEchoer_EchoClientStreamStream) (*echo.EchoMsg, error) {
+	s.started <- s.sendClosed.Load()
+	var count int
+	for {
+		if _, err := strm.Recv(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		count++
+	}
+	return &echo.EchoMsg{Body: strconv.Itoa(count)}, nil
+}
+
+func TestE2E_BufferedClientStream(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	echoServer := &batchClientStreamServer{EchoServer: echo.NewEchoServer(mux), started: make(chan bool, 1)}
+	if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+		t.Fatal(err.Error())
+	}
+	inv := srpc.NewBufferedClientStreamInvoker(mux).
+		BufferMethod(echo.SRPCEchoerServiceID, "EchoClientStream", 100)
+	client := echo.NewSRPCEchoerClient(srpc.NewClient(srpc.NewServerPipe(srpc.NewServer(inv))))
+
+	strm, err := client.EchoClientStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i < 3; i++ {
+		if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	echoServer.sendClosed.Store(true)
+	resp, err := strm.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != "3" {
+		t.Fatalf("expected 3 messages but got %s", resp.GetBody())
+	}
+	if sendClosed := <-echoServer.started; !sendClosed {
+		t.Fatal("expected handler to wait for the client to finish sending")
+	}
+
+	// exceed the max buffer size
+	strm, err = client.EchoClientStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i < 10; i++ {
+		if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+			break
+		}
+	}
+	_, err = strm.CloseAndRecv()
+	if code := srpc.StatusCodeOf(err); code != srpc.StatusResourceExhausted {
+		t.Fatalf("expected resource exhausted but got %v: %v", code, err)
+	}
+	if len(echoServer.started) != 0 {
+		t.Fatal("expected handler not to be invoked")
+	}
+}
+
+func TestE2E_Identity(t *testing.T) {
+	mux := srpc.NewMux()
+	msrv := &e2e_mock.MockServer{
+		MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+			id, ok := srpc.IdentityFromContext(ctx)
+			if !ok || !id.HasScope("mock") {
+				return nil, srpc.ErrUnauthenticated
+			}
+			return &e2e_mock.MockMsg{Body: id.Subject}, nil
+		},
+	}
+	if err := msrv.Register(mux); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv, err := srpc.NewHTTPServer(mux, "", srpc.WithPreUpgradeFunc(func(r *http.Request) (context.Context, error) {
+		id := &srpc.Identity{Subject: r.Header.Get("X-Subject"), Scopes: []string{"mock"}}
+		return srpc.WithIdentity(r.Context(), id), nil
+	}))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hsrv := httptest.NewServer(srv)
+	defer hsrv.Close()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer ctxCancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hsrv.URL, "http"), &websocket.DialOptions{
+		HTTPHeader: http.Header{"X-Subject": []string{"test-user"}},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mc, err := srpc.NewWebSocketConn(ctx, conn, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client := srpc.NewClientWithMuxedConn(mc)
+	defer srpc.CloseClient(client)
+
+	resp, err := e2e_mock.NewSRPCMockClient(client).MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != "test-user" {
+		t.Fatalf("expected identity subject but got %q", resp.GetBody())
+	}
+}
+
+func TestE2E_WebSocketReadTimeout(t *testing.T) {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv, err := srpc.NewHTTPServer(mux, "", srpc.WithWebSocketOptions(srpc.WithReadTimeout(time.Millisecond*200)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hsrv := httptest.NewServer(srv)
+	defer hsrv.Close()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer ctxCancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hsrv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mc, err := srpc.NewWebSocketConn(ctx, conn, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client := srpc.NewClientWithMuxedConn(mc)
+	defer srpc.CloseClient(client)
+
+	// calls within the timeout succeed
+	resp, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != bodyTxt {
+		t.Fatalf("response body incorrect: %q", resp.GetBody())
+	}
+
+	// the server closes the idle conn after the read timeout
+	select {
+	case <-mc.Done():
+	case <-ctx.Done():
+		t.Fatal("expected the server to close the idle conn")
+	}
+}
+
+func TestE2E_MaxConnections(t *testing.T) {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv, err := srpc.NewHTTPServer(mux, "", srpc.WithMaxConnections(1))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hsrv := httptest.NewServer(srv)
+	defer hsrv.Close()
+	url := "ws" + strings.TrimPrefix(hsrv.URL, "http")
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer ctxCancel()
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the second connection is rejected while the first is open
+	_, resp, err := websocket.Dial(ctx, url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected service unavailable but got %v", err)
+	}
+
+	// closing the first connection frees the slot
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+	for i := 0; ; i++ {
+		conn, _, err = websocket.Dial(ctx, url, nil)
+		if err == nil {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("expected connection to be accepted: %v", err)
+		}
+		<-time.After(time.Millisecond * 10)
+	}
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func TestE2E_ClientStreamCloseSendFlush(t *testing.T) {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, &rejectClientStreamServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv, err := srpc.NewHTTPServer(mux, "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hsrv := httptest.NewServer(srv)
+	defer hsrv.Close()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer ctxCancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hsrv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// coalesced writes would be delayed past the test timeout without flushing.
+	mc, err := srpc.NewWebSocketConn(ctx, conn, false, nil, srpc.WithWriteCoalesce(1<<20, time.Minute))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client := srpc.NewClientWithMuxedConn(mc)
+	defer srpc.CloseClient(client)
+
+	strm, err := echo.NewSRPCEchoerClient(client).EchoClientStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i < 5; i++ {
+		if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	_, err = strm.CloseAndRecv()
+	var remoteErr *srpc.RemoteError
+	if !errors.As(err, &remoteErr) || remoteErr.Message != "rejected 5 messages" {
+		t.Fatalf("expected server to receive all messages but got %v", err)
+	}
+}
+
+func TestE2E_BidiStream(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		strm, err := client.EchoBidiStream(ctx)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		clientExpected := "hello from client"
+		if err := strm.MsgSend(&echo.EchoMsg{Body: clientExpected}); err != nil {
+			t.Fatal(err.Error())
+		}
+		msg, err := strm.Recv()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expected := "hello from server"
+		if msg.GetBody() != expected {
+			t.Fatalf("expected %q got %q", expected, msg.GetBody())
+		}
+		// expect no error closing
+		return strm.Close()
+	})
+}
+
+// statusServer returns a status with details from the bidi stream handler.
+type statusServer struct {
+	*echo.EchoServer
+}
+
+// EchoBidiStream echoes messages until it receives an empty message.
+func (s *statusServer) EchoBidiStream(strm echo.SRPCEchoer_EchoBidiStreamStream) error {
+	for i := 0; ; i++ {
+		msg, err := strm.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.GetBody() == "" {
+			return srpc.NewStatusError(srpc.StatusInvalidArgument, "empty body").
+				WithDetail("item", strconv.Itoa(i))
+		}
+		if err := strm.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func TestE2E_BidiStreamStatus(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &statusServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+			return err
+		}
+		strm, err := echo.NewSRPCEchoerClient(client).EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		for _, body := range []string{bodyTxt, ""} {
+			if err := strm.Send(&echo.EchoMsg{Body: body}); err != nil {
+				return err
+			}
+		}
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		_, err = strm.Recv()
+		st, ok := srpc.StatusFromError(err)
+		if !ok {
+			return errors.Errorf("expected status error but got %v", err)
+		}
+		if st.Code != srpc.StatusInvalidArgument || st.Message != "empty body" || st.Details["item"] != "1" {
+			return errors.Errorf("unexpected status: %v %q %v", st.Code, st.Message, st.Details)
+		}
+		if srpc.StatusCodeOf(err) != srpc.StatusInvalidArgument {
+			return errors.Errorf("unexpected status code: %v", srpc.StatusCodeOf(err))
+		}
+		return nil
+	})
+}
+
+func TestE2E_BidiStreamBatchedStart(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		msgs := []srpc.Message{
+			&echo.EchoMsg{Body: "msg 1"},
+			&echo.EchoMsg{Body: "msg 2"},
+			&echo.EchoMsg{Body: "msg 3"},
+		}
+		strm, err := srpc.NewStreamWithMsgs(ctx, client.SRPCClient(), echo.SRPCEchoerServiceID, "EchoBidiStream", msgs...)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+
+		expected := []string{"hello from server", "msg 1", "msg 2", "msg 3"}
+		for _, exp := range expected {
+			msg := &echo.EchoMsg{}
+			if err := strm.MsgRecv(msg); err != nil {
+				return err
+			}
+			if msg.GetBody() != exp {
+				return errors.Errorf("expected %q got %q", exp, msg.GetBody())
+			}
+		}
+		return nil
+	})
+}
+
+func TestE2E_BidiStreamReadDeadline(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		strm, err := client.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+
+		msg, err := strm.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.GetBody() != "hello from server" {
+			return errors.Errorf("expected hello from server got %q", msg.GetBody())
+		}
+
+		if err := srpc.SetStreamReadDeadline(strm, time.Now().Add(50*time.Millisecond)); err != nil {
+			return err
+		}
+		if _, err := strm.Recv(); err != context.DeadlineExceeded {
+			return errors.Errorf("expected deadline exceeded got %v", err)
+		}
+
+		// the stream is still usable after extending the deadline
+		if err := srpc.SetStreamReadDeadline(strm, time.Time{}); err != nil {
+			return err
+		}
+		if err := strm.Send(&echo.EchoMsg{Body: "after deadline"}); err != nil {
+			return err
+		}
+		msg, err = strm.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.GetBody() != "after deadline" {
+			return errors.Errorf("expected after deadline got %q", msg.GetBody())
+		}
+		return nil
+	})
+}
+
+func TestE2E_StreamID(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		strm1, err := client.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm1.Close()
+		strm2, err := client.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		defer strm2.Close()
+
+		id1, ok1 := srpc.StreamID(strm1)
+		id2, ok2 := srpc.StreamID(strm2)
+		if !ok1 || !ok2 {
+			return errors.New("expected stream ids to be set")
+		}
+		if id1 == id2 {
+			return errors.Errorf("expected unique stream ids but got %d", id1)
+		}
+		return nil
+	})
+}
+
+func TestE2E_ClientClose(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		strm, err := client.EchoBidiStream(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+
+		cc := client.SRPCClient()
+		if err := srpc.CloseClient(cc); err != nil {
+			return err
+		}
+		if _, err := strm.Recv(); err != srpc.ErrClientClosed {
+			return errors.Errorf("expected client closed got %v", err)
+		}
+		if _, err := client.Echo(ctx, &echo.EchoMsg{Body: bodyTxt}); err != srpc.ErrClientClosed {
+			return errors.Errorf("expected client closed got %v", err)
+		}
+		// Close is idempotent
+		return srpc.CloseClient(cc)
+	})
+}
+
+func TestE2E_Fragmentation(t *testing.T) {
+	mux := srpc.NewMux()
+	echoServer := echo.NewEchoServer(mux)
+	if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+		t.Fatal(err.Error())
+	}
+	server := srpc.NewServer(mux, srpc.WithFragmentSize(16))
+	client := echo.NewSRPCEchoerClient(srpc.NewClient(
+		srpc.NewServerPipe(server),
+		srpc.WithClientFragmentSize(16),
+	))
+
+	ctx := context.Background()
+	body := strings.Repeat(bodyTxt, 10)
+	resp, err := client.Echo(ctx, &echo.EchoMsg{Body: body})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != body {
+		t.Fatalf("response body incorrect: %q", resp.GetBody())
+	}
+
+	strm, err := client.EchoBidiStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if _, err := strm.Recv(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.Send(&echo.EchoMsg{Body: body}); err != nil {
+		t.Fatal(err.Error())
+	}
+	msg, err := strm.Recv()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if msg.GetBody() != body {
+		t.Fatalf("response body incorrect: %q", msg.GetBody())
+	}
+}
+
+func TestE2E_TCP(t *testing.T) {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer lis.Close()
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	go func() {
+		_ = srpc.AcceptMuxedListener(ctx, lis, srpc.NewServer(mux), nil, srpc.WithTCPKeepAlive(time.Second*30))
+	}()
+
+	conn, err := srpc.DialTCP(ctx, lis.Addr().String(), srpc.WithTCPBufferSizes(64*1024, 64*1024))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client, err := srpc.NewClientWithConn(conn, true, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer srpc.CloseClient(client)
+
+	resp, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != bodyTxt {
+		t.Fatalf("unexpected response body: %q", resp.GetBody())
+	}
+}
+
+// resumeServer sends numbered messages, pausing after the first few.
+type resumeServer struct {
+	*echo.EchoServer
+	// release is closed to send the remaining messages.
+	release chan struct{}
+}
+
+// EchoServerStream sends 3 messages, waits for release, then sends 7 more.
+func (s *resumeServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	for i := 0; i < 10; i++ {
+		if i == 3 {
+			select {
+			case <-strm.Context().Done():
+				return strm.Context().Err()
+			case <-s.release:
+			}
+		}
+		if err := strm.Send(&echo.EchoMsg{Body: strconv.Itoa(i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestE2E_ResumableStream(t *testing.T) {
+	testResumableStream(t)
+}
+
+func TestE2E_ResumableStreamCodec(t *testing.T) {
+	testResumableStream(t, srpc.WithPreferredCodecs("json"))
+}
+
+// testResumableStream tests resuming a call after the connection drops.
+func testResumableStream(t *testing.T, clientOpts ...srpc.ClientOption) {
+	mux := srpc.NewMux()
+	echoServer := &resumeServer{EchoServer: echo.NewEchoServer(mux), release: make(chan struct{})}
+	if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+		t.Fatal(err.Error())
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer lis.Close()
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	server := srpc.NewServer(mux, srpc.WithResumableStreams(16, time.Second*10))
+	go func() {
+		_ = srpc.AcceptMuxedListener(ctx, lis, server, nil)
+	}()
+
+	// dial a new connection each time the call starts or resumes
+	var clients []srpc.Client
+	defer func() {
+		for _, client := range clients {
+			_ = srpc.CloseClient(client)
+		}
+	}()
+	getClient := func(ctx context.Context) (srpc.Client, error) {
+		conn, err := srpc.DialTCP(ctx, lis.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+		client, err := srpc.NewClientWithConn(conn, true, nil, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+		return client, nil
+	}
+
+	strm, err := srpc.NewResumableStream(
+		ctx,
+		getClient,
+		echo.SRPCEchoerServiceID,
+		"EchoServerStream",
+		&echo.EchoMsg{Body: bodyTxt},
+	)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+
+	msg := &echo.EchoMsg{}
+	for i := 0; i < 10; i++ {
+		if i == 3 {
+			// drop the connection then let the handler continue
+			_ = srpc.CloseClient(clients[0])
+			close(echoServer.release)
+		}
+		if err := strm.MsgRecv(msg); err != nil {
+			t.Fatalf("msg %d: %v", i, err)
+		}
+		if msg.GetBody() != strconv.Itoa(i) {
+			t.Fatalf("expected msg %d but got %q", i, msg.GetBody())
+		}
+	}
+	if err := strm.MsgRecv(msg); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+	if len(clients) != 2 {
+		t.Fatalf("expected the call to resume once but dialed %d times", len(clients))
+	}
+}
+
+func TestE2E_Transport(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	server := srpc.NewServer(mux)
+
+	clientTpt, serverTpt, err := srpc.NewPipeTransports(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ServeTransport(ctx, serverTpt)
+	}()
+
+	client := echo.NewSRPCEchoerClient(srpc.NewClientWithTransport(clientTpt))
+	out, err := client.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if out.GetBody() != bodyTxt {
+		t.Fatalf("expected %q got %q", bodyTxt, out.GetBody())
+	}
+
+	// closing the client transport ends the server loop
+	_ = clientTpt.Close()
+	select {
+	case <-clientTpt.Done():
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected client transport to be done")
+	}
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expected error from serve after transport closed")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected serve to return after transport closed")
+	}
+}
+
+func TestE2E_PipeListener(t *testing.T) {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	lis := srpc.NewPipeListener()
+	defer lis.Close()
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	go func() {
+		_ = srpc.NewServer(mux).Serve(ctx, lis)
+	}()
+
+	client := echo.NewSRPCEchoerClient(srpc.NewDialerClient(lis))
+	resp, err := client.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != bodyTxt {
+		t.Fatalf("unexpected response body: %q", resp.GetBody())
+	}
+
+	// inject a fault: the connection drops before the response
+	faultyDialer := srpc.DialerFunc(func(ctx context.Context) (net.Conn, error) {
+		conn, err := lis.Dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		time.AfterFunc(time.Millisecond*10, func() { _ = conn.Close() })
+		return conn, nil
+	})
+	faultyClient := echo.NewSRPCEchoerClient(srpc.NewDialerClient(faultyDialer))
+	strm, err := faultyClient.EchoBidiStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if _, err := strm.Recv(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := strm.Recv(); err == nil || err == io.EOF {
+		t.Fatalf("expected error after the connection dropped but got %v", err)
+	}
+}
+
+func TestE2E_H2C(t *testing.T) {
+	mux := srpc.NewMux()
+	echoServer := echo.NewEchoServer(mux)
+	if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	srv := httptest.NewUnstartedServer(srpc.NewH2CHandler(mux))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := echo.NewSRPCEchoerClient(srpc.NewH2CClient(srv.Client(), srv.URL))
+	resp, err := client.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if resp.GetBody() != bodyTxt {
+		t.Fatalf("response body incorrect: %q", resp.GetBody())
+	}
+
+	strm, err := client.EchoBidiStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if _, err := strm.Recv(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+		t.Fatal(err.Error())
+	}
+	msg, err := strm.Recv()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if msg.GetBody() != bodyTxt {
+		t.Fatalf("response body incorrect: %q", msg.GetBody())
+	}
+}
+
+func TestE2E_JSONRPC(t *testing.T) {
+	mux := srpc.NewMux()
+	echoServer := echo.NewEchoServer(mux)
+	if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	methods := map[string]*srpc.JSONRPCMethod{
+		"echo": {
+			ServiceID:   echo.SRPCEchoerServiceID,
+			MethodID:    "Echo",
+			NewRequest:  func() proto.Message { return &echo.EchoMsg{} },
+			NewResponse: func() proto.Message { return &echo.EchoMsg{} },
+		},
+	}
+	srv := httptest.NewServer(srpc.NewJSONRPCHandler(mux, methods))
+	defer srv.Close()
+
+	ctx := context.Background()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+
+	call := func(req string) string {
+		if err := conn.Write(ctx, websocket.MessageText, []byte(req)); err != nil {
+			t.Fatal(err.Error())
+		}
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return string(data)
+	}
+
+	resp := call(`{"jsonrpc":"2.0","method":"echo","params":{"body":"hello"},"id":1}`)
+	if resp != `{"jsonrpc":"2.0","result":{"body":"hello"},"id":1}` {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+
+	// the notification has no response
+	resp = call(`[{"jsonrpc":"2.0","method":"echo","params":{"body":"hi"}},{"jsonrpc":"2.0","method":"missing","id":"a"}]`)
+	if resp != `[{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: missing"},"id":"a"}]` {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+
+	resp = call(`{"jsonrpc":"2.0","method":"echo","params":[1],"id":2}`)
+	if resp != `{"jsonrpc":"2.0","error":{"code":-32602,"message":"params must be an object"},"id":2}` {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+
+	resp = call(`{"jsonrpc":"2.0",`)
+	if !strings.Contains(resp, `"code":-32700`) || !strings.HasSuffix(resp, `"id":null}`) {
+		t.Fatalf("unexpected response: %s", resp)
+	}
+}
+
+func TestE2E_RpcStream(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		openStreamFn := rpcstream.NewRpcStreamOpenStream(func(ctx context.Context) (rpcstream.RpcStream, error) {
+			return client.RpcStream(ctx)
+		}, "test", false)
+		proxiedClient := srpc.NewClient(openStreamFn)
+		proxiedSvc := echo.NewSRPCEchoerClient(proxiedClient)
+
+		// run a RPC proxied over another RPC
+		resp, err := proxiedSvc.Echo(ctx, &echo.EchoMsg{Body: "hello world"})
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != "hello world" {
+			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+		}
+
+		return nil
+	})
+}
+
+func TestE2E_Ping(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+			return err
+		}
+		if _, err := srpc.Ping(ctx, client); err != nil {
+			return err
+		}
+
+		// ping the server nested in a rpcstream
+		openStreamFn := rpcstream.NewRpcStreamOpenStream(func(ctx context.Context) (rpcstream.RpcStream, error) {
+			return echo.NewSRPCEchoerClient(client).RpcStream(ctx)
+		}, "test", false)
+		rtt, err := srpc.Ping(ctx, srpc.NewClient(openStreamFn))
+		if err != nil {
+			return err
+		}
+		if rtt <= 0 {
+			return errors.Errorf("expected positive rtt but got %v", rtt)
+		}
+		return nil
+	})
+}
+
+func TestE2E_ServerCapabilities(t *testing.T) {
+	ctx := context.Background()
+	opts := []srpc.ServerOption{srpc.WithRequiredChecksums()}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		caps, err := srpc.ServerCapabilities(ctx, client)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(caps.GetCompression(), "deflate") {
+			return errors.Errorf("expected deflate compression but got %v", caps.GetCompression())
+		}
+		if !slices.Contains(caps.GetCodecs(), "json") {
+			return errors.Errorf("expected json codec but got %v", caps.GetCodecs())
+		}
+		if caps.GetMaxMessageSize() == 0 {
+			return errors.New("expected max message size")
+		}
+		if !caps.GetChecksumsRequired() || caps.GetResumableStreams() {
+			return errors.Errorf("unexpected capabilities: %v", caps.String())
+		}
+		return nil
+	})
+}
+
+// authRpcStreamServer authorizes RpcStreams with the init payload.
+type authRpcStreamServer struct {
+	*echo.EchoServer
+	mux srpc.Mux
+}
+
+// RpcStream runs a rpc stream if the init payload contains the token.
+func (s *authRpcStreamServer) RpcStream(stream echo.SRPCEchoer_RpcStreamStream) error {
+	return rpcstream.HandleRpcStream(stream, func(ctx context.Context, componentID string) (srpc.Invoker, func(), error) {
+		if string(rpcstream.InitPayloadFromContext(ctx)) != "token" {
+			return nil, nil, srpc.ErrUnauthenticated
+		}
+		return s.mux, nil, nil
+	})
+}
+
+func TestE2E_RpcStreamInitPayload(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		echoServer := &authRpcStreamServer{EchoServer: echo.NewEchoServer(mux), mux: mux}
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+		echoClient := echo.NewSRPCEchoerClient(client)
+		rpcCaller := func(ctx context.Context) (rpcstream.RpcStream, error) {
+			return echoClient.RpcStream(ctx)
+		}
+
+		authClient := echo.NewSRPCEchoerClient(rpcstream.NewRpcStreamClient(rpcCaller, "test", true, rpcstream.WithInitPayload([]byte("token"))))
+		resp, err := authClient.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != bodyTxt {
+			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+		}
+
+		unauthClient := echo.NewSRPCEchoerClient(rpcstream.NewRpcStreamClient(rpcCaller, "test", true))
+		_, err = unauthClient.Echo(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err == nil || !strings.Contains(err.Error(), srpc.ErrUnauthenticated.Error()) {
+			return errors.Errorf("expected unauthenticated error but got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestE2E_RpcStreamCancel(t *testing.T) {
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		echoServer := echo.NewEchoServer(mux)
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+		startedCh := make(chan struct{})
+		canceledCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				close(startedCh)
+				<-ctx.Done()
+				close(canceledCh)
+				return nil, context.Canceled
+			},
+		}
+		if err := msrv.Register(mux); err != nil {
+			return err
+		}
+
+		// the outer component stream uses a separate context
+		outerCtx, outerCtxCancel := context.WithCancel(context.Background())
+		defer outerCtxCancel()
+		echoClient := echo.NewSRPCEchoerClient(client)
+		openStreamFn := rpcstream.NewRpcStreamOpenStream(func(ctx context.Context) (rpcstream.RpcStream, error) {
+			return echoClient.RpcStream(outerCtx)
+		}, "test", false)
+		proxiedClient := e2e_mock.NewSRPCMockClient(srpc.NewClient(openStreamFn))
+
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := proxiedClient.MockRequest(context.Background(), &e2e_mock.MockMsg{Body: bodyTxt})
+			errCh <- err
+		}()
+
+		// canceling the outer stream cancels the inner call
+		<-startedCh
+		outerCtxCancel()
+		select {
+		case <-canceledCh:
+		case <-time.After(time.Second * 5):
+			return errors.New("expected inner handler context to be canceled")
+		}
+		select {
+		case err := <-errCh:
+			if err == nil {
+				return errors.New("expected inner call to fail")
+			}
+		case <-time.After(time.Second * 5):
+			return errors.New("expected inner call to return")
+		}
+		return nil
+	})
+}
+
+func TestE2E_RpcStreamReconnectBackoff(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		var attempts int
+		proxiedClient := rpcstream.NewRpcStreamClient(
+			func(ctx context.Context) (rpcstream.RpcStream, error) {
+				attempts++
+				if attempts < 3 {
+					return nil, errors.New("base connection unavailable")
+				}
+				return client.RpcStream(ctx)
+			},
+			"test",
+			false,
+			rpcstream.WithReconnectBackoff(rpcstream.Backoff{
+				MaxAttempts:  5,
+				InitialDelay: time.Millisecond,
+				Jitter:       0.2,
+			}),
+		)
+		proxiedSvc := echo.NewSRPCEchoerClient(proxiedClient)
+
+		resp, err := proxiedSvc.Echo(ctx, &echo.EchoMsg{Body: "hello world"})
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != "hello world" {
+			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+		}
+		if attempts != 3 {
+			return errors.Errorf("expected 3 attempts but got %d", attempts)
+		}
+		return nil
+	})
+}
+
+func TestE2E_RecvEOF(t *testing.T) {
+	ctx := context.Background()
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		echoServer := echo.NewEchoServer(mux)
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+
+		// the server completed the call: exactly io.EOF after the messages
+		strm, err := echo.NewSRPCEchoerClient(client).EchoServerStream(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		for {
+			_, err := strm.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// the stream closed before the server completed the call: not io.EOF
+		msgData, err := (&echo.EchoMsg{Body: bodyTxt}).MarshalVT()
+		if err != nil {
+			return err
+		}
+		openStream := func(ctx context.Context, msgHandler srpc.PacketHandler, closeHandler srpc.CloseHandler) (srpc.Writer, error) {
+			clientConn, serverConn := net.Pipe()
+			serverPrw := srpc.NewPacketReadWriter(serverConn)
+			go func() {
+				_ = serverPrw.ReadToHandler(func(pkt *srpc.Packet) error {
+					if !pkt.GetCallData().GetComplete() {
+						return nil
+					}
+					// the client closed the send side: send one message and
+					// close without completing the call
+					_ = serverPrw.WritePacket(srpc.NewCallDataPacket(msgData, false, false, nil))
+					_ = serverConn.Close()
+					return io.EOF
+				})
+			}()
+			prw := srpc.NewPacketReadWriter(clientConn)
+			go prw.ReadPump(msgHandler, closeHandler)
+			return prw, nil
+		}
+		abortClient := echo.NewSRPCEchoerClient(srpc.NewClient(openStream))
+		abortStrm, err := abortClient.EchoServerStream(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		defer abortStrm.Close()
+		if _, err := abortStrm.Recv(); err != nil {
+			return err
+		}
+		_, err = abortStrm.Recv()
+		if err == nil || err == io.EOF {
+			return errors.Errorf("expected transport closed error but got %v", err)
+		}
+		if !errors.Is(err, srpc.ErrTransportClosed) {
+			return errors.Errorf("expected transport closed error but got %v", err)
+		}
+		return nil
+	})
+}
+
+// cancelReasonServer reports the reason the server stream was canceled with.
+type cancelReasonServer struct {
+	*echo.EchoServer
+	reasonCh chan error
+}
+
+// EchoServerStream sends the message and waits for the call to be canceled.
+func (s *cancelReasonServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	if err := strm.Send(msg); err != nil {
+		return err
+	}
+	ctx := strm.Context()
+	<-ctx.Done()
+	reason, ok := srpc.CancelReasonFromContext(ctx)
+	switch {
+	case !ok:
+		s.reasonCh <- errors.Errorf("expected cancel reason but got cause %v", context.Cause(ctx))
+	case !errors.Is(context.Cause(ctx), srpc.ErrRemoteCanceled):
+		s.reasonCh <- errors.Errorf("expected remote canceled cause but got %v", context.Cause(ctx))
+	case reason != "user-abort":
+		s.reasonCh <- errors.Errorf("unexpected cancel reason %q", reason)
+	default:
+		s.reasonCh <- nil
+	}
+	return context.Canceled
+}
+
+func TestE2E_CancelReason(t *testing.T) {
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		echoServer := &cancelReasonServer{EchoServer: echo.NewEchoServer(mux), reasonCh: make(chan error, 1)}
+		if err := echo.SRPCRegisterEchoer(mux, echoServer); err != nil {
+			return err
+		}
+
+		ctx, ctxCancel := context.WithCancelCause(context.Background())
+		defer ctxCancel(nil)
+		strm, err := echo.NewSRPCEchoerClient(client).EchoServerStream(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		srpc.CancelWithReason(ctxCancel, "user-abort")
+		return <-echoServer.reasonCh
+	})
+}
+
+// attachmentServer sends an attachment referenced by the message.
+type attachmentServer struct {
+	*echo.EchoServer
+	data []byte
+}
+
+// EchoServerStream sends a message with the ID of an attachment containing data.
+func (s *attachmentServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	w, id := srpc.OpenAttachment(strm)
+	if err := strm.Send(&echo.EchoMsg{Body: strconv.FormatUint(uint64(id), 10)}); err != nil {
+		return err
+	}
+	if _, err := w.Write(s.data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// EchoClientStream reads the attachment referenced by the message and checks it contains data.
+func (s *attachmentServer) EchoClientStream(strm echo.SRPCEchoer_EchoClientStreamStream) (*echo.EchoMsg, error) {
+	msg, err := strm.Recv()
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(msg.GetBody(), 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	r, err := srpc.OpenAttachmentReader(strm, uint32(id))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(got, s.data) {
+		return nil, errors.Errorf("attachment mismatch: got %d bytes expected %d", len(got), len(s.data))
+	}
+	return &echo.EchoMsg{Body: bodyTxt}, nil
+}
+
+func TestE2E_Attachments(t *testing.T) {
+	data := make([]byte, 200*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &attachmentServer{EchoServer: echo.NewEchoServer(mux), data: data}); err != nil {
+			return err
+		}
+
+		strm, err := echo.NewSRPCEchoerClient(client).EchoServerStream(context.Background(), &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		msg, err := strm.Recv()
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseUint(msg.GetBody(), 10, 32)
+		if err != nil {
+			return err
+		}
+		r, err := srpc.OpenAttachmentReader(strm, uint32(id))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if _, err := srpc.OpenAttachmentReader(strm, uint32(id)); !errors.Is(err, srpc.ErrAttachmentOpened) {
+			return errors.Errorf("expected ErrAttachmentOpened but got %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(got, data) {
+			return errors.Errorf("attachment mismatch: got %d bytes expected %d", len(got), len(data))
+		}
+		if _, err := strm.Recv(); err != io.EOF {
+			return errors.Errorf("expected io.EOF but got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestE2E_AttachmentsClientSend(t *testing.T) {
+	data := make([]byte, 200*1024)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &attachmentServer{EchoServer: echo.NewEchoServer(mux), data: data}); err != nil {
+			return err
+		}
+
+		strm, err := echo.NewSRPCEchoerClient(client).EchoClientStream(context.Background())
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		w, id := srpc.OpenAttachment(strm)
+		if err := strm.Send(&echo.EchoMsg{Body: strconv.FormatUint(uint64(id), 10)}); err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		out, err := strm.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		if out.GetBody() != bodyTxt {
+			return errors.Errorf("expected %q got %q", bodyTxt, out.GetBody())
+		}
+		return nil
+	})
+}
+
+func TestE2E_AttachmentsUnknownID(t *testing.T) {
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, &attachmentServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+			return err
+		}
+
+		strm, err := echo.NewSRPCEchoerClient(client).EchoServerStream(context.Background(), &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		if _, err := strm.Recv(); err != nil {
+			return err
+		}
+		// the server did not open an attachment with the ID
+		r, err := srpc.OpenAttachmentReader(strm, 1000)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if _, err := io.ReadAll(r); err == nil || !strings.Contains(err.Error(), srpc.ErrUnknownAttachment.Error()) {
+			return errors.Errorf("expected unknown attachment error but got %v", err)
+		}
+		return nil
+	})
+}
+
+// demandServer streams messages as fast as the client demands them.
+type demandServer struct {
+	*echo.EchoServer
+	// sent is the number of messages sent
+	sent atomic.Int32
+	// awaiting receives the number of messages sent before waiting for demand
+	awaiting chan int32
+}
+
+// EchoServerStream sends 5 messages when demanded by the client.
+func (s *demandServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	for i := 0; i < 5; i++ {
+		s.awaiting <- s.sent.Load()
+		if _, err := srpc.AwaitDemand(strm); err != nil {
+			return err
+		}
+		if err := strm.Send(msg); err != nil {
+			return err
+		}
+		s.sent.Add(1)
+	}
+	return nil
+}
+
+func TestE2E_Demand(t *testing.T) {
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		srv := &demandServer{EchoServer: echo.NewEchoServer(mux), awaiting: make(chan int32, 5)}
+		if err := echo.SRPCRegisterEchoer(mux, srv); err != nil {
+			return err
+		}
+
+		ctx := srpc.WithDemand(context.Background(), 2)
+		strm, err := echo.NewSRPCEchoerClient(client).EchoServerStream(ctx, &echo.EchoMsg{Body: bodyTxt})
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+
+		expectSent := func(n int32) error {
+			// wait for the server to wait for demand after sending n messages
+			for {
+				sent := <-srv.awaiting
+				if sent > n {
+					return errors.Errorf("expected %d messages sent but got %d", n, sent)
+				}
+				if sent == n {
+					break
+				}
+			}
+			if sent := srv.sent.Load(); sent != n {
+				return errors.Errorf("expected %d messages sent but got %d", n, sent)
+			}
+			return nil
+		}
+		recvN := func(n int) error {
+			for i := 0; i < n; i++ {
+				if _, err := strm.Recv(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if err := recvN(2); err != nil {
+			return err
+		}
+		if err := expectSent(2); err != nil {
+			return err
+		}
+		if err := srpc.RequestDemand(strm, 2); err != nil {
+			return err
+		}
+		if err := recvN(2); err != nil {
+			return err
+		}
+		if err := expectSent(4); err != nil {
+			return err
+		}
+		if err := srpc.RequestDemand(strm, 10); err != nil {
+			return err
+		}
+		if err := recvN(1); err != nil {
+			return err
+		}
+		if _, err := strm.Recv(); err != io.EOF {
+			return errors.Errorf("expected io.EOF but got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestE2E_WaitEstablished(t *testing.T) {
+	ctx := context.Background()
+	RunE2E(t, func(client echo.SRPCEchoerClient) error {
+		// the server does not send messages until the client closes the stream
+		strm, err := client.EchoClientStream(srpc.WithCallAck(ctx))
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		waitCtx, waitCtxCancel := context.WithTimeout(ctx, time.Second*5)
+		defer waitCtxCancel()
+		if err := srpc.WaitEstablished(waitCtx, strm); err != nil {
+			return err
+		}
+		if err := strm.Send(&echo.EchoMsg{Body: bodyTxt}); err != nil {
+			return err
+		}
+		resp, err := strm.CloseAndRecv()
+		if err != nil {
+			return err
+		}
+		if resp.GetBody() != bodyTxt {
+			return errors.Errorf("response body incorrect: %q", resp.GetBody())
+		}
+		return nil
+	})
+
+	// the server rejects calls without checksums at setup
+	opts := []srpc.ServerOption{srpc.WithRequiredChecksums()}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+			return err
+		}
+		strm, err := echo.NewSRPCEchoerClient(client).EchoClientStream(srpc.WithCallAck(ctx))
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		err = srpc.WaitEstablished(ctx, strm)
+		if err == nil || !strings.Contains(err.Error(), srpc.ErrChecksumRequired.Error()) {
+			return errors.Errorf("expected checksum required error but got %v", err)
+		}
+		return nil
+	})
+
+	// the server does not acknowledge calls to unknown methods
+	RunE2E_Setup(t, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		strm, err := client.NewStream(srpc.WithCallAck(ctx), "unknown-service", "unknown-method", nil)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		err = srpc.WaitEstablished(ctx, strm)
+		if !errors.Is(err, srpc.ErrUnimplemented) {
+			return errors.Errorf("expected unimplemented error but got %v", err)
+		}
+		return nil
+	})
+}
+
+// closeStatusServer closes the WebSocket conn with a close status.
+type closeStatusServer struct {
+	*echo.EchoServer
+}
+
+// Echo closes the conn carrying the call.
+func (s *closeStatusServer) Echo(ctx context.Context, msg *echo.EchoMsg) (*echo.EchoMsg, error) {
+	conn, ok := srpc.WebSocketConnFromContext(ctx)
+	if !ok {
+		return nil, errors.New("expected websocket conn in context")
+	}
+	_ = conn.CloseWithStatus(websocket.StatusCode(4001), "protocol violation")
+	return msg, nil
+}
+
+func TestE2E_WebSocketCloseStatus(t *testing.T) {
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, &closeStatusServer{EchoServer: echo.NewEchoServer(mux)}); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv, err := srpc.NewHTTPServer(mux, "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hsrv := httptest.NewServer(srv)
+	defer hsrv.Close()
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer ctxCancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hsrv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mc, err := srpc.NewWebSocketConn(ctx, conn, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client := srpc.NewClientWithMuxedConn(mc)
+	defer srpc.CloseClient(client)
+
+	if _, err := echo.NewSRPCEchoerClient(client).Echo(ctx, &echo.EchoMsg{Body: bodyTxt}); err == nil {
+		t.Fatal("expected call to fail when the conn closed")
+	}
+	_, _, err = conn.Reader(ctx)
+	if code := websocket.CloseStatus(err); code != 4001 {
+		t.Fatalf("expected close status 4001 but got %v: %v", code, err)
+	}
+}
+
+func TestE2E_GoroutineLimiter(t *testing.T) {
+	ctx := context.Background()
+	// one call uses two goroutines: the second call can read the stream but
+	// cannot start the handler.
+	limiter := srpc.NewGoroutineLimiter(3)
+	opts := []srpc.ServerOption{srpc.WithGoroutineLimiter(limiter)}
+	RunE2E_SetupWithOpts(t, opts, func(server *srpc.Server, mux srpc.Mux, client srpc.Client) error {
+		startedCh := make(chan struct{})
+		releaseCh := make(chan struct{})
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				select {
+				case <-startedCh:
+				default:
+					close(startedCh)
+					<-releaseCh
+				}
+				return msg, nil
+			},
+		}
+		_ = msrv.Register(mux)
+
+		mclient := e2e_mock.NewSRPCMockClient(client)
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+			errCh <- err
+		}()
+
+		<-startedCh
+		if n := limiter.Active(); n != 2 {
+			return errors.Errorf("expected 2 active goroutines but got %d", n)
+		}
+		if n := srpc.ActiveGoroutines(); n < 2 {
+			return errors.Errorf("expected library goroutines to be counted but got %d", n)
+		}
+		_, err := mclient.MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt})
+		close(releaseCh)
+		if !errors.Is(err, srpc.ErrServerBusy) {
+			return errors.Errorf("expected server busy error but got %v", err)
+		}
+		return <-errCh
+	})
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends data to the buffer.
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+// Bytes returns a copy of the buffer contents.
+func (b *syncBuffer) Bytes() []byte {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
+
+func TestE2E_RecordReplay(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	// newMux constructs a mux sending the received messages to a channel.
+	newMux := func(recvCh chan string) srpc.Mux {
+		mux := srpc.NewMux()
+		msrv := &e2e_mock.MockServer{
+			MockRequestCb: func(ctx context.Context, msg *e2e_mock.MockMsg) (*e2e_mock.MockMsg, error) {
+				recvCh <- msg.GetBody()
+				return msg, nil
+			},
+		}
+		_ = msrv.Register(mux)
+		return mux
+	}
+
+	// record a call on the server side
+	clientTpt, serverTpt, err := srpc.NewPipeTransports(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var recording syncBuffer
+	recTpt := srpc.NewRecordingTransport(serverTpt, &recording)
+	recvCh := make(chan string, 1)
+	go func() {
+		_ = srpc.NewServer(newMux(recvCh)).ServeTransport(ctx, recTpt)
+	}()
+	client := srpc.NewClientWithTransport(clientTpt)
+	if _, err := e2e_mock.NewSRPCMockClient(client).MockRequest(ctx, &e2e_mock.MockMsg{Body: bodyTxt}); err != nil {
+		t.Fatal(err.Error())
+	}
+	<-recvCh
+	_ = srpc.CloseClient(client)
+	if err := recTpt.Err(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// replay the recording against a new server
+	replayTpt := srpc.NewReplayTransport(bytes.NewReader(recording.Bytes()))
+	defer replayTpt.Close()
+	replayCh := make(chan string, 1)
+	go func() {
+		_ = srpc.NewServer(newMux(replayCh)).ServeTransport(ctx, replayTpt)
+	}()
+	select {
+	case body := <-replayCh:
+		if body != bodyTxt {
+			t.Fatalf("expected replayed message %q but got %q", bodyTxt, body)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the replayed call")
+	}
+}
+
+// pacingServer streams 5 messages and reports when the handler returns.
+type pacingServer struct {
+	*echo.EchoServer
+	// doneCh receives the error returned by the handler
+	doneCh chan error
+}
+
+// EchoServerStream sends 5 copies of the message.
+func (s *pacingServer) EchoServerStream(msg *echo.EchoMsg, strm echo.SRPCEchoer_EchoServerStreamStream) error {
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		err = strm.Send(msg)
+	}
+	s.doneCh <- err
+	return err
+}
+
+func TestE2E_Pacing(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	srv := &pacingServer{EchoServer: echo.NewEchoServer(mux), doneCh: make(chan error, 1)}
+	if err := echo.SRPCRegisterEchoer(mux, srv); err != nil {
+		t.Fatal(err.Error())
+	}
+	msg := &echo.EchoMsg{Body: bodyTxt}
+	size := msg.SizeVT()
+	inv := srpc.NewPacingInvoker(mux, func(ctx context.Context, serviceID, methodID string) (int, int) {
+		if srpc.MetadataFromContext(ctx).Get("tenant-id") == "free" {
+			// one message per second
+			return size, size
+		}
+		// one message per 50ms
+		return size * 20, size
+	})
+	client := echo.NewSRPCEchoerClient(srpc.NewClient(srpc.NewServerPipe(srpc.NewServer(inv))))
+
+	// expect 4 paced messages after the initial burst
+	start := time.Now()
+	strm, err := client.EchoServerStream(ctx, msg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for {
+		if _, err := strm.Recv(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err.Error())
+		}
+	}
+	_ = strm.Close()
+	if err := <-srv.doneCh; err != nil {
+		t.Fatal(err.Error())
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*180 {
+		t.Fatalf("expected messages to be paced but completed in %v", elapsed)
+	}
+
+	// expect canceling the call to interrupt pacing
+	strmCtx, strmCtxCancel := context.WithCancel(srpc.WithOutgoingMetadata(ctx, srpc.Metadata{"tenant-id": "free"}))
+	defer strmCtxCancel()
+	strm, err = client.EchoServerStream(strmCtx, msg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := strm.Recv(); err != nil {
+		t.Fatal(err.Error())
+	}
+	start = time.Now()
+	strmCtxCancel()
+	select {
+	case err := <-srv.doneCh:
+		if err == nil {
+			t.Fatal("expected the handler to fail after cancel")
+		}
+		if elapsed := time.Since(start); elapsed > time.Millisecond*500 {
+			t.Fatalf("expected cancel to interrupt pacing but took %v", elapsed)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the handler to return")
+	}
+}
@@ -36,7 +36,7 @@ func (c *srpcMockClient) SRPCClient() srpc.Client { return c.cc }
 
 func (c *srpcMockClient) MockRequest(ctx context.Context, in *MockMsg) (*MockMsg, error) {
 	out := new(MockMsg)
-	err := c.cc.ExecCall(ctx, c.serviceID, "MockRequest", in, out)
+	err := c.cc.ExecCall(ctx, c.serviceID, "MockRequest", srpc.AsMessage(in), srpc.AsMessage(out))
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +61,7 @@ type SRPCMockHandler struct {
 }
 
 // NewSRPCMockHandler constructs a new RPC handler.
+// The handler can be wrapped or inspected before registering with a Mux.
 // serviceID: if empty, uses default: e2e.mock.Mock
 func NewSRPCMockHandler(impl SRPCMockServer, serviceID string) srpc.Handler {
 	if serviceID == "" {
@@ -101,14 +102,14 @@ func (d *SRPCMockHandler) InvokeMethod(
 
 func (SRPCMockHandler) InvokeMethod_MockRequest(impl SRPCMockServer, strm srpc.Stream) error {
 	req := new(MockMsg)
-	if err := strm.MsgRecv(req); err != nil {
+	if err := strm.MsgRecv(srpc.AsMessage(req)); err != nil {
 		return err
 	}
 	out, err := impl.MockRequest(strm.Context(), req)
 	if err != nil {
 		return err
 	}
-	return strm.MsgSend(out)
+	return srpc.SendResponse(strm, srpc.AsMessage(out))
 }
 
 type SRPCMock_MockRequestStream interface {
@@ -41,7 +41,7 @@ func (c *srpcEchoerClient) SRPCClient() srpc.Client { return c.cc }
 
 func (c *srpcEchoerClient) Echo(ctx context.Context, in *EchoMsg) (*EchoMsg, error) {
 	out := new(EchoMsg)
-	err := c.cc.ExecCall(ctx, c.serviceID, "Echo", in, out)
+	err := c.cc.ExecCall(ctx, c.serviceID, "Echo", srpc.AsMessage(in), srpc.AsMessage(out))
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +49,7 @@ func (c *srpcEchoerClient) Echo(ctx context.Context, in *EchoMsg) (*EchoMsg, err
 }
 
 func (c *srpcEchoerClient) EchoServerStream(ctx context.Context, in *EchoMsg) (SRPCEchoer_EchoServerStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, c.serviceID, "EchoServerStream", in)
+	stream, err := c.cc.NewStream(ctx, c.serviceID, "EchoServerStream", srpc.AsMessage(in))
 	if err != nil {
 		return nil, err
 	}
@@ -72,14 +72,14 @@ type srpcEchoer_EchoServerStreamClient struct {
 
 func (x *srpcEchoer_EchoServerStreamClient) Recv() (*EchoMsg, error) {
 	m := new(EchoMsg)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.MsgRecv(srpc.AsMessage(m)); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_EchoServerStreamClient) RecvTo(m *EchoMsg) error {
-	return x.MsgRecv(m)
+	return x.MsgRecv(srpc.AsMessage(m))
 }
 
 func (c *srpcEchoerClient) EchoClientStream(ctx context.Context) (SRPCEchoer_EchoClientStreamClient, error) {
@@ -102,25 +102,19 @@ type srpcEchoer_EchoClientStreamClient struct {
 }
 
 func (x *srpcEchoer_EchoClientStreamClient) Send(m *EchoMsg) error {
-	return x.MsgSend(m)
+	return x.MsgSend(srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_EchoClientStreamClient) CloseAndRecv() (*EchoMsg, error) {
-	if err := x.CloseSend(); err != nil {
-		return nil, err
-	}
 	m := new(EchoMsg)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.CloseAndMsgRecv(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_EchoClientStreamClient) CloseAndMsgRecv(m *EchoMsg) error {
-	if err := x.CloseSend(); err != nil {
-		return err
-	}
-	return x.MsgRecv(m)
+	return srpc.CloseAndMsgRecv(x.Stream, srpc.AsMessage(m))
 }
 
 func (c *srpcEchoerClient) EchoBidiStream(ctx context.Context) (SRPCEchoer_EchoBidiStreamClient, error) {
@@ -144,19 +138,19 @@ type srpcEchoer_EchoBidiStreamClient struct {
 }
 
 func (x *srpcEchoer_EchoBidiStreamClient) Send(m *EchoMsg) error {
-	return x.MsgSend(m)
+	return x.MsgSend(srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_EchoBidiStreamClient) Recv() (*EchoMsg, error) {
 	m := new(EchoMsg)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.MsgRecv(srpc.AsMessage(m)); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_EchoBidiStreamClient) RecvTo(m *EchoMsg) error {
-	return x.MsgRecv(m)
+	return x.MsgRecv(srpc.AsMessage(m))
 }
 
 func (c *srpcEchoerClient) RpcStream(ctx context.Context) (SRPCEchoer_RpcStreamClient, error) {
@@ -180,19 +174,19 @@ type srpcEchoer_RpcStreamClient struct {
 }
 
 func (x *srpcEchoer_RpcStreamClient) Send(m *rpcstream.RpcStreamPacket) error {
-	return x.MsgSend(m)
+	return x.MsgSend(srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_RpcStreamClient) Recv() (*rpcstream.RpcStreamPacket, error) {
 	m := new(rpcstream.RpcStreamPacket)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.MsgRecv(srpc.AsMessage(m)); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_RpcStreamClient) RecvTo(m *rpcstream.RpcStreamPacket) error {
-	return x.MsgRecv(m)
+	return x.MsgRecv(srpc.AsMessage(m))
 }
 
 type SRPCEchoerServer interface {
@@ -233,6 +227,7 @@ type SRPCEchoerHandler struct {
 }
 
 // NewSRPCEchoerHandler constructs a new RPC handler.
+// The handler can be wrapped or inspected before registering with a Mux.
 // serviceID: if empty, uses default: echo.Echoer
 func NewSRPCEchoerHandler(impl SRPCEchoerServer, serviceID string) srpc.Handler {
 	if serviceID == "" {
@@ -285,19 +280,19 @@ func (d *SRPCEchoerHandler) InvokeMethod(
 
 func (SRPCEchoerHandler) InvokeMethod_Echo(impl SRPCEchoerServer, strm srpc.Stream) error {
 	req := new(EchoMsg)
-	if err := strm.MsgRecv(req); err != nil {
+	if err := strm.MsgRecv(srpc.AsMessage(req)); err != nil {
 		return err
 	}
 	out, err := impl.Echo(strm.Context(), req)
 	if err != nil {
 		return err
 	}
-	return strm.MsgSend(out)
+	return srpc.SendResponse(strm, srpc.AsMessage(out))
 }
 
 func (SRPCEchoerHandler) InvokeMethod_EchoServerStream(impl SRPCEchoerServer, strm srpc.Stream) error {
 	req := new(EchoMsg)
-	if err := strm.MsgRecv(req); err != nil {
+	if err := strm.MsgRecv(srpc.AsMessage(req)); err != nil {
 		return err
 	}
 	serverStrm := &srpcEchoer_EchoServerStreamStream{strm}
@@ -310,7 +305,7 @@ func (SRPCEchoerHandler) InvokeMethod_EchoClientStream(impl SRPCEchoerServer, st
 	if err != nil {
 		return err
 	}
-	return strm.MsgSend(out)
+	return srpc.SendResponse(strm, srpc.AsMessage(out))
 }
 
 func (SRPCEchoerHandler) InvokeMethod_EchoBidiStream(impl SRPCEchoerServer, strm srpc.Stream) error {
@@ -342,19 +337,17 @@ type srpcEchoer_EchoServerStreamStream struct {
 }
 
 func (x *srpcEchoer_EchoServerStreamStream) Send(m *EchoMsg) error {
-	return x.MsgSend(m)
+	return x.MsgSend(srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_EchoServerStreamStream) SendAndClose(m *EchoMsg) error {
-	if err := x.MsgSend(m); err != nil {
-		return err
-	}
-	return x.CloseSend()
+	return srpc.SendClose(x.Stream, srpc.AsMessage(m))
 }
 
 type SRPCEchoer_EchoClientStreamStream interface {
 	srpc.Stream
 	Recv() (*EchoMsg, error)
+	RecvTo(*EchoMsg) error
 }
 
 type srpcEchoer_EchoClientStreamStream struct {
@@ -363,14 +356,14 @@ type srpcEchoer_EchoClientStreamStream struct {
 
 func (x *srpcEchoer_EchoClientStreamStream) Recv() (*EchoMsg, error) {
 	m := new(EchoMsg)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.MsgRecv(srpc.AsMessage(m)); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_EchoClientStreamStream) RecvTo(m *EchoMsg) error {
-	return x.MsgRecv(m)
+	return x.MsgRecv(srpc.AsMessage(m))
 }
 
 type SRPCEchoer_EchoBidiStreamStream interface {
@@ -378,6 +371,7 @@ type SRPCEchoer_EchoBidiStreamStream interface {
 	Send(*EchoMsg) error
 	SendAndClose(*EchoMsg) error
 	Recv() (*EchoMsg, error)
+	RecvTo(*EchoMsg) error
 }
 
 type srpcEchoer_EchoBidiStreamStream struct {
@@ -385,26 +379,23 @@ type srpcEchoer_EchoBidiStreamStream struct {
 }
 
 func (x *srpcEchoer_EchoBidiStreamStream) Send(m *EchoMsg) error {
-	return x.MsgSend(m)
+	return x.MsgSend(srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_EchoBidiStreamStream) SendAndClose(m *EchoMsg) error {
-	if err := x.MsgSend(m); err != nil {
-		return err
-	}
-	return x.CloseSend()
+	return srpc.SendClose(x.Stream, srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_EchoBidiStreamStream) Recv() (*EchoMsg, error) {
 	m := new(EchoMsg)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.MsgRecv(srpc.AsMessage(m)); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_EchoBidiStreamStream) RecvTo(m *EchoMsg) error {
-	return x.MsgRecv(m)
+	return x.MsgRecv(srpc.AsMessage(m))
 }
 
 type SRPCEchoer_RpcStreamStream interface {
@@ -412,6 +403,7 @@ type SRPCEchoer_RpcStreamStream interface {
 	Send(*rpcstream.RpcStreamPacket) error
 	SendAndClose(*rpcstream.RpcStreamPacket) error
 	Recv() (*rpcstream.RpcStreamPacket, error)
+	RecvTo(*rpcstream.RpcStreamPacket) error
 }
 
 type srpcEchoer_RpcStreamStream struct {
@@ -419,24 +411,21 @@ type srpcEchoer_RpcStreamStream struct {
 }
 
 func (x *srpcEchoer_RpcStreamStream) Send(m *rpcstream.RpcStreamPacket) error {
-	return x.MsgSend(m)
+	return x.MsgSend(srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_RpcStreamStream) SendAndClose(m *rpcstream.RpcStreamPacket) error {
-	if err := x.MsgSend(m); err != nil {
-		return err
-	}
-	return x.CloseSend()
+	return srpc.SendClose(x.Stream, srpc.AsMessage(m))
 }
 
 func (x *srpcEchoer_RpcStreamStream) Recv() (*rpcstream.RpcStreamPacket, error) {
 	m := new(rpcstream.RpcStreamPacket)
-	if err := x.MsgRecv(m); err != nil {
+	if err := x.MsgRecv(srpc.AsMessage(m)); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
 func (x *srpcEchoer_RpcStreamStream) RecvTo(m *rpcstream.RpcStreamPacket) error {
-	return x.MsgRecv(m)
+	return x.MsgRecv(srpc.AsMessage(m))
 }
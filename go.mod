@@ -1,6 +1,6 @@
 module github.com/aperturerobotics/starpc
 
-go 1.18
+go 1.21
 
 require (
 	github.com/pkg/errors v0.9.1
@@ -10,6 +10,7 @@ require (
 
 require (
 	github.com/aperturerobotics/util v1.0.3-0.20230130061818-dab10b56858b
+	github.com/klauspost/compress v1.15.12
 	github.com/libp2p/go-libp2p v0.24.2
 	github.com/libp2p/go-yamux/v4 v4.0.1-0.20220919134236-1c09f2ab3ec1
 	github.com/sirupsen/logrus v1.9.0
@@ -19,7 +20,6 @@ require (
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.1.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/ipfs/go-cid v0.3.2 // indirect
-	github.com/klauspost/compress v1.15.12 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.1 // indirect
 	github.com/libp2p/go-buffer-pool v0.1.0 // indirect
 	github.com/libp2p/go-openssl v0.1.0 // indirect
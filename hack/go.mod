@@ -1,6 +1,6 @@
 module hack
 
-go 1.18
+go 1.21
 
 replace github.com/aperturerobotics/starpc => ../
 
@@ -116,6 +116,7 @@ type SRPCIntegrationService_RpcStreamStream interface {
 	srpc.Stream
 	Send(*rpcstream.RpcStreamPacket) error
 	Recv() (*rpcstream.RpcStreamPacket, error)
+	RecvTo(*rpcstream.RpcStreamPacket) error
 }
 
 type srpcIntegrationService_RpcStreamStream struct {
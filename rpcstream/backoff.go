@@ -0,0 +1,149 @@
+package rpcstream
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/aperturerobotics/starpc/srpc"
+)
+
+// Backoff configures re-trying to open a RpcStream after a failure.
+type Backoff struct {
+	// MaxAttempts is the maximum number of attempts to open the stream.
+	// If zero, retries until the context is canceled.
+	MaxAttempts int
+	// InitialDelay is the delay after the first failure.
+	// If zero, defaults to 100ms.
+	InitialDelay time.Duration
+	// MaxDelay is the maximum delay between attempts.
+	// If zero, defaults to 10s.
+	MaxDelay time.Duration
+	// Multiplier is the factor to increase the delay by after each failure.
+	// If less than 1, defaults to 2.
+	Multiplier float64
+	// Jitter is the fraction of the delay to randomize by, between 0 and 1.
+	// For example 0.2 waits between 80% and 120% of the delay.
+	Jitter float64
+}
+
+// delay returns the delay before the given attempt (starting at 1).
+func (b *Backoff) delay(attempt int) time.Duration {
+	initialDelay, maxDelay, multiplier := b.InitialDelay, b.MaxDelay, b.Multiplier
+	if initialDelay <= 0 {
+		initialDelay = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(initialDelay)
+	for i := 1; i < attempt && delay < float64(maxDelay); i++ {
+		delay *= multiplier
+	}
+	if delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+	if jitter := b.Jitter; jitter > 0 {
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay += delay * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
+
+// retry calls fn until it succeeds, the attempts are exhausted, or ctx is canceled.
+//
+// Waits at least the retry-after hint of a status returned by fn (see
+// srpc.RetryAfterOf) before the next attempt.
+// Returns the last error from fn if the attempts are exhausted.
+func (b *Backoff) retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		default:
+		}
+		if b.MaxAttempts > 0 && attempt >= b.MaxAttempts {
+			return err
+		}
+
+		// honor the retry-after hint sent by overloaded servers
+		delay := b.delay(attempt)
+		if retryAfter := srpc.RetryAfterOf(err); retryAfter > delay {
+			delay = retryAfter
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Canceled
+		case <-timer.C:
+		}
+	}
+}
+
+// Option configures opening RpcStreams.
+type Option func(opts *openStreamOpts)
+
+// openStreamOpts contains the options for opening RpcStreams.
+type openStreamOpts struct {
+	// backoff is the backoff for re-trying to open the stream.
+	backoff *Backoff
+	// maxBuffer is the max number of bytes to receive ahead of the reader.
+	maxBuffer int
+	// initPayload is the payload to send with the init packet.
+	initPayload []byte
+}
+
+// newOpenStreamOpts applies the list of options.
+func newOpenStreamOpts(opts []Option) openStreamOpts {
+	var o openStreamOpts
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithReconnectBackoff re-opens the RpcStream with a backoff on failure.
+//
+// If calling rpcCaller or the init handshake fails, retries with the backoff
+// until it succeeds, MaxAttempts is reached, or the call context is canceled.
+// Each attempt calls rpcCaller and re-sends the init packet with the component
+// id. Calls already in-flight when the base connection drops still fail, but
+// new calls re-open the stream over the recovered connection.
+func WithReconnectBackoff(b Backoff) Option {
+	return func(opts *openStreamOpts) {
+		opts.backoff = &b
+	}
+}
+
+// WithMaxBuffer receives up to size bytes ahead of the RpcStreamReadWriter reader.
+//
+// Receiving waits for the reader once size bytes are buffered.
+// See NewRpcStreamReadWriterWithMaxBuffer.
+func WithMaxBuffer(size int) Option {
+	return func(opts *openStreamOpts) {
+		opts.maxBuffer = size
+	}
+}
+
+// WithInitPayload sends the payload with the component id in the init packet.
+//
+// For example credentials to authorize access to the component without an
+// extra round trip. The remote reads it with InitPayloadFromContext.
+func WithInitPayload(payload []byte) Option {
+	return func(opts *openStreamOpts) {
+		opts.initPayload = payload
+	}
+}
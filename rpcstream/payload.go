@@ -0,0 +1,21 @@
+package rpcstream
+
+import "context"
+
+// initPayloadCtxKey is the context key for the init payload.
+type initPayloadCtxKey struct{}
+
+// withInitPayload attaches the init payload to the context.
+func withInitPayload(ctx context.Context, payload []byte) context.Context {
+	return context.WithValue(ctx, initPayloadCtxKey{}, payload)
+}
+
+// InitPayloadFromContext returns the payload sent with the RpcStream init packet.
+//
+// Set on the context passed to the RpcStreamGetter and the calls within the
+// RpcStream by HandleRpcStream. Returns nil if no payload was sent.
+// The returned data must not be modified.
+func InitPayloadFromContext(ctx context.Context) []byte {
+	payload, _ := ctx.Value(initPayloadCtxKey{}).([]byte)
+	return payload
+}
@@ -1,11 +1,12 @@
 package rpcstream
 
 import (
-	"bytes"
 	"context"
 	"io"
+	"sync"
 
 	"github.com/aperturerobotics/starpc/srpc"
+	"github.com/aperturerobotics/util/broadcast"
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +21,7 @@ type RpcStream interface {
 // RpcStreamGetter returns the Mux for the component ID from the remote.
 // Returns a release function to call when done with the Mux.
 // Returns nil, nil, nil if not found.
+// The payload sent with the component ID is available with InitPayloadFromContext.
 type RpcStreamGetter func(ctx context.Context, componentID string) (srpc.Invoker, func(), error)
 
 // RpcStreamCaller is a function which starts the RpcStream call.
@@ -29,6 +31,38 @@ type RpcStreamCaller[T RpcStream] func(ctx context.Context) (T, error)
 //
 // if waitAck is set, waits for acknowledgment from the remote before returning.
 func OpenRpcStream[T RpcStream](ctx context.Context, rpcCaller RpcStreamCaller[T], componentID string, waitAck bool) (io.ReadWriteCloser, error) {
+	return OpenRpcStreamWithPayload(ctx, rpcCaller, componentID, nil, waitAck)
+}
+
+// OpenRpcStreamWithPayload opens a RPC stream with a remote sending a payload.
+//
+// The payload is sent with the component id in the init packet, for example
+// credentials to authorize access to the component. The remote can read it
+// with InitPayloadFromContext. payload can be nil.
+// if waitAck is set, waits for acknowledgment from the remote before returning.
+func OpenRpcStreamWithPayload[T RpcStream](
+	ctx context.Context,
+	rpcCaller RpcStreamCaller[T],
+	componentID string,
+	payload []byte,
+	waitAck bool,
+) (io.ReadWriteCloser, error) {
+	rw, err := openRpcStream(ctx, rpcCaller, componentID, payload, waitAck, 0)
+	if err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+// openRpcStream opens a RPC stream with a remote with a max buffer size.
+func openRpcStream[T RpcStream](
+	ctx context.Context,
+	rpcCaller RpcStreamCaller[T],
+	componentID string,
+	payload []byte,
+	waitAck bool,
+	maxBuffer int,
+) (*RpcStreamReadWriter, error) {
 	// open the rpc stream
 	rpcStream, err := rpcCaller(ctx)
 	if err != nil {
@@ -40,6 +74,7 @@ func OpenRpcStream[T RpcStream](ctx context.Context, rpcCaller RpcStreamCaller[T
 		Body: &RpcStreamPacket_Init{
 			Init: &RpcStreamInit{
 				ComponentId: componentID,
+				Payload:     payload,
 			},
 		},
 	})
@@ -68,17 +103,34 @@ func OpenRpcStream[T RpcStream](ctx context.Context, rpcCaller RpcStreamCaller[T
 	}
 
 	// ready
-	rw := NewRpcStreamReadWriter(rpcStream)
+	rw := NewRpcStreamReadWriterWithMaxBuffer(rpcStream, maxBuffer)
 	return rw, nil
 }
 
 // NewRpcStreamOpenStream constructs an OpenStream function with a RpcStream.
 //
 // if waitAck is set, OpenStream waits for acknowledgment from the remote.
-func NewRpcStreamOpenStream[T RpcStream](rpcCaller RpcStreamCaller[T], componentID string, waitAck bool) srpc.OpenStreamFunc {
+func NewRpcStreamOpenStream[T RpcStream](
+	rpcCaller RpcStreamCaller[T],
+	componentID string,
+	waitAck bool,
+	opts ...Option,
+) srpc.OpenStreamFunc {
+	o := newOpenStreamOpts(opts)
 	return func(ctx context.Context, msgHandler srpc.PacketHandler, closeHandler srpc.CloseHandler) (srpc.Writer, error) {
 		// open the stream
-		rw, err := OpenRpcStream(ctx, rpcCaller, componentID, waitAck)
+		var rw *RpcStreamReadWriter
+		openStream := func() error {
+			var err error
+			rw, err = openRpcStream(ctx, rpcCaller, componentID, o.initPayload, waitAck, o.maxBuffer)
+			return err
+		}
+		var err error
+		if o.backoff != nil {
+			err = o.backoff.retry(ctx, openStream)
+		} else {
+			err = openStream()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -97,12 +149,20 @@ func NewRpcStreamOpenStream[T RpcStream](rpcCaller RpcStreamCaller[T], component
 // NewRpcStreamClient constructs a Client which opens streams with a RpcStream.
 //
 // if waitAck is set, OpenStream waits for acknowledgment from the remote.
-func NewRpcStreamClient[T RpcStream](rpcCaller RpcStreamCaller[T], componentID string, waitAck bool) srpc.Client {
-	openStream := NewRpcStreamOpenStream(rpcCaller, componentID, waitAck)
+func NewRpcStreamClient[T RpcStream](
+	rpcCaller RpcStreamCaller[T],
+	componentID string,
+	waitAck bool,
+	opts ...Option,
+) srpc.Client {
+	openStream := NewRpcStreamOpenStream(rpcCaller, componentID, waitAck, opts...)
 	return srpc.NewClient(openStream)
 }
 
 // HandleRpcStream handles an incoming RPC stream (remote is the initiator).
+//
+// The calls within the stream are canceled when the stream context is canceled
+// or when HandleRpcStream returns.
 func HandleRpcStream(stream RpcStream, getter RpcStreamGetter) error {
 	// Read the "init" packet.
 	initPkt, err := stream.Recv()
@@ -119,7 +179,11 @@ func HandleRpcStream(stream RpcStream, getter RpcStreamGetter) error {
 	}
 
 	// lookup the server for this component id
-	ctx := stream.Context()
+	ctx, ctxCancel := context.WithCancel(stream.Context())
+	defer ctxCancel()
+	if payload := initInner.Init.GetPayload(); len(payload) != 0 {
+		ctx = withInitPayload(ctx, payload)
+	}
 	mux, muxRel, err := getter(ctx, componentID)
 	if err == nil && mux == nil {
 		err = errors.New("no server for that component")
@@ -154,11 +218,36 @@ func HandleRpcStream(stream RpcStream, getter RpcStreamGetter) error {
 }
 
 // RpcStreamReadWriter reads and writes a buffered RpcStream.
+//
+// By default buffers at most one received packet: the next packet is not
+// received from the RpcStream until the previous packet was read. A slow reader
+// applies backpressure to the RpcStream instead of buffering incoming data.
+//
+// With a max buffer size, packets are received ahead of the reader until the
+// buffered data reaches the max buffer size. Receiving then waits for the
+// reader to drain the buffer, applying backpressure to the remote.
 type RpcStreamReadWriter struct {
 	// stream is the RpcStream
 	stream RpcStream
-	// buf is the incoming data buffer
-	buf bytes.Buffer
+	// maxBuffer is the max number of bytes to receive ahead of the reader.
+	// if zero, packets are received when read.
+	maxBuffer int
+	// pending is the unread remainder of the last received data packet.
+	// references the packet data to avoid copying it to a buffer.
+	pending []byte
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// bcast broadcasts when below fields change
+	bcast broadcast.Broadcast
+	// started is set when the receive loop was started.
+	started bool
+	// queue contains the data packets received ahead of the reader.
+	queue [][]byte
+	// queued is the number of bytes in queue.
+	queued int
+	// recvErr is the error returned by the receive loop, if any.
+	recvErr error
 }
 
 // NewRpcStreamReadWriter constructs a new read/writer.
@@ -166,6 +255,19 @@ func NewRpcStreamReadWriter(stream RpcStream) *RpcStreamReadWriter {
 	return &RpcStreamReadWriter{stream: stream}
 }
 
+// NewRpcStreamReadWriterWithMaxBuffer constructs a new read/writer with a max buffer size.
+//
+// Receives packets ahead of the reader until maxBuffer bytes are buffered,
+// then waits for the reader to drain the buffer before receiving more. A
+// packet larger than maxBuffer is received once the buffer is empty.
+// If maxBuffer is zero or negative, packets are received when read.
+func NewRpcStreamReadWriterWithMaxBuffer(stream RpcStream, maxBuffer int) *RpcStreamReadWriter {
+	if maxBuffer < 0 {
+		maxBuffer = 0
+	}
+	return &RpcStreamReadWriter{stream: stream, maxBuffer: maxBuffer}
+}
+
 // Write writes a packet to the writer.
 func (r *RpcStreamReadWriter) Write(p []byte) (n int, err error) {
 	if len(p) == 0 {
@@ -183,52 +285,122 @@ func (r *RpcStreamReadWriter) Write(p []byte) (n int, err error) {
 }
 
 // Read reads a packet from the writer.
+//
+// Returns the data from at most one packet per call. Data which does not fit
+// in p is retained and returned by subsequent calls without copying.
 func (r *RpcStreamReadWriter) Read(p []byte) (n int, err error) {
-	readBuf := p
-	for len(readBuf) != 0 && err == nil {
-		var rn int
-
-		// if the buffer has data, read from it.
-		if r.buf.Len() != 0 {
-			rn, err = r.buf.Read(readBuf)
-		} else {
-			if n != 0 {
-				// if we read data to p already, return now.
-				break
-			}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(r.pending) == 0 {
+		r.pending, err = r.ReadChunk()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
 
-			var pkt *RpcStreamPacket
-			pkt, err = r.stream.Recv()
-			if err != nil {
-				break
-			}
+// ReadChunk returns the data from the next packet without copying.
+//
+// Implements srpc.ChunkReader for the nested PacketReadWriter.
+// Returns context.Canceled if the stream context is canceled while waiting.
+func (r *RpcStreamReadWriter) ReadChunk() ([]byte, error) {
+	if len(r.pending) != 0 {
+		data := r.pending
+		r.pending = nil
+		return data, nil
+	}
+	if r.maxBuffer == 0 {
+		return r.recvChunk()
+	}
 
-			if errStr := pkt.GetAck().GetError(); errStr != "" {
-				return n, errors.New(errStr)
-			}
+	ctx := r.stream.Context()
+	r.mtx.Lock()
+	if !r.started {
+		r.started = true
+		go r.recvLoop(ctx)
+	}
+	for {
+		if len(r.queue) != 0 {
+			data := r.queue[0]
+			r.queue[0] = nil
+			r.queue = r.queue[1:]
+			r.queued -= len(data)
+			r.bcast.Broadcast()
+			r.mtx.Unlock()
+			return data, nil
+		}
+		if r.recvErr != nil {
+			err := r.recvErr
+			r.mtx.Unlock()
+			return nil, err
+		}
+		waitCh := r.bcast.GetWaitCh()
+		r.mtx.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, context.Canceled
+		case <-waitCh:
+		}
+		r.mtx.Lock()
+	}
+}
 
-			data := pkt.GetData()
-			if len(data) == 0 {
-				continue
-			}
+// recvChunk receives the data from the next non-empty packet.
+func (r *RpcStreamReadWriter) recvChunk() ([]byte, error) {
+	for {
+		pkt, err := r.stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errStr := pkt.GetAck().GetError(); errStr != "" {
+			return nil, errors.New(errStr)
+		}
+		if data := pkt.GetData(); len(data) != 0 {
+			return data, nil
+		}
+	}
+}
 
-			// read as much as possible directly to the output
-			copy(readBuf, data)
-			if len(data) > len(readBuf) {
-				// we read some of the data, buffer the rest.
-				rn = len(readBuf)
-				_, _ = r.buf.Write(data[rn:]) // never returns an error
-			} else {
-				// we read all of data
-				rn = len(data)
+// recvLoop receives packets ahead of the reader until maxBuffer bytes are queued.
+//
+// Waits for the reader to drain the queue before calling Recv again.
+func (r *RpcStreamReadWriter) recvLoop(ctx context.Context) {
+	for {
+		r.mtx.Lock()
+		for r.queued >= r.maxBuffer {
+			waitCh := r.bcast.GetWaitCh()
+			r.mtx.Unlock()
+			select {
+			case <-ctx.Done():
+				r.mtx.Lock()
+				r.recvErr = context.Canceled
+				r.bcast.Broadcast()
+				r.mtx.Unlock()
+				return
+			case <-waitCh:
 			}
+			r.mtx.Lock()
 		}
+		r.mtx.Unlock()
 
-		// advance readBuf by rn
-		n += rn
-		readBuf = readBuf[rn:]
+		data, err := r.recvChunk()
+		r.mtx.Lock()
+		if err != nil {
+			r.recvErr = err
+		} else {
+			r.queue = append(r.queue, data)
+			r.queued += len(data)
+		}
+		r.bcast.Broadcast()
+		r.mtx.Unlock()
+		if err != nil {
+			return
+		}
 	}
-	return n, err
 }
 
 // Close closes the packet rw.
@@ -237,4 +409,7 @@ func (r *RpcStreamReadWriter) Close() error {
 }
 
 // _ is a type assertion
-var _ io.ReadWriteCloser = (*RpcStreamReadWriter)(nil)
+var (
+	_ io.ReadWriteCloser = (*RpcStreamReadWriter)(nil)
+	_ srpc.ChunkReader   = (*RpcStreamReadWriter)(nil)
+)
@@ -0,0 +1,243 @@
+package rpcstream
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/starpc/srpc"
+	"github.com/pkg/errors"
+)
+
+// benchRpcStream is a RpcStream which returns a fixed list of packets.
+type benchRpcStream struct {
+	srpc.Stream
+	pkts []*RpcStreamPacket
+}
+
+// Send discards the packet.
+func (s *benchRpcStream) Send(*RpcStreamPacket) error { return nil }
+
+// Recv returns the next packet.
+func (s *benchRpcStream) Recv() (*RpcStreamPacket, error) {
+	if len(s.pkts) == 0 {
+		return nil, io.EOF
+	}
+	pkt := s.pkts[0]
+	s.pkts = s.pkts[1:]
+	return pkt, nil
+}
+
+// Close does nothing.
+func (s *benchRpcStream) Close() error { return nil }
+
+// buildFramedPackets builds RpcStream data packets with framed srpc packets.
+func buildFramedPackets(b *testing.B, count, msgSize int) []*RpcStreamPacket {
+	var frames []*RpcStreamPacket
+	capture := &captureWriter{}
+	prw := srpc.NewPacketReadWriter(capture)
+	for i := 0; i < count; i++ {
+		if err := prw.WritePacket(srpc.NewCallDataPacket(make([]byte, msgSize), false, false, nil)); err != nil {
+			b.Fatal(err.Error())
+		}
+	}
+	for _, frame := range capture.frames {
+		frames = append(frames, &RpcStreamPacket{Body: &RpcStreamPacket_Data{Data: frame}})
+	}
+	return frames
+}
+
+// captureWriter records each written buffer.
+type captureWriter struct {
+	frames [][]byte
+}
+
+// Read returns io.EOF.
+func (w *captureWriter) Read(p []byte) (int, error) { return 0, io.EOF }
+
+// Write records the buffer.
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.frames = append(w.frames, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// Close does nothing.
+func (w *captureWriter) Close() error { return nil }
+
+// benchmarkNestedRead benchmarks reading framed packets through a RpcStreamReadWriter.
+func benchmarkNestedRead(b *testing.B, msgSize int) {
+	const count = 64
+	frames := buildFramedPackets(b, count, msgSize)
+	b.SetBytes(int64(count * msgSize))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		strm := &benchRpcStream{pkts: frames}
+		prw := srpc.NewPacketReadWriter(NewRpcStreamReadWriter(strm))
+		var n int
+		if err := prw.ReadToHandler(func(pkt *srpc.Packet) error {
+			n++
+			return nil
+		}); err != nil {
+			b.Fatal(err.Error())
+		}
+		if n != count {
+			b.Fatalf("expected %d packets but got %d", count, n)
+		}
+	}
+}
+
+func BenchmarkRpcStreamReadWriter_Read_1KiB(b *testing.B) {
+	benchmarkNestedRead(b, 1024)
+}
+
+func BenchmarkRpcStreamReadWriter_Read_64KiB(b *testing.B) {
+	benchmarkNestedRead(b, 64*1024)
+}
+
+// countRpcStream is a benchRpcStream which counts the Recv calls.
+type countRpcStream struct {
+	benchRpcStream
+	recvCount atomic.Int32
+}
+
+// Context returns the stream context.
+func (s *countRpcStream) Context() context.Context {
+	return context.Background()
+}
+
+// Recv returns the next packet.
+func (s *countRpcStream) Recv() (*RpcStreamPacket, error) {
+	s.recvCount.Add(1)
+	return s.benchRpcStream.Recv()
+}
+
+// buildDataPackets builds count data packets of size bytes.
+func buildDataPackets(count, size int) []*RpcStreamPacket {
+	var pkts []*RpcStreamPacket
+	for i := 0; i < count; i++ {
+		pkts = append(pkts, &RpcStreamPacket{Body: &RpcStreamPacket_Data{Data: make([]byte, size)}})
+	}
+	return pkts
+}
+
+// TestRpcStreamReadWriter_SlowReader tests that packets are not received until
+// the previous packet was read.
+func TestRpcStreamReadWriter_SlowReader(t *testing.T) {
+	strm := &countRpcStream{benchRpcStream: benchRpcStream{pkts: buildDataPackets(8, 16)}}
+	rw := NewRpcStreamReadWriter(strm)
+
+	// read one byte at a time
+	buf := make([]byte, 1)
+	for i := 0; i < 8*16; i++ {
+		if _, err := rw.Read(buf); err != nil {
+			t.Fatal(err.Error())
+		}
+		if expected := int32(i/16 + 1); strm.recvCount.Load() != expected {
+			t.Fatalf("read %d bytes: expected %d packets received but got %d", i+1, expected, strm.recvCount.Load())
+		}
+	}
+	if _, err := rw.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+}
+
+// TestRpcStreamReadWriter_MaxBuffer tests that a slow reader stops receiving
+// once the max buffer size is reached.
+func TestRpcStreamReadWriter_MaxBuffer(t *testing.T) {
+	strm := &countRpcStream{benchRpcStream: benchRpcStream{pkts: buildDataPackets(8, 16)}}
+	rw := NewRpcStreamReadWriterWithMaxBuffer(strm, 32)
+
+	// read one byte at a time
+	buf := make([]byte, 1)
+	for i := 0; i < 8*16; i++ {
+		if _, err := rw.Read(buf); err != nil {
+			t.Fatal(err.Error())
+		}
+		// the packet being read plus at most 32 bytes are buffered
+		if limit := int32(i/16 + 3); strm.recvCount.Load() > limit {
+			t.Fatalf("read %d bytes: expected at most %d packets received but got %d", i+1, limit, strm.recvCount.Load())
+		}
+		if i%16 == 0 {
+			<-time.After(time.Millisecond)
+		}
+	}
+	if _, err := rw.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+}
+
+// TestRpcStreamReadWriter_MaxBufferLargePacket tests receiving a packet larger
+// than the max buffer size.
+func TestRpcStreamReadWriter_MaxBufferLargePacket(t *testing.T) {
+	strm := &countRpcStream{benchRpcStream: benchRpcStream{pkts: buildDataPackets(2, 64)}}
+	rw := NewRpcStreamReadWriterWithMaxBuffer(strm, 16)
+	data, err := io.ReadAll(rw)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(data) != 128 {
+		t.Fatalf("expected 128 bytes but got %d", len(data))
+	}
+}
+
+// blockRpcStream is a RpcStream which blocks in Recv until ctx is canceled.
+type blockRpcStream struct {
+	benchRpcStream
+	ctx context.Context
+}
+
+// Context returns the stream context.
+func (s *blockRpcStream) Context() context.Context {
+	return s.ctx
+}
+
+// Recv waits for ctx to be canceled.
+func (s *blockRpcStream) Recv() (*RpcStreamPacket, error) {
+	<-s.ctx.Done()
+	return nil, s.ctx.Err()
+}
+
+// TestRpcStreamReadWriter_MaxBufferCanceled tests canceling the stream context
+// while waiting for a packet.
+func TestRpcStreamReadWriter_MaxBufferCanceled(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	rw := NewRpcStreamReadWriterWithMaxBuffer(&blockRpcStream{ctx: ctx}, 16)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := rw.Read(make([]byte, 1))
+		errCh <- err
+	}()
+	ctxCancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled but got %v", err)
+	}
+}
+
+// TestBackoff_RetryAfter tests waiting for the retry-after hint of the server.
+func TestBackoff_RetryAfter(t *testing.T) {
+	const retryAfter = 50 * time.Millisecond
+	b := &Backoff{InitialDelay: time.Millisecond}
+	busyErr := srpc.NewStatusError(srpc.StatusUnavailable, "busy").WithRetryAfter(retryAfter)
+
+	var attempts int
+	start := time.Now()
+	err := b.retry(context.Background(), func() error {
+		attempts++
+		if attempts == 1 {
+			return busyErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts but got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed < retryAfter {
+		t.Fatalf("expected to wait at least %v but waited %v", retryAfter, elapsed)
+	}
+}
@@ -2,6 +2,7 @@ package srpc
 
 import (
 	"context"
+	"log/slog"
 	"net"
 
 	"github.com/libp2p/go-yamux/v4"
@@ -11,13 +12,22 @@ import (
 //
 // Uses the default yamux muxer.
 // If yamux conf is nil, uses the defaults.
-func AcceptMuxedListener(ctx context.Context, lis net.Listener, srv *Server, yamuxConf *yamux.Config) error {
+// The TCP options are applied to accepted TCP connections: TCP_NODELAY is set by default.
+// If the options cannot be applied to a connection, logs the error with slog
+// and closes the connection.
+func AcceptMuxedListener(ctx context.Context, lis net.Listener, srv *Server, yamuxConf *yamux.Config, tcpOpts ...TCPOption) error {
 	for {
 		nc, err := lis.Accept()
 		if err != nil {
 			return err
 		}
 
+		if err := ConfigureTCPConn(nc, tcpOpts...); err != nil {
+			slog.Warn("srpc: failed to configure accepted connection", "remote-addr", nc.RemoteAddr().String(), "error", err)
+			_ = nc.Close()
+			continue
+		}
+
 		mc, err := NewMuxedConn(nc, false, yamuxConf)
 		if err != nil {
 			_ = nc.Close()
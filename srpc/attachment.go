@@ -0,0 +1,779 @@
+package srpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/util/broadcast"
+	"github.com/pkg/errors"
+)
+
+// attachmentChunkSize is the max size of data in an attachment CallData packet.
+const attachmentChunkSize = 32 * 1024
+
+// attachmentKeyLen is the length of the key identifying a call for attachment streams.
+const attachmentKeyLen = 16
+
+// maxCallAttachments is the max number of attachments received by a call which
+// were not closed by the reader yet.
+const maxCallAttachments = 64
+
+// attachmentLookupTimeout is how long an attachment stream waits for the call
+// to register the attachment key.
+//
+// The attachment stream may be accepted before the packet registering the key
+// is read from the stream of the call.
+const attachmentLookupTimeout = 10 * time.Second
+
+// AttachmentStream is a Stream which can send and receive attachments.
+//
+// Attachments are binary blobs sent alongside the messages of the call. A
+// message references an attachment by its ID. Each attachment is sent on a
+// separate stream of the transport: the messages of the call are not blocked
+// behind a large attachment. The attachment is not read from the transport
+// faster than the reader consumes it.
+type AttachmentStream interface {
+	Stream
+
+	// OpenAttachment opens a new attachment to send to the remote.
+	//
+	// Returns the writer and the ID to reference the attachment in a message.
+	// The writer must be closed to complete the attachment.
+	OpenAttachment() (io.WriteCloser, uint32)
+	// OpenAttachmentReader opens the attachment with the ID sent by the remote.
+	//
+	// The reader must be closed to release the attachment.
+	OpenAttachmentReader(id uint32) (io.ReadCloser, error)
+}
+
+// msgStreamAttacher is a MsgStreamRw which can send and receive attachments.
+type msgStreamAttacher interface {
+	// OpenAttachment opens a new attachment to send to the remote.
+	OpenAttachment() (io.WriteCloser, uint32)
+	// OpenAttachmentReader opens the attachment with the ID sent by the remote.
+	OpenAttachmentReader(id uint32) (io.ReadCloser, error)
+}
+
+// OpenAttachment opens a new attachment to send to the remote of the stream.
+//
+// Works with wrapped streams (such as the generated stream types) by looking
+// up the call in the stream context. The returned writer fails with
+// ErrUnimplemented if the stream does not support attachments.
+func OpenAttachment(strm Stream) (io.WriteCloser, uint32) {
+	if as, ok := strm.(AttachmentStream); ok {
+		return as.OpenAttachment()
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.OpenAttachment()
+	}
+	return &attachmentWriter{err: ErrUnimplemented}, 0
+}
+
+// OpenAttachmentReader opens the attachment with the ID sent by the remote of the stream.
+//
+// Returns ErrUnimplemented if the stream does not support attachments.
+func OpenAttachmentReader(strm Stream, id uint32) (io.ReadCloser, error) {
+	if as, ok := strm.(AttachmentStream); ok {
+		return as.OpenAttachmentReader(id)
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.OpenAttachmentReader(id)
+	}
+	return nil, ErrUnimplemented
+}
+
+// NewAttachmentKeyPacket constructs a new AttachmentKey packet registering the key of the call.
+func NewAttachmentKeyPacket(key []byte) *Packet {
+	return &Packet{Body: &Packet_AttachmentKey{AttachmentKey: key}}
+}
+
+// NewAttachmentStartPacket constructs a new CallStart packet opening an attachment stream.
+func NewAttachmentStartPacket(callKey []byte, id uint32, pull bool) *Packet {
+	return &Packet{Body: &Packet_CallStart{
+		CallStart: &CallStart{
+			Attachment: &AttachmentStart{CallKey: callKey, Id: id, Pull: pull},
+		},
+	}}
+}
+
+// Validate performs cursory validation of the packet.
+func (p *AttachmentStart) Validate() error {
+	if len(p.GetCallKey()) != attachmentKeyLen {
+		return errors.Wrap(ErrInvalidPacket, "invalid attachment call key")
+	}
+	if p.GetId() == 0 {
+		return ErrUnknownAttachment
+	}
+	return nil
+}
+
+// OpenAttachment opens a new attachment to send to the remote.
+func (c *commonRPC) OpenAttachment() (io.WriteCloser, uint32) {
+	if c.attacher == nil {
+		return &attachmentWriter{err: ErrUnimplemented}, 0
+	}
+	return c.attacher.OpenAttachment()
+}
+
+// OpenAttachmentReader opens the attachment with the ID sent by the remote.
+func (c *commonRPC) OpenAttachmentReader(id uint32) (io.ReadCloser, error) {
+	if c.attacher == nil {
+		return nil, ErrUnimplemented
+	}
+	return c.attacher.OpenAttachmentReader(id)
+}
+
+// OpenAttachment opens a new attachment stream to send to the server.
+//
+// The writer fails with ErrUnimplemented if the client cannot open streams.
+func (r *ClientRPC) OpenAttachment() (io.WriteCloser, uint32) {
+	if r.openStream == nil {
+		return &attachmentWriter{err: ErrUnimplemented}, 0
+	}
+	r.mtx.Lock()
+	r.attachmentSeq++
+	id := r.attachmentSeq
+	r.mtx.Unlock()
+
+	w := newAttachmentWriter(r.ctx)
+	msgHandler := func(pkt *Packet) error {
+		if cd := pkt.GetCallData(); len(cd.GetError()) != 0 {
+			w.fail(parseRemoteErrorWithStatus(cd.GetError(), cd.GetStatus()))
+		}
+		return nil
+	}
+	closeHandler := func(closeErr error) {
+		if closeErr == nil {
+			closeErr = ErrStreamClosed
+		}
+		w.fail(closeErr)
+	}
+	writer, err := r.openAttachmentStream(context.WithoutCancel(r.ctx), id, false, msgHandler, closeHandler)
+	if err != nil {
+		w.fail(err)
+		return w, id
+	}
+	if !w.connect(writer) {
+		_ = writer.Close()
+	}
+	return w, id
+}
+
+// OpenAttachmentReader opens a stream pulling the attachment sent by the server.
+//
+// Returns ErrAttachmentOpened if the attachment is already open.
+// Returns ErrUnimplemented if the client cannot open streams.
+func (r *ClientRPC) OpenAttachmentReader(id uint32) (io.ReadCloser, error) {
+	if id == 0 {
+		return nil, ErrUnknownAttachment
+	}
+	if r.openStream == nil {
+		return nil, ErrUnimplemented
+	}
+	ctx, ctxCancel := context.WithCancel(context.WithoutCancel(r.ctx))
+	pipe := newAttachmentPipe(ctx)
+	r.mtx.Lock()
+	if _, ok := r.attachmentsIn[id]; ok {
+		r.mtx.Unlock()
+		ctxCancel()
+		return nil, ErrAttachmentOpened
+	}
+	if r.attachmentsIn == nil {
+		r.attachmentsIn = make(map[uint32]*attachmentPipe)
+	}
+	r.attachmentsIn[id] = pipe
+	r.mtx.Unlock()
+	release := func() {
+		ctxCancel()
+		r.mtx.Lock()
+		if r.attachmentsIn[id] == pipe {
+			delete(r.attachmentsIn, id)
+		}
+		r.mtx.Unlock()
+	}
+
+	msgHandler := func(pkt *Packet) error {
+		cd := pkt.GetCallData()
+		if cd == nil {
+			return nil
+		}
+		if errStr := cd.GetError(); len(errStr) != 0 {
+			pipe.finish(parseRemoteErrorWithStatus(errStr, cd.GetStatus()))
+			return nil
+		}
+		if data := cd.GetData(); len(data) != 0 {
+			if err := pipe.push(ctx, data); err != nil {
+				return err
+			}
+		}
+		if cd.GetComplete() {
+			pipe.finish(io.EOF)
+		}
+		return nil
+	}
+	closeHandler := func(closeErr error) {
+		pipe.finish(io.ErrUnexpectedEOF)
+	}
+	writer, err := r.openAttachmentStream(ctx, id, true, msgHandler, closeHandler)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &attachmentReader{pipe: pipe, release: func() {
+		_ = writer.Close()
+		release()
+	}}, nil
+}
+
+// openAttachmentStream opens a stream carrying the attachment with the ID.
+//
+// Registers the attachment key of the call with the server if necessary.
+func (r *ClientRPC) openAttachmentStream(
+	ctx context.Context,
+	id uint32,
+	pull bool,
+	msgHandler PacketHandler,
+	closeHandler CloseHandler,
+) (Writer, error) {
+	key, err := r.registerAttachmentKey()
+	if err != nil {
+		return nil, err
+	}
+	writer, err := r.openStream(ctx, msgHandler, closeHandler)
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.WritePacket(NewAttachmentStartPacket(key, id, pull)); err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	if err := flushWriter(ctx, writer); err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+// registerAttachmentKey sends the attachment key of the call to the server.
+//
+// The key is generated and sent once per call.
+func (r *ClientRPC) registerAttachmentKey() ([]byte, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.attachmentKey != nil {
+		return r.attachmentKey, nil
+	}
+	if r.writer == nil || r.ctx.Err() != nil {
+		return nil, ErrCompleted
+	}
+	key := make([]byte, attachmentKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := r.writer.WritePacket(NewAttachmentKeyPacket(key)); err != nil {
+		return nil, err
+	}
+	if err := flushWriter(r.ctx, r.writer); err != nil {
+		return nil, err
+	}
+	r.attachmentKey = key
+	return key, nil
+}
+
+// OpenAttachment opens a new attachment to be pulled by the client.
+//
+// The writer waits for the client to open the attachment reader.
+func (r *ServerRPC) OpenAttachment() (io.WriteCloser, uint32) {
+	if r.opts.attachments == nil {
+		return &attachmentWriter{err: ErrUnimplemented}, 0
+	}
+	w := newAttachmentWriter(r.ctx)
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.attachmentSeq++
+	id := r.attachmentSeq
+	if r.attachmentsOut == nil {
+		r.attachmentsOut = make(map[uint32]*attachmentWriter)
+	}
+	r.attachmentsOut[id] = w
+	return w, id
+}
+
+// OpenAttachmentReader opens the attachment sent by the client with the ID.
+//
+// The reader waits for the client to open the attachment stream.
+// Returns ErrAttachmentOpened if the attachment is already open.
+func (r *ServerRPC) OpenAttachmentReader(id uint32) (io.ReadCloser, error) {
+	if id == 0 {
+		return nil, ErrUnknownAttachment
+	}
+	if r.opts.attachments == nil {
+		return nil, ErrUnimplemented
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	pipe, err := r.getAttachmentInLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	if pipe.opened {
+		return nil, ErrAttachmentOpened
+	}
+	pipe.opened = true
+	return &attachmentReader{pipe: pipe, release: func() {
+		r.mtx.Lock()
+		if r.attachmentsIn[id] == pipe {
+			delete(r.attachmentsIn, id)
+		}
+		r.mtx.Unlock()
+	}}, nil
+}
+
+// getAttachmentInLocked returns the attachment received with the ID, creating it if necessary.
+//
+// Returns ErrAttachmentLimit if the call has too many open attachments.
+// r.mtx must be locked by the caller.
+func (r *ServerRPC) getAttachmentInLocked(id uint32) (*attachmentPipe, error) {
+	if pipe := r.attachmentsIn[id]; pipe != nil {
+		return pipe, nil
+	}
+	if r.ctx.Err() != nil {
+		return nil, ErrCompleted
+	}
+	if len(r.attachmentsIn) >= maxCallAttachments {
+		return nil, ErrAttachmentLimit
+	}
+	if r.attachmentsIn == nil {
+		r.attachmentsIn = make(map[uint32]*attachmentPipe)
+	}
+	pipe := newAttachmentPipe(r.ctx)
+	r.attachmentsIn[id] = pipe
+	return pipe, nil
+}
+
+// HandleAttachmentKey registers the attachment key of the call.
+//
+// Ignored if the server does not support attachments.
+func (r *ServerRPC) HandleAttachmentKey(key []byte) error {
+	reg := r.opts.attachments
+	if reg == nil {
+		return nil
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.service == "" && r.method == "" {
+		return ErrCallNotStarted
+	}
+	if r.attachmentKey != nil {
+		return errors.Wrap(ErrInvalidPacket, "attachment key already registered")
+	}
+	if r.ctx.Err() != nil {
+		return nil
+	}
+	if !reg.register(key, r) {
+		return errors.Wrap(ErrInvalidPacket, "attachment key in use")
+	}
+	r.attachmentKey = key
+	return nil
+}
+
+// handleAttachmentStart connects the attachment stream to the call.
+//
+// If the stream cannot be connected the error is written to the remote and
+// the stream is closed.
+func (r *ServerRPC) handleAttachmentStart(pkt *AttachmentStart) error {
+	r.mtx.Lock()
+	if r.attachmentStream || r.service != "" || r.method != "" {
+		r.mtx.Unlock()
+		return errors.New("call start must be sent only once")
+	}
+	r.attachmentStream = true
+	r.mtx.Unlock()
+
+	err := r.connectAttachment(pkt)
+	if err != nil {
+		_ = r.WriteCallData(nil, true, err)
+	}
+	return err
+}
+
+// connectAttachment looks up the call and connects the attachment stream.
+func (r *ServerRPC) connectAttachment(pkt *AttachmentStart) error {
+	reg := r.opts.attachments
+	if reg == nil {
+		return ErrUnimplemented
+	}
+	ctx, ctxCancel := context.WithTimeout(r.ctx, attachmentLookupTimeout)
+	call, err := reg.lookup(ctx, pkt.GetCallKey())
+	ctxCancel()
+	if err != nil {
+		return err
+	}
+
+	id := pkt.GetId()
+	call.mtx.Lock()
+	defer call.mtx.Unlock()
+	if pkt.GetPull() {
+		w := call.attachmentsOut[id]
+		if w == nil {
+			return ErrUnknownAttachment
+		}
+		delete(call.attachmentsOut, id)
+		if !w.connect(r.writer) {
+			return ErrCompleted
+		}
+		return nil
+	}
+
+	pipe, err := call.getAttachmentInLocked(id)
+	if err != nil {
+		return err
+	}
+	if pipe.connected {
+		return errors.Wrapf(ErrInvalidPacket, "attachment %d already sent", id)
+	}
+	pipe.connected = true
+	r.mtx.Lock()
+	r.attachmentIn = pipe
+	r.mtx.Unlock()
+	return nil
+}
+
+// handleAttachmentData passes the data received on the attachment stream to the reader.
+//
+// Blocks until the reader consumed the previous data.
+func (r *ServerRPC) handleAttachmentData(pipe *attachmentPipe, pkt *CallData) error {
+	if errStr := pkt.GetError(); len(errStr) != 0 {
+		pipe.finish(parseRemoteErrorWithStatus(errStr, pkt.GetStatus()))
+		return nil
+	}
+	if data := pkt.GetData(); len(data) != 0 {
+		if err := pipe.push(r.ctx, data); err != nil {
+			return err
+		}
+	}
+	if pkt.GetComplete() {
+		pipe.finish(io.EOF)
+	}
+	return nil
+}
+
+// attachmentRegistry contains the calls of a server which registered an attachment key.
+type attachmentRegistry struct {
+	// mtx guards below fields
+	mtx sync.Mutex
+	// bcast broadcasts when calls changes
+	bcast broadcast.Broadcast
+	// calls contains the calls by attachment key.
+	calls map[string]*ServerRPC
+}
+
+// newAttachmentRegistry constructs a new attachmentRegistry.
+func newAttachmentRegistry() *attachmentRegistry {
+	return &attachmentRegistry{calls: make(map[string]*ServerRPC)}
+}
+
+// register registers the call with the key until the call completes.
+//
+// Returns false if the key is already registered.
+func (g *attachmentRegistry) register(key []byte, rpc *ServerRPC) bool {
+	k := string(key)
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if _, ok := g.calls[k]; ok {
+		return false
+	}
+	g.calls[k] = rpc
+	g.bcast.Broadcast()
+	context.AfterFunc(rpc.ctx, func() {
+		g.mtx.Lock()
+		if g.calls[k] == rpc {
+			delete(g.calls, k)
+		}
+		g.mtx.Unlock()
+	})
+	return true
+}
+
+// lookup waits for the call with the key to be registered.
+//
+// Returns ErrUnknownAttachment if ctx is canceled first.
+func (g *attachmentRegistry) lookup(ctx context.Context, key []byte) (*ServerRPC, error) {
+	k := string(key)
+	for {
+		g.mtx.Lock()
+		rpc := g.calls[k]
+		waitCh := g.bcast.GetWaitCh()
+		g.mtx.Unlock()
+		if rpc != nil {
+			return rpc, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrUnknownAttachment
+		case <-waitCh:
+		}
+	}
+}
+
+// attachmentPipe passes the data of an attachment from the stream to the reader.
+//
+// Holds at most one chunk: the stream is not read until the reader consumed
+// the previous chunk, applying backpressure to the sender.
+type attachmentPipe struct {
+	// ctx is canceled when the attachment can no longer be read.
+	ctx context.Context
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// bcast broadcasts when below fields change
+	bcast broadcast.Broadcast
+	// chunk contains the received data which was not read yet.
+	chunk []byte
+	// done is set after the attachment ended.
+	done bool
+	// err is returned after the data was read: io.EOF if complete.
+	err error
+	// closed is set after the reader was closed: data is discarded.
+	closed bool
+
+	// opened is set after a reader was opened, guarded by the mtx of the call.
+	opened bool
+	// connected is set after a stream was connected, guarded by the mtx of the call.
+	connected bool
+}
+
+// newAttachmentPipe constructs a new attachmentPipe.
+func newAttachmentPipe(ctx context.Context) *attachmentPipe {
+	return &attachmentPipe{ctx: ctx}
+}
+
+// push passes the data to the reader after the previous data was read.
+//
+// Returns ErrStreamClosed if the reader was closed or the attachment ended.
+func (p *attachmentPipe) push(ctx context.Context, data []byte) error {
+	for {
+		p.mtx.Lock()
+		if p.closed || p.done {
+			p.mtx.Unlock()
+			return ErrStreamClosed
+		}
+		if len(p.chunk) == 0 {
+			p.chunk = data
+			p.bcast.Broadcast()
+			p.mtx.Unlock()
+			return nil
+		}
+		waitCh := p.bcast.GetWaitCh()
+		p.mtx.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case <-p.ctx.Done():
+			return ErrStreamClosed
+		case <-waitCh:
+		}
+	}
+}
+
+// finish ends the attachment with the error returned after the data was read.
+func (p *attachmentPipe) finish(err error) {
+	p.mtx.Lock()
+	if !p.done {
+		p.done, p.err = true, err
+		p.bcast.Broadcast()
+	}
+	p.mtx.Unlock()
+}
+
+// Read reads data from the attachment.
+//
+// Returns io.EOF after the remote closed the attachment. Returns
+// io.ErrUnexpectedEOF if the stream or call ended before the attachment was closed.
+func (p *attachmentPipe) Read(b []byte) (int, error) {
+	for {
+		p.mtx.Lock()
+		if p.closed {
+			p.mtx.Unlock()
+			return 0, ErrStreamClosed
+		}
+		if len(p.chunk) != 0 {
+			n := copy(b, p.chunk)
+			p.chunk = p.chunk[n:]
+			if len(p.chunk) == 0 {
+				p.chunk = nil
+				p.bcast.Broadcast()
+			}
+			p.mtx.Unlock()
+			return n, nil
+		}
+		if p.done {
+			err := p.err
+			p.mtx.Unlock()
+			return 0, err
+		}
+		waitCh := p.bcast.GetWaitCh()
+		p.mtx.Unlock()
+
+		select {
+		case <-p.ctx.Done():
+			p.finish(io.ErrUnexpectedEOF)
+		case <-waitCh:
+		}
+	}
+}
+
+// close closes the pipe discarding any remaining data.
+func (p *attachmentPipe) close() {
+	p.mtx.Lock()
+	p.closed = true
+	p.chunk = nil
+	p.bcast.Broadcast()
+	p.mtx.Unlock()
+}
+
+// attachmentReader reads an attachment sent by the remote.
+type attachmentReader struct {
+	// pipe contains the received data
+	pipe *attachmentPipe
+	// release releases the attachment
+	release func()
+	// closeOnce guards calling release
+	closeOnce sync.Once
+}
+
+// Read reads data from the attachment.
+func (r *attachmentReader) Read(p []byte) (int, error) {
+	return r.pipe.Read(p)
+}
+
+// Close closes the reader discarding any remaining data.
+func (r *attachmentReader) Close() error {
+	r.closeOnce.Do(func() {
+		r.pipe.close()
+		r.release()
+	})
+	return nil
+}
+
+// attachmentWriter writes an attachment to the stream of the attachment.
+type attachmentWriter struct {
+	// ctx is the context of the call
+	ctx context.Context
+	// ready is closed when the stream is connected or the attachment failed.
+	ready chan struct{}
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// writer is the writer of the attachment stream.
+	writer Writer
+	// err is returned by subsequent calls if set.
+	err error
+}
+
+// newAttachmentWriter constructs an attachmentWriter waiting for a stream.
+func newAttachmentWriter(ctx context.Context) *attachmentWriter {
+	return &attachmentWriter{ctx: ctx, ready: make(chan struct{})}
+}
+
+// connect sets the writer of the attachment stream.
+//
+// Returns false if the attachment already failed.
+func (w *attachmentWriter) connect(writer Writer) bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.err != nil || w.writer != nil {
+		return false
+	}
+	w.writer = writer
+	close(w.ready)
+	return true
+}
+
+// fail fails the attachment with the error and closes the stream.
+func (w *attachmentWriter) fail(err error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.err != nil {
+		return
+	}
+	w.err = err
+	if w.writer != nil {
+		_ = w.writer.Close()
+	} else {
+		close(w.ready)
+	}
+}
+
+// wait waits for the stream to be connected.
+func (w *attachmentWriter) wait() (Writer, error) {
+	if w.ready != nil {
+		select {
+		case <-w.ctx.Done():
+			w.fail(ErrCompleted)
+		case <-w.ready:
+		}
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.writer, w.err
+}
+
+// Write writes data to the attachment.
+//
+// Blocks until the remote opened the attachment and read the data.
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	writer, err := w.wait()
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	for n < len(p) {
+		chunk := p[n:min(n+attachmentChunkSize, len(p))]
+		// the packet may be queued: copy the data which the caller may reuse.
+		if err := writer.WritePacket(NewCallDataPacket(bytes.Clone(chunk), false, false, nil)); err != nil {
+			w.fail(err)
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+// Close completes the attachment and closes the stream.
+//
+// Close is idempotent: subsequent calls return nil.
+func (w *attachmentWriter) Close() error {
+	writer, err := w.wait()
+	if err != nil {
+		if err == ErrStreamClosed {
+			return nil
+		}
+		return err
+	}
+	err = writer.WritePacket(NewCallDataPacket(nil, false, true, nil))
+	if err == nil {
+		err = flushWriter(context.WithoutCancel(w.ctx), writer)
+	}
+	if err != nil {
+		w.fail(err)
+		return err
+	}
+	w.fail(ErrStreamClosed)
+	return nil
+}
+
+// _ is a type assertion
+var (
+	_ msgStreamAttacher = ((*commonRPC)(nil))
+	_ msgStreamAttacher = ((*ClientRPC)(nil))
+	_ msgStreamAttacher = ((*ServerRPC)(nil))
+	_ io.WriteCloser    = ((*attachmentWriter)(nil))
+	_ io.ReadCloser     = ((*attachmentReader)(nil))
+)
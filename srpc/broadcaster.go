@@ -0,0 +1,151 @@
+package srpc
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcaster publishes messages to many server streams.
+//
+// Server-streaming handlers call Subscribe to receive the published messages,
+// for example to implement pub/sub or real-time updates. Each stream has a
+// queue of pending messages sent in the background. If the queue of a stream
+// is full when publishing, the stream is a slow consumer: it is evicted and
+// Subscribe returns ErrSlowConsumer. Publishing never blocks on a stream.
+type Broadcaster[T Message] struct {
+	// queueSize is the max number of pending messages per stream.
+	queueSize int
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// subs contains the subscribed streams.
+	subs map[*broadcastSub[T]]struct{}
+	// closed indicates Close was called.
+	closed bool
+}
+
+// broadcastSub is a stream subscribed to a Broadcaster.
+type broadcastSub[T Message] struct {
+	// queue contains the pending messages.
+	// closed when the Broadcaster is closed.
+	queue chan T
+	// evicted is closed when the stream is evicted.
+	evicted chan struct{}
+}
+
+// NewBroadcaster constructs a new Broadcaster.
+//
+// queueSize is the max number of pending messages per stream before the
+// stream is evicted. If zero or negative, defaults to 1.
+func NewBroadcaster[T Message](queueSize int) *Broadcaster[T] {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	return &Broadcaster[T]{
+		queueSize: queueSize,
+		subs:      make(map[*broadcastSub[T]]struct{}),
+	}
+}
+
+// Subscribe sends the published messages to the stream.
+//
+// Blocks until the stream context is canceled, sending a message fails, the
+// stream is evicted, or the Broadcaster is closed. Intended to be called by a
+// server-streaming handler returning the result:
+//
+//	func (s *server) Watch(req *WatchRequest, strm SRPCWatcher_WatchStream) error {
+//		return s.bcast.Subscribe(strm)
+//	}
+//
+// Returns nil if the Broadcaster was closed after sending the pending messages.
+// Returns ErrSlowConsumer if the stream was evicted: if a message is being
+// sent, returns after MsgSend returns.
+// Returns context.Canceled if the stream context was canceled.
+func (b *Broadcaster[T]) Subscribe(strm Stream) error {
+	sub := &broadcastSub[T]{
+		queue:   make(chan T, b.queueSize),
+		evicted: make(chan struct{}),
+	}
+	b.mtx.Lock()
+	if b.closed {
+		b.mtx.Unlock()
+		return nil
+	}
+	b.subs[sub] = struct{}{}
+	b.mtx.Unlock()
+	defer b.remove(sub)
+
+	ctx := strm.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case <-sub.evicted:
+			return ErrSlowConsumer
+		case msg, ok := <-sub.queue:
+			if !ok {
+				return nil
+			}
+			select {
+			case <-sub.evicted:
+				return ErrSlowConsumer
+			default:
+			}
+			if err := strm.MsgSend(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Publish queues the message to be sent to all subscribed streams.
+//
+// Evicts the streams which have a full queue.
+// The message is shared between the streams and must not be modified.
+// Returns the number of streams the message was queued for.
+func (b *Broadcaster[T]) Publish(msg T) int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	var n int
+	for sub := range b.subs {
+		select {
+		case sub.queue <- msg:
+			n++
+		default:
+			delete(b.subs, sub)
+			close(sub.evicted)
+		}
+	}
+	return n
+}
+
+// Len returns the number of subscribed streams.
+func (b *Broadcaster[T]) Len() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return len(b.subs)
+}
+
+// Close unsubscribes all streams after sending their pending messages.
+//
+// Subscribe returns nil for the streams. Subsequent calls to Subscribe return
+// nil immediately and Publish does nothing.
+func (b *Broadcaster[T]) Close() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.queue)
+	}
+	b.subs = nil
+}
+
+// remove removes the stream from the subscribed streams.
+func (b *Broadcaster[T]) remove(sub *broadcastSub[T]) {
+	b.mtx.Lock()
+	delete(b.subs, sub)
+	b.mtx.Unlock()
+}
@@ -0,0 +1,88 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// chanStream is a Stream which sends messages to a channel.
+type chanStream struct {
+	Stream
+	ctx context.Context
+	ch  chan Message
+	// subscribed is closed when Context is first called.
+	// Subscribe calls Context after registering the stream.
+	subscribed     chan struct{}
+	subscribedOnce sync.Once
+}
+
+// newChanStream constructs a new chanStream with a channel of size n.
+func newChanStream(ctx context.Context, n int) *chanStream {
+	return &chanStream{ctx: ctx, ch: make(chan Message, n), subscribed: make(chan struct{})}
+}
+
+// Context returns the stream context.
+func (s *chanStream) Context() context.Context {
+	s.subscribedOnce.Do(func() { close(s.subscribed) })
+	return s.ctx
+}
+
+// MsgSend blocks until the message is read from the channel.
+func (s *chanStream) MsgSend(msg Message) error {
+	select {
+	case <-s.ctx.Done():
+		return context.Canceled
+	case s.ch <- msg:
+		return nil
+	}
+}
+
+// TestBroadcaster tests publishing to streams and evicting slow consumers.
+func TestBroadcaster(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	bcast := NewBroadcaster[*RawMessage](1)
+	fast, slow := newChanStream(ctx, 10), newChanStream(ctx, 0)
+	fastErr, slowErr := make(chan error, 1), make(chan error, 1)
+	go func() { fastErr <- bcast.Subscribe(fast) }()
+	go func() { slowErr <- bcast.Subscribe(slow) }()
+	<-fast.subscribed
+	<-slow.subscribed
+	if n := bcast.Len(); n != 2 {
+		t.Fatalf("expected 2 streams but got %d", n)
+	}
+
+	// the slow stream blocks sending the first message, queues the second,
+	// and is evicted publishing the third.
+	for i := 0; i < 3; i++ {
+		bcast.Publish(NewRawMessage([]byte{byte(i)}, false))
+		// wait for the fast stream to send the message
+		if msg := <-fast.ch; msg.(*RawMessage).GetData()[0] != byte(i) {
+			t.Fatalf("expected message %d", i)
+		}
+	}
+	// unblock sending the first message, if the stream started sending it
+	var err error
+	select {
+	case <-slow.ch:
+		err = <-slowErr
+	case err = <-slowErr:
+	}
+	if !errors.Is(err, ErrSlowConsumer) {
+		t.Fatalf("expected ErrSlowConsumer but got %v", err)
+	}
+	if n := bcast.Len(); n != 1 {
+		t.Fatalf("expected 1 stream but got %d", n)
+	}
+
+	bcast.Close()
+	if err := <-fastErr; err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := bcast.Publish(NewRawMessage(nil, false)); n != 0 {
+		t.Fatalf("expected publish after close to do nothing but queued for %d", n)
+	}
+}
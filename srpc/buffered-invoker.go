@@ -0,0 +1,122 @@
+package srpc
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// DefaultClientStreamBufferSize is the default max number of bytes buffered per call.
+const DefaultClientStreamBufferSize = 4 * 1024 * 1024
+
+// BufferedClientStreamInvoker buffers client-streaming calls before invoking the handler.
+//
+// For the buffered methods, the messages sent by the client are received and
+// buffered until the client closes the stream for sending (CloseSend). The
+// handler is invoked afterwards and receives the buffered messages followed
+// by io.EOF. Useful for handlers with batch semantics which need the complete
+// input. Calls sending more than the max buffer size fail with a
+// StatusResourceExhausted status before the handler runs.
+type BufferedClientStreamInvoker struct {
+	// inv is the underlying invoker
+	inv Invoker
+	// methods contains the max buffer size by service & method ID.
+	methods map[[2]string]int
+}
+
+// NewBufferedClientStreamInvoker constructs a new BufferedClientStreamInvoker.
+//
+// Calls are not buffered until enabled with BufferMethod.
+func NewBufferedClientStreamInvoker(inv Invoker) *BufferedClientStreamInvoker {
+	return &BufferedClientStreamInvoker{inv: inv, methods: make(map[[2]string]int)}
+}
+
+// BufferMethod enables buffering the messages of calls to the method.
+//
+// maxBytes is the max number of message bytes buffered per call. If zero or
+// negative, uses DefaultClientStreamBufferSize.
+// Not concurrency safe: call before the invoker is used.
+// Returns the invoker.
+func (i *BufferedClientStreamInvoker) BufferMethod(serviceID, methodID string, maxBytes int) *BufferedClientStreamInvoker {
+	if maxBytes <= 0 {
+		maxBytes = DefaultClientStreamBufferSize
+	}
+	i.methods[[2]string{serviceID, methodID}] = maxBytes
+	return i
+}
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+// If service string is empty, ignore it.
+func (i *BufferedClientStreamInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	maxBytes, ok := i.methods[[2]string{serviceID, methodID}]
+	if !ok {
+		return i.inv.InvokeMethod(serviceID, methodID, strm)
+	}
+	msgs, err := bufferClientStream(strm, maxBytes)
+	if err != nil {
+		return true, err
+	}
+	return i.inv.InvokeMethod(serviceID, methodID, &bufferedClientStream{Stream: strm, msgs: msgs})
+}
+
+// bufferClientStream receives the messages until the remote closes the stream for sending.
+//
+// Returns a StatusResourceExhausted status if the messages exceed maxBytes.
+func bufferClientStream(strm Stream, maxBytes int) ([][]byte, error) {
+	var msgs [][]byte
+	var size int
+	for {
+		msg := NewRawMessage(nil, true)
+		if err := strm.MsgRecv(msg); err != nil {
+			if err == io.EOF {
+				return msgs, nil
+			}
+			return nil, err
+		}
+		data := msg.GetData()
+		size += len(data)
+		if size > maxBytes {
+			return nil, NewStatusErrorf(StatusResourceExhausted, "client stream exceeds buffer size of %d bytes", maxBytes).
+				WithDetail("max_bytes", strconv.Itoa(maxBytes))
+		}
+		msgs = append(msgs, data)
+	}
+}
+
+// bufferedClientStream is a Stream which returns the buffered messages.
+type bufferedClientStream struct {
+	Stream
+	// mtx guards msgs
+	mtx sync.Mutex
+	// msgs are the remaining buffered messages
+	msgs [][]byte
+}
+
+// MsgRecv returns the next buffered message.
+//
+// Returns io.EOF after the buffered messages.
+func (s *bufferedClientStream) MsgRecv(msg Message) error {
+	s.mtx.Lock()
+	if len(s.msgs) == 0 {
+		s.mtx.Unlock()
+		return io.EOF
+	}
+	data := s.msgs[0]
+	s.msgs[0] = nil
+	s.msgs = s.msgs[1:]
+	s.mtx.Unlock()
+	return msg.UnmarshalVT(data)
+}
+
+// PeekFirstMessage returns the raw first message sent with the call, if any.
+func (s *bufferedClientStream) PeekFirstMessage() ([]byte, bool) {
+	return PeekFirstMessage(s.Stream)
+}
+
+// _ is a type assertion
+var (
+	_ Invoker            = ((*BufferedClientStreamInvoker)(nil))
+	_ Stream             = ((*bufferedClientStream)(nil))
+	_ FirstMessagePeeker = ((*bufferedClientStream)(nil))
+)
@@ -0,0 +1,81 @@
+package srpc
+
+import (
+	"context"
+	"strconv"
+)
+
+// ByteQuotaFunc returns the max number of message bytes a call may receive.
+//
+// ctx is the call context: use it to look up the tenant, for example with
+// MetadataFromContext or IdentityFromContext. Returns 0 for no limit.
+type ByteQuotaFunc func(ctx context.Context, serviceID, methodID string) uint64
+
+// ByteQuotaInvoker limits the number of message bytes received per call.
+//
+// When a call receives more than the quota, MsgRecv returns a
+// StatusResourceExhausted status which aborts the call when returned by the
+// handler. Received bytes are counted with StreamBytesReceived.
+type ByteQuotaInvoker struct {
+	// inv is the underlying invoker
+	inv Invoker
+	// quota returns the quota for a call
+	quota ByteQuotaFunc
+}
+
+// NewByteQuotaInvoker constructs a new ByteQuotaInvoker.
+func NewByteQuotaInvoker(inv Invoker, quota ByteQuotaFunc) *ByteQuotaInvoker {
+	return &ByteQuotaInvoker{inv: inv, quota: quota}
+}
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+// If service string is empty, ignore it.
+func (i *ByteQuotaInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	limit := i.quota(strm.Context(), serviceID, methodID)
+	if limit == 0 {
+		return i.inv.InvokeMethod(serviceID, methodID, strm)
+	}
+	return i.inv.InvokeMethod(serviceID, methodID, &byteQuotaStream{Stream: strm, limit: limit})
+}
+
+// byteQuotaStream is a Stream which limits the number of received bytes.
+type byteQuotaStream struct {
+	Stream
+	// limit is the max number of received bytes
+	limit uint64
+}
+
+// MsgRecv receives an incoming message from the remote.
+//
+// Returns a StatusResourceExhausted status if the quota was exceeded.
+func (s *byteQuotaStream) MsgRecv(msg Message) error {
+	if err := s.checkQuota(); err != nil {
+		return err
+	}
+	if err := s.Stream.MsgRecv(msg); err != nil {
+		return err
+	}
+	return s.checkQuota()
+}
+
+// checkQuota returns an error if the quota was exceeded.
+func (s *byteQuotaStream) checkQuota() error {
+	if n := StreamBytesReceived(s.Stream); n > s.limit {
+		return NewStatusErrorf(StatusResourceExhausted, "received %d bytes: exceeds quota of %d bytes", n, s.limit).
+			WithDetail("quota", strconv.FormatUint(s.limit, 10))
+	}
+	return nil
+}
+
+// PeekFirstMessage returns the raw first message sent with the call, if any.
+func (s *byteQuotaStream) PeekFirstMessage() ([]byte, bool) {
+	return PeekFirstMessage(s.Stream)
+}
+
+// _ is a type assertion
+var (
+	_ Invoker            = ((*ByteQuotaInvoker)(nil))
+	_ Stream             = ((*byteQuotaStream)(nil))
+	_ FirstMessagePeeker = ((*byteQuotaStream)(nil))
+)
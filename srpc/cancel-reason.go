@@ -0,0 +1,75 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+)
+
+// CancelReasonError is the cancel cause of a call canceled with a reason.
+//
+// Canceling the context of a client call with a CancelReasonError cause (see
+// CancelWithReason) sends the reason to the server with the cancellation.
+// The context of the server handler is canceled with a CancelReasonError which
+// wraps ErrRemoteCanceled.
+type CancelReasonError struct {
+	// Reason describes why the call was canceled.
+	Reason string
+	// remote is set if the reason was received from the remote.
+	remote bool
+}
+
+// CancelWithReason cancels the context with a reason sent to the remote.
+//
+// cancel is the cancel func of a context used to start a client call:
+//
+//	ctx, cancel := context.WithCancelCause(ctx)
+//	strm, err := client.NewStream(ctx, service, method, nil)
+//	// ...
+//	srpc.CancelWithReason(cancel, "user-abort")
+//
+// The server handler reads the reason with CancelReasonFromContext.
+func CancelWithReason(cancel context.CancelCauseFunc, reason string) {
+	cancel(&CancelReasonError{Reason: reason})
+}
+
+// CancelReasonFromContext returns the reason the context was canceled with.
+//
+// Returns false if the context was not canceled with a reason.
+func CancelReasonFromContext(ctx context.Context) (string, bool) {
+	var reasonErr *CancelReasonError
+	if !errors.As(context.Cause(ctx), &reasonErr) {
+		return "", false
+	}
+	return reasonErr.Reason, true
+}
+
+// Error returns the error string.
+func (e *CancelReasonError) Error() string {
+	if e.remote {
+		return ErrRemoteCanceled.Error() + ": " + e.Reason
+	}
+	return "call canceled: " + e.Reason
+}
+
+// Unwrap returns ErrRemoteCanceled if the reason was received from the remote.
+func (e *CancelReasonError) Unwrap() error {
+	if e.remote {
+		return ErrRemoteCanceled
+	}
+	return nil
+}
+
+// localCancelReason returns the reason the call was canceled with locally.
+//
+// Returns false if the context was not canceled with a reason or the reason
+// was received from the remote.
+func localCancelReason(ctx context.Context) (string, bool) {
+	var reasonErr *CancelReasonError
+	if ctx.Err() == nil || !errors.As(context.Cause(ctx), &reasonErr) || reasonErr.remote {
+		return "", false
+	}
+	return reasonErr.Reason, true
+}
+
+// _ is a type assertion
+var _ error = ((*CancelReasonError)(nil))
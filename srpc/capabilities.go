@@ -0,0 +1,36 @@
+package srpc
+
+import "context"
+
+// CapabilitiesClient is a Client which can request the capabilities of the server.
+type CapabilitiesClient interface {
+	Client
+
+	// ServerCapabilities requests the optional features supported by the server.
+	ServerCapabilities(ctx context.Context) (*Capabilities, error)
+}
+
+// ServerCapabilities requests the optional features supported by the server of the client.
+//
+// The server answers without invoking a handler. Use this to adapt to the
+// server before starting calls, for example to select a supported stream
+// compressor. Returns ErrUnimplemented if the client does not implement
+// CapabilitiesClient.
+func ServerCapabilities(ctx context.Context, cc Client) (*Capabilities, error) {
+	capc, ok := cc.(CapabilitiesClient)
+	if !ok {
+		return nil, ErrUnimplemented
+	}
+	return capc.ServerCapabilities(ctx)
+}
+
+// newServerCapabilities returns the capabilities advertised by a server with the options.
+func newServerCapabilities(opts serverOpts) *Capabilities {
+	return &Capabilities{
+		Compression:       streamCompressorNames(),
+		Codecs:            codecNames(),
+		MaxMessageSize:    uint32(maxMessageSize),
+		ChecksumsRequired: opts.requireChecksums,
+		ResumableStreams:  opts.resume != nil,
+	}
+}
@@ -0,0 +1,34 @@
+package srpc
+
+import (
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+)
+
+// checksumTable is the CRC-32C (Castagnoli) table used for packet checksums.
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumData computes the checksum of the messages in order.
+func checksumData(msgs ...[]byte) uint32 {
+	var sum uint32
+	for _, msg := range msgs {
+		sum = crc32.Update(sum, checksumTable, msg)
+	}
+	return sum
+}
+
+// checksumCallStart computes the checksum of the messages sent with the call start.
+func checksumCallStart(pkt *CallStart) uint32 {
+	return checksumData(append([][]byte{pkt.GetData()}, pkt.GetExtraData()...)...)
+}
+
+// verifyChecksum checks that the checksum of the received data matches.
+//
+// Returns ErrChecksumMismatch if the data is corrupted.
+func verifyChecksum(expected, actual uint32) error {
+	if actual != expected {
+		return errors.Wrapf(ErrChecksumMismatch, "expected %08x got %08x", expected, actual)
+	}
+	return nil
+}
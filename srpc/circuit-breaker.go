@@ -0,0 +1,361 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerSettings configures a circuit breaker client.
+//
+// Zero values are replaced with the defaults.
+type CircuitBreakerSettings struct {
+	// WindowSize is the number of recent calls used to compute the failure rate.
+	// Defaults to 20.
+	WindowSize int
+	// MinCalls is the minimum number of calls in the window before the circuit can open.
+	// Defaults to WindowSize / 2.
+	MinCalls int
+	// FailureRatio is the ratio of failed calls in the window which opens the circuit.
+	// Defaults to 0.5.
+	FailureRatio float64
+	// OpenTimeout is how long the circuit stays open before allowing a probe call.
+	// Defaults to 5 seconds.
+	OpenTimeout time.Duration
+	// IsFailure checks if the error returned by a call counts as a failure.
+	// Defaults to IsCircuitBreakerFailure.
+	IsFailure func(err error) bool
+}
+
+// IsCircuitBreakerFailure checks if the error indicates the remote is unhealthy.
+//
+// Returns true for the Unavailable, DeadlineExceeded, ResourceExhausted,
+// Internal, DataLoss and Unknown status codes. Errors caused by the caller,
+// such as InvalidArgument, NotFound or Canceled, are not failures.
+func IsCircuitBreakerFailure(err error) bool {
+	switch StatusCodeOf(err) {
+	case StatusUnavailable,
+		StatusDeadlineExceeded,
+		StatusResourceExhausted,
+		StatusInternal,
+		StatusDataLoss,
+		StatusUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// withDefaults returns the settings with the defaults applied.
+func (s CircuitBreakerSettings) withDefaults() CircuitBreakerSettings {
+	if s.WindowSize <= 0 {
+		s.WindowSize = 20
+	}
+	if s.MinCalls <= 0 {
+		s.MinCalls = max(s.WindowSize/2, 1)
+	}
+	if s.FailureRatio <= 0 {
+		s.FailureRatio = 0.5
+	}
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = 5 * time.Second
+	}
+	if s.IsFailure == nil {
+		s.IsFailure = IsCircuitBreakerFailure
+	}
+	return s
+}
+
+// CircuitBreakerClient is a Client which short-circuits calls to failing methods.
+//
+// A circuit is kept per service and method. The circuit opens if the ratio of
+// failed calls in the window exceeds the FailureRatio. While open, calls fail
+// immediately with a StatusUnavailable status matching ErrCircuitOpen. After
+// OpenTimeout a single probe call is allowed: if it succeeds the circuit
+// closes, otherwise it opens again.
+//
+// A stream counts as successful if MsgRecv returned io.EOF or the stream was
+// closed before MsgRecv returned an error. Streams must be closed to release
+// the probe of a half-open circuit.
+type CircuitBreakerClient struct {
+	// client is the underlying client
+	client Client
+	// settings are the circuit breaker settings
+	settings CircuitBreakerSettings
+	// now returns the current time, replaced in tests.
+	now func() time.Time
+
+	// mtx guards circuits
+	mtx sync.Mutex
+	// circuits contains the circuits by service and method.
+	circuits map[circuitKey]*circuit
+}
+
+// NewCircuitBreakerClient constructs a new CircuitBreakerClient.
+func NewCircuitBreakerClient(inner Client, settings CircuitBreakerSettings) *CircuitBreakerClient {
+	return &CircuitBreakerClient{
+		client:   inner,
+		settings: settings.withDefaults(),
+		now:      time.Now,
+		circuits: make(map[circuitKey]*circuit),
+	}
+}
+
+// ExecCall executes a request/reply RPC with the remote.
+func (c *CircuitBreakerClient) ExecCall(ctx context.Context, service, method string, in, out Message) error {
+	circ := c.getCircuit(service, method)
+	if err := circ.allow(); err != nil {
+		return err
+	}
+	err := c.client.ExecCall(ctx, service, method, in, out)
+	circ.done(err)
+	return err
+}
+
+// NewStream starts a streaming RPC with the remote & returns the stream.
+// firstMsg is optional.
+func (c *CircuitBreakerClient) NewStream(ctx context.Context, service, method string, firstMsg Message) (Stream, error) {
+	return c.newStream(service, method, func() (Stream, error) {
+		return c.client.NewStream(ctx, service, method, firstMsg)
+	})
+}
+
+// NewStreamWithMsgs starts a streaming RPC sending the initial msgs.
+//
+// Batches the msgs with the call start if the underlying client implements
+// BatchStreamClient. Otherwise sends the msgs one at a time.
+func (c *CircuitBreakerClient) NewStreamWithMsgs(ctx context.Context, service, method string, msgs ...Message) (Stream, error) {
+	return c.newStream(service, method, func() (Stream, error) {
+		return NewStreamWithMsgs(ctx, c.client, service, method, msgs...)
+	})
+}
+
+// newStream opens a stream with openStream if the circuit for the method allows it.
+func (c *CircuitBreakerClient) newStream(service, method string, openStream func() (Stream, error)) (Stream, error) {
+	circ := c.getCircuit(service, method)
+	if err := circ.allow(); err != nil {
+		return nil, err
+	}
+	strm, err := openStream()
+	if err != nil {
+		circ.done(err)
+		return nil, err
+	}
+	return &circuitStream{Stream: strm, circ: circ}, nil
+}
+
+// Ping measures the round-trip time to the remote of the underlying client.
+//
+// Returns ErrUnimplemented if the underlying client does not implement PingClient.
+func (c *CircuitBreakerClient) Ping(ctx context.Context) (time.Duration, error) {
+	return Ping(ctx, c.client)
+}
+
+// ServerCapabilities requests the capabilities of the server of the underlying client.
+//
+// Returns ErrUnimplemented if the underlying client does not implement CapabilitiesClient.
+func (c *CircuitBreakerClient) ServerCapabilities(ctx context.Context) (*Capabilities, error) {
+	return ServerCapabilities(ctx, c.client)
+}
+
+// Close closes the underlying client if it implements io.Closer.
+func (c *CircuitBreakerClient) Close() error {
+	return CloseClient(c.client)
+}
+
+// getCircuit returns the circuit for the method, creating it if necessary.
+func (c *CircuitBreakerClient) getCircuit(service, method string) *circuit {
+	key := circuitKey{service: service, method: method}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	circ := c.circuits[key]
+	if circ == nil {
+		circ = &circuit{settings: &c.settings, now: c.now, window: make([]bool, c.settings.WindowSize)}
+		c.circuits[key] = circ
+	}
+	return circ
+}
+
+// circuitKey identifies a circuit.
+type circuitKey struct {
+	service, method string
+}
+
+// circuitState is the state of a circuit.
+type circuitState int
+
+const (
+	// circuitClosed allows all calls.
+	circuitClosed circuitState = iota
+	// circuitOpen rejects all calls until the open timeout elapses.
+	circuitOpen
+	// circuitHalfOpen allows a single probe call.
+	circuitHalfOpen
+)
+
+// circuit tracks the recent calls to a method.
+type circuit struct {
+	// settings are the circuit breaker settings
+	settings *CircuitBreakerSettings
+	// now returns the current time
+	now func() time.Time
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// state is the current state
+	state circuitState
+	// openedAt is when the circuit was opened.
+	openedAt time.Time
+	// probing indicates a probe call is in progress.
+	probing bool
+	// window is a ring buffer of the recent calls: true if failed.
+	window []bool
+	// pos is the next position to write in window.
+	pos int
+	// calls is the number of calls in window.
+	calls int
+	// failures is the number of failed calls in window.
+	failures int
+}
+
+// allow checks if a call is allowed, returning an error if not.
+func (c *circuit) allow() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	switch c.state {
+	case circuitOpen:
+		remaining := c.settings.OpenTimeout - c.now().Sub(c.openedAt)
+		if remaining > 0 {
+			return newCircuitOpenError(remaining)
+		}
+		c.state = circuitHalfOpen
+		fallthrough
+	case circuitHalfOpen:
+		if c.probing {
+			return newCircuitOpenError(0)
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+// done records the result of a call allowed by allow.
+func (c *circuit) done(err error) {
+	failed := err != nil && c.settings.IsFailure(err)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.state == circuitHalfOpen && c.probing {
+		c.probing = false
+		if failed {
+			c.openLocked()
+		} else {
+			c.resetLocked()
+		}
+		return
+	}
+	if c.state != circuitClosed {
+		// result of a call started before the circuit opened
+		return
+	}
+
+	if c.calls == len(c.window) {
+		if c.window[c.pos] {
+			c.failures--
+		}
+	} else {
+		c.calls++
+	}
+	c.window[c.pos] = failed
+	if failed {
+		c.failures++
+	}
+	c.pos = (c.pos + 1) % len(c.window)
+
+	if c.calls >= c.settings.MinCalls && float64(c.failures) >= c.settings.FailureRatio*float64(c.calls) {
+		c.openLocked()
+	}
+}
+
+// openLocked opens the circuit.
+func (c *circuit) openLocked() {
+	c.state = circuitOpen
+	c.openedAt = c.now()
+}
+
+// resetLocked closes the circuit and clears the window.
+func (c *circuit) resetLocked() {
+	c.state = circuitClosed
+	clear(c.window)
+	c.pos, c.calls, c.failures = 0, 0, 0
+}
+
+// circuitOpenError is returned if a call was rejected by an open circuit.
+//
+// Matches both ErrCircuitOpen and the StatusUnavailable status.
+type circuitOpenError struct {
+	status *StatusError
+}
+
+// newCircuitOpenError constructs a new circuitOpenError.
+//
+// retryAfter is the time until the circuit allows a probe call.
+func newCircuitOpenError(retryAfter time.Duration) *circuitOpenError {
+	return &circuitOpenError{
+		status: NewStatusError(StatusUnavailable, ErrCircuitOpen.Error()).WithRetryAfter(retryAfter),
+	}
+}
+
+// Error returns the error string.
+func (e *circuitOpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+// Unwrap returns ErrCircuitOpen and the status.
+func (e *circuitOpenError) Unwrap() []error {
+	return []error{ErrCircuitOpen, e.status}
+}
+
+// circuitStream is a Stream which records its result to a circuit.
+type circuitStream struct {
+	Stream
+	// circ is the circuit
+	circ *circuit
+	// once guards recording the result
+	once sync.Once
+}
+
+// MsgRecv receives an incoming message from the remote.
+func (s *circuitStream) MsgRecv(msg Message) error {
+	err := s.Stream.MsgRecv(msg)
+	if err != nil {
+		if err == io.EOF {
+			s.record(nil)
+		} else {
+			s.record(err)
+		}
+	}
+	return err
+}
+
+// Close closes the stream.
+func (s *circuitStream) Close() error {
+	s.record(nil)
+	return s.Stream.Close()
+}
+
+// record records the result of the stream once.
+func (s *circuitStream) record(err error) {
+	s.once.Do(func() {
+		s.circ.done(err)
+	})
+}
+
+// _ is a type assertion
+var (
+	_ BatchStreamClient  = ((*CircuitBreakerClient)(nil))
+	_ PingClient         = ((*CircuitBreakerClient)(nil))
+	_ CapabilitiesClient = ((*CircuitBreakerClient)(nil))
+	_ error              = ((*circuitOpenError)(nil))
+	_ Stream             = ((*circuitStream)(nil))
+)
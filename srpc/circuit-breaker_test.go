@@ -0,0 +1,106 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// errClient is a Client which returns err from ExecCall.
+type errClient struct {
+	Client
+	err   error
+	calls int
+}
+
+// ExecCall returns the error.
+func (c *errClient) ExecCall(ctx context.Context, service, method string, in, out Message) error {
+	c.calls++
+	return c.err
+}
+
+// NewStream returns the error.
+func (c *errClient) NewStream(ctx context.Context, service, method string, firstMsg Message) (Stream, error) {
+	c.calls++
+	return nil, c.err
+}
+
+// TestCircuitBreakerClient tests opening and closing the circuit.
+func TestCircuitBreakerClient(t *testing.T) {
+	ctx := context.Background()
+	inner := &errClient{err: NewStatusError(StatusUnavailable, "down")}
+	client := NewCircuitBreakerClient(inner, CircuitBreakerSettings{
+		WindowSize:  4,
+		MinCalls:    2,
+		OpenTimeout: time.Minute,
+	})
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	// caller errors do not open the circuit
+	inner.err = NewStatusError(StatusInvalidArgument, "bad request")
+	for i := 0; i < 4; i++ {
+		_ = client.ExecCall(ctx, "svc", "method", nil, nil)
+	}
+
+	inner.err = NewStatusError(StatusUnavailable, "down")
+	for i := 0; i < 4; i++ {
+		_ = client.ExecCall(ctx, "svc", "method", nil, nil)
+	}
+	calls := inner.calls
+	err := client.ExecCall(ctx, "svc", "method", nil, nil)
+	if !errors.Is(err, ErrCircuitOpen) || StatusCodeOf(err) != StatusUnavailable || RetryAfterOf(err) <= 0 {
+		t.Fatalf("expected open circuit but got %v", err)
+	}
+	if inner.calls != calls {
+		t.Fatal("expected call to be short-circuited")
+	}
+	// other methods have their own circuit
+	if err := client.ExecCall(ctx, "svc", "other", nil, nil); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected other method to be allowed")
+	}
+
+	// a failed probe opens the circuit again
+	now = now.Add(time.Minute)
+	if err := client.ExecCall(ctx, "svc", "method", nil, nil); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("expected probe call to be allowed")
+	}
+	if err := client.ExecCall(ctx, "svc", "method", nil, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit but got %v", err)
+	}
+
+	// a successful probe closes the circuit
+	now = now.Add(time.Minute)
+	inner.err = nil
+	for i := 0; i < 2; i++ {
+		if err := client.ExecCall(ctx, "svc", "method", nil, nil); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+// TestCircuitBreakerClient_Streams tests opening the circuit with streams.
+func TestCircuitBreakerClient_Streams(t *testing.T) {
+	ctx := context.Background()
+	inner := &errClient{err: NewStatusError(StatusUnavailable, "down")}
+	client := NewCircuitBreakerClient(inner, CircuitBreakerSettings{
+		WindowSize:  4,
+		MinCalls:    2,
+		OpenTimeout: time.Minute,
+	})
+
+	for i := 0; i < 4; i++ {
+		_, _ = NewStreamWithMsgs(ctx, client, "svc", "method", nil, nil)
+	}
+	calls := inner.calls
+	if _, err := client.NewStreamWithMsgs(ctx, "svc", "method", nil, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit but got %v", err)
+	}
+	if _, err := client.NewStream(ctx, "svc", "method", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit but got %v", err)
+	}
+	if inner.calls != calls {
+		t.Fatal("expected streams to be short-circuited")
+	}
+}
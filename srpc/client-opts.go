@@ -0,0 +1,134 @@
+package srpc
+
+// ClientOption configures a Client.
+type ClientOption func(opts *clientOpts)
+
+// clientOpts contains the client options.
+type clientOpts struct {
+	// fragmentSize is the max size of data in a CallData packet.
+	fragmentSize int
+	// maxRecvQueue is the max number of queued incoming messages per stream.
+	maxRecvQueue int
+	// sequence enables sending and verifying CallData sequence numbers.
+	sequence bool
+	// compression is the name of the stream compressor to use.
+	compression string
+	// checksum enables sending and verifying CallData checksums.
+	checksum bool
+	// codecs is the ordered list of codecs to offer to the server.
+	codecs []string
+	// goroutineLimiter limits the goroutines started for outgoing calls.
+	// if nil, the number of goroutines is unlimited.
+	goroutineLimiter *GoroutineLimiter
+	// warningHandler handles warnings sent by the server.
+	// if nil, warnings are logged.
+	warningHandler WarningHandler
+}
+
+// newClientOpts applies the list of options.
+func newClientOpts(opts []ClientOption) clientOpts {
+	var o clientOpts
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithClientFragmentSize fragments outgoing messages larger than size bytes.
+//
+// Large messages are split into multiple CallData packets of at most size
+// bytes which are reassembled by the remote. Use this if a transport or
+// intermediary limits the frame size. If zero or negative, messages are not
+// fragmented (the default). The remote must support fragmented messages.
+func WithClientFragmentSize(size int) ClientOption {
+	return func(opts *clientOpts) {
+		opts.fragmentSize = size
+	}
+}
+
+// WithClientMaxRecvQueue limits the number of incoming messages queued per stream.
+//
+// Incoming messages are queued until the caller calls MsgRecv. If the remote
+// sends more than max messages which were not yet received, the stream is
+// closed with ErrRecvQueueFull instead of buffering without bound.
+// If zero or negative, the queue is unbounded (the default).
+func WithClientMaxRecvQueue(max int) ClientOption {
+	return func(opts *clientOpts) {
+		opts.maxRecvQueue = max
+	}
+}
+
+// WithClientSequenceNumbers enables sequence numbers on CallData packets.
+//
+// Outgoing CallData packets are numbered and incoming packets are verified to
+// be numbered consecutively: gaps or reordering fail the call with
+// ErrSequenceMismatch. Incoming packets are not verified if the remote does
+// not send sequence numbers.
+func WithClientSequenceNumbers() ClientOption {
+	return func(opts *clientOpts) {
+		opts.sequence = true
+	}
+}
+
+// WithStreamCompression compresses the messages of each call as a single stream.
+//
+// The compressor is selected by name with the call start and must be
+// registered with RegisterStreamCompressor on both the client and the server:
+// the server fails calls with an unsupported compressor. The server compresses
+// the messages it sends with the same compressor.
+//
+// Stream compression compresses many small messages better than per-message
+// compression as the compression window is shared across messages, at the cost
+// of holding the compressor state for each call. Each message is flushed when
+// sent: compression does not delay messages.
+// If empty, messages are not compressed (the default).
+func WithStreamCompression(name string) ClientOption {
+	return func(opts *clientOpts) {
+		opts.compression = name
+	}
+}
+
+// WithClientChecksums enables CRC-32C checksums on the packets of each call.
+//
+// The client checksums the data of each packet it sends and requests
+// checksums with the call start. The server verifies them and confirms with
+// the call start response: the client verifies the checksums of the packets
+// received after the confirmation. Servers which do not support checksums do
+// not confirm them. A mismatch closes the stream with ErrChecksumMismatch.
+//
+// Only the message data of each packet is checksummed: errors, trailers and
+// flags are not covered. Use this with transports which do not guarantee the
+// integrity of the data.
+func WithClientChecksums() ClientOption {
+	return func(opts *clientOpts) {
+		opts.checksum = true
+	}
+}
+
+// WithPreferredCodecs negotiates the codec of each call with the server.
+//
+// The names of the codecs are offered with the call start, preferred first.
+// The server selects the first codec it supports (see RegisterCodec) and the
+// messages of the call are encoded with it in both directions. Calls fail
+// with a StatusUnimplemented status if the server supports none of them.
+// Starting a call waits for the server to answer before sending messages.
+// If empty, messages are encoded with protobuf without negotiation (the default).
+func WithPreferredCodecs(names ...string) ClientOption {
+	return func(opts *clientOpts) {
+		opts.codecs = names
+	}
+}
+
+// WithClientGoroutineLimiter limits the goroutines started for outgoing calls.
+//
+// The limiter is passed to the OpenStreamFunc with the context: the read pumps
+// started by the transports of this package are limited. Calls which would
+// exceed the limit fail with ErrGoroutineLimit.
+// If nil, the number of goroutines is unlimited (the default).
+func WithClientGoroutineLimiter(l *GoroutineLimiter) ClientOption {
+	return func(opts *clientOpts) {
+		opts.goroutineLimiter = l
+	}
+}
@@ -1,6 +1,9 @@
 package srpc
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // PrefixClient checks for and strips a set of prefixes from a Client.
 type PrefixClient struct {
@@ -42,6 +45,25 @@ func (i *PrefixClient) NewStream(ctx context.Context, service, method string, fi
 	return i.client.NewStream(ctx, service, method, firstMsg)
 }
 
+// Ping measures the round-trip time to the remote of the underlying client.
+//
+// Returns ErrUnimplemented if the underlying client does not implement PingClient.
+func (i *PrefixClient) Ping(ctx context.Context) (time.Duration, error) {
+	return Ping(ctx, i.client)
+}
+
+// ServerCapabilities requests the capabilities of the server of the underlying client.
+//
+// Returns ErrUnimplemented if the underlying client does not implement CapabilitiesClient.
+func (i *PrefixClient) ServerCapabilities(ctx context.Context) (*Capabilities, error) {
+	return ServerCapabilities(ctx, i.client)
+}
+
+// Close closes the underlying client if it implements io.Closer.
+func (i *PrefixClient) Close() error {
+	return CloseClient(i.client)
+}
+
 // stripCheckServiceIDPrefix strips the prefix & returns unimplemented if necessary.
 func (i *PrefixClient) stripCheckServiceIDPrefix(service string) (string, error) {
 	if len(i.serviceIDPrefixes) != 0 {
@@ -55,4 +77,7 @@ func (i *PrefixClient) stripCheckServiceIDPrefix(service string) (string, error)
 }
 
 // _ is a type assertion
-var _ Client = ((*PrefixClient)(nil))
+var (
+	_ PingClient         = ((*PrefixClient)(nil))
+	_ CapabilitiesClient = ((*PrefixClient)(nil))
+)
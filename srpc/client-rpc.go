@@ -2,6 +2,8 @@ package srpc
 
 import (
 	"context"
+	"io"
+	"slices"
 
 	"github.com/pkg/errors"
 )
@@ -9,6 +11,18 @@ import (
 // ClientRPC represents the client side of an on-going RPC call message stream.
 type ClientRPC struct {
 	commonRPC
+	// codecs is the ordered list of codecs offered to the server.
+	codecs []string
+	// codec is the codec selected by the server, if negotiated.
+	codec Codec
+	// warningHandler handles warnings sent with the trailer, if set.
+	warningHandler WarningHandler
+	// ack requests the server to acknowledge the call.
+	ack bool
+	// openStream opens the streams of the attachments, if set.
+	openStream OpenStreamFunc
+	// resumable indicates the call was started as a resumable call.
+	resumable bool
 }
 
 // NewClientRPC constructs a new ClientRPC session and writes CallStart.
@@ -20,35 +34,149 @@ func NewClientRPC(ctx context.Context, service, method string) *ClientRPC {
 	initCommonRPC(ctx, &rpc.commonRPC)
 	rpc.service = service
 	rpc.method = method
+	rpc.progressHandler = progressHandlerFromContext(ctx)
+	rpc.debugLogHandler = debugLogHandlerFromContext(ctx)
+	rpc.ack = callAckFromContext(ctx)
+	rpc.attacher = rpc
+	_, rpc.resumable = resumeStartFromContext(ctx)
 	return rpc
 }
 
 // Start sets the writer and writes the MsgSend message.
 // must only be called once!
 func (r *ClientRPC) Start(writer Writer, writeFirstMsg bool, firstMsg []byte) error {
+	return r.start(writer, writeFirstMsg, firstMsg, nil)
+}
+
+// StartWithMsgs sets the writer and writes the CallStart with the messages.
+//
+// Batches all of the initial messages into the CallStart packet.
+// must only be called once!
+func (r *ClientRPC) StartWithMsgs(writer Writer, msgs [][]byte) error {
+	if len(msgs) == 0 {
+		return r.start(writer, false, nil, nil)
+	}
+	return r.start(writer, true, msgs[0], msgs[1:])
+}
+
+// start sets the writer and writes the CallStart packet.
+func (r *ClientRPC) start(writer Writer, writeFirstMsg bool, firstMsg []byte, extraMsgs [][]byte) error {
 	select {
 	case <-r.ctx.Done():
 		r.ctxCancel()
 		return context.Canceled
 	default:
 	}
+
+	// send messages larger than the fragment size after the call start.
+	var fragmentMsgs [][]byte
+	if writeFirstMsg && r.fragmentSize > 0 && exceedsFragmentSize(r.fragmentSize, firstMsg, extraMsgs) {
+		fragmentMsgs = append([][]byte{firstMsg}, extraMsgs...)
+		writeFirstMsg, firstMsg, extraMsgs = false, nil, nil
+	}
+
+	var compressionName string
+	if r.compression != nil {
+		compressionName = r.compression.compressor.Name()
+		if writeFirstMsg {
+			var err error
+			firstMsg, extraMsgs, err = r.compressStartMsgs(firstMsg, extraMsgs)
+			if err != nil {
+				r.ctxCancelCause(err)
+				_ = writer.Close()
+				return err
+			}
+		}
+	}
+
 	r.mtx.Lock()
-	defer r.mtx.Unlock()
-	defer r.bcast.Broadcast()
 	r.writer = writer
+	r.streamID = streamIDOf(writer)
 	var firstMsgEmpty bool
 	if writeFirstMsg {
 		firstMsgEmpty = len(firstMsg) == 0
 	}
 	pkt := NewCallStartPacket(r.service, r.method, firstMsg, firstMsgEmpty)
-	if err := writer.WritePacket(pkt); err != nil {
-		r.ctxCancel()
+	pkt.GetCallStart().Metadata = OutgoingMetadataFromContext(r.ctx)
+	pkt.GetCallStart().ExtraData = extraMsgs
+	pkt.GetCallStart().Compression = compressionName
+	pkt.GetCallStart().Codecs = r.codecs
+	pkt.GetCallStart().InitialDemand = initialDemandFromContext(r.ctx)
+	pkt.GetCallStart().Ack = r.ack
+	if r.checksum {
+		pkt.GetCallStart().Checksum = true
+		pkt.GetCallStart().DataChecksum = checksumCallStart(pkt.GetCallStart())
+	}
+	if rs, ok := resumeStartFromContext(r.ctx); ok {
+		if rs.token == "" {
+			pkt.GetCallStart().Resumable = true
+		} else {
+			pkt.GetCallStart().ResumeToken = rs.token
+			pkt.GetCallStart().ResumeOffset = rs.offset
+		}
+	}
+	err := writer.WritePacket(pkt)
+	if err != nil {
+		r.ctxCancelCause(err)
 		_ = writer.Close()
+	}
+	r.bcast.Broadcast()
+	r.mtx.Unlock()
+	if err != nil {
 		return err
 	}
+
+	// send the reason to the remote if canceled with CancelWithReason
+	context.AfterFunc(r.ctx, func() {
+		if _, ok := localCancelReason(r.ctx); !ok {
+			return
+		}
+		r.mtx.Lock()
+		if !r.dataClosed {
+			r.closeLocked()
+		}
+		r.mtx.Unlock()
+	})
+
+	for _, msg := range fragmentMsgs {
+		if err := r.WriteCallData(msg, false, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// compressStartMsgs compresses the messages sent with the call start.
+func (r *ClientRPC) compressStartMsgs(firstMsg []byte, extraMsgs [][]byte) ([]byte, [][]byte, error) {
+	r.sendSeqMtx.Lock()
+	defer r.sendSeqMtx.Unlock()
+	firstMsg, err := r.compression.compress(firstMsg)
+	if err != nil {
+		return nil, nil, err
+	}
+	compressedMsgs := make([][]byte, len(extraMsgs))
+	for i, msg := range extraMsgs {
+		compressedMsgs[i], err = r.compression.compress(msg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return firstMsg, compressedMsgs, nil
+}
+
+// exceedsFragmentSize checks if any of the messages are larger than the fragment size.
+func exceedsFragmentSize(fragmentSize int, firstMsg []byte, extraMsgs [][]byte) bool {
+	if len(firstMsg) > fragmentSize {
+		return true
+	}
+	for _, msg := range extraMsgs {
+		if len(msg) > fragmentSize {
+			return true
+		}
+	}
+	return false
+}
+
 // HandlePacketData handles an incoming unparsed message packet.
 func (r *ClientRPC) HandlePacketData(data []byte) error {
 	pkt := &Packet{}
@@ -58,16 +186,23 @@ func (r *ClientRPC) HandlePacketData(data []byte) error {
 	return r.HandlePacket(pkt)
 }
 
+// RemoteFinished returns true if the server finished the call and closed the stream.
+//
+// The server discards any messages sent after the call finished.
+func (r *ClientRPC) RemoteFinished() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.remoteCompleted && r.ctx.Err() != nil
+}
+
 // HandleStreamClose handles the stream closing optionally w/ an error.
 func (r *ClientRPC) HandleStreamClose(closeErr error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 	defer r.bcast.Broadcast()
-	if closeErr != nil && r.remoteErr == nil {
-		r.remoteErr = closeErr
-	}
+	r.handleStreamCloseErrLocked(closeErr)
 	r.dataClosed = true
-	r.ctxCancel()
+	r.ctxCancelCause(transportClosedCause(closeErr))
 }
 
 // HandlePacket handles an incoming parsed message packet.
@@ -79,6 +214,8 @@ func (r *ClientRPC) HandlePacket(msg *Packet) error {
 	switch b := msg.GetBody().(type) {
 	case *Packet_CallStart:
 		return r.HandleCallStart(b.CallStart)
+	case *Packet_CallStartResp:
+		return r.HandleCallStartResp(b.CallStartResp)
 	case *Packet_CallData:
 		return r.HandleCallData(b.CallData)
 	case *Packet_CallCancel:
@@ -86,11 +223,106 @@ func (r *ClientRPC) HandlePacket(msg *Packet) error {
 			return r.HandleCallCancel()
 		}
 		return nil
+	case *Packet_CallCancelReason:
+		return r.HandleCallCancelReason(b.CallCancelReason)
 	default:
 		return nil
 	}
 }
 
+// HandleCallData handles the call data packet.
+//
+// Calls the warning handler if the trailer contains a warning: the handler is
+// called after the packet is verified and before the call completes.
+func (r *ClientRPC) HandleCallData(pkt *CallData) error {
+	warning := pkt.GetTrailer()[WarningTrailerKey]
+	if warning == "" || r.warningHandler == nil || pkt.GetHeartbeat() || pkt.GetProgress() || pkt.GetDebugLog() {
+		return r.commonRPC.HandleCallData(pkt)
+	}
+
+	r.mtx.Lock()
+	err := r.checkRecvPacketLocked(pkt)
+	r.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	r.warningHandler(r.service, r.method, warning)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.pushCallDataLocked(pkt)
+}
+
+// HandleCallStartResp handles the server accepting the call.
+//
+// Contains the codec selected by the server if the call offered codecs.
+// Enables verifying the checksums of the received packets if the server
+// confirmed them.
+func (r *ClientRPC) HandleCallStartResp(pkt *CallStartResp) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.established || (len(r.codecs) == 0 && !r.ack && !r.resumable && !r.checksum) {
+		return errors.Wrap(ErrUnrecognizedPacket, "call start resp unexpected")
+	}
+	if r.resumable {
+		r.recvResumeToken = pkt.GetResumeToken()
+	}
+	if r.checksum && pkt.GetChecksum() {
+		r.verifyChecksums = true
+	}
+	if len(r.codecs) == 0 {
+		r.established = true
+		r.bcast.Broadcast()
+		return nil
+	}
+	name := pkt.GetCodec()
+	codec, ok := LookupCodec(name)
+	if !ok || !slices.Contains(r.codecs, name) {
+		return errors.Wrap(ErrUnsupportedCodec, name)
+	}
+	r.codec = codec
+	r.established = true
+	r.bcast.Broadcast()
+	return nil
+}
+
+// WaitCodec waits for the server to select one of the offered codecs.
+//
+// Returns the error of the call if it ended before the server answered.
+func (r *ClientRPC) WaitCodec(ctx context.Context) (Codec, error) {
+	var ctxDone bool
+	for {
+		r.mtx.Lock()
+		if r.codec != nil {
+			codec := r.codec
+			r.mtx.Unlock()
+			return codec, nil
+		}
+		if r.dataClosed {
+			err := r.remoteErr
+			r.mtx.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		waiter := r.bcast.GetWaitCh()
+		r.mtx.Unlock()
+		if ctxDone {
+			return nil, context.Canceled
+		}
+		select {
+		case <-ctx.Done():
+			return nil, context.Canceled
+		case <-r.ctx.Done():
+			// check if the call ended with an error
+			ctxDone = true
+		case <-waiter:
+		}
+	}
+}
+
 // HandleCallStart handles the call start packet.
 func (r *ClientRPC) HandleCallStart(pkt *CallStart) error {
 	// server-to-client calls not supported
@@ -107,3 +339,23 @@ func (r *ClientRPC) Close() {
 	r.bcast.Broadcast()
 	r.mtx.Unlock()
 }
+
+// closeWithErr cancels the ClientRPC with the given error.
+//
+// Pending and subsequent reads return err.
+func (r *ClientRPC) closeWithErr(err error) {
+	if r.writer != nil {
+		_ = r.WriteCancel()
+	}
+	r.mtx.Lock()
+	if r.remoteErr == nil {
+		r.remoteErr = err
+	}
+	r.dataClosed = true
+	if r.writer != nil {
+		_ = r.writer.Close()
+	}
+	r.ctxCancelCause(err)
+	r.bcast.Broadcast()
+	r.mtx.Unlock()
+}
@@ -1,4 +1,4 @@
-import type { CallStart } from './rpcproto.pb.js'
+import { CallStart } from './rpcproto.pb.js'
 import { CommonRPC } from './common-rpc.js'
 
 // ClientRPC is an ongoing RPC from the client side.
@@ -15,12 +15,12 @@ export class ClientRPC extends CommonRPC {
     if (!this.service || !this.method) {
       throw new Error('service and method must be set')
     }
-    const callStart: CallStart = {
+    const callStart = CallStart.fromPartial({
       rpcService: this.service,
       rpcMethod: this.method,
       data: data || new Uint8Array(0),
       dataIsZero: !!data && data.length === 0,
-    }
+    })
     await this.writePacket({
       body: {
         $case: 'callStart',
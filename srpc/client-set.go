@@ -43,6 +43,22 @@ func (c *ClientSet) NewStream(
 	return strm, err
 }
 
+// Close closes all of the clients in the set.
+//
+// Returns the first error, if any. Close is idempotent if the clients are.
+func (c *ClientSet) Close() error {
+	var firstErr error
+	for _, client := range c.clients {
+		if client == nil {
+			continue
+		}
+		if err := CloseClient(client); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // execCall executes the call conditionally retrying against subsequent client handles.
 func (c *ClientSet) execCall(ctx context.Context, doCall func(client Client) error) error {
 	var any bool
@@ -63,7 +79,7 @@ func (c *ClientSet) execCall(ctx context.Context, doCall func(client Client) err
 				continue
 			}
 		}
-		if err.Error() == ErrUnimplemented.Error() {
+		if errors.Is(err, ErrUnimplemented) {
 			continue
 		}
 		return err
@@ -2,6 +2,9 @@ package srpc
 
 import (
 	"context"
+	"io"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -16,6 +19,18 @@ type Client interface {
 	NewStream(ctx context.Context, service, method string, firstMsg Message) (Stream, error)
 }
 
+// CloseClient closes the client if it implements io.Closer.
+//
+// The clients constructed with NewClient cancel all in-flight calls and close
+// the underlying transport. Calls started after Close return ErrClientClosed.
+// Returns nil if the client does not implement io.Closer.
+func CloseClient(c Client) error {
+	if cl, ok := c.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
 // OpenStreamFunc opens a stream with a remote.
 // msgHandler must not be called concurrently.
 type OpenStreamFunc = func(
@@ -28,23 +43,65 @@ type OpenStreamFunc = func(
 type client struct {
 	// openStream opens a new stream.
 	openStream OpenStreamFunc
+	// closeFn closes the underlying transport, if set.
+	closeFn func() error
+	// opts are the client options
+	opts clientOpts
+	// mtx guards below fields
+	mtx sync.Mutex
+	// closed indicates Close was called.
+	closed bool
+	// calls contains the in-flight calls.
+	calls map[*ClientRPC]struct{}
+	// pingSeq is the value of the last ping.
+	pingSeq uint64
 }
 
 // NewClient constructs a client with a OpenStreamFunc.
-func NewClient(openStream OpenStreamFunc) Client {
+func NewClient(openStream OpenStreamFunc, opts ...ClientOption) Client {
+	return NewClientWithClose(openStream, nil, opts...)
+}
+
+// NewClientWithClose constructs a client with a OpenStreamFunc.
+//
+// closeFn is called once when the Client is closed to release the transport.
+// closeFn can be nil.
+func NewClientWithClose(openStream OpenStreamFunc, closeFn func() error, opts ...ClientOption) Client {
+	o := newClientOpts(opts)
+	if l := o.goroutineLimiter; l != nil {
+		baseOpenStream := openStream
+		openStream = func(ctx context.Context, msgHandler PacketHandler, closeHandler CloseHandler) (Writer, error) {
+			return baseOpenStream(withGoroutineLimiter(ctx, l), msgHandler, closeHandler)
+		}
+	}
 	return &client{
 		openStream: openStream,
+		closeFn:    closeFn,
+		opts:       o,
+		calls:      make(map[*ClientRPC]struct{}),
 	}
 }
 
 // ExecCall executes a request/reply RPC with the remote.
 func (c *client) ExecCall(ctx context.Context, service, method string, in, out Message) error {
+	if len(c.opts.codecs) != 0 {
+		strm, err := c.newNegotiatedStream(ctx, service, method, in)
+		if err != nil {
+			return err
+		}
+		defer strm.Close()
+		return strm.MsgRecv(out)
+	}
+
 	firstMsg, err := in.MarshalVT()
 	if err != nil {
 		return err
 	}
 
-	clientRPC := NewClientRPC(ctx, service, method)
+	clientRPC, err := c.newClientRPC(ctx, service, method)
+	if err != nil {
+		return err
+	}
 	defer clientRPC.Close()
 
 	writer, err := c.openStream(ctx, clientRPC.HandlePacket, clientRPC.HandleStreamClose)
@@ -57,6 +114,12 @@ func (c *client) ExecCall(ctx context.Context, service, method string, in, out M
 
 	msg, err := clientRPC.ReadOne()
 	if err != nil {
+		select {
+		case <-ctx.Done():
+			// the deferred Close sends the cancel packet to the remote.
+			return context.Canceled
+		default:
+		}
 		// this includes any server returned error.
 		return err
 	}
@@ -69,6 +132,14 @@ func (c *client) ExecCall(ctx context.Context, service, method string, in, out M
 // NewStream starts a streaming RPC with the remote & returns the stream.
 // firstMsg is optional.
 func (c *client) NewStream(ctx context.Context, service, method string, firstMsg Message) (Stream, error) {
+	if len(c.opts.codecs) != 0 {
+		var msgs []Message
+		if firstMsg != nil {
+			msgs = append(msgs, firstMsg)
+		}
+		return c.newNegotiatedStream(ctx, service, method, msgs...)
+	}
+
 	var firstMsgData []byte
 	if firstMsg != nil {
 		var err error
@@ -78,17 +149,317 @@ func (c *client) NewStream(ctx context.Context, service, method string, firstMsg
 		}
 	}
 
-	clientRPC := NewClientRPC(ctx, service, method)
+	clientRPC, err := c.newClientRPC(ctx, service, method)
+	if err != nil {
+		return nil, err
+	}
 	writer, err := c.openStream(ctx, clientRPC.HandlePacket, clientRPC.HandleStreamClose)
 	if err != nil {
+		clientRPC.Close()
 		return nil, err
 	}
 	if err := clientRPC.Start(writer, firstMsg != nil, firstMsgData); err != nil {
 		return nil, err
 	}
 
-	return NewMsgStream(ctx, clientRPC, clientRPC.ctxCancel), nil
+	return NewMsgStream(withStreamRPC(ctx, &clientRPC.commonRPC), clientRPC, clientRPC.ctxCancel), nil
+}
+
+// NewStreamWithMsgs starts a streaming RPC with the remote & returns the stream.
+// Batches all of the msgs into the CallStart packet.
+func (c *client) NewStreamWithMsgs(ctx context.Context, service, method string, msgs ...Message) (Stream, error) {
+	if len(c.opts.codecs) != 0 {
+		return c.newNegotiatedStream(ctx, service, method, msgs...)
+	}
+
+	msgsData, err := marshalMsgs(msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	clientRPC, err := c.newClientRPC(ctx, service, method)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := c.openStream(ctx, clientRPC.HandlePacket, clientRPC.HandleStreamClose)
+	if err != nil {
+		clientRPC.Close()
+		return nil, err
+	}
+	if err := clientRPC.StartWithMsgs(writer, msgsData); err != nil {
+		return nil, err
+	}
+
+	return NewMsgStream(withStreamRPC(ctx, &clientRPC.commonRPC), clientRPC, clientRPC.ctxCancel), nil
+}
+
+// newNegotiatedStream starts a streaming RPC offering the preferred codecs.
+//
+// Waits for the server to select the codec and sends the msgs encoded with it.
+func (c *client) newNegotiatedStream(ctx context.Context, service, method string, msgs ...Message) (Stream, error) {
+	clientRPC, err := c.newClientRPC(ctx, service, method)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := c.openStream(ctx, clientRPC.HandlePacket, clientRPC.HandleStreamClose)
+	if err != nil {
+		clientRPC.Close()
+		return nil, err
+	}
+	if err := clientRPC.Start(writer, false, nil); err != nil {
+		clientRPC.Close()
+		return nil, err
+	}
+	codec, err := clientRPC.WaitCodec(ctx)
+	if err != nil {
+		clientRPC.Close()
+		return nil, err
+	}
+
+	strm := NewCodecStream(NewMsgStream(withStreamRPC(ctx, &clientRPC.commonRPC), clientRPC, clientRPC.ctxCancel), codec)
+	for _, msg := range msgs {
+		if err := strm.MsgSend(msg); err != nil {
+			_ = strm.Close()
+			return nil, err
+		}
+	}
+	return strm, nil
+}
+
+// Close cancels all in-flight calls and closes the underlying transport.
+//
+// Calls started after Close return ErrClientClosed.
+// Close is idempotent: subsequent calls return nil.
+func (c *client) Close() error {
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return nil
+	}
+	c.closed = true
+	calls := make([]*ClientRPC, 0, len(c.calls))
+	for call := range c.calls {
+		calls = append(calls, call)
+	}
+	c.calls = nil
+	c.mtx.Unlock()
+
+	for _, call := range calls {
+		call.closeWithErr(ErrClientClosed)
+	}
+	if c.closeFn != nil {
+		return c.closeFn()
+	}
+	return nil
+}
+
+// Ping sends a ping packet on a new stream and waits for the pong.
+//
+// The remote answers the ping without invoking a handler.
+// Returns the round-trip time.
+func (c *client) Ping(ctx context.Context) (time.Duration, error) {
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return 0, ErrClientClosed
+	}
+	c.pingSeq++
+	value := c.pingSeq
+	c.mtx.Unlock()
+
+	var start time.Time
+	_, err := c.requestPacket(ctx, NewPingPacket(value), func(pkt *Packet) bool {
+		pong, ok := pkt.GetBody().(*Packet_Pong)
+		return ok && pong.Pong == value
+	}, func() { start = time.Now() })
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// ServerCapabilities requests the optional features supported by the server.
+//
+// The server answers on a new stream without invoking a handler.
+func (c *client) ServerCapabilities(ctx context.Context) (*Capabilities, error) {
+	c.mtx.Lock()
+	closed := c.closed
+	c.mtx.Unlock()
+	if closed {
+		return nil, ErrClientClosed
+	}
+
+	resp, err := c.requestPacket(ctx, NewCapabilitiesRequestPacket(), func(pkt *Packet) bool {
+		return pkt.GetCapabilities() != nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetCapabilities(), nil
+}
+
+// requestPacket writes the packet on a new stream and waits for the response.
+//
+// match returns true if the packet is the response.
+// beforeSend is called before writing the packet, if set.
+func (c *client) requestPacket(ctx context.Context, req *Packet, match func(pkt *Packet) bool, beforeSend func()) (*Packet, error) {
+	ctx, ctxCancel := context.WithCancel(ctx)
+	defer ctxCancel()
+	type result struct {
+		pkt *Packet
+		err error
+	}
+	resultCh := make(chan result, 1)
+	msgHandler := func(pkt *Packet) error {
+		if match(pkt) {
+			select {
+			case resultCh <- result{pkt: pkt}:
+			default:
+			}
+		}
+		return nil
+	}
+	closeHandler := func(closeErr error) {
+		if closeErr == nil {
+			closeErr = ErrStreamClosed
+		}
+		select {
+		case resultCh <- result{err: closeErr}:
+		default:
+		}
+	}
+	writer, err := c.openStream(ctx, msgHandler, closeHandler)
+	if err != nil {
+		return nil, err
+	}
+	defer writer.Close()
+
+	if beforeSend != nil {
+		beforeSend()
+	}
+	if err := writer.WritePacket(req); err != nil {
+		return nil, err
+	}
+	if err := flushWriter(ctx, writer); err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, context.Canceled
+	case res := <-resultCh:
+		return res.pkt, res.err
+	}
+}
+
+// newClientRPC constructs a new ClientRPC and tracks it until it completes.
+func (c *client) newClientRPC(ctx context.Context, service, method string) (*ClientRPC, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	var compression *streamCompression
+	if name := c.opts.compression; name != "" {
+		compressor, ok := LookupStreamCompressor(name)
+		if !ok {
+			return nil, errors.Wrap(ErrUnsupportedCompression, name)
+		}
+		compression = newStreamCompression(compressor)
+	}
+	clientRPC := NewClientRPC(ctx, service, method)
+	clientRPC.compression = compression
+	clientRPC.fragmentSize = c.opts.fragmentSize
+	clientRPC.maxRecvQueue = c.opts.maxRecvQueue
+	clientRPC.sequence = c.opts.sequence
+	clientRPC.checksum = c.opts.checksum
+	clientRPC.codecs = c.opts.codecs
+	clientRPC.warningHandler = c.opts.warningHandler
+	clientRPC.openStream = c.openStream
+	if clientRPC.warningHandler == nil {
+		clientRPC.warningHandler = logWarning
+	}
+	c.calls[clientRPC] = struct{}{}
+	context.AfterFunc(clientRPC.Context(), func() {
+		c.mtx.Lock()
+		delete(c.calls, clientRPC)
+		c.mtx.Unlock()
+	})
+	return clientRPC, nil
+}
+
+// BatchStreamClient is a Client which can batch initial messages with the call start.
+type BatchStreamClient interface {
+	Client
+
+	// NewStreamWithMsgs starts a streaming RPC with the remote & returns the stream.
+	// Batches all of the msgs into the CallStart packet.
+	NewStreamWithMsgs(ctx context.Context, service, method string, msgs ...Message) (Stream, error)
+}
+
+// NewStreamWithMsgs starts a streaming RPC sending the initial msgs.
+//
+// If the client implements BatchStreamClient, sends the msgs in one packet
+// with the call start. Otherwise sends the msgs one at a time.
+func NewStreamWithMsgs(ctx context.Context, cc Client, service, method string, msgs ...Message) (Stream, error) {
+	if bc, ok := cc.(BatchStreamClient); ok {
+		return bc.NewStreamWithMsgs(ctx, service, method, msgs...)
+	}
+
+	var firstMsg Message
+	if len(msgs) != 0 {
+		firstMsg = msgs[0]
+		msgs = msgs[1:]
+	}
+	strm, err := cc.NewStream(ctx, service, method, firstMsg)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		if err := strm.MsgSend(msg); err != nil {
+			_ = strm.Close()
+			return nil, err
+		}
+	}
+	return strm, nil
+}
+
+// PingClient is a Client which can measure the round-trip time to the remote.
+type PingClient interface {
+	Client
+
+	// Ping sends a ping to the remote and waits for the pong.
+	// Returns the round-trip time.
+	Ping(ctx context.Context) (time.Duration, error)
+}
+
+// Ping measures the round-trip time to the remote of the client.
+//
+// Returns ErrUnimplemented if the client does not implement PingClient.
+func Ping(ctx context.Context, cc Client) (time.Duration, error) {
+	pc, ok := cc.(PingClient)
+	if !ok {
+		return 0, ErrUnimplemented
+	}
+	return pc.Ping(ctx)
+}
+
+// marshalMsgs marshals the list of messages.
+func marshalMsgs(msgs []Message) ([][]byte, error) {
+	msgsData := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		var err error
+		msgsData[i], err = msg.MarshalVT()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msgsData, nil
 }
 
 // _ is a type assertion
-var _ Client = ((*client)(nil))
+var (
+	_ BatchStreamClient  = ((*client)(nil))
+	_ PingClient         = ((*client)(nil))
+	_ CapabilitiesClient = ((*client)(nil))
+	_ io.Closer          = ((*client)(nil))
+)
@@ -0,0 +1,68 @@
+package srpc
+
+// codecStream encodes and decodes the messages of a Stream with a Codec.
+type codecStream struct {
+	Stream
+	codec Codec
+}
+
+// NewCodecStream wraps a stream to encode and decode messages with the codec.
+//
+// RawMessage values are sent and received as-is without the codec.
+func NewCodecStream(strm Stream, codec Codec) Stream {
+	return &codecStream{Stream: strm, codec: codec}
+}
+
+// MsgSend encodes the message with the codec and sends it.
+func (s *codecStream) MsgSend(msg Message) error {
+	raw, err := s.encode(msg)
+	if err != nil {
+		return err
+	}
+	return s.Stream.MsgSend(raw)
+}
+
+// SendClose encodes the final message with the codec and sends it with the completion.
+func (s *codecStream) SendClose(msg Message) error {
+	raw, err := s.encode(msg)
+	if err != nil {
+		return err
+	}
+	return SendClose(s.Stream, raw)
+}
+
+// MsgRecv receives a message and decodes it with the codec.
+func (s *codecStream) MsgRecv(msg Message) error {
+	if raw, ok := msg.(*RawMessage); ok {
+		return s.Stream.MsgRecv(raw)
+	}
+	raw := NewRawMessage(nil, false)
+	if err := s.Stream.MsgRecv(raw); err != nil {
+		return err
+	}
+	return s.codec.Unmarshal(raw.GetData(), msg)
+}
+
+// PeekFirstMessage returns the raw first message sent with the call, if any.
+func (s *codecStream) PeekFirstMessage() ([]byte, bool) {
+	return PeekFirstMessage(s.Stream)
+}
+
+// encode encodes the message with the codec into a RawMessage.
+func (s *codecStream) encode(msg Message) (*RawMessage, error) {
+	if raw, ok := msg.(*RawMessage); ok {
+		return raw, nil
+	}
+	data, err := s.codec.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return NewRawMessage(data, false), nil
+}
+
+// _ is a type assertion
+var (
+	_ Stream             = ((*codecStream)(nil))
+	_ SendCloser         = ((*codecStream)(nil))
+	_ FirstMessagePeeker = ((*codecStream)(nil))
+)
@@ -15,6 +15,8 @@ type commonRPC struct {
 	ctx context.Context
 	// ctxCancel is called when the rpc ends.
 	ctxCancel context.CancelFunc
+	// ctxCancelCause cancels the context with a cause.
+	ctxCancelCause context.CancelCauseFunc
 	// service is the rpc service
 	service string
 	// method is the rpc method
@@ -33,11 +35,78 @@ type commonRPC struct {
 	dataClosed bool
 	// remoteErr is an error set by the remote.
 	remoteErr error
+	// remoteCompleted is set after the remote completed or canceled the call.
+	remoteCompleted bool
+	// recvResumeToken is the token to resume the call with sent by the server.
+	recvResumeToken string
+	// localCompleted is set after we write a packet with complete set.
+	localCompleted bool
+	// fragmentSize is the max size of data in a CallData packet.
+	// if zero, messages are not fragmented.
+	fragmentSize int
+	// fragmentBuf contains the fragments of the incoming message.
+	fragmentBuf []byte
+	// maxRecvQueue is the max number of queued incoming messages.
+	// if zero, the queue is unbounded.
+	maxRecvQueue int
+	// streamID is the multiplexing stream ID of the transport stream.
+	streamID uint64
+	// sequence enables sending and verifying CallData sequence numbers.
+	sequence bool
+	// checksum enables sending CallData checksums.
+	checksum bool
+	// verifyChecksums enables verifying the received CallData checksums.
+	verifyChecksums bool
+	// recvSeq is the sequence number of the last received CallData packet.
+	recvSeq uint32
+	// sendSeqMtx guards sendSeq and compression.
+	// orders writing CallData packets.
+	sendSeqMtx sync.Mutex
+	// sendSeq is the sequence number of the last sent CallData packet.
+	sendSeq uint32
+	// trailer contains the trailer to send with the final packet.
+	// nil if the rpc does not send a trailer.
+	trailer *trailerHolder
+	// remoteTrailer is the trailer received with the final packet.
+	remoteTrailer Metadata
+	// compression is the stream compression state.
+	// nil if the messages are not compressed.
+	compression *streamCompression
+	// progressHandler handles incoming progress updates.
+	// if nil, progress updates are ignored.
+	progressHandler ProgressHandler
+	// debugLogHandler handles incoming debug log entries.
+	// if nil, debug log entries are ignored.
+	debugLogHandler DebugLogHandler
+	// bytesReceived is the number of message bytes received.
+	bytesReceived uint64
+	// cancelSent is set after writing a call cancel packet.
+	cancelSent bool
+	// attacher opens the attachments of the call, if supported.
+	attacher msgStreamAttacher
+	// attachmentSeq is the ID of the last attachment opened to send.
+	attachmentSeq uint32
+	// attachmentKey is the key identifying the call for attachment streams.
+	// nil if the key was not registered yet.
+	attachmentKey []byte
+	// attachmentsIn contains the open attachments received from the remote by ID.
+	attachmentsIn map[uint32]*attachmentPipe
+	// attachmentsOut contains the attachments waiting for the remote to pull them by ID.
+	attachmentsOut map[uint32]*attachmentWriter
+	// established is set when the call was accepted by the remote.
+	established bool
+	// demandEnabled indicates the remote enabled demand flow control.
+	demandEnabled bool
+	// demand is the number of messages requested by the remote.
+	demand uint64
 }
 
 // initCommonRPC initializes the commonRPC.
 func initCommonRPC(ctx context.Context, rpc *commonRPC) {
-	rpc.ctx, rpc.ctxCancel = context.WithCancel(ctx)
+	rpc.ctx, rpc.ctxCancelCause = context.WithCancelCause(ctx)
+	rpc.ctxCancel = func() {
+		rpc.ctxCancelCause(ErrStreamClosed)
+	}
 }
 
 // Context is canceled when the rpc has finished.
@@ -45,6 +114,31 @@ func (c *commonRPC) Context() context.Context {
 	return c.ctx
 }
 
+// StreamID returns the multiplexing stream ID of the transport stream.
+//
+// Returns 0 if unknown.
+func (c *commonRPC) StreamID() uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.streamID
+}
+
+// Trailer returns the trailer metadata received with the final packet.
+//
+// Returns nil if the remote did not send a trailer or the call is not complete.
+func (c *commonRPC) Trailer() Metadata {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.remoteTrailer
+}
+
+// BytesReceived returns the number of message bytes received from the remote.
+func (c *commonRPC) BytesReceived() uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.bytesReceived
+}
+
 // Wait waits for the RPC to finish.
 func (c *commonRPC) Wait(ctx context.Context) error {
 	for {
@@ -68,6 +162,14 @@ func (c *commonRPC) Wait(ctx context.Context) error {
 //
 // returns io.EOF if the stream ended without a packet.
 func (c *commonRPC) ReadOne() ([]byte, error) {
+	return c.ReadOneContext(context.Background())
+}
+
+// ReadOneContext reads a single message and returns.
+//
+// returns ctx.Err() if ctx is canceled before a message arrives.
+// returns io.EOF if the stream ended without a packet.
+func (c *commonRPC) ReadOneContext(ctx context.Context) ([]byte, error) {
 	var msg []byte
 	var err error
 	var ctxDone bool
@@ -98,6 +200,8 @@ func (c *commonRPC) ReadOne() ([]byte, error) {
 		}
 		c.mtx.Unlock()
 		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		case <-c.ctx.Done():
 			ctxDone = true
 		case <-waiter:
@@ -110,33 +214,144 @@ func (c *commonRPC) WriteCallData(data []byte, complete bool, err error) error {
 	if c.writer == nil {
 		return ErrCompleted
 	}
-	outPkt := NewCallDataPacket(data, len(data) == 0, false, nil)
-	return c.writer.WritePacket(outPkt)
+	complete = complete || err != nil
+	if len(data) != 0 || !complete {
+		// sending a message consumes the demand of the remote
+		if derr := c.acquireDemand(); derr != nil {
+			return derr
+		}
+	}
+	// hold the lock while writing so the packets (and the fragments of each
+	// message) are written in the order the messages were sent.
+	c.sendSeqMtx.Lock()
+	defer c.sendSeqMtx.Unlock()
+	c.mtx.Lock()
+	if c.localCompleted {
+		c.mtx.Unlock()
+		if complete && len(data) == 0 && err == nil {
+			// already sent the complete packet
+			return nil
+		}
+		return ErrCompleted
+	}
+	if complete {
+		c.localCompleted = true
+	}
+	c.mtx.Unlock()
+	if c.compression != nil && err == nil && (len(data) != 0 || !complete) {
+		var cerr error
+		data, cerr = c.compression.compress(data)
+		if cerr != nil {
+			return cerr
+		}
+	}
+	if fragmentSize := c.fragmentSize; fragmentSize > 0 && err == nil {
+		for len(data) > fragmentSize {
+			if werr := c.writeCallDataPacket(NewCallDataFragmentPacket(data[:fragmentSize])); werr != nil {
+				return werr
+			}
+			data = data[fragmentSize:]
+		}
+	}
+	outPkt := NewCallDataPacket(data, len(data) == 0 && !complete, complete, err)
+	if st, ok := StatusFromError(err); ok {
+		outPkt.GetCallData().Status = st.toProto()
+	}
+	if complete && c.trailer != nil {
+		outPkt.GetCallData().Trailer = c.trailer.take()
+	}
+	return c.writeCallDataPacket(outPkt)
+}
+
+// WriteProgress writes a progress update packet.
+func (c *commonRPC) WriteProgress(progress []byte) error {
+	if c.writer == nil {
+		return ErrCompleted
+	}
+	c.sendSeqMtx.Lock()
+	defer c.sendSeqMtx.Unlock()
+	c.mtx.Lock()
+	completed := c.localCompleted
+	c.mtx.Unlock()
+	if completed {
+		return ErrCompleted
+	}
+	return c.writeCallDataPacket(NewCallDataProgressPacket(progress))
+}
+
+// Flush flushes the writer if it buffers writes.
+func (c *commonRPC) Flush(ctx context.Context) error {
+	if c.writer == nil {
+		return nil
+	}
+	return flushWriter(ctx, c.writer)
+}
+
+// writeCallDataPacket writes a CallData packet setting the sequence number and checksum.
+//
+// sendSeqMtx must be locked by the caller.
+func (c *commonRPC) writeCallDataPacket(pkt *Packet) error {
+	if c.sequence {
+		c.sendSeq++
+		pkt.GetCallData().Seq = c.sendSeq
+	}
+	if c.checksum {
+		pkt.GetCallData().Checksum = checksumData(pkt.GetCallData().GetData())
+	}
+	return c.writer.WritePacket(pkt)
 }
 
 // HandleStreamClose handles the incoming stream closing w/ optional error.
 func (c *commonRPC) HandleStreamClose(closeErr error) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
-	if closeErr != nil && c.remoteErr == nil {
-		c.remoteErr = closeErr
-	}
+	c.handleStreamCloseErrLocked(closeErr)
 	c.dataClosed = true
-	c.ctxCancel()
+	c.ctxCancelCause(transportClosedCause(closeErr))
 	if c.writer != nil {
 		_ = c.writer.Close()
 	}
 	c.bcast.Broadcast()
 }
 
+// handleStreamCloseErrLocked sets the error returned by ReadOne after the stream closed.
+//
+// If the remote completed the call, ReadOne returns io.EOF after the queued
+// messages. Otherwise the stream closed abruptly: ReadOne returns closeErr or
+// ErrTransportClosed if closeErr is nil.
+// c.mtx must be locked by the caller.
+func (c *commonRPC) handleStreamCloseErrLocked(closeErr error) {
+	if c.remoteErr != nil || c.remoteCompleted {
+		return
+	}
+	if closeErr == nil {
+		closeErr = ErrTransportClosed
+	}
+	c.remoteErr = closeErr
+}
+
 // HandleCallCancel handles the call cancel packet.
 func (c *commonRPC) HandleCallCancel() error {
+	return c.handleCallCancel(ErrRemoteCanceled)
+}
+
+// HandleCallCancelReason handles the call cancel packet with a reason.
+//
+// The context is canceled with a CancelReasonError wrapping ErrRemoteCanceled.
+func (c *commonRPC) HandleCallCancelReason(reason string) error {
+	return c.handleCallCancel(&CancelReasonError{Reason: reason, remote: true})
+}
+
+// handleCallCancel handles the remote canceling the call with the cause.
+func (c *commonRPC) handleCallCancel(cause error) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
-	if c.remoteErr != nil {
+	if c.remoteErr == nil {
 		c.remoteErr = context.Canceled
 	}
 	c.dataClosed = true
+	c.remoteCompleted = true
+	c.ctxCancelCause(cause)
 	if c.writer != nil {
 		_ = c.writer.Close()
 	}
@@ -146,48 +361,185 @@ func (c *commonRPC) HandleCallCancel() error {
 
 // HandleCallData handles the call data packet.
 func (c *commonRPC) HandleCallData(pkt *CallData) error {
+	// drop heartbeat packets
+	if pkt.GetHeartbeat() {
+		return nil
+	}
+
+	if pkt.GetProgress() {
+		return c.handleProgress(pkt)
+	}
+	if pkt.GetDebugLog() {
+		return c.handleDebugLog(pkt)
+	}
+
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
+	if err := c.checkRecvPacketLocked(pkt); err != nil {
+		return err
+	}
+	return c.pushCallDataLocked(pkt)
+}
+
+// pushCallDataLocked queues the message of a verified CallData packet.
+//
+// c.mtx must be locked by the caller.
+func (c *commonRPC) pushCallDataLocked(pkt *CallData) error {
 	if c.dataClosed {
 		return ErrCompleted
 	}
 
-	if data := pkt.GetData(); len(data) != 0 || pkt.GetDataIsZero() {
+	data := pkt.GetData()
+	c.bytesReceived += uint64(len(data))
+	if pkt.GetFragment() {
+		if len(c.fragmentBuf)+len(data) > int(maxMessageSize) {
+			return errors.Errorf("fragmented message size greater than maximum %v", maxMessageSize)
+		}
+		c.fragmentBuf = append(c.fragmentBuf, data...)
+		return nil
+	}
+	if len(c.fragmentBuf) != 0 {
+		if len(data) == 0 {
+			return errors.Wrap(ErrInvalidPacket, "expected last fragment of message")
+		}
+		data = append(c.fragmentBuf, data...)
+		c.fragmentBuf = nil
+	}
+
+	if len(data) != 0 || pkt.GetDataIsZero() {
+		if c.compression != nil && len(data) != 0 {
+			var err error
+			data, err = c.compression.decompress(data)
+			if err != nil {
+				return err
+			}
+		}
+		if c.maxRecvQueue > 0 && len(c.dataQueue) >= c.maxRecvQueue {
+			return errors.Wrapf(ErrRecvQueueFull, "%d messages queued", len(c.dataQueue))
+		}
 		c.dataQueue = append(c.dataQueue, data)
 	}
 
 	complete := pkt.GetComplete()
 	if err := pkt.GetError(); len(err) != 0 {
 		complete = true
-		c.remoteErr = errors.New(err)
+		c.remoteErr = parseRemoteErrorWithStatus(err, pkt.GetStatus())
 	}
 
 	if complete {
 		c.dataClosed = true
+		c.remoteCompleted = true
+		c.remoteTrailer = pkt.GetTrailer()
 	}
 
 	c.bcast.Broadcast()
 	return nil
 }
 
+// handleProgress handles a progress update packet.
+func (c *commonRPC) handleProgress(pkt *CallData) error {
+	c.mtx.Lock()
+	if err := c.checkRecvPacketLocked(pkt); err != nil {
+		c.mtx.Unlock()
+		return err
+	}
+	handler := c.progressHandler
+	c.mtx.Unlock()
+
+	if handler != nil {
+		handler(pkt.GetData())
+	}
+	return nil
+}
+
+// checkRecvPacketLocked verifies the checksum and sequence number of a received packet.
+//
+// c.mtx must be locked by the caller.
+func (c *commonRPC) checkRecvPacketLocked(pkt *CallData) error {
+	if c.dataClosed {
+		return ErrCompleted
+	}
+	if c.verifyChecksums {
+		if err := verifyChecksum(pkt.GetChecksum(), checksumData(pkt.GetData())); err != nil {
+			return err
+		}
+	}
+	if c.sequence {
+		if err := c.checkRecvSeq(pkt.GetSeq()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRecvSeq verifies the sequence number of an incoming CallData packet.
+//
+// Sequence numbers are not verified if the remote does not send them.
+// c.mtx must be locked by the caller.
+func (c *commonRPC) checkRecvSeq(seq uint32) error {
+	if seq == 0 && c.recvSeq == 0 {
+		return nil
+	}
+	if seq != c.recvSeq+1 {
+		return errors.Wrapf(ErrSequenceMismatch, "expected %d got %d", c.recvSeq+1, seq)
+	}
+	c.recvSeq = seq
+	return nil
+}
+
 // WriteCancel writes a call cancel packet.
+//
+// Sends the reason if the context was canceled with CancelWithReason.
 func (c *commonRPC) WriteCancel() error {
-	if c.writer != nil {
-		return c.writer.WritePacket(NewCallCancelPacket())
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.writeCancelLocked()
+}
+
+// writeCancelLocked writes a call cancel packet.
+//
+// c.mtx must be locked by the caller.
+func (c *commonRPC) writeCancelLocked() error {
+	if c.writer == nil {
+		return nil
 	}
-	return nil
+	c.cancelSent = true
+	if reason, ok := localCancelReason(c.ctx); ok {
+		return c.writer.WritePacket(NewCallCancelReasonPacket(reason))
+	}
+	return c.writer.WritePacket(NewCallCancelPacket())
 }
 
 // closeLocked releases resources held by the RPC.
+//
+// Sends the cancel reason to the remote if the context was canceled with
+// CancelWithReason and the cancel was not already sent.
 func (c *commonRPC) closeLocked() {
 	c.dataClosed = true
 	if c.remoteErr == nil {
 		c.remoteErr = context.Canceled
 	}
 	if c.writer != nil {
+		if _, ok := localCancelReason(c.ctx); ok && !c.cancelSent && !c.remoteCompleted {
+			_ = c.writeCancelLocked()
+		}
 		_ = c.writer.Close()
 	}
+	if c.compression != nil {
+		c.compression.close()
+	}
 	c.bcast.Broadcast()
 	c.ctxCancel()
 }
+
+// transportClosedCause returns the cancellation cause for the transport closing.
+//
+// Uses WithMessage instead of Wrap: the stack trace is not useful here and
+// capturing it is costly as this is called for every stream.
+func transportClosedCause(closeErr error) error {
+	if closeErr == nil {
+		return ErrTransportClosed
+	}
+	return errors.WithMessage(ErrTransportClosed, closeErr.Error())
+}
@@ -1,8 +1,8 @@
 import type { Sink } from 'it-stream-types'
 import { pushable } from 'it-pushable'
 
-import type { CallData, CallStart } from './rpcproto.pb.js'
-import { Packet } from './rpcproto.pb.js'
+import type { CallStart } from './rpcproto.pb.js'
+import { CallData, Packet } from './rpcproto.pb.js'
 
 // CommonRPC is common logic between server and client RPCs.
 export class CommonRPC {
@@ -46,12 +46,12 @@ export class CommonRPC {
     complete?: boolean,
     error?: string
   ) {
-    const callData: CallData = {
+    const callData = CallData.fromPartial({
       data: data || new Uint8Array(0),
       dataIsZero: !!data && data.length === 0,
       complete: complete || false,
       error: error || '',
-    }
+    })
     await this.writePacket({
       body: {
         $case: 'callData',
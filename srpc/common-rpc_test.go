@@ -0,0 +1,248 @@
+package srpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// blockingInvoker blocks until the stream context is canceled.
+type blockingInvoker struct{}
+
+// InvokeMethod invokes the method matching the service & method ID.
+func (blockingInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	<-strm.Context().Done()
+	return true, nil
+}
+
+// TestCommonRPC_MaxRecvQueue tests the bounded receive queue.
+func TestCommonRPC_MaxRecvQueue(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	serverRPC := NewServerRPC(ctx, blockingInvoker{}, discardWriter{}, WithMaxRecvQueue(2))
+	if err := serverRPC.HandlePacket(NewCallStartPacket("test-service", "test-method", []byte("1"), false)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := serverRPC.HandlePacket(NewCallDataPacket([]byte("2"), false, false, nil)); err != nil {
+		t.Fatal(err.Error())
+	}
+	err := serverRPC.HandlePacket(NewCallDataPacket([]byte("3"), false, false, nil))
+	if !errors.Is(err, ErrRecvQueueFull) {
+		t.Fatalf("expected ErrRecvQueueFull but got %v", err)
+	}
+}
+
+// TestCommonRPC_SequenceNumbers tests verifying the CallData sequence numbers.
+func TestCommonRPC_SequenceNumbers(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	newSeqPacket := func(data string, seq uint32) *Packet {
+		pkt := NewCallDataPacket([]byte(data), false, false, nil)
+		pkt.GetCallData().Seq = seq
+		return pkt
+	}
+
+	serverRPC := NewServerRPC(ctx, blockingInvoker{}, discardWriter{}, WithSequenceNumbers())
+	if err := serverRPC.HandlePacket(NewCallStartPacket("test-service", "test-method", nil, false)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := serverRPC.HandlePacket(newSeqPacket("1", 1)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := serverRPC.HandlePacket(newSeqPacket("2", 2)); err != nil {
+		t.Fatal(err.Error())
+	}
+	err := serverRPC.HandlePacket(newSeqPacket("4", 4))
+	if !errors.Is(err, ErrSequenceMismatch) {
+		t.Fatalf("expected ErrSequenceMismatch but got %v", err)
+	}
+}
+
+// TestCommonRPC_Checksums tests verifying the CallData checksums.
+func TestCommonRPC_Checksums(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	serverRPC := NewServerRPC(ctx, blockingInvoker{}, discardWriter{})
+	start := NewCallStartPacket("test-service", "test-method", []byte("start"), false)
+	start.GetCallStart().Checksum = true
+	start.GetCallStart().DataChecksum = checksumCallStart(start.GetCallStart())
+	if err := serverRPC.HandlePacket(start); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	pkt := NewCallDataPacket([]byte("hello"), false, false, nil)
+	pkt.GetCallData().Checksum = checksumData([]byte("hello"))
+	if err := serverRPC.HandlePacket(pkt); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// corrupt the data
+	pkt = NewCallDataPacket([]byte("hellp"), false, false, nil)
+	pkt.GetCallData().Checksum = checksumData([]byte("hello"))
+	err := serverRPC.HandlePacket(pkt)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch but got %v", err)
+	}
+}
+
+// TestClientRPC_ChecksumsConfirmed tests the client verifies checksums after the server confirmed them.
+func TestClientRPC_ChecksumsConfirmed(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	clientRPC := NewClientRPC(ctx, "test-service", "test-method")
+	clientRPC.checksum = true
+	if err := clientRPC.Start(discardWriter{}, false, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the server did not confirm checksums yet
+	if err := clientRPC.HandlePacket(NewCallDataPacket([]byte("hello"), false, false, nil)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	resp := NewCallStartRespPacket("")
+	resp.GetCallStartResp().Checksum = true
+	if err := clientRPC.HandlePacket(resp); err != nil {
+		t.Fatal(err.Error())
+	}
+	err := clientRPC.HandlePacket(NewCallDataPacket([]byte("hello"), false, false, nil))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch but got %v", err)
+	}
+}
+
+// heartbeatWriter is a Writer which signals when a heartbeat packet is written.
+type heartbeatWriter struct {
+	heartbeats chan struct{}
+}
+
+// WritePacket writes a packet to the remote.
+func (w *heartbeatWriter) WritePacket(p *Packet) error {
+	if p.GetCallData().GetHeartbeat() {
+		select {
+		case w.heartbeats <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close closes the writer.
+func (w *heartbeatWriter) Close() error { return nil }
+
+// TestServerRPC_Heartbeats tests the server sends heartbeats while the call is idle.
+func TestServerRPC_Heartbeats(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	writer := &heartbeatWriter{heartbeats: make(chan struct{}, 1)}
+	serverRPC := NewServerRPC(ctx, blockingInvoker{}, writer, WithHeartbeatInterval(time.Millisecond))
+	if err := serverRPC.HandlePacket(NewCallStartPacket("test-service", "test-method", nil, false)); err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-writer.heartbeats:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expected heartbeat %d", i+1)
+		}
+	}
+
+	// the client drops heartbeats
+	clientRPC := NewClientRPC(ctx, "test-service", "test-method")
+	if err := clientRPC.Start(discardWriter{}, false, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := clientRPC.HandlePacket(NewCallDataHeartbeatPacket()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := clientRPC.HandlePacket(NewCallDataPacket(nil, false, true, nil)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := NewMsgStream(ctx, clientRPC, nil).MsgRecv(NewRawMessage(nil, false)); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+}
+
+// packetForwarder is a Writer which passes packets to a PacketHandler.
+type packetForwarder struct {
+	mtx     sync.Mutex
+	handler PacketHandler
+}
+
+// WritePacket passes the packet to the handler.
+func (f *packetForwarder) WritePacket(p *Packet) error {
+	f.mtx.Lock()
+	err := f.handler(p)
+	f.mtx.Unlock()
+	// let other senders write between packets
+	runtime.Gosched()
+	return err
+}
+
+// Close closes the writer.
+func (f *packetForwarder) Close() error { return nil }
+
+// TestCommonRPC_SendOrdering tests messages sent concurrently are received
+// intact and in the order they were sent by each sender.
+func TestCommonRPC_SendOrdering(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	const senders, count = 4, 200
+	clientRPC := NewClientRPC(ctx, "test-service", "test-method")
+	serverRPC := NewServerRPC(
+		ctx,
+		blockingInvoker{},
+		&packetForwarder{handler: clientRPC.HandlePacket},
+		WithFragmentSize(8),
+	)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, senders)
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				// larger than the fragment size
+				msg := fmt.Sprintf("sender %d message %04d", s, i)
+				if err := serverRPC.WriteCallData([]byte(msg), false, nil); err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+	close(errCh)
+	if err := <-errCh; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	next := make([]int, senders)
+	for n := 0; n < senders*count; n++ {
+		msg, err := clientRPC.ReadOne()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		var s, i int
+		if _, err := fmt.Sscanf(string(msg), "sender %d message %d", &s, &i); err != nil {
+			t.Fatalf("corrupted message %q: %v", msg, err)
+		}
+		if i != next[s] {
+			t.Fatalf("sender %d: expected message %d but got %d", s, next[s], i)
+		}
+		next[s]++
+	}
+}
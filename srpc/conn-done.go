@@ -0,0 +1,59 @@
+package srpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// connDonePollInterval is the interval to poll IsClosed of conns which do not
+// support a done channel.
+const connDonePollInterval = time.Millisecond * 100
+
+// MuxedConnDone returns a channel which is closed when the MuxedConn is closed.
+//
+// Closing the conn ends all of its streams. Supports conns with a Done
+// function (such as WebSocketConn) or a CloseChan function (such as
+// *yamux.Session). Otherwise polls IsClosed in a goroutine until the conn is
+// closed. Returns nil if mc is nil: receiving from a nil channel blocks forever.
+func MuxedConnDone(mc network.MuxedConn) <-chan struct{} {
+	switch c := mc.(type) {
+	case nil:
+		return nil
+	case interface{ Done() <-chan struct{} }:
+		return c.Done()
+	case interface{ CloseChan() <-chan struct{} }:
+		return c.CloseChan()
+	}
+
+	done := make(chan struct{})
+	goTracked(func() {
+		defer close(done)
+		tkr := time.NewTicker(connDonePollInterval)
+		defer tkr.Stop()
+		for !mc.IsClosed() {
+			<-tkr.C
+		}
+	})
+	return done
+}
+
+// connDoneCtxKey is the context key for the done channel of the connection.
+type connDoneCtxKey struct{}
+
+// withConnDone attaches the done channel of the connection to the context.
+func withConnDone(ctx context.Context, done <-chan struct{}) context.Context {
+	return context.WithValue(ctx, connDoneCtxKey{}, done)
+}
+
+// ConnDoneFromContext returns a channel which is closed when the connection
+// carrying the call ends.
+//
+// Set for calls accepted with Server.ServeTransport or AcceptMuxedConn. Use to
+// clean up state kept per connection. Returns nil if unknown: receiving from a
+// nil channel blocks forever.
+func ConnDoneFromContext(ctx context.Context) <-chan struct{} {
+	done, _ := ctx.Value(connDoneCtxKey{}).(<-chan struct{})
+	return done
+}
@@ -0,0 +1,210 @@
+package srpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DebugLogMetadataKey is the metadata key sent by clients opting in to debug logs.
+const DebugLogMetadataKey = "srpc-debug-log"
+
+// DebugLogHandler handles a log entry forwarded by the call handler.
+//
+// Called in order with the messages of the call.
+// Must not block: the messages of the call are not received until it returns.
+type DebugLogHandler = func(entry *DebugLogEntry)
+
+// debugLogHandlerCtxKey is the context key for the debug log handler.
+type debugLogHandlerCtxKey struct{}
+
+// WithDebugLogHandler requests debug logs for calls started with ctx.
+//
+// Adds DebugLogMetadataKey to the outgoing metadata: servers with debug logs
+// enabled (see WithDebugLogs) forward the entries logged by the call handler
+// with DebugLogger. Servers without debug logs enabled ignore the request.
+func WithDebugLogHandler(ctx context.Context, handler DebugLogHandler) context.Context {
+	ctx = WithOutgoingMetadata(ctx, Metadata{DebugLogMetadataKey: "1"})
+	return context.WithValue(ctx, debugLogHandlerCtxKey{}, handler)
+}
+
+// debugLogHandlerFromContext returns the debug log handler attached to the context.
+func debugLogHandlerFromContext(ctx context.Context) DebugLogHandler {
+	handler, _ := ctx.Value(debugLogHandlerCtxKey{}).(DebugLogHandler)
+	return handler
+}
+
+// WithDebugLogs forwards the debug logs of call handlers to clients requesting them.
+//
+// If enabled, calls with DebugLogMetadataKey in the metadata have a logger
+// attached to the context which forwards entries to the client (see
+// DebugLogger). Disabled by default: the logger discards all entries and the
+// metadata key is ignored. Do not enable in production.
+func WithDebugLogs(enable bool) ServerOption {
+	return func(opts *serverOpts) {
+		opts.debugLogs = enable
+	}
+}
+
+// debugLoggerCtxKey is the context key for the debug logger of a call.
+type debugLoggerCtxKey struct{}
+
+// withDebugLogger attaches a logger forwarding entries to the rpc.
+func withDebugLogger(ctx context.Context, rpc *commonRPC) context.Context {
+	return context.WithValue(ctx, debugLoggerCtxKey{}, slog.New(&debugLogSlogHandler{rpc: rpc}))
+}
+
+// DebugLogger returns the logger forwarding debug logs to the caller.
+//
+// ctx must be the context of the call passed to the handler. If the server
+// did not enable debug logs or the caller did not request them, returns a
+// logger which discards all entries.
+func DebugLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(debugLoggerCtxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.New(discardSlogHandler{})
+}
+
+// WriteDebugLog writes a debug log entry packet.
+func (c *commonRPC) WriteDebugLog(entry *DebugLogEntry) error {
+	data, err := entry.MarshalVT()
+	if err != nil {
+		return err
+	}
+	if c.writer == nil {
+		return ErrCompleted
+	}
+	c.sendSeqMtx.Lock()
+	defer c.sendSeqMtx.Unlock()
+	c.mtx.Lock()
+	completed := c.localCompleted
+	c.mtx.Unlock()
+	if completed {
+		return ErrCompleted
+	}
+	return c.writeCallDataPacket(NewCallDataDebugLogPacket(data))
+}
+
+// handleDebugLog handles a debug log entry packet.
+func (c *commonRPC) handleDebugLog(pkt *CallData) error {
+	c.mtx.Lock()
+	if err := c.checkRecvPacketLocked(pkt); err != nil {
+		c.mtx.Unlock()
+		return err
+	}
+	handler := c.debugLogHandler
+	c.mtx.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+	entry := &DebugLogEntry{}
+	if err := entry.UnmarshalVT(pkt.GetData()); err != nil {
+		return err
+	}
+	handler(entry)
+	return nil
+}
+
+// debugLogSlogHandler is a slog.Handler which forwards entries to the remote.
+type debugLogSlogHandler struct {
+	// rpc is the rpc to write entries to
+	rpc *commonRPC
+	// attrs are the attributes added with WithAttrs, formatted as strings.
+	attrs map[string]string
+	// prefix is the group prefix of attribute keys.
+	prefix string
+}
+
+// Enabled returns true: all levels are forwarded.
+func (h *debugLogSlogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle forwards the entry to the remote.
+func (h *debugLogSlogHandler) Handle(_ context.Context, rec slog.Record) error {
+	entry := &DebugLogEntry{
+		Level:   int32(rec.Level),
+		Message: rec.Message,
+	}
+	if !rec.Time.IsZero() {
+		entry.TimeUnixMs = rec.Time.UnixMilli()
+	}
+	if len(h.attrs) != 0 || rec.NumAttrs() != 0 {
+		entry.Attrs = make(map[string]string, len(h.attrs)+rec.NumAttrs())
+		for k, v := range h.attrs {
+			entry.Attrs[k] = v
+		}
+		rec.Attrs(func(attr slog.Attr) bool {
+			addSlogAttr(entry.Attrs, h.prefix, attr)
+			return true
+		})
+	}
+	return h.rpc.WriteDebugLog(entry)
+}
+
+// WithAttrs returns a handler which includes the attributes in each entry.
+func (h *debugLogSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := &debugLogSlogHandler{rpc: h.rpc, prefix: h.prefix, attrs: make(map[string]string, len(h.attrs)+len(attrs))}
+	for k, v := range h.attrs {
+		next.attrs[k] = v
+	}
+	for _, attr := range attrs {
+		addSlogAttr(next.attrs, h.prefix, attr)
+	}
+	return next
+}
+
+// WithGroup returns a handler which prefixes attribute keys with the group name.
+func (h *debugLogSlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &debugLogSlogHandler{rpc: h.rpc, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// addSlogAttr formats the attribute into attrs.
+//
+// Group attributes are flattened with keys joined by dots.
+func addSlogAttr(attrs map[string]string, prefix string, attr slog.Attr) {
+	val := attr.Value.Resolve()
+	if val.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, sub := range val.Group() {
+			addSlogAttr(attrs, prefix, sub)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	if val.Kind() == slog.KindTime {
+		attrs[prefix+attr.Key] = val.Time().Format(time.RFC3339Nano)
+		return
+	}
+	attrs[prefix+attr.Key] = val.String()
+}
+
+// discardSlogHandler is a slog.Handler which discards all entries.
+type discardSlogHandler struct{}
+
+// Enabled returns false: all levels are discarded.
+func (discardSlogHandler) Enabled(context.Context, slog.Level) bool { return false }
+
+// Handle discards the entry.
+func (discardSlogHandler) Handle(context.Context, slog.Record) error { return nil }
+
+// WithAttrs returns the handler.
+func (h discardSlogHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+// WithGroup returns the handler.
+func (h discardSlogHandler) WithGroup(string) slog.Handler { return h }
+
+// _ is a type assertion
+var (
+	_ slog.Handler = ((*debugLogSlogHandler)(nil))
+	_ slog.Handler = discardSlogHandler{}
+)
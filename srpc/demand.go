@@ -0,0 +1,150 @@
+package srpc
+
+import (
+	"context"
+	"math"
+)
+
+// demandCtxKey is the context key for the initial demand of a call.
+type demandCtxKey struct{}
+
+// WithDemand enables demand flow control for calls started with ctx.
+//
+// The server sends at most initial messages until the client requests more
+// with RequestDemand: MsgSend on the server blocks until there is demand.
+// Use to prevent a fast server from overwhelming a slow consumer of a
+// server-streaming call. If initial is zero, demand flow control is disabled.
+func WithDemand(ctx context.Context, initial uint32) context.Context {
+	return context.WithValue(ctx, demandCtxKey{}, initial)
+}
+
+// initialDemandFromContext returns the initial demand attached to the context.
+func initialDemandFromContext(ctx context.Context) uint32 {
+	n, _ := ctx.Value(demandCtxKey{}).(uint32)
+	return n
+}
+
+// RequestDemand requests n more messages from the server of the stream.
+//
+// The call must have been started with a context from WithDemand.
+// Returns ErrUnimplemented if the stream does not support demand flow control.
+func RequestDemand(strm Stream, n uint32) error {
+	if ds, ok := strm.(interface{ RequestDemand(n uint32) error }); ok {
+		return ds.RequestDemand(n)
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.RequestDemand(n)
+	}
+	return ErrUnimplemented
+}
+
+// AwaitDemand waits until the client of the stream requested more messages.
+//
+// Returns the number of messages which can be sent without blocking. Returns
+// math.MaxInt if the client did not enable demand flow control.
+// Returns ErrUnimplemented if the stream does not support demand flow control.
+func AwaitDemand(strm Stream) (int, error) {
+	if ds, ok := strm.(interface{ AwaitDemand() (int, error) }); ok {
+		return ds.AwaitDemand()
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.AwaitDemand()
+	}
+	return 0, ErrUnimplemented
+}
+
+// RequestDemand requests n more messages from the remote.
+func (c *commonRPC) RequestDemand(n uint32) error {
+	if n == 0 {
+		return nil
+	}
+	if c.writer == nil {
+		return ErrCompleted
+	}
+	c.mtx.Lock()
+	done := c.dataClosed
+	c.mtx.Unlock()
+	if done {
+		return ErrCompleted
+	}
+	c.sendSeqMtx.Lock()
+	err := c.writer.WritePacket(NewCallDemandPacket(n))
+	c.sendSeqMtx.Unlock()
+	if err != nil {
+		return err
+	}
+	return c.Flush(c.ctx)
+}
+
+// AwaitDemand waits until the remote requested more messages.
+//
+// Returns the number of messages which can be sent without blocking.
+// Returns math.MaxInt if demand flow control is disabled.
+func (c *commonRPC) AwaitDemand() (int, error) {
+	for {
+		c.mtx.Lock()
+		if !c.demandEnabled {
+			c.mtx.Unlock()
+			return math.MaxInt, nil
+		}
+		if c.demand > 0 {
+			demand := c.demand
+			c.mtx.Unlock()
+			return int(min(demand, math.MaxInt)), nil
+		}
+		if c.ctx.Err() != nil {
+			c.mtx.Unlock()
+			return 0, ErrStreamClosed
+		}
+		waitCh := c.bcast.GetWaitCh()
+		c.mtx.Unlock()
+
+		select {
+		case <-c.ctx.Done():
+		case <-waitCh:
+		}
+	}
+}
+
+// acquireDemand waits for demand to send a message and consumes it.
+//
+// Returns immediately if demand flow control is disabled.
+func (c *commonRPC) acquireDemand() error {
+	for {
+		c.mtx.Lock()
+		if !c.demandEnabled || c.localCompleted {
+			// WriteCallData returns ErrCompleted if completed
+			c.mtx.Unlock()
+			return nil
+		}
+		if c.demand > 0 {
+			c.demand--
+			c.mtx.Unlock()
+			return nil
+		}
+		if c.ctx.Err() != nil {
+			c.mtx.Unlock()
+			return ErrStreamClosed
+		}
+		waitCh := c.bcast.GetWaitCh()
+		c.mtx.Unlock()
+
+		select {
+		case <-c.ctx.Done():
+		case <-waitCh:
+		}
+	}
+}
+
+// HandleCallDemand handles a request for n more messages.
+//
+// Ignored if demand flow control is disabled.
+func (c *commonRPC) HandleCallDemand(n uint32) error {
+	c.mtx.Lock()
+	if c.demandEnabled {
+		c.demand += uint64(n)
+		c.bcast.Broadcast()
+	}
+	c.mtx.Unlock()
+	return nil
+}
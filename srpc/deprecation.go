@@ -0,0 +1,60 @@
+package srpc
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WarningTrailerKey is the trailer key containing a warning for the caller.
+const WarningTrailerKey = "srpc-warning"
+
+// WithDeprecationWarnings sends a warning to callers of deprecated methods.
+//
+// Methods marked with the deprecated option in the proto call WarnDeprecated
+// in the generated handlers. If enabled, the warning is sent to the caller in
+// the trailer with WarningTrailerKey. The call is not failed.
+// Disabled by default.
+func WithDeprecationWarnings(enable bool) ServerOption {
+	return func(opts *serverOpts) {
+		opts.deprecationWarnings = enable
+	}
+}
+
+// deprecationWarningsCtxKey is the context key for enabling deprecation warnings.
+type deprecationWarningsCtxKey struct{}
+
+// withDeprecationWarnings enables deprecation warnings for the call.
+func withDeprecationWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deprecationWarningsCtxKey{}, true)
+}
+
+// WarnDeprecated warns the caller that the method is deprecated.
+//
+// ctx must be the context of the call passed to the handler. Called by the
+// generated handlers of deprecated methods. Does nothing unless the server
+// enabled deprecation warnings with WithDeprecationWarnings.
+func WarnDeprecated(ctx context.Context, serviceID, methodID string) {
+	if enabled, _ := ctx.Value(deprecationWarningsCtxKey{}).(bool); !enabled {
+		return
+	}
+	_ = SetTrailer(ctx, Metadata{WarningTrailerKey: "method " + serviceID + "/" + methodID + " is deprecated"})
+}
+
+// WarningHandler handles a warning sent by the server with the trailer of a call.
+type WarningHandler = func(serviceID, methodID, warning string)
+
+// WithWarningHandler sets the handler for warnings sent by the server.
+//
+// The handler is called before a call completes if the trailer contains
+// WarningTrailerKey, for example when calling a deprecated method. If not
+// set, warnings are logged with the default slog logger.
+func WithWarningHandler(handler WarningHandler) ClientOption {
+	return func(opts *clientOpts) {
+		opts.warningHandler = handler
+	}
+}
+
+// logWarning is the default WarningHandler: logs the warning with slog.
+func logWarning(serviceID, methodID, warning string) {
+	slog.Warn("srpc: warning from server", "service", serviceID, "method", methodID, "warning", warning)
+}
@@ -0,0 +1,46 @@
+package srpc
+
+import (
+	"context"
+	"net"
+)
+
+// Dialer dials connections to a server.
+//
+// Each connection carries a single RPC stream. Implementations can wrap the
+// connections to inject faults (latency, packet loss) for testing.
+type Dialer interface {
+	// Dial dials a new connection to the server.
+	Dial(ctx context.Context) (net.Conn, error)
+}
+
+// DialerFunc implements Dialer with a function.
+type DialerFunc func(ctx context.Context) (net.Conn, error)
+
+// Dial dials a new connection to the server.
+func (f DialerFunc) Dial(ctx context.Context) (net.Conn, error) {
+	return f(ctx)
+}
+
+// NewDialerOpenStream constructs a OpenStreamFunc which dials a connection per stream.
+func NewDialerOpenStream(dialer Dialer) OpenStreamFunc {
+	return func(ctx context.Context, msgHandler PacketHandler, closeHandler CloseHandler) (Writer, error) {
+		conn, err := dialer.Dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		prw := NewPacketReadWriter(conn)
+		if err := startReadPump(ctx, prw, msgHandler, closeHandler); err != nil {
+			return nil, err
+		}
+		return prw, nil
+	}
+}
+
+// NewDialerClient constructs a Client which dials a connection per stream.
+func NewDialerClient(dialer Dialer, opts ...ClientOption) Client {
+	return NewClient(NewDialerOpenStream(dialer), opts...)
+}
+
+// _ is a type assertion
+var _ Dialer = (DialerFunc)(nil)
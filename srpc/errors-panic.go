@@ -0,0 +1,73 @@
+package srpc
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Status detail keys of a handler panic.
+//
+// Only sent if the server was constructed with WithDebugStacks.
+const (
+	// PanicValueDetail is the status detail containing the value passed to panic.
+	PanicValueDetail = "panic-value"
+	// PanicStackDetail is the status detail containing the stack trace of the panic.
+	PanicStackDetail = "panic-stack"
+)
+
+// PanicError is a recovered panic of a call handler.
+//
+// The caller receives the status returned by toStatus instead of the panic.
+// errors.Is(err, ErrHandlerPanic) returns true for a PanicError.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panic.
+	// Only captured if the server was constructed with WithDebugStacks.
+	Stack []byte
+}
+
+// newPanicError constructs a new PanicError for a recovered panic.
+//
+// Captures the stack trace if withStack is set.
+func newPanicError(value any, withStack bool) *PanicError {
+	perr := &PanicError{Value: value}
+	if withStack {
+		perr.Stack = debug.Stack()
+	}
+	return perr
+}
+
+// Error returns the error string.
+//
+// Includes the stack trace if it was captured.
+func (e *PanicError) Error() string {
+	msg := fmt.Sprintf("%s: %v", ErrHandlerPanic.Error(), e.Value)
+	if len(e.Stack) != 0 {
+		msg += "\n\n" + string(e.Stack)
+	}
+	return msg
+}
+
+// toStatus converts the panic to the status sent to the caller.
+//
+// The panic value and stack trace are included in the details only if
+// withDetails is set: otherwise the status contains a generic message.
+func (e *PanicError) toStatus(withDetails bool) *StatusError {
+	st := NewStatusError(StatusInternal, ErrHandlerPanic.Error())
+	if withDetails {
+		st.WithDetail(PanicValueDetail, fmt.Sprint(e.Value))
+		if len(e.Stack) != 0 {
+			st.WithDetail(PanicStackDetail, string(e.Stack))
+		}
+	}
+	return st
+}
+
+// Is returns true if target is ErrHandlerPanic.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrHandlerPanic
+}
+
+// _ is a type assertion
+var _ error = ((*PanicError)(nil))
@@ -0,0 +1,95 @@
+package srpc
+
+import "github.com/pkg/errors"
+
+// RemoteError is an error returned by the remote call handler.
+//
+// Distinguishes errors sent by the remote from local transport errors.
+type RemoteError struct {
+	// Message is the error string sent by the remote.
+	Message string
+	// Status is the structured status sent by the remote, if any.
+	Status *StatusError
+}
+
+// NewRemoteError constructs a new RemoteError.
+func NewRemoteError(msg string) *RemoteError {
+	return &RemoteError{Message: msg}
+}
+
+// Error returns the error string.
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the structured status sent by the remote, if any.
+func (e *RemoteError) Unwrap() error {
+	if e.Status == nil {
+		return nil
+	}
+	return e.Status
+}
+
+// parseRemoteErrorWithStatus converts an error and status from the remote into an error.
+//
+// If st is set, the structured status is available with StatusFromError.
+func parseRemoteErrorWithStatus(errStr string, st *Status) error {
+	err := parseRemoteError(errStr)
+	if st == nil {
+		return err
+	}
+	status := newStatusErrorFromProto(st)
+	if remoteErr, ok := err.(*RemoteError); ok {
+		remoteErr.Status = status
+		return remoteErr
+	}
+	return &remoteStatusError{err: err, status: status}
+}
+
+// remoteStatusError is a known error sent by the remote with a status.
+//
+// Matches both the known error (for example ErrServerBusy) and the status.
+type remoteStatusError struct {
+	err    error
+	status *StatusError
+}
+
+// Error returns the error string.
+func (e *remoteStatusError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the known error and the status.
+func (e *remoteStatusError) Unwrap() []error {
+	return []error{e.err, e.status}
+}
+
+// isRemoteError checks if the error was returned by the remote.
+func isRemoteError(err error) bool {
+	var remoteErr *RemoteError
+	var unimplementedErr *UnimplementedError
+	var statusErr *remoteStatusError
+	return errors.As(err, &remoteErr) || errors.As(err, &unimplementedErr) || errors.As(err, &statusErr)
+}
+
+// CloseSendError is returned by CloseAndMsgRecv if CloseSend failed locally.
+type CloseSendError struct {
+	// Err is the error returned by CloseSend.
+	Err error
+}
+
+// Error returns the error string.
+func (e *CloseSendError) Error() string {
+	return "close send: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by CloseSend.
+func (e *CloseSendError) Unwrap() error {
+	return e.Err
+}
+
+// _ is a type assertion
+var (
+	_ error = ((*RemoteError)(nil))
+	_ error = ((*CloseSendError)(nil))
+)
@@ -0,0 +1,58 @@
+package srpc
+
+import (
+	"strings"
+)
+
+// UnimplementedError is returned if the RPC method was not implemented.
+//
+// errors.Is(err, ErrUnimplemented) returns true for an UnimplementedError.
+type UnimplementedError struct {
+	// Service is the service ID, if known.
+	Service string
+	// Method is the method ID, if known.
+	Method string
+}
+
+// NewUnimplementedError constructs a new UnimplementedError.
+func NewUnimplementedError(service, method string) *UnimplementedError {
+	return &UnimplementedError{Service: service, Method: method}
+}
+
+// Error returns the error string.
+func (e *UnimplementedError) Error() string {
+	if e.Service == "" && e.Method == "" {
+		return ErrUnimplemented.Error()
+	}
+	return ErrUnimplemented.Error() + ": " + e.Service + "/" + e.Method
+}
+
+// Is returns true if target is ErrUnimplemented.
+func (e *UnimplementedError) Is(target error) bool {
+	return target == ErrUnimplemented
+}
+
+// parseRemoteError converts an error string from the remote into an error.
+//
+// Reconstructs an UnimplementedError or ErrServerBusy if the string matches.
+// Otherwise returns a RemoteError.
+func parseRemoteError(errStr string) error {
+	if errStr == ErrServerBusy.Error() {
+		return ErrServerBusy
+	}
+	if errStr == ErrHandlerPanic.Error() {
+		return ErrHandlerPanic
+	}
+	if errStr == ErrUnimplemented.Error() {
+		return &UnimplementedError{}
+	}
+	if rest, ok := strings.CutPrefix(errStr, ErrUnimplemented.Error()+": "); ok {
+		if service, method, ok := strings.Cut(rest, "/"); ok {
+			return NewUnimplementedError(service, method)
+		}
+	}
+	return NewRemoteError(errStr)
+}
+
+// _ is a type assertion
+var _ error = ((*UnimplementedError)(nil))
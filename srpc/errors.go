@@ -11,10 +11,65 @@ var (
 	ErrUnrecognizedPacket = errors.New("unrecognized packet type")
 	// ErrEmptyPacket is returned if nothing is specified in a packet.
 	ErrEmptyPacket = errors.New("invalid empty packet")
+	// ErrInvalidPacket is returned if the packet fields are inconsistent.
+	ErrInvalidPacket = errors.New("invalid packet")
 	// ErrInvalidMessage indicates the message failed to parse.
 	ErrInvalidMessage = errors.New("invalid message")
 	// ErrEmptyMethodID is returned if the method id was empty.
 	ErrEmptyMethodID = errors.New("method id empty")
 	// ErrEmptyServiceID is returned if the service id was empty.
 	ErrEmptyServiceID = errors.New("service id empty")
+	// ErrServiceAlreadyRegistered is returned if the service id was already registered.
+	ErrServiceAlreadyRegistered = errors.New("service already registered")
+	// ErrStreamClosed is returned when writing to a stream which was closed.
+	// It is also the cancel cause when the stream was closed locally.
+	ErrStreamClosed = errors.New("stream closed")
+	// ErrRemoteCanceled is the cancel cause when the remote canceled the call.
+	ErrRemoteCanceled = errors.New("call canceled by remote")
+	// ErrTransportClosed is the cancel cause when the underlying transport closed.
+	// It is also returned by MsgRecv if the transport closed before the remote
+	// completed the call, distinct from io.EOF when the remote closed cleanly.
+	ErrTransportClosed = errors.New("transport closed")
+	// ErrCallCompleted is the cancel cause when the call handler returned.
+	ErrCallCompleted = errors.New("call completed")
+	// ErrCallCanceled is the default cause when a call was canceled with Server.CancelCall.
+	ErrCallCanceled = errors.New("call canceled by server")
+	// ErrClientClosed is returned if the Client was closed.
+	ErrClientClosed = errors.New("client closed")
+	// ErrRecvQueueFull is returned if the remote sent more messages than can be queued.
+	ErrRecvQueueFull = errors.New("flow control: receive queue full")
+	// ErrSequenceMismatch is returned if a packet arrived out of order or was lost.
+	ErrSequenceMismatch = errors.New("call data sequence mismatch")
+	// ErrChecksumMismatch is returned if the checksum of a packet does not match its data.
+	ErrChecksumMismatch = errors.New("packet checksum mismatch")
+	// ErrChecksumRequired is returned if the server requires checksums but the call did not enable them.
+	ErrChecksumRequired = errors.New("packet checksums required")
+	// ErrFrameTooLarge is returned if an incoming frame exceeds the maximum size.
+	ErrFrameTooLarge = errors.New("frame too large")
+	// ErrUnauthenticated is returned if the request is missing valid credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrServerBusy is returned if the server rejected the stream due to load.
+	ErrServerBusy = errors.New("server busy")
+	// ErrCircuitOpen is returned if a call was rejected by an open circuit breaker.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+	// ErrGoroutineLimit is returned if starting a call would exceed the goroutine limit.
+	ErrGoroutineLimit = errors.New("goroutine limit reached")
+	// ErrSlowConsumer is returned if a stream was evicted for not keeping up with the published messages.
+	ErrSlowConsumer = errors.New("slow consumer evicted")
+	// ErrUnsupportedCompression is returned if the stream compressor is not registered.
+	ErrUnsupportedCompression = errors.New("unsupported stream compression")
+	// ErrUnsupportedCodec is returned if none of the offered codecs are registered.
+	ErrUnsupportedCodec = errors.New("no supported codec")
+	// ErrResumeExpired is returned if a call cannot be resumed from the offset.
+	ErrResumeExpired = errors.New("call cannot be resumed")
+	// ErrHandlerPanic is returned if the call handler panicked.
+	ErrHandlerPanic = errors.New("handler panicked")
+	// ErrUnknownAttachment is returned if the attachment ID is invalid.
+	ErrUnknownAttachment = errors.New("unknown attachment")
+	// ErrAttachmentOpened is returned if the attachment was already opened.
+	ErrAttachmentOpened = errors.New("attachment already opened")
+	// ErrAttachmentLimit is returned if a call has too many open attachments.
+	ErrAttachmentLimit = errors.New("too many open attachments")
+	// ErrCallNotStarted is returned if call data is received before the call start.
+	ErrCallNotStarted = errors.New("call data received before call start")
 )
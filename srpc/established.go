@@ -0,0 +1,86 @@
+package srpc
+
+import (
+	"context"
+	"io"
+)
+
+// callAckCtxKey is the context key for requesting call acknowledgments.
+type callAckCtxKey struct{}
+
+// WithCallAck requests the server to acknowledge calls started with ctx.
+//
+// The server answers the call start with a CallStartResp after accepting the
+// call and before invoking the handler. Use with WaitEstablished to measure
+// the call setup latency or to fail fast if the server rejects the call.
+//
+// If the server invoker is a Mux, only calls to methods registered with the
+// Mux are acknowledged: calls to unknown methods fail with the unimplemented
+// error, calls handled by the fallback invokers are not acknowledged.
+func WithCallAck(ctx context.Context) context.Context {
+	return context.WithValue(ctx, callAckCtxKey{}, true)
+}
+
+// callAckFromContext checks if the context requests call acknowledgments.
+func callAckFromContext(ctx context.Context) bool {
+	ack, _ := ctx.Value(callAckCtxKey{}).(bool)
+	return ack
+}
+
+// WaitEstablished waits for the server to accept the call of the stream.
+//
+// The call must have been started with a context from WithCallAck or
+// negotiate a codec (see WithPreferredCodecs). Otherwise waits for the first
+// message from the server. Returns the error of the call if the server
+// rejected it. Returns nil if the call completed successfully.
+// Returns ErrUnimplemented if the stream does not support waiting.
+//
+// Servers which do not support acknowledgments ignore WithCallAck: against
+// these WaitEstablished blocks until the first message from the server or the
+// end of the call. Use a ctx with a timeout to bound the wait.
+func WaitEstablished(ctx context.Context, strm Stream) error {
+	if es, ok := strm.(interface {
+		WaitEstablished(ctx context.Context) error
+	}); ok {
+		return es.WaitEstablished(ctx)
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.WaitEstablished(ctx)
+	}
+	return ErrUnimplemented
+}
+
+// WaitEstablished waits for the remote to accept the call.
+//
+// Returns the error of the call if it ended before it was accepted.
+func (c *commonRPC) WaitEstablished(ctx context.Context) error {
+	var ctxDone bool
+	for {
+		c.mtx.Lock()
+		if c.established || len(c.dataQueue) != 0 {
+			c.mtx.Unlock()
+			return nil
+		}
+		if c.dataClosed {
+			err := c.remoteErr
+			c.mtx.Unlock()
+			if err == nil && !c.remoteCompleted {
+				err = io.EOF
+			}
+			return err
+		}
+		waiter := c.bcast.GetWaitCh()
+		c.mtx.Unlock()
+		if ctxDone {
+			return context.Canceled
+		}
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case <-c.ctx.Done():
+			// check if the call ended with an error
+			ctxDone = true
+		case <-waiter:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package srpc
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// activeGoroutines is the number of running goroutines started by the library.
+var activeGoroutines atomic.Int64
+
+// ActiveGoroutines returns the number of running goroutines started by the library.
+//
+// Includes the goroutines handling streams and calls, read pumps, and
+// background writers of all clients and servers in the process.
+func ActiveGoroutines() int64 {
+	return activeGoroutines.Load()
+}
+
+// goTracked runs fn in a new goroutine counted by ActiveGoroutines.
+func goTracked(fn func()) {
+	activeGoroutines.Add(1)
+	go func() {
+		defer activeGoroutines.Add(-1)
+		fn()
+	}()
+}
+
+// GoroutineLimiter caps the number of goroutines started for streams and calls.
+//
+// Use with WithGoroutineLimiter and WithClientGoroutineLimiter to bound the
+// number of goroutines on constrained devices. The same limiter can be shared
+// by multiple servers and clients. Each incoming call uses two goroutines: one
+// reading the stream and one running the handler. Each outgoing call uses one
+// goroutine reading the stream. If the limit is reached, incoming streams are
+// rejected with ErrServerBusy and outgoing calls fail with ErrGoroutineLimit.
+//
+// A nil *GoroutineLimiter does not limit the number of goroutines.
+type GoroutineLimiter struct {
+	// max is the max number of goroutines
+	max int64
+	// active is the number of running goroutines started by the limiter
+	active atomic.Int64
+}
+
+// NewGoroutineLimiter constructs a new GoroutineLimiter.
+//
+// If max is zero or negative, the number of goroutines is unlimited but still
+// counted by Active.
+func NewGoroutineLimiter(max int) *GoroutineLimiter {
+	return &GoroutineLimiter{max: int64(max)}
+}
+
+// Active returns the number of running goroutines started with the limiter.
+func (l *GoroutineLimiter) Active() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.active.Load())
+}
+
+// TryGo runs fn in a new goroutine if the limit was not reached.
+//
+// Returns false without running fn if the limit was reached.
+func (l *GoroutineLimiter) TryGo(fn func()) bool {
+	if l == nil {
+		goTracked(fn)
+		return true
+	}
+	for {
+		active := l.active.Load()
+		if l.max > 0 && active >= l.max {
+			return false
+		}
+		if l.active.CompareAndSwap(active, active+1) {
+			break
+		}
+	}
+	goTracked(func() {
+		defer l.active.Add(-1)
+		fn()
+	})
+	return true
+}
+
+// goroutineLimiterCtxKey is the context key for the goroutine limiter of a client.
+type goroutineLimiterCtxKey struct{}
+
+// withGoroutineLimiter attaches the goroutine limiter to the context.
+func withGoroutineLimiter(ctx context.Context, l *GoroutineLimiter) context.Context {
+	return context.WithValue(ctx, goroutineLimiterCtxKey{}, l)
+}
+
+// goroutineLimiterFromContext returns the goroutine limiter attached to the context.
+//
+// Returns nil if none was attached: nil does not limit goroutines.
+func goroutineLimiterFromContext(ctx context.Context) *GoroutineLimiter {
+	l, _ := ctx.Value(goroutineLimiterCtxKey{}).(*GoroutineLimiter)
+	return l
+}
+
+// startReadPump starts the read pump of the stream limited by the goroutine limiter of ctx.
+//
+// Closes the stream and returns ErrGoroutineLimit if the limit was reached.
+func startReadPump(ctx context.Context, prw *PacketReaderWriter, msgHandler PacketHandler, closeHandler CloseHandler) error {
+	if !goroutineLimiterFromContext(ctx).TryGo(func() { prw.ReadPump(msgHandler, closeHandler) }) {
+		_ = prw.Close()
+		return ErrGoroutineLimit
+	}
+	return nil
+}
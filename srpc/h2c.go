@@ -0,0 +1,193 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// H2CHandler serves RPC streams over HTTP/2 streams without WebSocket.
+//
+// Each HTTP/2 request is a single RPC stream: the request body carries the
+// packets from the client and the response body the packets from the server.
+//
+// Requires HTTP/2 as HTTP/1.x does not support full-duplex bodies. Over TLS the
+// net/http server negotiates HTTP/2 by default. For cleartext HTTP/2 (h2c) wrap
+// the handler with golang.org/x/net/http2/h2c.NewHandler or enable unencrypted
+// HTTP/2 in http.Server.Protocols (Go 1.24+).
+type H2CHandler struct {
+	srpc *Server
+}
+
+// NewH2CHandler constructs a new HTTP/2 handler with a mux.
+func NewH2CHandler(mux Mux, opts ...ServerOption) *H2CHandler {
+	return &H2CHandler{srpc: NewServer(mux, opts...)}
+}
+
+// ServeHTTP handles an incoming HTTP/2 request as a RPC stream.
+func (h *H2CHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor < 2 {
+		http.Error(w, "http/2 required", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	conn := &h2cServerConn{body: r.Body, w: w, rc: rc}
+	defer conn.Close()
+	ctx := withPeerAddr(r.Context(), r.RemoteAddr)
+	ctx = withPeerCerts(ctx, r.TLS)
+	h.srpc.HandleStream(ctx, conn)
+}
+
+// h2cServerConn is the server side of a RPC stream over a HTTP/2 request.
+type h2cServerConn struct {
+	body io.ReadCloser
+	w    io.Writer
+	rc   *http.ResponseController
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// closed is set when the stream is closed.
+	// the ResponseWriter must not be used after the handler returns.
+	closed bool
+}
+
+// Read reads data from the request body.
+func (c *h2cServerConn) Read(p []byte) (int, error) {
+	return c.body.Read(p)
+}
+
+// Write writes data to the response body and flushes it.
+func (c *h2cServerConn) Write(p []byte) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := c.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, c.rc.Flush()
+}
+
+// Close closes the request body and prevents further writes.
+func (c *h2cServerConn) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.body.Close()
+}
+
+// NewH2COpenStream constructs a OpenStream func which opens HTTP/2 requests.
+//
+// The http client must use HTTP/2: HTTP/1.x does not support full-duplex bodies.
+// url is the address of the H2CHandler.
+func NewH2COpenStream(client *http.Client, url string) OpenStreamFunc {
+	return func(ctx context.Context, msgHandler PacketHandler, closeHandler CloseHandler) (Writer, error) {
+		reqCtx, reqCtxCancel := context.WithCancel(ctx)
+		pr, pw := io.Pipe()
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, pr)
+		if err != nil {
+			reqCtxCancel()
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		conn := &h2cClientConn{
+			pw:        pw,
+			ctxCancel: reqCtxCancel,
+			ready:     make(chan struct{}),
+		}
+		goTracked(func() { conn.doRequest(client, req) })
+
+		prw := NewPacketReadWriter(conn)
+		if err := startReadPump(ctx, prw, msgHandler, closeHandler); err != nil {
+			return nil, err
+		}
+		return prw, nil
+	}
+}
+
+// NewH2CClient constructs a Client which opens streams with HTTP/2 requests.
+//
+// The http client must use HTTP/2: HTTP/1.x does not support full-duplex bodies.
+func NewH2CClient(client *http.Client, url string) Client {
+	return NewClient(NewH2COpenStream(client, url))
+}
+
+// h2cClientConn is the client side of a RPC stream over a HTTP/2 request.
+type h2cClientConn struct {
+	// pw is the writer for the request body
+	pw *io.PipeWriter
+	// ctxCancel cancels the request
+	ctxCancel context.CancelFunc
+	// ready is closed when body or err is set
+	ready chan struct{}
+	// body is the response body
+	body io.ReadCloser
+	// err is the error performing the request
+	err error
+}
+
+// doRequest performs the request and sets the response body.
+func (c *h2cClientConn) doRequest(client *http.Client, req *http.Request) {
+	defer close(c.ready)
+	resp, err := client.Do(req)
+	if err != nil {
+		_ = c.pw.CloseWithError(err)
+		c.err = err
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		c.err = errors.Errorf("unexpected http status: %s", resp.Status)
+		_ = c.pw.CloseWithError(c.err)
+		return
+	}
+	c.body = resp.Body
+}
+
+// Read reads data from the response body.
+func (c *h2cClientConn) Read(p []byte) (int, error) {
+	<-c.ready
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.body.Read(p)
+}
+
+// Write writes data to the request body.
+func (c *h2cClientConn) Write(p []byte) (int, error) {
+	return c.pw.Write(p)
+}
+
+// Close closes the request body and cancels the request.
+func (c *h2cClientConn) Close() error {
+	err := c.pw.Close()
+	c.ctxCancel()
+	return err
+}
+
+// _ is a type assertion
+var (
+	_ http.Handler       = ((*H2CHandler)(nil))
+	_ io.ReadWriteCloser = ((*h2cServerConn)(nil))
+	_ io.ReadWriteCloser = ((*h2cClientConn)(nil))
+)
@@ -0,0 +1,50 @@
+package srpc
+
+import "context"
+
+// Identity is the authenticated identity of the remote peer.
+//
+// Authentication middleware attaches the Identity to the context with
+// WithIdentity so that handlers, rate limiters, and audit logging can use it
+// without agreeing on their own context key.
+type Identity struct {
+	// Subject identifies the authenticated principal.
+	Subject string
+	// Scopes are the permissions granted to the principal.
+	Scopes []string
+	// Claims contains additional attributes of the principal.
+	Claims map[string]any
+}
+
+// HasScope checks if the identity was granted the scope.
+func (i *Identity) HasScope(scope string) bool {
+	if i == nil {
+		return false
+	}
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// identityCtxKey is the context key for the authenticated identity.
+type identityCtxKey struct{}
+
+// WithIdentity attaches the authenticated identity of the remote to the context.
+//
+// For example, set the identity in a PreUpgradeFunc to make it available to
+// all calls on the connection.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, id)
+}
+
+// IdentityFromContext returns the authenticated identity of the remote.
+//
+// Returns nil, false if no identity is attached to the context.
+// The returned value must not be modified.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityCtxKey{}).(*Identity)
+	return id, ok && id != nil
+}
@@ -0,0 +1,38 @@
+package srpc
+
+// MuxInterceptor wraps an Invoker to intercept the calls to it.
+//
+// The interceptor can inspect or wrap the stream before calling next, or
+// short-circuit the call by returning without calling next. Use the
+// constructors of the Invoker wrappers in this package as interceptors, for
+// example:
+//
+//	func(next Invoker) Invoker { return NewRequireMetadataInvoker(next, "authorization") }
+type MuxInterceptor func(next Invoker) Invoker
+
+// Chain composes the interceptors into a single interceptor.
+//
+// The interceptors are applied left-to-right: the first interceptor is the
+// outermost and sees each call first. Nil interceptors are skipped.
+func Chain(interceptors ...MuxInterceptor) MuxInterceptor {
+	return func(next Invoker) Invoker {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			if interceptors[i] != nil {
+				next = interceptors[i](next)
+			}
+		}
+		return next
+	}
+}
+
+// InvokerFunc implements Invoker with a function.
+type InvokerFunc func(serviceID, methodID string, strm Stream) (bool, error)
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+func (f InvokerFunc) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	return f(serviceID, methodID, strm)
+}
+
+// _ is a type assertion
+var _ Invoker = (InvokerFunc)(nil)
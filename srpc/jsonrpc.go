@@ -0,0 +1,303 @@
+package srpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	// JSONRPCParseError indicates invalid JSON was received.
+	JSONRPCParseError = -32700
+	// JSONRPCInvalidRequest indicates the JSON is not a valid request object.
+	JSONRPCInvalidRequest = -32600
+	// JSONRPCMethodNotFound indicates the method does not exist.
+	JSONRPCMethodNotFound = -32601
+	// JSONRPCInvalidParams indicates the method parameters are invalid.
+	JSONRPCInvalidParams = -32602
+	// JSONRPCInternalError indicates an internal error.
+	JSONRPCInternalError = -32603
+	// JSONRPCServerError indicates the call returned an error.
+	JSONRPCServerError = -32000
+)
+
+// jsonrpcVersion is the JSON-RPC protocol version.
+const jsonrpcVersion = "2.0"
+
+// jsonrpcMaxInFlight is the max number of messages handled concurrently per conn.
+const jsonrpcMaxInFlight = 32
+
+// JSONRPCMethod maps a JSON-RPC method to a unary RPC method.
+type JSONRPCMethod struct {
+	// ServiceID is the RPC service ID.
+	ServiceID string
+	// MethodID is the RPC method ID.
+	MethodID string
+	// NewRequest constructs a new request message.
+	NewRequest func() proto.Message
+	// NewResponse constructs a new response message.
+	NewResponse func() proto.Message
+}
+
+// JSONRPCError is a JSON-RPC 2.0 error object.
+type JSONRPCError struct {
+	// Code is the error code.
+	Code int `json:"code"`
+	// Message is the error message.
+	Message string `json:"message"`
+}
+
+// Error returns the error string.
+func (e *JSONRPCError) Error() string {
+	return e.Message
+}
+
+// jsonrpcRequest is a JSON-RPC 2.0 request object.
+type jsonrpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// jsonrpcResponse is a JSON-RPC 2.0 response object.
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// JSONRPCHandler serves JSON-RPC 2.0 requests over WebSocket with a Mux.
+//
+// Each JSON-RPC method is mapped to a unary RPC method. The params are decoded
+// into the request message with protojson and the response message is encoded
+// as the result. Each WebSocket message contains a request or a batch.
+type JSONRPCHandler struct {
+	client  Client
+	methods map[string]*JSONRPCMethod
+	// goroutineLimiter limits the goroutines handling messages, if set
+	goroutineLimiter *GoroutineLimiter
+}
+
+// NewJSONRPCHandler constructs a new JSON-RPC handler with a mux.
+//
+// methodMap maps JSON-RPC method names to RPC methods.
+// If WithGoroutineLimiter is set, messages exceeding the goroutine limit are
+// rejected with ErrServerBusy.
+func NewJSONRPCHandler(mux Mux, methodMap map[string]*JSONRPCMethod, opts ...ServerOption) *JSONRPCHandler {
+	return &JSONRPCHandler{
+		client:           NewClient(NewServerPipe(NewServer(mux, opts...))),
+		methods:          methodMap,
+		goroutineLimiter: newServerOpts(opts).goroutineLimiter,
+	}
+}
+
+// ServeHTTP accepts a WebSocket and handles JSON-RPC messages until it closes.
+//
+// Handles up to jsonrpcMaxInFlight messages concurrently: further messages are
+// not read until a message completes. If the goroutine limit was reached, the
+// requests of the message are rejected with ErrServerBusy. When the WebSocket
+// closes the in-flight calls are canceled and ServeHTTP waits for them to return.
+func (h *JSONRPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{})
+	if err != nil {
+		return
+	}
+	defer c.Close(websocket.StatusInternalError, "closed")
+
+	ctx, ctxCancel := context.WithCancel(r.Context())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer ctxCancel()
+
+	ctx = withPeerAddr(ctx, r.RemoteAddr)
+	ctx = withPeerCerts(ctx, r.TLS)
+	inFlight := make(chan struct{}, jsonrpcMaxInFlight)
+	for {
+		_, data, err := c.Read(ctx)
+		if err != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case inFlight <- struct{}{}:
+		}
+		wg.Add(1)
+		handled := h.goroutineLimiter.TryGo(func() {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+			if resp := h.HandleMessage(ctx, data); resp != nil {
+				_ = c.Write(ctx, websocket.MessageText, resp)
+			}
+		})
+		if !handled {
+			<-inFlight
+			wg.Done()
+			if resp := rejectJSONRPCMessage(data, ErrServerBusy.Error()); resp != nil {
+				writeCtx, writeCtxCancel := context.WithTimeout(ctx, rejectWriteTimeout)
+				err := c.Write(writeCtx, websocket.MessageText, resp)
+				writeCtxCancel()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// HandleMessage handles a JSON-RPC request or batch and returns the response.
+//
+// Returns nil if there is no response (the message contained notifications).
+func (h *JSONRPCHandler) HandleMessage(ctx context.Context, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return marshalJSONRPCResponse(newJSONRPCErrorResponse(nil, JSONRPCParseError, err.Error()))
+		}
+		if len(batch) == 0 {
+			return marshalJSONRPCResponse(newJSONRPCErrorResponse(nil, JSONRPCInvalidRequest, "empty batch"))
+		}
+		var resps []*jsonrpcResponse
+		for _, reqData := range batch {
+			if resp := h.handleRequest(ctx, reqData); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			return nil
+		}
+		out, _ := json.Marshal(resps)
+		return out
+	}
+
+	resp := h.handleRequest(ctx, data)
+	if resp == nil {
+		return nil
+	}
+	return marshalJSONRPCResponse(resp)
+}
+
+// rejectJSONRPCMessage returns the error response rejecting the requests of the message.
+//
+// Returns nil if there is no response (the message contained notifications).
+func rejectJSONRPCMessage(data []byte, msg string) []byte {
+	data = bytes.TrimSpace(data)
+	batch := len(data) != 0 && data[0] == '['
+	var reqs []jsonrpcRequest
+	if batch {
+		_ = json.Unmarshal(data, &reqs)
+	} else {
+		var req jsonrpcRequest
+		if err := json.Unmarshal(data, &req); err == nil {
+			reqs = append(reqs, req)
+		}
+	}
+	var resps []*jsonrpcResponse
+	for _, req := range reqs {
+		if len(req.ID) != 0 {
+			resps = append(resps, newJSONRPCErrorResponse(req.ID, JSONRPCServerError, msg))
+		}
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+	if !batch {
+		return marshalJSONRPCResponse(resps[0])
+	}
+	out, _ := json.Marshal(resps)
+	return out
+}
+
+// handleRequest handles a single JSON-RPC request.
+//
+// Returns nil if the request was a notification.
+func (h *JSONRPCHandler) handleRequest(ctx context.Context, data []byte) *jsonrpcResponse {
+	req := &jsonrpcRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return newJSONRPCErrorResponse(nil, JSONRPCParseError, err.Error())
+		}
+		return newJSONRPCErrorResponse(nil, JSONRPCInvalidRequest, err.Error())
+	}
+	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		return newJSONRPCErrorResponse(req.ID, JSONRPCInvalidRequest, "invalid request")
+	}
+
+	result, rerr := h.call(ctx, req)
+	if len(req.ID) == 0 {
+		// notification: no response
+		return nil
+	}
+	if rerr != nil {
+		return &jsonrpcResponse{JSONRPC: jsonrpcVersion, Error: rerr, ID: req.ID}
+	}
+	return &jsonrpcResponse{JSONRPC: jsonrpcVersion, Result: result, ID: req.ID}
+}
+
+// call calls the RPC method for the request.
+func (h *JSONRPCHandler) call(ctx context.Context, req *jsonrpcRequest) (json.RawMessage, *JSONRPCError) {
+	method := h.methods[req.Method]
+	if method == nil {
+		return nil, &JSONRPCError{Code: JSONRPCMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	in := method.NewRequest()
+	if params := bytes.TrimSpace(req.Params); len(params) != 0 && !bytes.Equal(params, []byte("null")) {
+		if params[0] != '{' {
+			return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "params must be an object"}
+		}
+		if err := protojson.Unmarshal(params, in); err != nil {
+			return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+		}
+	}
+
+	out := method.NewResponse()
+	if err := h.client.ExecCall(ctx, method.ServiceID, method.MethodID, AsMessage(in), AsMessage(out)); err != nil {
+		code := JSONRPCServerError
+		if errors.Is(err, ErrUnimplemented) {
+			code = JSONRPCMethodNotFound
+		}
+		return nil, &JSONRPCError{Code: code, Message: err.Error()}
+	}
+
+	result, err := protojson.Marshal(out)
+	if err != nil {
+		return nil, &JSONRPCError{Code: JSONRPCInternalError, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// newJSONRPCErrorResponse constructs a new error response.
+func newJSONRPCErrorResponse(id json.RawMessage, code int, msg string) *jsonrpcResponse {
+	return &jsonrpcResponse{
+		JSONRPC: jsonrpcVersion,
+		Error:   &JSONRPCError{Code: code, Message: msg},
+		ID:      id,
+	}
+}
+
+// marshalJSONRPCResponse marshals the response to JSON.
+func marshalJSONRPCResponse(resp *jsonrpcResponse) []byte {
+	out, _ := json.Marshal(resp)
+	return out
+}
+
+// _ is a type assertion
+var (
+	_ http.Handler = ((*JSONRPCHandler)(nil))
+	_ error        = ((*JSONRPCError)(nil))
+)
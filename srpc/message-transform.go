@@ -0,0 +1,102 @@
+package srpc
+
+import "context"
+
+// MessageTransformFunc transforms a decoded message in place.
+//
+// serviceID and methodID identify the call. Returning an error fails the
+// MsgRecv or MsgSend call with the error.
+type MessageTransformFunc func(ctx context.Context, serviceID, methodID string, msg Message) error
+
+// MessageTransformInvoker transforms the messages of calls around the handler.
+//
+// Operates on decoded messages: the recv transform is applied to each message
+// after it is received and decoded, before the handler sees it, and the send
+// transform is applied to each message sent by the handler before it is
+// encoded. Streaming calls transform each message individually. The send
+// transform modifies the message of the handler in place and should be
+// idempotent: handlers may send the same message more than once. Use for
+// schema migration shims such as defaulting fields or upgrading old message
+// versions without changing the handlers.
+//
+// The raw first message returned by PeekFirstMessage is not transformed:
+// streams passed to the handler do not implement FirstMessagePeeker.
+type MessageTransformInvoker struct {
+	// inv is the underlying invoker
+	inv Invoker
+	// recv transforms received messages, if set
+	recv MessageTransformFunc
+	// send transforms sent messages, if set
+	send MessageTransformFunc
+}
+
+// NewMessageTransformInvoker constructs a new MessageTransformInvoker.
+//
+// recv transforms received messages and send transforms sent messages.
+// Either can be nil to skip the transform.
+func NewMessageTransformInvoker(inv Invoker, recv, send MessageTransformFunc) *MessageTransformInvoker {
+	return &MessageTransformInvoker{inv: inv, recv: recv, send: send}
+}
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+// If service string is empty, ignore it.
+func (i *MessageTransformInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	return i.inv.InvokeMethod(serviceID, methodID, &transformStream{
+		Stream:    strm,
+		inv:       i,
+		serviceID: serviceID,
+		methodID:  methodID,
+	})
+}
+
+// transformStream is a Stream which transforms the messages.
+type transformStream struct {
+	Stream
+	inv                 *MessageTransformInvoker
+	serviceID, methodID string
+}
+
+// MsgSend transforms and sends the message to the remote.
+//
+// The message is modified in place by the send transform.
+func (s *transformStream) MsgSend(msg Message) error {
+	if err := s.transformSend(msg); err != nil {
+		return err
+	}
+	return s.Stream.MsgSend(msg)
+}
+
+// SendClose transforms and sends the final message and signals the end of sending.
+func (s *transformStream) SendClose(msg Message) error {
+	if err := s.transformSend(msg); err != nil {
+		return err
+	}
+	return SendClose(s.Stream, msg)
+}
+
+// transformSend applies the send transform to the message.
+func (s *transformStream) transformSend(msg Message) error {
+	if fn := s.inv.send; fn != nil {
+		return fn(s.Context(), s.serviceID, s.methodID, msg)
+	}
+	return nil
+}
+
+// MsgRecv receives and transforms an incoming message from the remote.
+func (s *transformStream) MsgRecv(msg Message) error {
+	if err := s.Stream.MsgRecv(msg); err != nil {
+		return err
+	}
+	if fn := s.inv.recv; fn != nil {
+		return fn(s.Context(), s.serviceID, s.methodID, msg)
+	}
+	return nil
+}
+
+// _ is a type assertion
+var (
+	_ Invoker    = ((*MessageTransformInvoker)(nil))
+	_ Stream     = ((*transformStream)(nil))
+	_ SendCloser = ((*transformStream)(nil))
+)
@@ -0,0 +1,67 @@
+package srpc
+
+import "context"
+
+// Metadata contains key/value pairs sent with a call.
+//
+// Keys are case-sensitive and should be lower-case by convention.
+type Metadata map[string]string
+
+// Get returns the value for the key or an empty string if not set.
+func (m Metadata) Get(key string) string {
+	return m[key]
+}
+
+// Clone copies the metadata.
+func (m Metadata) Clone() Metadata {
+	if m == nil {
+		return nil
+	}
+	out := make(Metadata, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// outgoingMetadataCtxKey is the context key for outgoing metadata.
+type outgoingMetadataCtxKey struct{}
+
+// incomingMetadataCtxKey is the context key for incoming metadata.
+type incomingMetadataCtxKey struct{}
+
+// WithOutgoingMetadata attaches metadata to the context to be sent with calls.
+//
+// Merges with any existing outgoing metadata on the context.
+func WithOutgoingMetadata(ctx context.Context, md Metadata) context.Context {
+	existing := OutgoingMetadataFromContext(ctx)
+	merged := existing.Clone()
+	if merged == nil {
+		merged = make(Metadata, len(md))
+	}
+	for k, v := range md {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, outgoingMetadataCtxKey{}, merged)
+}
+
+// OutgoingMetadataFromContext returns the metadata to send with calls.
+//
+// Returns nil if none is set. The returned value must not be modified.
+func OutgoingMetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(outgoingMetadataCtxKey{}).(Metadata)
+	return md
+}
+
+// withIncomingMetadata attaches the metadata received from the remote.
+func withIncomingMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, incomingMetadataCtxKey{}, md)
+}
+
+// MetadataFromContext returns the metadata sent by the remote with the call.
+//
+// Returns nil if none was sent. The returned value must not be modified.
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(incomingMetadataCtxKey{}).(Metadata)
+	return md
+}
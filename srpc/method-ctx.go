@@ -0,0 +1,25 @@
+package srpc
+
+import "context"
+
+// methodCtxKey is the context key for the invoked service and method.
+type methodCtxKey struct{}
+
+// methodCtxValue is the value stored at methodCtxKey.
+type methodCtxValue struct {
+	serviceID, methodID string
+}
+
+// withMethod attaches the invoked service and method IDs to the context.
+func withMethod(ctx context.Context, serviceID, methodID string) context.Context {
+	return context.WithValue(ctx, methodCtxKey{}, methodCtxValue{serviceID: serviceID, methodID: methodID})
+}
+
+// MethodFromContext returns the service and method IDs of the call being handled.
+//
+// The IDs are the values sent by the remote before any prefix stripping.
+// Returns ok=false if the context does not belong to an incoming call.
+func MethodFromContext(ctx context.Context) (serviceID, methodID string, ok bool) {
+	val, ok := ctx.Value(methodCtxKey{}).(methodCtxValue)
+	return val.serviceID, val.methodID, ok
+}
@@ -2,6 +2,10 @@ package srpc
 
 import (
 	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // MsgStreamRw is the read-write interface for MsgStream.
@@ -14,6 +18,59 @@ type MsgStreamRw interface {
 	WriteCallData(data []byte, complete bool, err error) error
 }
 
+// msgStreamIDer is a MsgStreamRw which has a stream ID.
+type msgStreamIDer interface {
+	// StreamID returns the multiplexing stream ID of the transport stream.
+	StreamID() uint64
+}
+
+// msgStreamTrailer is a MsgStreamRw which receives trailer metadata.
+type msgStreamTrailer interface {
+	// Trailer returns the trailer metadata received with the final packet.
+	Trailer() Metadata
+}
+
+// msgStreamByteCounter is a MsgStreamRw which counts received bytes.
+type msgStreamByteCounter interface {
+	// BytesReceived returns the number of message bytes received.
+	BytesReceived() uint64
+}
+
+// msgStreamRemoteFinisher is a MsgStreamRw which reports if the remote
+// finished the call and stopped reading.
+type msgStreamRemoteFinisher interface {
+	// RemoteFinished returns true if the remote finished the call.
+	RemoteFinished() bool
+}
+
+// msgStreamContextReader is a MsgStreamRw which can read with a Context.
+type msgStreamContextReader interface {
+	// ReadOneContext reads a single message and returns.
+	//
+	// returns ctx.Err() if ctx is canceled before a message arrives.
+	ReadOneContext(ctx context.Context) ([]byte, error)
+}
+
+// msgStreamFlusher is a MsgStreamRw which can flush buffered writes.
+type msgStreamFlusher interface {
+	// Flush writes any buffered data to the transport.
+	Flush(ctx context.Context) error
+}
+
+// msgStreamEstablisher is a MsgStreamRw which waits for the remote to accept the call.
+type msgStreamEstablisher interface {
+	// WaitEstablished waits for the remote to accept the call.
+	WaitEstablished(ctx context.Context) error
+}
+
+// msgStreamDemander is a MsgStreamRw which supports demand flow control.
+type msgStreamDemander interface {
+	// RequestDemand requests n more messages from the remote.
+	RequestDemand(n uint32) error
+	// AwaitDemand waits until the remote requested more messages.
+	AwaitDemand() (int, error)
+}
+
 // MsgStream implements the stream interface passed to implementations.
 type MsgStream struct {
 	// ctx is the stream context
@@ -22,6 +79,14 @@ type MsgStream struct {
 	rw MsgStreamRw
 	// closeCb is the close callback
 	closeCb func()
+	// closed is set when Close is called
+	closed atomic.Bool
+	// deadlineMtx guards below fields
+	deadlineMtx sync.Mutex
+	// readDeadline is the deadline for MsgRecv calls
+	readDeadline time.Time
+	// writeDeadline is the deadline for MsgSend calls
+	writeDeadline time.Time
 }
 
 // NewMsgStream constructs a new Stream with a ClientRPC.
@@ -31,11 +96,12 @@ func NewMsgStream(
 	rw MsgStreamRw,
 	closeCb func(),
 ) *MsgStream {
-	return &MsgStream{
-		ctx:     ctx,
+	strm := &MsgStream{
 		rw:      rw,
 		closeCb: closeCb,
 	}
+	strm.ctx = withDeadlineStream(ctx, strm)
+	return strm
 }
 
 // Context is canceled when the Stream is no longer valid.
@@ -44,38 +110,162 @@ func (r *MsgStream) Context() context.Context {
 }
 
 // MsgSend sends the message to the remote.
+//
+// Returns ErrStreamClosed if the stream was closed or CloseSend was called.
 func (r *MsgStream) MsgSend(msg Message) error {
-	select {
-	case <-r.ctx.Done():
-		return context.Canceled
-	default:
+	return r.msgSend(msg, false)
+}
+
+// SendClose sends the final message and signals the end of sending.
+//
+// The message and the completion are sent in a single packet, equivalent to
+// MsgSend followed by CloseSend. Empty messages are sent in a separate packet
+// from the completion: a CallData packet with complete set and no data does
+// not contain a message. Subsequent calls to MsgSend return ErrStreamClosed.
+func (r *MsgStream) SendClose(msg Message) error {
+	return r.msgSend(msg, true)
+}
+
+// msgSend sends the message to the remote, optionally completing the stream.
+func (r *MsgStream) msgSend(msg Message, complete bool) error {
+	if err := r.checkOpen(); err != nil {
+		return err
+	}
+
+	r.deadlineMtx.Lock()
+	writeDeadline := r.writeDeadline
+	r.deadlineMtx.Unlock()
+	if !writeDeadline.IsZero() && !time.Now().Before(writeDeadline) {
+		return context.DeadlineExceeded
 	}
 
 	msgData, err := msg.MarshalVT()
 	if err != nil {
 		return err
 	}
-	return r.rw.WriteCallData(msgData, false, nil)
+	// an empty message must be sent separately from the completion.
+	coalesce := complete && len(msgData) != 0
+	if err := r.rw.WriteCallData(msgData, coalesce, nil); err != nil {
+		if err == ErrCompleted {
+			return ErrStreamClosed
+		}
+		if f, ok := r.rw.(msgStreamRemoteFinisher); ok && f.RemoteFinished() {
+			return io.EOF
+		}
+		return err
+	}
+	if complete && !coalesce {
+		if err := r.rw.WriteCallData(nil, true, nil); err != nil {
+			return err
+		}
+	}
+	if complete {
+		return r.flush()
+	}
+	return nil
 }
 
 // MsgRecv receives an incoming message from the remote.
 // Parses the message into the object at msg.
 func (r *MsgStream) MsgRecv(msg Message) error {
-	data, err := r.rw.ReadOne()
+	data, err := r.readOne()
 	if err != nil {
 		return err
 	}
 	return msg.UnmarshalVT(data)
 }
 
+// readOne reads a single message from rw bounded by the read deadline.
+func (r *MsgStream) readOne() ([]byte, error) {
+	r.deadlineMtx.Lock()
+	readDeadline := r.readDeadline
+	r.deadlineMtx.Unlock()
+	if readDeadline.IsZero() {
+		return r.rw.ReadOne()
+	}
+	if !time.Now().Before(readDeadline) {
+		return nil, context.DeadlineExceeded
+	}
+	cr, ok := r.rw.(msgStreamContextReader)
+	if !ok {
+		return r.rw.ReadOne()
+	}
+	ctx, ctxCancel := context.WithDeadline(context.Background(), readDeadline)
+	defer ctxCancel()
+	return cr.ReadOneContext(ctx)
+}
+
+// SetDeadline sets the read and write deadlines for the stream.
+func (r *MsgStream) SetDeadline(t time.Time) error {
+	r.deadlineMtx.Lock()
+	r.readDeadline, r.writeDeadline = t, t
+	r.deadlineMtx.Unlock()
+	return nil
+}
+
+// SetReadDeadline sets the deadline for subsequent MsgRecv calls.
+//
+// If the MsgStreamRw does not implement ReadOneContext the deadline is only
+// checked before starting to read.
+func (r *MsgStream) SetReadDeadline(t time.Time) error {
+	r.deadlineMtx.Lock()
+	r.readDeadline = t
+	r.deadlineMtx.Unlock()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for subsequent MsgSend calls.
+//
+// The deadline is checked before writing the message to the transport.
+func (r *MsgStream) SetWriteDeadline(t time.Time) error {
+	r.deadlineMtx.Lock()
+	r.writeDeadline = t
+	r.deadlineMtx.Unlock()
+	return nil
+}
+
+// PeekFirstMessage returns the raw data of the first message sent with CallStart.
+// Does not consume the message: it is still returned by MsgRecv.
+// Returns nil, false if the call start did not include a message.
+func (r *MsgStream) PeekFirstMessage() ([]byte, bool) {
+	p, ok := r.rw.(FirstMessagePeeker)
+	if !ok {
+		return nil, false
+	}
+	return p.PeekFirstMessage()
+}
+
 // CloseSend signals to the remote that we will no longer send any messages.
+//
+// Flushes any buffered data to the transport before returning, bounded by the
+// stream context and the write deadline.
+// Calling CloseSend more than once is a no-op.
+// Returns ErrStreamClosed if the stream was closed.
 func (r *MsgStream) CloseSend() error {
-	return r.rw.WriteCallData(nil, true, nil)
+	if err := r.checkOpen(); err != nil {
+		if err == io.EOF {
+			// the remote finished the call: nothing to close
+			return nil
+		}
+		return err
+	}
+	if err := r.rw.WriteCallData(nil, true, nil); err != nil {
+		return err
+	}
+	return r.flush()
 }
 
 // Close closes the stream.
+//
+// Flushes any buffered data to the transport before closing.
+// Close is idempotent: subsequent calls return nil.
 func (r *MsgStream) Close() error {
-	_ = r.CloseSend()
+	if r.closed.Swap(true) {
+		return nil
+	}
+	if err := r.rw.WriteCallData(nil, true, nil); err == nil {
+		_ = r.flush()
+	}
 	if r.closeCb != nil {
 		r.closeCb()
 	}
@@ -83,5 +273,135 @@ func (r *MsgStream) Close() error {
 	return nil
 }
 
+// ID returns the multiplexing stream ID of the stream on the connection.
+//
+// Returns 0 if the transport does not have stream IDs.
+func (r *MsgStream) ID() uint64 {
+	if s, ok := r.rw.(msgStreamIDer); ok {
+		return s.StreamID()
+	}
+	return 0
+}
+
+// Trailer returns the trailer metadata sent by the remote with the final packet.
+//
+// Returns nil if the remote did not send a trailer.
+func (r *MsgStream) Trailer() Metadata {
+	if t, ok := r.rw.(msgStreamTrailer); ok {
+		return t.Trailer()
+	}
+	return nil
+}
+
+// BytesReceived returns the number of message bytes received from the remote.
+//
+// Returns 0 if the read-writer does not count received bytes.
+func (r *MsgStream) BytesReceived() uint64 {
+	if c, ok := r.rw.(msgStreamByteCounter); ok {
+		return c.BytesReceived()
+	}
+	return 0
+}
+
+// OpenAttachment opens a new attachment to send to the remote.
+//
+// The returned writer fails with ErrUnimplemented if the read-writer does not
+// support attachments.
+func (r *MsgStream) OpenAttachment() (io.WriteCloser, uint32) {
+	if a, ok := r.rw.(msgStreamAttacher); ok {
+		return a.OpenAttachment()
+	}
+	return &attachmentWriter{err: ErrUnimplemented}, 0
+}
+
+// OpenAttachmentReader opens the attachment with the ID sent by the remote.
+//
+// Returns ErrUnimplemented if the read-writer does not support attachments.
+func (r *MsgStream) OpenAttachmentReader(id uint32) (io.ReadCloser, error) {
+	if a, ok := r.rw.(msgStreamAttacher); ok {
+		return a.OpenAttachmentReader(id)
+	}
+	return nil, ErrUnimplemented
+}
+
+// WaitEstablished waits for the remote to accept the call.
+//
+// See the WaitEstablished function for details.
+// Returns ErrUnimplemented if the read-writer does not support waiting.
+func (r *MsgStream) WaitEstablished(ctx context.Context) error {
+	if e, ok := r.rw.(msgStreamEstablisher); ok {
+		return e.WaitEstablished(ctx)
+	}
+	return ErrUnimplemented
+}
+
+// RequestDemand requests n more messages from the remote.
+//
+// The call must have been started with a context from WithDemand.
+// Returns ErrUnimplemented if the read-writer does not support demand flow control.
+func (r *MsgStream) RequestDemand(n uint32) error {
+	if d, ok := r.rw.(msgStreamDemander); ok {
+		return d.RequestDemand(n)
+	}
+	return ErrUnimplemented
+}
+
+// AwaitDemand waits until the remote requested more messages.
+//
+// Returns the number of messages which can be sent without blocking. Returns
+// math.MaxInt if the remote did not enable demand flow control.
+// Returns ErrUnimplemented if the read-writer does not support demand flow control.
+func (r *MsgStream) AwaitDemand() (int, error) {
+	if d, ok := r.rw.(msgStreamDemander); ok {
+		return d.AwaitDemand()
+	}
+	return 0, ErrUnimplemented
+}
+
+// flush flushes buffered writes bounded by the context and write deadline.
+func (r *MsgStream) flush() error {
+	f, ok := r.rw.(msgStreamFlusher)
+	if !ok {
+		return nil
+	}
+	r.deadlineMtx.Lock()
+	writeDeadline := r.writeDeadline
+	r.deadlineMtx.Unlock()
+	ctx := r.ctx
+	if !writeDeadline.IsZero() {
+		var ctxCancel context.CancelFunc
+		ctx, ctxCancel = context.WithDeadline(ctx, writeDeadline)
+		defer ctxCancel()
+	}
+	return f.Flush(ctx)
+}
+
+// checkOpen returns ErrStreamClosed if the stream is finished.
+//
+// Returns io.EOF if the remote finished the call.
+func (r *MsgStream) checkOpen() error {
+	if r.closed.Load() {
+		return ErrStreamClosed
+	}
+	if f, ok := r.rw.(msgStreamRemoteFinisher); ok && f.RemoteFinished() {
+		return io.EOF
+	}
+	select {
+	case <-r.ctx.Done():
+		return ErrStreamClosed
+	default:
+		return nil
+	}
+}
+
 // _ is a type assertion
-var _ Stream = ((*MsgStream)(nil))
+var (
+	_ Stream             = ((*MsgStream)(nil))
+	_ FirstMessagePeeker = ((*MsgStream)(nil))
+	_ SendCloser         = ((*MsgStream)(nil))
+	_ AttachmentStream   = ((*MsgStream)(nil))
+	_ TrailerReceiver    = ((*MsgStream)(nil))
+	_ ByteCounter        = ((*MsgStream)(nil))
+	_ DeadlineStream     = ((*MsgStream)(nil))
+	_ StreamIDer         = ((*MsgStream)(nil))
+)
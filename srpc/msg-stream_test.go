@@ -0,0 +1,134 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+)
+
+// newTestMsgStream constructs a MsgStream with a ClientRPC and discardWriter.
+func newTestMsgStream(t *testing.T) *MsgStream {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	t.Cleanup(ctxCancel)
+
+	clientRPC := NewClientRPC(ctx, "test-service", "test-method")
+	if err := clientRPC.Start(discardWriter{}, false, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	return NewMsgStream(ctx, clientRPC, clientRPC.ctxCancel)
+}
+
+// TestMsgStream_SendAfterClose tests sending after the stream was closed.
+func TestMsgStream_SendAfterClose(t *testing.T) {
+	strm := newTestMsgStream(t)
+	if err := strm.MsgSend(NewRawMessage([]byte("hello"), false)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.MsgSend(NewRawMessage([]byte("hello"), false)); err != ErrStreamClosed {
+		t.Fatalf("expected ErrStreamClosed but got %v", err)
+	}
+	if err := strm.CloseSend(); err != ErrStreamClosed {
+		t.Fatalf("expected ErrStreamClosed but got %v", err)
+	}
+}
+
+// TestMsgStream_SendAfterCloseSend tests sending after CloseSend was called.
+func TestMsgStream_SendAfterCloseSend(t *testing.T) {
+	strm := newTestMsgStream(t)
+	if err := strm.CloseSend(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.CloseSend(); err != nil {
+		t.Fatalf("expected CloseSend to be idempotent but got %v", err)
+	}
+	if err := strm.MsgSend(NewRawMessage([]byte("hello"), false)); err != ErrStreamClosed {
+		t.Fatalf("expected ErrStreamClosed but got %v", err)
+	}
+}
+
+// TestMsgStream_CloseAfterClose tests that Close is idempotent.
+func TestMsgStream_CloseAfterClose(t *testing.T) {
+	strm := newTestMsgStream(t)
+	for i := 0; i < 3; i++ {
+		if err := strm.Close(); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+// recordWriter is a Writer which records all packets.
+type recordWriter struct {
+	mtx  sync.Mutex
+	pkts []*Packet
+}
+
+// WritePacket writes a packet to the remote.
+func (w *recordWriter) WritePacket(p *Packet) error {
+	w.mtx.Lock()
+	w.pkts = append(w.pkts, p)
+	w.mtx.Unlock()
+	return nil
+}
+
+// Close closes the writer.
+func (w *recordWriter) Close() error { return nil }
+
+// responseInvoker reads one message and sends it back with SendResponse.
+type responseInvoker struct{}
+
+// InvokeMethod invokes the method matching the service & method ID.
+func (responseInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	msg := NewRawMessage(nil, false)
+	if err := strm.MsgRecv(msg); err != nil {
+		return true, err
+	}
+	return true, SendResponse(strm, msg)
+}
+
+// TestSendResponse tests the response and completion are sent in one packet.
+func TestSendResponse(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	writer := &recordWriter{}
+	serverRPC := NewServerRPC(ctx, responseInvoker{}, writer)
+	if err := serverRPC.HandlePacket(NewCallStartPacket("test-service", "test-method", []byte("hello"), false)); err != nil {
+		t.Fatal(err.Error())
+	}
+	<-serverRPC.Context().Done()
+
+	writer.mtx.Lock()
+	defer writer.mtx.Unlock()
+	if len(writer.pkts) != 1 {
+		t.Fatalf("expected 1 packet but got %d", len(writer.pkts))
+	}
+	callData := writer.pkts[0].GetCallData()
+	if string(callData.GetData()) != "hello" || !callData.GetComplete() || callData.GetError() != "" {
+		t.Fatalf("unexpected packet: %v", writer.pkts[0])
+	}
+}
+
+// TestSendClose_Fallback tests SendClose with a Stream which is not a SendCloser.
+func TestSendClose_Fallback(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	s1, s2 := NewPipeStream(ctx)
+	if err := SendClose(s1, NewRawMessage([]byte("hello"), true)); err != nil {
+		t.Fatal(err.Error())
+	}
+	msg := NewRawMessage(nil, false)
+	if err := s2.MsgRecv(msg); err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(msg.GetData()) != "hello" {
+		t.Fatalf("unexpected message: %q", msg.GetData())
+	}
+	if err := s2.MsgRecv(msg); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+}
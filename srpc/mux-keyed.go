@@ -0,0 +1,162 @@
+package srpc
+
+import (
+	"sort"
+)
+
+// MuxKeyFunc derives the key of the Mux to handle a call.
+//
+// Called with the service and method IDs and the metadata sent with the call.
+type MuxKeyFunc func(serviceID, methodID string, md Metadata) string
+
+// keyedMux is a Mux which dispatches calls to the Mux selected by a key.
+type keyedMux struct {
+	// keyFn derives the key for a call
+	keyFn MuxKeyFunc
+	// muxes contains the muxes by key
+	muxes map[string]Mux
+	// keys is the sorted list of keys
+	keys []string
+}
+
+// NewKeyedMux constructs a new Mux which routes each call to the Mux selected by a key.
+//
+// keyFn derives the key of each call, for example a shard or tenant ID from
+// the call metadata. Calls with a key not in muxes fail with a StatusNotFound
+// status. The muxes map is copied: the set of keys is fixed.
+//
+// Register and Unregister apply to all of the muxes: if registering with one
+// of the muxes fails, the service is unregistered from the others. Register
+// services with a single key with the Mux of the key. HasService,
+// HasServiceMethod, and Services include the services of all of the muxes.
+func NewKeyedMux(keyFn MuxKeyFunc, muxes map[string]Mux) Mux {
+	m := &keyedMux{keyFn: keyFn, muxes: make(map[string]Mux, len(muxes))}
+	for key, mux := range muxes {
+		if mux != nil {
+			m.muxes[key] = mux
+			m.keys = append(m.keys, key)
+		}
+	}
+	sort.Strings(m.keys)
+	return m
+}
+
+// Register registers a new RPC method handler (service) with all of the muxes.
+//
+// Returns ErrServiceAlreadyRegistered if the service ID was already registered.
+func (m *keyedMux) Register(handler Handler) error {
+	return m.RegisterWithOptions(handler)
+}
+
+// RegisterWithOptions registers a new RPC method handler (service) with options
+// with all of the muxes.
+//
+// Returns ErrServiceAlreadyRegistered if the service ID was already registered.
+// Returns ErrUnimplemented if options are set and one of the muxes does not
+// implement OptionsRegisterer.
+func (m *keyedMux) RegisterWithOptions(handler Handler, opts ...RegisterOption) error {
+	for i, key := range m.keys {
+		if err := RegisterHandler(m.muxes[key], handler, opts...); err != nil {
+			for _, prev := range m.keys[:i] {
+				_ = UnregisterService(m.muxes[prev], handler.GetServiceID())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Unregister removes the handler for the service ID from all of the muxes.
+//
+// In-flight calls to the service are not canceled.
+// Returns nil if the service was not registered.
+// Returns ErrUnimplemented if one of the muxes does not implement Unregisterer.
+func (m *keyedMux) Unregister(serviceID string) error {
+	if serviceID == "" {
+		return ErrEmptyServiceID
+	}
+	for _, key := range m.keys {
+		if err := UnregisterService(m.muxes[key], serviceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasService checks if the service ID exists in any of the muxes.
+func (m *keyedMux) HasService(serviceID string) bool {
+	for _, key := range m.keys {
+		if m.muxes[key].HasService(serviceID) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasServiceMethod checks if <service-id, method-id> exists in any of the muxes.
+func (m *keyedMux) HasServiceMethod(serviceID, methodID string) bool {
+	for _, key := range m.keys {
+		if m.muxes[key].HasServiceMethod(serviceID, methodID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Services returns a snapshot of the services of all muxes sorted by ID.
+//
+// The method IDs of services registered with multiple muxes are merged.
+func (m *keyedMux) Services() []ServiceInfo {
+	methods := make(map[string]map[string]struct{})
+	for _, key := range m.keys {
+		for _, info := range MuxServices(m.muxes[key]) {
+			svcMethods := methods[info.ServiceID]
+			if svcMethods == nil {
+				svcMethods = make(map[string]struct{}, len(info.MethodIDs))
+				methods[info.ServiceID] = svcMethods
+			}
+			for _, methodID := range info.MethodIDs {
+				svcMethods[methodID] = struct{}{}
+			}
+		}
+	}
+
+	infos := make([]ServiceInfo, 0, len(methods))
+	for serviceID, svcMethods := range methods {
+		methodIDs := make([]string, 0, len(svcMethods))
+		for methodID := range svcMethods {
+			methodIDs = append(methodIDs, methodID)
+		}
+		sort.Strings(methodIDs)
+		infos = append(infos, ServiceInfo{ServiceID: serviceID, MethodIDs: methodIDs})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ServiceID < infos[j].ServiceID
+	})
+	return infos
+}
+
+// InvokeMethod invokes the method with the Mux selected by the key of the call.
+//
+// Returns true and a StatusNotFound status if no Mux matches the key.
+// Returns false, nil if the Mux did not find the method.
+func (m *keyedMux) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	var md Metadata
+	if strm != nil {
+		md = MetadataFromContext(strm.Context())
+	}
+	key := m.keyFn(serviceID, methodID, md)
+	mux, ok := m.muxes[key]
+	if !ok {
+		return true, NewStatusErrorf(StatusNotFound, "no mux found for key %q", key).WithDetail("key", key)
+	}
+	return mux.InvokeMethod(serviceID, methodID, strm)
+}
+
+// _ is a type assertion
+var (
+	_ Mux               = ((*keyedMux)(nil))
+	_ OptionsRegisterer = ((*keyedMux)(nil))
+	_ Unregisterer      = ((*keyedMux)(nil))
+	_ ServiceLister     = ((*keyedMux)(nil))
+)
@@ -1,13 +1,29 @@
 package srpc
 
-import "sync"
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
 
 // Mux contains a set of <service, method> handlers.
+//
+// The Mux returned by NewMux also implements OptionsRegisterer, Unregisterer
+// and ServiceLister.
+// Register and Unregister are safe to call concurrently with each other and
+// with calls being invoked, allowing services to be added and removed at
+// runtime without closing connections. Unregister does not affect calls which
+// were already started: they run to completion with the removed handler. Calls
+// started after Unregister returns are not routed to the removed handler: they
+// fall through to the fallback invokers or fail with ErrUnimplemented.
 type Mux interface {
 	// Invoker invokes the methods.
 	Invoker
 
 	// Register registers a new RPC method handler (service).
+	//
+	// Returns ErrServiceAlreadyRegistered if the service ID was already registered.
 	Register(handler Handler) error
 	// HasService checks if the service ID exists in the handlers.
 	HasService(serviceID string) bool
@@ -15,6 +31,116 @@ type Mux interface {
 	HasServiceMethod(serviceID, methodID string) bool
 }
 
+// OptionsRegisterer is implemented by a Mux which accepts registration options.
+type OptionsRegisterer interface {
+	// RegisterWithOptions registers a new RPC method handler (service) with options.
+	//
+	// Returns ErrServiceAlreadyRegistered if the service ID was already registered.
+	RegisterWithOptions(handler Handler, opts ...RegisterOption) error
+}
+
+// RegisterHandler registers the handler with the mux with the options.
+//
+// Calls Register if no options are set. Returns ErrUnimplemented if options
+// are set and the mux does not implement OptionsRegisterer.
+func RegisterHandler(mux Mux, handler Handler, opts ...RegisterOption) error {
+	if r, ok := mux.(OptionsRegisterer); ok {
+		return r.RegisterWithOptions(handler, opts...)
+	}
+	if len(opts) != 0 {
+		return ErrUnimplemented
+	}
+	return mux.Register(handler)
+}
+
+// Unregisterer is implemented by a Mux which can remove services.
+type Unregisterer interface {
+	// Unregister removes the handler for the service ID.
+	//
+	// In-flight calls to the service are not canceled.
+	// Returns nil if the service was not registered.
+	Unregister(serviceID string) error
+}
+
+// UnregisterService removes the handler for the service ID from the mux.
+//
+// Returns ErrUnimplemented if the mux does not implement Unregisterer.
+func UnregisterService(mux Mux, serviceID string) error {
+	u, ok := mux.(Unregisterer)
+	if !ok {
+		return ErrUnimplemented
+	}
+	return u.Unregister(serviceID)
+}
+
+// ServiceLister is implemented by a Mux which can list the registered services.
+type ServiceLister interface {
+	// Services returns a snapshot of the registered services sorted by ID.
+	//
+	// Does not include the services of the fallback invokers.
+	Services() []ServiceInfo
+}
+
+// MuxServices returns a snapshot of the services registered with the mux.
+//
+// Returns nil if the mux does not implement ServiceLister.
+func MuxServices(mux Mux) []ServiceInfo {
+	l, ok := mux.(ServiceLister)
+	if !ok {
+		return nil
+	}
+	return l.Services()
+}
+
+// ServiceInfo describes a service registered with a Mux.
+type ServiceInfo struct {
+	// ServiceID is the service ID.
+	ServiceID string
+	// MethodIDs is the sorted list of method IDs of the service.
+	MethodIDs []string
+}
+
+// RegisterOption configures a service registered with a Mux.
+type RegisterOption func(opts *registerOpts)
+
+// registerOpts contains the service registration options.
+type registerOpts struct {
+	// codec is the codec pinned to the service.
+	codec Codec
+}
+
+// WithServiceCodec pins the codec used to encode the messages of the service.
+//
+// The streams passed to the handler encode and decode messages with the codec
+// regardless of the encoding used by the client: the pinned codec takes
+// precedence over any codec negotiated with the client. Clients must use the
+// same codec when calling the service, for example with NewCodecStream.
+// Services without a pinned codec use the encoding of the connection.
+func WithServiceCodec(codec Codec) RegisterOption {
+	return func(opts *registerOpts) {
+		opts.codec = codec
+	}
+}
+
+// MuxOption configures a Mux.
+type MuxOption func(opts *muxOpts)
+
+// muxOpts contains the mux options.
+type muxOpts struct {
+	// interceptors is the list of interceptors for all calls.
+	interceptors []MuxInterceptor
+}
+
+// WithMuxInterceptors sets the interceptors applied to all calls to the Mux.
+//
+// The interceptors are composed with Chain: the first interceptor sees each
+// call first. Calls to methods of the fallback invokers are also intercepted.
+func WithMuxInterceptors(interceptors ...MuxInterceptor) MuxOption {
+	return func(opts *muxOpts) {
+		opts.interceptors = append(opts.interceptors, interceptors...)
+	}
+}
+
 // muxMethods is a mapping from method id to handler.
 type muxMethods map[string]Handler
 
@@ -23,10 +149,15 @@ type mux struct {
 	// fallback is the list of fallback invokers
 	// if the mux doesn't match the service, calls the invokers.
 	fallback []Invoker
+	// invoker invokes the methods through the interceptors.
+	// if nil, calls invokeMethod directly.
+	invoker Invoker
 	// rmtx guards below fields
 	rmtx sync.RWMutex
 	// services contains a mapping from services to handlers.
 	services map[string]muxMethods
+	// codecs contains the codecs pinned to services.
+	codecs map[string]Codec
 }
 
 // NewMux constructs a new Mux.
@@ -34,33 +165,88 @@ type mux struct {
 // fallbackInvokers is the list of fallback Invokers to call in the case that
 // the service/method is not found on this mux.
 func NewMux(fallbackInvokers ...Invoker) Mux {
-	return &mux{
+	return NewMuxWithOptions(fallbackInvokers)
+}
+
+// NewMuxWithOptions constructs a new Mux with options.
+//
+// fallbackInvokers is the list of fallback Invokers to call in the case that
+// the service/method is not found on this mux.
+func NewMuxWithOptions(fallbackInvokers []Invoker, opts ...MuxOption) Mux {
+	var o muxOpts
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	m := &mux{
 		fallback: fallbackInvokers,
 		services: make(map[string]muxMethods),
+		codecs:   make(map[string]Codec),
 	}
+	if len(o.interceptors) != 0 {
+		m.invoker = Chain(o.interceptors...)(InvokerFunc(m.invokeMethod))
+	}
+	return m
 }
 
 // Register registers a new RPC method handler (service).
+//
+// Returns ErrServiceAlreadyRegistered if the service ID was already registered.
 func (m *mux) Register(handler Handler) error {
+	return m.RegisterWithOptions(handler)
+}
+
+// RegisterWithOptions registers a new RPC method handler (service) with options.
+//
+// Returns ErrServiceAlreadyRegistered if the service ID was already registered.
+func (m *mux) RegisterWithOptions(handler Handler, opts ...RegisterOption) error {
 	serviceID := handler.GetServiceID()
 	methodIDs := handler.GetMethodIDs()
 	if serviceID == "" {
 		return ErrEmptyServiceID
 	}
 
+	var ropts registerOpts
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&ropts)
+		}
+	}
+
 	m.rmtx.Lock()
 	defer m.rmtx.Unlock()
 
-	serviceMethods := m.services[serviceID]
-	if serviceMethods == nil {
-		serviceMethods = make(muxMethods)
-		m.services[serviceID] = serviceMethods
+	if _, ok := m.services[serviceID]; ok {
+		return errors.Wrap(ErrServiceAlreadyRegistered, serviceID)
 	}
+	serviceMethods := make(muxMethods)
 	for _, methodID := range methodIDs {
 		if methodID != "" {
 			serviceMethods[methodID] = handler
 		}
 	}
+	m.services[serviceID] = serviceMethods
+	if ropts.codec != nil {
+		m.codecs[serviceID] = ropts.codec
+	}
+
+	return nil
+}
+
+// Unregister removes the handler for the service ID.
+//
+// In-flight calls to the service are not canceled.
+// Returns nil if the service was not registered.
+func (m *mux) Unregister(serviceID string) error {
+	if serviceID == "" {
+		return ErrEmptyServiceID
+	}
+
+	m.rmtx.Lock()
+	delete(m.services, serviceID)
+	delete(m.codecs, serviceID)
+	m.rmtx.Unlock()
 
 	return nil
 }
@@ -71,8 +257,8 @@ func (m *mux) HasService(serviceID string) bool {
 		return false
 	}
 
-	m.rmtx.Lock()
-	defer m.rmtx.Unlock()
+	m.rmtx.RLock()
+	defer m.rmtx.RUnlock()
 
 	return len(m.services[serviceID]) != 0
 }
@@ -83,8 +269,8 @@ func (m *mux) HasServiceMethod(serviceID, methodID string) bool {
 		return false
 	}
 
-	m.rmtx.Lock()
-	defer m.rmtx.Unlock()
+	m.rmtx.RLock()
+	defer m.rmtx.RUnlock()
 
 	handlers := m.services[serviceID]
 	for _, mh := range handlers {
@@ -98,15 +284,47 @@ func (m *mux) HasServiceMethod(serviceID, methodID string) bool {
 	return false
 }
 
+// Services returns a snapshot of the registered services sorted by ID.
+//
+// Does not include the services of the fallback invokers.
+func (m *mux) Services() []ServiceInfo {
+	m.rmtx.RLock()
+	infos := make([]ServiceInfo, 0, len(m.services))
+	for serviceID, methods := range m.services {
+		methodIDs := make([]string, 0, len(methods))
+		for methodID := range methods {
+			methodIDs = append(methodIDs, methodID)
+		}
+		sort.Strings(methodIDs)
+		infos = append(infos, ServiceInfo{ServiceID: serviceID, MethodIDs: methodIDs})
+	}
+	m.rmtx.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ServiceID < infos[j].ServiceID
+	})
+	return infos
+}
+
 // InvokeMethod invokes the method matching the service & method ID.
 // Returns false, nil if not found.
 // If service string is empty, ignore it.
 func (m *mux) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	if m.invoker != nil {
+		return m.invoker.InvokeMethod(serviceID, methodID, strm)
+	}
+	return m.invokeMethod(serviceID, methodID, strm)
+}
+
+// invokeMethod invokes the registered handler or the fallback invokers.
+func (m *mux) invokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
 	var handler Handler
+	var codec Codec
 	m.rmtx.RLock()
 	if serviceID == "" {
-		for _, svc := range m.services {
+		for svcID, svc := range m.services {
 			if handler = svc[methodID]; handler != nil {
+				codec = m.codecs[svcID]
 				break
 			}
 		}
@@ -114,11 +332,15 @@ func (m *mux) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error
 		svcMethods := m.services[serviceID]
 		if svcMethods != nil {
 			handler = svcMethods[methodID]
+			codec = m.codecs[serviceID]
 		}
 	}
 	m.rmtx.RUnlock()
 
 	if handler != nil {
+		if codec != nil {
+			strm = NewCodecStream(strm, codec)
+		}
 		return handler.InvokeMethod(serviceID, methodID, strm)
 	}
 
@@ -135,4 +357,9 @@ func (m *mux) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error
 }
 
 // _ is a type assertion
-var _ Mux = ((*mux)(nil))
+var (
+	_ Mux               = ((*mux)(nil))
+	_ OptionsRegisterer = ((*mux)(nil))
+	_ Unregisterer      = ((*mux)(nil))
+	_ ServiceLister     = ((*mux)(nil))
+)
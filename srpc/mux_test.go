@@ -0,0 +1,172 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testHandler is a Handler with a service ID and a list of methods.
+type testHandler struct {
+	drainInvoker
+	serviceID string
+	methodIDs []string
+}
+
+// GetServiceID returns the ID of the service.
+func (h *testHandler) GetServiceID() string { return h.serviceID }
+
+// GetMethodIDs returns the list of methods for the service.
+func (h *testHandler) GetMethodIDs() []string { return h.methodIDs }
+
+// TestMux_RegisterDuplicate tests registering the same service ID twice.
+func TestMux_RegisterDuplicate(t *testing.T) {
+	mux := NewMux()
+	handler := &testHandler{serviceID: "test-service", methodIDs: []string{"test-method"}}
+	if err := mux.Register(handler); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := mux.Register(handler); !errors.Is(err, ErrServiceAlreadyRegistered) {
+		t.Fatalf("expected ErrServiceAlreadyRegistered but got %v", err)
+	}
+
+	if err := UnregisterService(mux, "test-service"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if mux.HasService("test-service") {
+		t.Fatal("expected service to be unregistered")
+	}
+	if err := mux.Register(handler); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !mux.HasServiceMethod("test-service", "test-method") {
+		t.Fatal("expected service to be registered")
+	}
+}
+
+// TestMux_Services tests listing the registered services.
+func TestMux_Services(t *testing.T) {
+	mux := NewMux()
+	handlers := []*testHandler{
+		{serviceID: "svc-b", methodIDs: []string{"method-2", "method-1"}},
+		{serviceID: "svc-a", methodIDs: []string{"method-1"}},
+	}
+	for _, handler := range handlers {
+		if err := mux.Register(handler); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	infos := MuxServices(mux)
+	if len(infos) != 2 ||
+		infos[0].ServiceID != "svc-a" ||
+		infos[1].ServiceID != "svc-b" ||
+		strings.Join(infos[1].MethodIDs, ",") != "method-1,method-2" {
+		t.Fatalf("unexpected services: %v", infos)
+	}
+
+	// the snapshot is not affected by unregistering
+	if err := UnregisterService(mux, "svc-a"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(infos) != 2 || len(MuxServices(mux)) != 1 {
+		t.Fatalf("unexpected services after unregister: %v", MuxServices(mux))
+	}
+}
+
+// TestChain tests the order and short-circuiting of chained interceptors.
+func TestChain(t *testing.T) {
+	var calls []string
+	record := func(name string, shortCircuit bool) MuxInterceptor {
+		return func(next Invoker) Invoker {
+			return InvokerFunc(func(serviceID, methodID string, strm Stream) (bool, error) {
+				calls = append(calls, name)
+				if shortCircuit && methodID == "denied" {
+					return true, ErrUnauthenticated
+				}
+				return next.InvokeMethod(serviceID, methodID, strm)
+			})
+		}
+	}
+	handler := InvokerFunc(func(serviceID, methodID string, strm Stream) (bool, error) {
+		calls = append(calls, "handler")
+		return true, nil
+	})
+	mux := NewMuxWithOptions([]Invoker{handler}, WithMuxInterceptors(
+		record("first", false),
+		nil,
+		record("second", true),
+		record("third", false),
+	))
+
+	if _, err := mux.InvokeMethod("svc", "allowed", nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got := strings.Join(calls, ","); got != "first,second,third,handler" {
+		t.Fatalf("unexpected call order: %s", got)
+	}
+
+	calls = nil
+	handled, err := mux.InvokeMethod("svc", "denied", nil)
+	if !handled || !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected short-circuit with ErrUnauthenticated but got %v, %v", handled, err)
+	}
+	if got := strings.Join(calls, ","); got != "first,second" {
+		t.Fatalf("unexpected calls after short-circuit: %s", got)
+	}
+}
+
+// TestKeyedMux tests routing calls to the mux selected by the metadata.
+func TestKeyedMux(t *testing.T) {
+	muxA, muxB := NewMux(), NewMux()
+	if err := muxA.Register(&testHandler{serviceID: "svc", methodIDs: []string{"method-a"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := muxB.Register(&testHandler{serviceID: "svc", methodIDs: []string{"method-b"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	mux := NewKeyedMux(func(serviceID, methodID string, md Metadata) string {
+		return md.Get("shard")
+	}, map[string]Mux{"a": muxA, "b": muxB})
+
+	invoke := func(shard, methodID string) (bool, error) {
+		ctx := withIncomingMetadata(context.Background(), Metadata{"shard": shard})
+		strm, remote := NewPipeStream(ctx)
+		defer strm.Close()
+		// the handler receives io.EOF
+		_ = remote.CloseSend()
+		return mux.InvokeMethod("svc", methodID, strm)
+	}
+	if handled, err := invoke("a", "method-a"); !handled || err != nil {
+		t.Fatalf("expected shard a to handle method-a but got %v, %v", handled, err)
+	}
+	if handled, err := invoke("a", "method-b"); handled || err != nil {
+		t.Fatalf("expected shard a not to find method-b but got %v, %v", handled, err)
+	}
+	if handled, err := invoke("c", "method-a"); !handled || StatusCodeOf(err) != StatusNotFound {
+		t.Fatalf("expected not found for unknown shard but got %v, %v", handled, err)
+	}
+
+	infos := MuxServices(mux)
+	if len(infos) != 1 || strings.Join(infos[0].MethodIDs, ",") != "method-a,method-b" {
+		t.Fatalf("unexpected services: %v", infos)
+	}
+
+	// registering fails if any of the muxes already has the service
+	if err := muxB.Register(&testHandler{serviceID: "only-b", methodIDs: []string{"method"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := mux.Register(&testHandler{serviceID: "only-b", methodIDs: []string{"method"}}); !errors.Is(err, ErrServiceAlreadyRegistered) {
+		t.Fatalf("expected ErrServiceAlreadyRegistered but got %v", err)
+	}
+	if muxA.HasService("only-b") {
+		t.Fatal("expected service to be unregistered after failing to register")
+	}
+	if err := mux.Register(&testHandler{serviceID: "other", methodIDs: []string{"method"}}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !muxA.HasService("other") || !muxB.HasService("other") {
+		t.Fatal("expected service to be registered with all muxes")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net"
+	"sync"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	ymuxer "github.com/libp2p/go-libp2p/p2p/muxer/yamux"
@@ -56,29 +57,110 @@ func NewMuxedConnWithRwc(
 // NewClientWithConn constructs the muxer and the client.
 //
 // if yamuxConf is nil, uses defaults.
-func NewClientWithConn(conn net.Conn, outbound bool, yamuxConf *yamux.Config) (Client, error) {
+func NewClientWithConn(conn net.Conn, outbound bool, yamuxConf *yamux.Config, opts ...ClientOption) (Client, error) {
 	mconn, err := NewMuxedConn(conn, outbound, yamuxConf)
 	if err != nil {
 		return nil, err
 	}
-	return NewClientWithMuxedConn(mconn), nil
+	return NewClientWithMuxedConn(mconn, opts...), nil
 }
 
 // NewClientWithMuxedConn constructs a new client with a MuxedConn.
-func NewClientWithMuxedConn(conn network.MuxedConn) Client {
-	openStreamFn := NewOpenStreamWithMuxedConn(conn)
-	return NewClient(openStreamFn)
+//
+// Closing the client closes the MuxedConn.
+func NewClientWithMuxedConn(conn network.MuxedConn, opts ...ClientOption) Client {
+	return NewClientWithTransport(NewTransport(conn), opts...)
 }
 
 // NewOpenStreamWithMuxedConn constructs a OpenStream func with a MuxedConn.
 func NewOpenStreamWithMuxedConn(conn network.MuxedConn) OpenStreamFunc {
-	return func(ctx context.Context, msgHandler PacketHandler, closeHandler CloseHandler) (Writer, error) {
-		mstrm, err := conn.OpenStream(ctx)
-		if err != nil {
-			return nil, err
-		}
-		rw := NewPacketReadWriter(mstrm)
-		go rw.ReadPump(msgHandler, closeHandler)
-		return rw, nil
+	return NewOpenStreamWithTransport(NewTransport(conn))
+}
+
+// streamAcceptor accepts streams from a MuxedConn with a Context.
+//
+// AcceptStream cannot be interrupted without closing the conn. The pending
+// AcceptStream call is kept across calls to accept so that streams accepted
+// after a context was canceled are returned by the next call to accept.
+type streamAcceptor struct {
+	mc streamAccepter
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// pending receives the result of the pending AcceptStream call.
+	pending chan acceptResult
+	// closed indicates close was called.
+	closed bool
+}
+
+// streamAccepter accepts streams opened by the remote.
+type streamAccepter interface {
+	// AcceptStream accepts a stream opened by the remote.
+	AcceptStream() (network.MuxedStream, error)
+}
+
+// acceptResult is the result of an AcceptStream call.
+type acceptResult struct {
+	strm network.MuxedStream
+	err  error
+}
+
+// newStreamAcceptor constructs a new streamAcceptor.
+func newStreamAcceptor(mc streamAccepter) *streamAcceptor {
+	return &streamAcceptor{mc: mc}
+}
+
+// accept accepts a stream until ctx is canceled.
+//
+// Must not be called concurrently.
+// Returns ctx.Err() if ctx is canceled before a stream is accepted.
+// Returns io.EOF if close was called.
+func (a *streamAcceptor) accept(ctx context.Context) (network.MuxedStream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a.mtx.Lock()
+	if a.closed {
+		a.mtx.Unlock()
+		return nil, io.EOF
+	}
+	pending := a.pending
+	if pending == nil {
+		pending = make(chan acceptResult, 1)
+		a.pending = pending
+		goTracked(func() {
+			strm, err := a.mc.AcceptStream()
+			pending <- acceptResult{strm: strm, err: err}
+		})
+	}
+	a.mtx.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-pending:
+		a.mtx.Lock()
+		a.pending = nil
+		a.mtx.Unlock()
+		return res.strm, res.err
+	}
+}
+
+// close resets any stream accepted by the pending AcceptStream call.
+func (a *streamAcceptor) close() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.closed {
+		return
+	}
+	a.closed = true
+	if pending := a.pending; pending != nil {
+		a.pending = nil
+		goTracked(func() {
+			if res := <-pending; res.err == nil && res.strm != nil {
+				_ = res.strm.Reset()
+			}
+		})
 	}
 }
@@ -0,0 +1,79 @@
+package srpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestStreamAcceptor tests canceling accept and accepting a stream after.
+func TestStreamAcceptor(t *testing.T) {
+	clientPipe, serverPipe := net.Pipe()
+	clientMc, err := NewMuxedConn(clientPipe, true, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer clientMc.Close()
+	serverMc, err := NewMuxedConn(serverPipe, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer serverMc.Close()
+
+	acceptor := newStreamAcceptor(serverMc)
+	defer acceptor.close()
+	ctx, ctxCancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer ctxCancel()
+	if _, err := acceptor.accept(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded but got %v", err)
+	}
+
+	// the stream is returned by the next call after the canceled call
+	ctx, ctxCancel = context.WithTimeout(context.Background(), time.Second*5)
+	defer ctxCancel()
+	strm, err := clientMc.OpenStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if _, err := strm.Write([]byte("hello")); err != nil {
+		t.Fatal(err.Error())
+	}
+	accepted, err := acceptor.accept(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer accepted.Close()
+}
+
+// TestMuxedConnDone tests observing the remote closing the conn.
+func TestMuxedConnDone(t *testing.T) {
+	clientPipe, serverPipe := net.Pipe()
+	clientMc, err := NewMuxedConn(clientPipe, true, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	serverMc, err := NewMuxedConn(serverPipe, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer serverMc.Close()
+
+	done := MuxedConnDone(serverMc)
+	if done == nil {
+		t.Fatal("expected done channel for yamux conn")
+	}
+	select {
+	case <-done:
+		t.Fatal("expected conn to be open")
+	default:
+	}
+
+	_ = clientMc.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected done channel to be closed")
+	}
+}
@@ -0,0 +1,153 @@
+package srpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PacingFunc returns the max rate of message bytes a call may send.
+//
+// ctx is the call context: use it to look up the tenant, for example with
+// MetadataFromContext or IdentityFromContext. burst is the max number of
+// bytes sent at once after the call was idle. Returns 0 for no limit.
+type PacingFunc func(ctx context.Context, serviceID, methodID string) (bytesPerSec, burst int)
+
+// PacingInvoker paces the messages sent by each call to a max rate of bytes.
+//
+// Use to prevent a single streaming call from saturating the link, for example
+// on multi-tenant streaming servers. See NewPacedStream.
+type PacingInvoker struct {
+	// inv is the underlying invoker
+	inv Invoker
+	// pacing returns the rate for a call
+	pacing PacingFunc
+}
+
+// NewPacingInvoker constructs a new PacingInvoker.
+func NewPacingInvoker(inv Invoker, pacing PacingFunc) *PacingInvoker {
+	return &PacingInvoker{inv: inv, pacing: pacing}
+}
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+// If service string is empty, ignore it.
+func (i *PacingInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	bytesPerSec, burst := i.pacing(strm.Context(), serviceID, methodID)
+	if bytesPerSec <= 0 {
+		return i.inv.InvokeMethod(serviceID, methodID, strm)
+	}
+	return i.inv.InvokeMethod(serviceID, methodID, NewPacedStream(strm, bytesPerSec, burst))
+}
+
+// pacedStream is a Stream which paces the sent messages with a token bucket.
+type pacedStream struct {
+	Stream
+	// rate is the number of bytes per second
+	rate float64
+	// burst is the max number of tokens
+	burst float64
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// tokens is the number of bytes which can be sent without waiting.
+	// negative if sending was reserved ahead of the rate.
+	tokens float64
+	// last is the time tokens was last updated
+	last time.Time
+}
+
+// NewPacedStream wraps a stream to pace the sent messages to bytesPerSec.
+//
+// Uses a token bucket of burst bytes, initially full: MsgSend waits until the
+// size of the message is available. Messages larger than burst are sent when
+// the bucket is full and delay subsequent messages. Waiting is interrupted
+// when the stream context is canceled: MsgSend returns ErrStreamClosed.
+// If burst is zero or negative, uses bytesPerSec. If bytesPerSec is zero or
+// negative, returns strm.
+func NewPacedStream(strm Stream, bytesPerSec, burst int) Stream {
+	if bytesPerSec <= 0 {
+		return strm
+	}
+	if burst <= 0 {
+		burst = bytesPerSec
+	}
+	return &pacedStream{
+		Stream: strm,
+		rate:   float64(bytesPerSec),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// MsgSend waits for the rate limit and sends the message to the remote.
+func (s *pacedStream) MsgSend(msg Message) error {
+	if err := s.wait(msg); err != nil {
+		return err
+	}
+	return s.Stream.MsgSend(msg)
+}
+
+// SendClose waits for the rate limit, sends the final message and signals the end of sending.
+func (s *pacedStream) SendClose(msg Message) error {
+	if err := s.wait(msg); err != nil {
+		return err
+	}
+	return SendClose(s.Stream, msg)
+}
+
+// PeekFirstMessage returns the raw first message sent with the call, if any.
+func (s *pacedStream) PeekFirstMessage() ([]byte, bool) {
+	return PeekFirstMessage(s.Stream)
+}
+
+// wait reserves the size of the message and waits until it can be sent.
+func (s *pacedStream) wait(msg Message) error {
+	size, err := messageSize(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	now := time.Now()
+	s.tokens = min(s.burst, s.tokens+now.Sub(s.last).Seconds()*s.rate)
+	s.last = now
+	// wait until the bucket is full for messages larger than burst
+	need := min(float64(size), s.burst)
+	var delay time.Duration
+	if s.tokens < need {
+		delay = time.Duration((need - s.tokens) / s.rate * float64(time.Second))
+	}
+	s.tokens -= float64(size)
+	s.mtx.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-s.Context().Done():
+		return ErrStreamClosed
+	case <-timer.C:
+		return nil
+	}
+}
+
+// messageSize returns the encoded size of the message.
+func messageSize(msg Message) (int, error) {
+	if sized, ok := msg.(interface{ SizeVT() int }); ok {
+		return sized.SizeVT(), nil
+	}
+	data, err := msg.MarshalVT()
+	return len(data), err
+}
+
+// _ is a type assertion
+var (
+	_ Invoker            = ((*PacingInvoker)(nil))
+	_ Stream             = ((*pacedStream)(nil))
+	_ SendCloser         = ((*pacedStream)(nil))
+	_ FirstMessagePeeker = ((*pacedStream)(nil))
+)
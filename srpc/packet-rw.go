@@ -13,6 +13,23 @@ import (
 // maxMessageSize is the max message size in bytes
 var maxMessageSize = 1e7
 
+// defaultReadBufferSize is the default size of the read buffer in bytes.
+const defaultReadBufferSize = 2048
+
+// maxWriteBufRetain is the max size of the write buffer retained between writes.
+const maxWriteBufRetain = 16 * 1024
+
+// ChunkReader is implemented by readers which receive data in chunks.
+//
+// PacketReaderWriter reads whole chunks from a ChunkReader instead of copying
+// the data through a fixed-size read buffer.
+type ChunkReader interface {
+	// ReadChunk returns the next chunk of data.
+	//
+	// The returned data is valid until the next call to ReadChunk or Read.
+	ReadChunk() ([]byte, error)
+}
+
 // PacketReaderWriter reads and writes packets from a io.ReadWriter.
 // Uses a LittleEndian uint32 length prefix.
 type PacketReaderWriter struct {
@@ -22,11 +39,76 @@ type PacketReaderWriter struct {
 	buf bytes.Buffer
 	// writeMtx is the write mutex
 	writeMtx sync.Mutex
+	// writeBuf is reused to encode outgoing packets.
+	// guarded by writeMtx
+	writeBuf []byte
+	// onPacketSent is called with a copy of each written packet.
+	onPacketSent PacketObserver
+	// onPacketRecv is called with a copy of each received packet.
+	onPacketRecv PacketObserver
+	// maxFrameSize is the maximum size of an incoming frame.
+	maxFrameSize uint32
+	// readBufferSize is the size of the read buffer.
+	readBufferSize int
+}
+
+// PacketReadWriterOption configures a PacketReaderWriter.
+type PacketReadWriterOption func(r *PacketReaderWriter)
+
+// WithMaxFrameSize sets the maximum size of an incoming frame in bytes.
+//
+// If the length prefix of an incoming frame is larger than size, the read pump
+// closes the stream with ErrFrameTooLarge without allocating the frame.
+// If zero, uses the default maximum of 10MB.
+func WithMaxFrameSize(size uint32) PacketReadWriterOption {
+	return func(r *PacketReaderWriter) {
+		r.maxFrameSize = size
+	}
+}
+
+// WithReadBufferSize sets the size of the read buffer in bytes.
+//
+// Data is read from the stream in reads of up to size bytes. The decode buffer
+// grows by up to size bytes at a time while a frame is incomplete: the buffer
+// is not grown to the length prefix before the data arrived. Larger sizes use
+// more memory per stream but need fewer reads and reallocations for large
+// messages. Not used if the stream implements ChunkReader. If zero or
+// negative, uses 2048 bytes.
+func WithReadBufferSize(size int) PacketReadWriterOption {
+	return func(r *PacketReaderWriter) {
+		r.readBufferSize = size
+	}
 }
 
 // NewPacketReadWriter constructs a new read/writer.
-func NewPacketReadWriter(rw io.ReadWriteCloser) *PacketReaderWriter {
-	return &PacketReaderWriter{rw: rw}
+func NewPacketReadWriter(rw io.ReadWriteCloser, opts ...PacketReadWriterOption) *PacketReaderWriter {
+	prw := &PacketReaderWriter{rw: rw}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(prw)
+		}
+	}
+	if prw.maxFrameSize == 0 {
+		prw.maxFrameSize = uint32(maxMessageSize)
+	}
+	if prw.readBufferSize <= 0 {
+		prw.readBufferSize = defaultReadBufferSize
+	}
+	return prw
+}
+
+// SetOnPacketSent sets a callback called with each packet written.
+//
+// Must be called before using the read/writer.
+func (r *PacketReaderWriter) SetOnPacketSent(cb PacketObserver) {
+	r.onPacketSent = cb
+}
+
+// SetOnPacketRecv sets a callback called with each packet received.
+//
+// Must be called before using the read/writer.
+func (r *PacketReaderWriter) SetOnPacketRecv(cb PacketObserver) {
+	r.onPacketRecv = cb
 }
 
 // WritePacket writes a packet to the writer.
@@ -35,7 +117,15 @@ func (r *PacketReaderWriter) WritePacket(p *Packet) error {
 	defer r.writeMtx.Unlock()
 
 	msgSize := p.SizeVT()
-	data := make([]byte, 4+msgSize)
+	data := r.writeBuf
+	if cap(data) < 4+msgSize {
+		data = make([]byte, 4+msgSize)
+	} else {
+		data = data[:4+msgSize]
+	}
+	if cap(data) <= maxWriteBufRetain {
+		r.writeBuf = data
+	}
 	binary.LittleEndian.PutUint32(data, uint32(msgSize))
 	_, err := p.MarshalToVT(data[4:])
 	if err != nil {
@@ -43,12 +133,15 @@ func (r *PacketReaderWriter) WritePacket(p *Packet) error {
 	}
 	var written, n int
 	for written < len(data) {
-		n, err = r.rw.Write(data)
+		n, err = r.rw.Write(data[written:])
 		if err != nil {
 			return err
 		}
 		written += n
 	}
+	if r.onPacketSent != nil {
+		r.onPacketSent(p.CloneVT())
+	}
 	return nil
 }
 
@@ -67,11 +160,24 @@ func (r *PacketReaderWriter) ReadPump(cb PacketHandler, closed CloseHandler) {
 // Does not handle closing the stream, use ReadPump instead.
 func (r *PacketReaderWriter) ReadToHandler(cb PacketHandler) error {
 	var currLen uint32
-	buf := make([]byte, 2048)
+	var buf []byte
+	chunkReader, _ := r.rw.(ChunkReader)
+	if chunkReader == nil {
+		buf = make([]byte, r.readBufferSize)
+		r.buf.Grow(r.readBufferSize)
+	}
 	isOpen := true
 	for isOpen {
 		// read some data into the buffer
-		n, err := r.rw.Read(buf)
+		var data []byte
+		var err error
+		if chunkReader != nil {
+			data, err = chunkReader.ReadChunk()
+		} else {
+			var n int
+			n, err = r.rw.Read(buf)
+			data = buf[:n]
+		}
 		if err != nil {
 			if err == io.EOF || err == context.Canceled {
 				isOpen = false
@@ -81,36 +187,51 @@ func (r *PacketReaderWriter) ReadToHandler(cb PacketHandler) error {
 		}
 
 		// push the data to r.buf
-		_, err = r.buf.Write(buf[:n])
+		_, err = r.buf.Write(data)
 		if err != nil {
 			return err
 		}
 
-		// check if we have enough data for a length prefix
-		bufLen := r.buf.Len()
-		if bufLen < 4 {
-			continue
-		}
+		// emit all fully buffered packets
+		for {
+			// check if we have enough data for a length prefix
+			bufLen := r.buf.Len()
+			if bufLen < 4 {
+				break
+			}
 
-		// parse the length prefix if not done already
-		if currLen == 0 {
-			currLen = r.readLengthPrefix(r.buf.Bytes()[:4])
+			// parse the length prefix if not done already
 			if currLen == 0 {
-				return errors.New("unexpected zero len prefix")
+				currLen = r.readLengthPrefix(r.buf.Bytes()[:4])
+				if currLen == 0 {
+					return errors.New("unexpected zero len prefix")
+				}
+				if currLen > r.maxFrameSize {
+					_ = r.rw.Close()
+					return errors.Wrapf(ErrFrameTooLarge, "frame size %v greater than maximum %v", currLen, r.maxFrameSize)
+				}
 			}
-			if currLen > uint32(maxMessageSize) {
-				return errors.Errorf("message size %v greater than maximum %v", currLen, maxMessageSize)
+
+			// wait for more data if the packet is not fully buffered
+			if bufLen < int(currLen)+4 {
+				// make room for the next read: the buffer grows with the data
+				// received, not with the length claimed by the remote.
+				if chunkReader == nil {
+					r.buf.Grow(min(int(currLen)+4-bufLen, r.readBufferSize))
+				}
+				break
 			}
-		}
 
-		// emit the packet if fully buffered
-		if currLen != 0 && bufLen >= int(currLen)+4 {
+			// emit the packet
 			pkt := r.buf.Next(int(currLen + 4))[4:]
 			currLen = 0
 			npkt := &Packet{}
 			if err := npkt.UnmarshalVT(pkt); err != nil {
 				return err
 			}
+			if r.onPacketRecv != nil {
+				r.onPacketRecv(npkt.CloneVT())
+			}
 			if err := cb(npkt); err != nil {
 				return err
 			}
@@ -121,6 +242,18 @@ func (r *PacketReaderWriter) ReadToHandler(cb PacketHandler) error {
 	return nil
 }
 
+// StreamID returns the multiplexing stream ID of the underlying stream.
+//
+// Returns 0 if unknown.
+func (r *PacketReaderWriter) StreamID() uint64 {
+	return streamIDOf(r.rw)
+}
+
+// Flush flushes the underlying stream if it buffers writes.
+func (r *PacketReaderWriter) Flush(ctx context.Context) error {
+	return flushWriter(ctx, r.rw)
+}
+
 // Close closes the packet rw.
 func (r *PacketReaderWriter) Close() error {
 	return r.rw.Close()
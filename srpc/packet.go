@@ -1,5 +1,7 @@
 package srpc
 
+import "github.com/pkg/errors"
+
 // PacketHandler handles a packet.
 //
 // pkt is optional (can be nil)
@@ -9,6 +11,11 @@ type PacketHandler = func(pkt *Packet) error
 // CloseHandler handles the stream closing with an optional error.
 type CloseHandler = func(closeErr error)
 
+// PacketObserver observes a packet sent or received.
+//
+// pkt is a copy and may be retained.
+type PacketObserver = func(pkt *Packet)
+
 // Validate performs cursory validation of the packet.
 func (p *Packet) Validate() error {
 	switch b := p.GetBody().(type) {
@@ -17,7 +24,36 @@ func (p *Packet) Validate() error {
 	case *Packet_CallData:
 		return b.CallData.Validate()
 	case *Packet_CallCancel:
+		if !b.CallCancel {
+			return ErrEmptyPacket
+		}
+		return nil
+	case *Packet_CallStartResp:
+		return b.CallStartResp.Validate()
+	case *Packet_CallCancelReason:
+		if len(b.CallCancelReason) == 0 {
+			return ErrEmptyPacket
+		}
+		return nil
+	case *Packet_CallDemand:
+		if b.CallDemand == 0 {
+			return ErrEmptyPacket
+		}
+		return nil
+	case *Packet_CapabilitiesRequest:
+		if !b.CapabilitiesRequest {
+			return ErrEmptyPacket
+		}
+		return nil
+	case *Packet_AttachmentKey:
+		if len(b.AttachmentKey) != attachmentKeyLen {
+			return errors.Wrap(ErrInvalidPacket, "invalid attachment key")
+		}
+		return nil
+	case *Packet_Ping, *Packet_Pong, *Packet_Capabilities:
 		return nil
+	case nil:
+		return ErrEmptyPacket
 	default:
 		return ErrUnrecognizedPacket
 	}
@@ -37,6 +73,12 @@ func NewCallStartPacket(service, method string, data []byte, dataIsZero bool) *P
 
 // Validate performs cursory validation of the packet.
 func (p *CallStart) Validate() error {
+	if att := p.GetAttachment(); att != nil {
+		if len(p.GetRpcService()) != 0 || len(p.GetRpcMethod()) != 0 {
+			return errors.Wrap(ErrInvalidPacket, "attachment stream cannot invoke a method")
+		}
+		return att.Validate()
+	}
 	method := p.GetRpcMethod()
 	if len(method) == 0 {
 		return ErrEmptyMethodID
@@ -45,6 +87,26 @@ func (p *CallStart) Validate() error {
 	if len(service) == 0 {
 		return ErrEmptyServiceID
 	}
+	if p.GetDataIsZero() && len(p.GetData()) != 0 {
+		return errors.Wrap(ErrInvalidPacket, "data is zero but data was set")
+	}
+	if len(p.GetExtraData()) != 0 && len(p.GetData()) == 0 && !p.GetDataIsZero() {
+		return errors.Wrap(ErrInvalidPacket, "extra data set without data")
+	}
+	return nil
+}
+
+// NewCallStartRespPacket constructs a new CallStartResp packet with the selected codec.
+func NewCallStartRespPacket(codec string) *Packet {
+	return &Packet{Body: &Packet_CallStartResp{
+		CallStartResp: &CallStartResp{Codec: codec},
+	}}
+}
+
+// Validate performs cursory validation of the packet.
+//
+// The codec is empty if the packet acknowledges a call which did not offer codecs.
+func (p *CallStartResp) Validate() error {
 	return nil
 }
 
@@ -64,15 +126,103 @@ func NewCallDataPacket(data []byte, dataIsZero bool, complete bool, err error) *
 	}}
 }
 
+// NewCallDataFragmentPacket constructs a new CallData packet with a message fragment.
+func NewCallDataFragmentPacket(data []byte) *Packet {
+	return &Packet{Body: &Packet_CallData{
+		CallData: &CallData{Data: data, Fragment: true},
+	}}
+}
+
+// NewCallDataHeartbeatPacket constructs a new heartbeat CallData packet.
+func NewCallDataHeartbeatPacket() *Packet {
+	return &Packet{Body: &Packet_CallData{
+		CallData: &CallData{Heartbeat: true},
+	}}
+}
+
+// NewCallDataProgressPacket constructs a new CallData packet with a progress update.
+func NewCallDataProgressPacket(progress []byte) *Packet {
+	return &Packet{Body: &Packet_CallData{
+		CallData: &CallData{Data: progress, Progress: true},
+	}}
+}
+
+// NewCallDataDebugLogPacket constructs a new CallData packet with an encoded DebugLogEntry.
+func NewCallDataDebugLogPacket(entry []byte) *Packet {
+	return &Packet{Body: &Packet_CallData{
+		CallData: &CallData{Data: entry, DebugLog: true},
+	}}
+}
+
 // NewCallCancelPacket constructs a new CallCancel packet with cancel.
 func NewCallCancelPacket() *Packet {
 	return &Packet{Body: &Packet_CallCancel{CallCancel: true}}
 }
 
+// NewCallCancelReasonPacket constructs a new CallCancelReason packet with the reason.
+func NewCallCancelReasonPacket(reason string) *Packet {
+	return &Packet{Body: &Packet_CallCancelReason{CallCancelReason: reason}}
+}
+
+// NewPingPacket constructs a new Ping packet with a value.
+func NewPingPacket(value uint64) *Packet {
+	return &Packet{Body: &Packet_Ping{Ping: value}}
+}
+
+// NewPongPacket constructs a new Pong packet answering a Ping with value.
+func NewPongPacket(value uint64) *Packet {
+	return &Packet{Body: &Packet_Pong{Pong: value}}
+}
+
 // Validate performs cursory validation of the packet.
 func (p *CallData) Validate() error {
+	if p.GetHeartbeat() {
+		if len(p.GetData()) != 0 || p.GetDataIsZero() || p.GetComplete() || len(p.GetError()) != 0 {
+			return errors.Wrap(ErrInvalidPacket, "heartbeat cannot contain data")
+		}
+		return nil
+	}
+	if p.GetProgress() {
+		if p.GetDataIsZero() || p.GetComplete() || len(p.GetError()) != 0 || p.GetFragment() {
+			return errors.Wrap(ErrInvalidPacket, "progress must contain only data")
+		}
+		return nil
+	}
+	if p.GetDebugLog() {
+		if p.GetDataIsZero() || p.GetComplete() || len(p.GetError()) != 0 || p.GetFragment() {
+			return errors.Wrap(ErrInvalidPacket, "debug log must contain only data")
+		}
+		return nil
+	}
+	if p.GetFragment() {
+		if len(p.GetData()) == 0 || p.GetDataIsZero() || p.GetComplete() || len(p.GetError()) != 0 {
+			return errors.Wrap(ErrInvalidPacket, "fragment must contain only data")
+		}
+		return nil
+	}
 	if len(p.GetData()) == 0 && !p.GetComplete() && len(p.GetError()) == 0 && !p.GetDataIsZero() {
 		return ErrEmptyPacket
 	}
+	if p.GetDataIsZero() && len(p.GetData()) != 0 {
+		return errors.Wrap(ErrInvalidPacket, "data is zero but data was set")
+	}
+	if len(p.GetError()) != 0 && (len(p.GetData()) != 0 || p.GetDataIsZero()) {
+		return errors.Wrap(ErrInvalidPacket, "data and error cannot both be set")
+	}
 	return nil
 }
+
+// NewCallDemandPacket constructs a new CallDemand packet requesting n messages.
+func NewCallDemandPacket(n uint32) *Packet {
+	return &Packet{Body: &Packet_CallDemand{CallDemand: n}}
+}
+
+// NewCapabilitiesRequestPacket constructs a new CapabilitiesRequest packet.
+func NewCapabilitiesRequestPacket() *Packet {
+	return &Packet{Body: &Packet_CapabilitiesRequest{CapabilitiesRequest: true}}
+}
+
+// NewCapabilitiesPacket constructs a new Capabilities packet answering a CapabilitiesRequest.
+func NewCapabilitiesPacket(caps *Capabilities) *Packet {
+	return &Packet{Body: &Packet_Capabilities{Capabilities: caps}}
+}
@@ -0,0 +1,247 @@
+package srpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// discardWriter is a Writer which discards all packets.
+type discardWriter struct{}
+
+// WritePacket writes a packet to the remote.
+func (discardWriter) WritePacket(p *Packet) error { return nil }
+
+// Close closes the writer.
+func (discardWriter) Close() error { return nil }
+
+// drainInvoker reads all messages from the stream and returns.
+type drainInvoker struct{}
+
+// InvokeMethod invokes the method matching the service & method ID.
+func (drainInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	return true, RecvAll(strm, func(msg *RawMessage) error { return nil })
+}
+
+// nopReadWriteCloser wraps a io.Reader into a io.ReadWriteCloser.
+type nopReadWriteCloser struct {
+	io.Reader
+}
+
+// Write discards the data.
+func (nopReadWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+
+// Close does nothing.
+func (nopReadWriteCloser) Close() error { return nil }
+
+// FuzzServerRPC_HandlePacket feeds random data to the packet decoder and ServerRPC.
+func FuzzServerRPC_HandlePacket(f *testing.F) {
+	seeds := []*Packet{
+		NewCallStartPacket("test-service", "test-method", []byte("hello"), false),
+		NewCallStartPacket("test-service", "test-method", nil, true),
+		NewCallDataPacket([]byte("world"), false, false, nil),
+		NewCallDataPacket(nil, true, false, nil),
+		NewCallDataPacket(nil, false, true, nil),
+		NewCallDataPacket(nil, false, true, ErrUnimplemented),
+		NewCallCancelPacket(),
+	}
+	for _, seed := range seeds {
+		data, err := seed.MarshalVT()
+		if err != nil {
+			f.Fatal(err.Error())
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx, ctxCancel := context.WithCancel(context.Background())
+		defer ctxCancel()
+
+		serverRPC := NewServerRPC(ctx, drainInvoker{}, discardWriter{})
+		pkt := &Packet{}
+		if err := pkt.UnmarshalVT(data); err != nil {
+			return
+		}
+		_ = serverRPC.HandlePacket(pkt)
+		serverRPC.HandleStreamClose(nil)
+	})
+}
+
+// FuzzPacketReadWriter_ReadToHandler feeds random framed data to the read pump.
+func FuzzPacketReadWriter_ReadToHandler(f *testing.F) {
+	pkt := NewCallStartPacket("test-service", "test-method", []byte("hello"), false)
+	pktData, err := pkt.MarshalVT()
+	if err != nil {
+		f.Fatal(err.Error())
+	}
+	framed := make([]byte, 4, 4+len(pktData))
+	binary.LittleEndian.PutUint32(framed, uint32(len(pktData)))
+	framed = append(framed, pktData...)
+	f.Add(framed)
+	f.Add(append(append([]byte{}, framed...), framed...))
+	f.Add([]byte{0, 0, 0, 0})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ctx, ctxCancel := context.WithCancel(context.Background())
+		defer ctxCancel()
+
+		serverRPC := NewServerRPC(ctx, drainInvoker{}, discardWriter{})
+		prw := NewPacketReadWriter(nopReadWriteCloser{Reader: bytes.NewReader(data)})
+		prw.ReadPump(serverRPC.HandlePacket, serverRPC.HandleStreamClose)
+	})
+}
+
+// closeTrackingRwc wraps a io.Reader and records if Close was called.
+type closeTrackingRwc struct {
+	nopReadWriteCloser
+	closed bool
+}
+
+// Close records that the stream was closed.
+func (c *closeTrackingRwc) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestPacketReadWriter_MaxFrameSize tests rejecting an oversized length prefix.
+func TestPacketReadWriter_MaxFrameSize(t *testing.T) {
+	data := make([]byte, 4, 8)
+	binary.LittleEndian.PutUint32(data, 0xfffffff0)
+	data = append(data, 1, 2, 3, 4)
+
+	rwc := &closeTrackingRwc{nopReadWriteCloser: nopReadWriteCloser{Reader: bytes.NewReader(data)}}
+	prw := NewPacketReadWriter(rwc, WithMaxFrameSize(1024))
+	err := prw.ReadToHandler(func(pkt *Packet) error {
+		t.Fatal("unexpected packet")
+		return nil
+	})
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge but got %v", err)
+	}
+	if !rwc.closed {
+		t.Fatal("expected stream to be closed")
+	}
+}
+
+// TestPacketReadWriter_LengthPrefixAlloc tests that a large length prefix does not allocate the frame.
+func TestPacketReadWriter_LengthPrefixAlloc(t *testing.T) {
+	data := make([]byte, 4, 8)
+	binary.LittleEndian.PutUint32(data, 1<<20)
+	data = append(data, 1, 2, 3, 4)
+
+	prw := NewPacketReadWriter(nopReadWriteCloser{Reader: bytes.NewReader(data)}, WithMaxFrameSize(1<<21))
+	if err := prw.ReadToHandler(func(pkt *Packet) error {
+		t.Fatal("unexpected packet")
+		return nil
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if c := prw.buf.Cap(); c >= 1<<20 {
+		t.Fatalf("expected the buffer to grow with the received data but has cap %d", c)
+	}
+}
+
+// TestServer_CallDataBeforeStart tests receiving call data before the call start.
+func TestServer_CallDataBeforeStart(t *testing.T) {
+	srvConn, clientConn := net.Pipe()
+	doneCh := make(chan struct{})
+	go func() {
+		NewServer(NewMux()).HandleStream(context.Background(), srvConn)
+		close(doneCh)
+	}()
+
+	prw := NewPacketReadWriter(clientConn)
+	if err := prw.WritePacket(NewCallDataPacket([]byte("hello"), false, false, nil)); err != nil {
+		t.Fatal(err.Error())
+	}
+	var remoteErr string
+	_ = prw.ReadToHandler(func(pkt *Packet) error {
+		remoteErr = pkt.GetCallData().GetError()
+		return nil
+	})
+	if remoteErr != ErrCallNotStarted.Error() {
+		t.Fatalf("expected ErrCallNotStarted but got %q", remoteErr)
+	}
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the server to close the stream")
+	}
+}
+
+// TestServerRPC_UnknownPacketPolicy tests ignoring or rejecting unknown packets.
+func TestServerRPC_UnknownPacketPolicy(t *testing.T) {
+	// packet with field 99 which is not known to this version
+	unknownPkt := &Packet{}
+	if err := unknownPkt.UnmarshalVT([]byte{0x98, 0x06, 0x01}); err != nil {
+		t.Fatal(err.Error())
+	}
+	pkts := []*Packet{unknownPkt, {Body: &Packet_Pong{Pong: 1}}}
+
+	for _, pkt := range pkts {
+		ctx, ctxCancel := context.WithCancel(context.Background())
+		tolerant := NewServerRPC(ctx, drainInvoker{}, discardWriter{})
+		if err := tolerant.HandlePacket(pkt); err != nil {
+			t.Fatalf("expected packet to be ignored but got %v", err)
+		}
+		strict := NewServerRPC(ctx, drainInvoker{}, discardWriter{}, WithUnknownPacketPolicy(UnknownPacketError))
+		if err := strict.HandlePacket(pkt); !errors.Is(err, ErrUnrecognizedPacket) {
+			t.Fatalf("expected ErrUnrecognizedPacket but got %v", err)
+		}
+		ctxCancel()
+	}
+
+	// a packet without a body is rejected by either policy
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	tolerant := NewServerRPC(ctx, drainInvoker{}, discardWriter{})
+	if err := tolerant.HandlePacket(&Packet{}); !errors.Is(err, ErrEmptyPacket) {
+		t.Fatalf("expected ErrEmptyPacket but got %v", err)
+	}
+}
+
+// BenchmarkPacketReadWriter_ReadLarge benchmarks reading large packets with different read buffer sizes.
+func BenchmarkPacketReadWriter_ReadLarge(b *testing.B) {
+	const count, msgSize = 16, 256 * 1024
+	var framed []byte
+	pkt := NewCallDataPacket(make([]byte, msgSize), false, false, nil)
+	for i := 0; i < count; i++ {
+		data, err := pkt.MarshalVT()
+		if err != nil {
+			b.Fatal(err.Error())
+		}
+		framed = binary.LittleEndian.AppendUint32(framed, uint32(len(data)))
+		framed = append(framed, data...)
+	}
+
+	for _, size := range []int{0, 16 * 1024, 256 * 1024} {
+		b.Run(fmt.Sprintf("ReadBufferSize=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(framed)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rwc := nopReadWriteCloser{Reader: bytes.NewReader(framed)}
+				prw := NewPacketReadWriter(rwc, WithReadBufferSize(size))
+				var n int
+				if err := prw.ReadToHandler(func(pkt *Packet) error {
+					n++
+					return nil
+				}); err != nil {
+					b.Fatal(err.Error())
+				}
+				if n != count {
+					b.Fatalf("expected %d packets but got %d", count, n)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package srpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// peerCertsCtxKey is the context key for the verified peer certificate chain.
+type peerCertsCtxKey struct{}
+
+// withPeerCerts attaches the verified peer certificate chain from the TLS state.
+//
+// Does nothing if state is nil or the peer certificate was not verified.
+func withPeerCerts(ctx context.Context, state *tls.ConnectionState) context.Context {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, peerCertsCtxKey{}, state.VerifiedChains[0])
+}
+
+// PeerCertFromContext returns the verified client certificate of the connection.
+//
+// Set by HTTPServer and H2CHandler if the client presented a certificate which
+// was verified by the TLS server (mTLS). Returns nil for non-TLS transports or
+// if the client certificate was not verified.
+func PeerCertFromContext(ctx context.Context) *x509.Certificate {
+	chain := PeerCertChainFromContext(ctx)
+	if len(chain) == 0 {
+		return nil
+	}
+	return chain[0]
+}
+
+// PeerCertChainFromContext returns the verified client certificate chain.
+//
+// The first element is the client certificate. Returns nil if not set.
+// The returned value must not be modified.
+func PeerCertChainFromContext(ctx context.Context) []*x509.Certificate {
+	chain, _ := ctx.Value(peerCertsCtxKey{}).([]*x509.Certificate)
+	return chain
+}
@@ -0,0 +1,140 @@
+package srpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// peerCertHandler is a Handler which sends the peer certificate of each call to certs.
+type peerCertHandler struct {
+	testHandler
+	certs chan *x509.Certificate
+}
+
+// InvokeMethod sends the peer certificate from the stream context to certs.
+func (h *peerCertHandler) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	h.certs <- PeerCertFromContext(strm.Context())
+	return true, nil
+}
+
+// callPeerCert calls the HTTP server over a WebSocket and returns the peer
+// certificate seen by the handler.
+func callPeerCert(t *testing.T, url string, httpClient *http.Client, certs <-chan *x509.Certificate) *x509.Certificate {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(url, "http"), &websocket.DialOptions{HTTPClient: httpClient})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	mc, err := NewWebSocketConn(ctx, conn, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	client := NewClientWithMuxedConn(mc)
+	defer func() { _ = CloseClient(client) }()
+
+	strm, err := client.NewStream(ctx, "test-service", "test-method", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if err := strm.CloseSend(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.MsgRecv(NewRawMessage(nil, false)); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+	return <-certs
+}
+
+// newPeerCertServer starts a HTTP server with a handler reporting the peer certificates.
+func newPeerCertServer(t *testing.T) (*httptest.Server, <-chan *x509.Certificate) {
+	handler := &peerCertHandler{
+		testHandler: testHandler{serviceID: "test-service", methodIDs: []string{"test-method"}},
+		certs:       make(chan *x509.Certificate, 1),
+	}
+	mux := NewMux()
+	if err := mux.Register(handler); err != nil {
+		t.Fatal(err.Error())
+	}
+	srv, err := NewHTTPServer(mux, "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return httptest.NewUnstartedServer(srv), handler.certs
+}
+
+// newClientCert generates a self-signed client certificate.
+func newClientCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// TestPeerCertFromContext_NoTLS tests the peer certificate is not set without TLS.
+func TestPeerCertFromContext_NoTLS(t *testing.T) {
+	hsrv, certs := newPeerCertServer(t)
+	hsrv.Start()
+	defer hsrv.Close()
+
+	if cert := callPeerCert(t, hsrv.URL, hsrv.Client(), certs); cert != nil {
+		t.Fatalf("expected no peer certificate but got %v", cert.Subject)
+	}
+}
+
+// TestPeerCertFromContext_TLS tests the verified client certificate is exposed with mTLS.
+func TestPeerCertFromContext_TLS(t *testing.T) {
+	clientCert := newClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.Leaf)
+
+	hsrv, certs := newPeerCertServer(t)
+	hsrv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	hsrv.StartTLS()
+	defer hsrv.Close()
+
+	httpClient := hsrv.Client()
+	httpClient.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+
+	cert := callPeerCert(t, hsrv.URL, httpClient, certs)
+	if cert == nil {
+		t.Fatal("expected the client certificate")
+	}
+	if !cert.Equal(clientCert.Leaf) {
+		t.Fatalf("expected the client certificate but got %v", cert.Subject)
+	}
+}
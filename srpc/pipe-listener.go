@@ -0,0 +1,90 @@
+package srpc
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// pipeAddr is the address of a PipeListener.
+type pipeAddr struct{}
+
+// Network returns the name of the network.
+func (pipeAddr) Network() string { return "pipe" }
+
+// String returns the string form of the address.
+func (pipeAddr) String() string { return "pipe" }
+
+// PipeListener is an in-memory net.Listener which is also a Dialer.
+//
+// Each Dial creates a net.Pipe and returns one end: the other end is returned
+// by Accept. Use with Server.Serve and NewDialerClient to test without sockets.
+type PipeListener struct {
+	// conns contains the connections waiting to be accepted.
+	conns chan net.Conn
+	// closeOnce guards closing closed.
+	closeOnce sync.Once
+	// closed is closed when the listener is closed.
+	closed chan struct{}
+}
+
+// NewPipeListener constructs a new PipeListener.
+func NewPipeListener() *PipeListener {
+	return &PipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// Dial creates a new connection which is returned by Accept.
+//
+// Blocks until the connection is accepted.
+// Returns net.ErrClosed if the listener is closed.
+func (l *PipeListener) Dial(ctx context.Context) (net.Conn, error) {
+	srvConn, clientConn := net.Pipe()
+	var err error
+	select {
+	case l.conns <- srvConn:
+		return clientConn, nil
+	case <-ctx.Done():
+		err = context.Canceled
+	case <-l.closed:
+		err = net.ErrClosed
+	}
+	_ = srvConn.Close()
+	_ = clientConn.Close()
+	return nil, err
+}
+
+// Accept waits for and returns the next dialed connection.
+//
+// Returns net.ErrClosed if the listener is closed.
+func (l *PipeListener) Accept() (net.Conn, error) {
+	select {
+	case <-l.closed:
+		return nil, net.ErrClosed
+	case conn := <-l.conns:
+		return conn, nil
+	}
+}
+
+// Close closes the listener.
+//
+// Connections which were already accepted are not closed.
+func (l *PipeListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+// Addr returns the listener's network address.
+func (l *PipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// _ is a type assertion
+var (
+	_ net.Listener = ((*PipeListener)(nil))
+	_ Dialer       = ((*PipeListener)(nil))
+)
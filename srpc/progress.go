@@ -0,0 +1,50 @@
+package srpc
+
+import "context"
+
+// ProgressHandler handles an interim progress update sent by the call handler.
+//
+// Called in order with the messages of the call.
+// Must not block: the messages of the call are not received until it returns.
+type ProgressHandler = func(progress []byte)
+
+// progressHandlerCtxKey is the context key for the progress handler.
+type progressHandlerCtxKey struct{}
+
+// WithProgressHandler attaches a progress handler for calls started with ctx.
+//
+// The handler is called with each progress update sent by the call handler
+// with EmitProgress. If not set, progress updates are ignored.
+func WithProgressHandler(ctx context.Context, handler ProgressHandler) context.Context {
+	return context.WithValue(ctx, progressHandlerCtxKey{}, handler)
+}
+
+// progressHandlerFromContext returns the progress handler attached to the context.
+func progressHandlerFromContext(ctx context.Context) ProgressHandler {
+	handler, _ := ctx.Value(progressHandlerCtxKey{}).(ProgressHandler)
+	return handler
+}
+
+// progressWriterCtxKey is the context key for the progress writer of a call.
+type progressWriterCtxKey struct{}
+
+// withProgressWriter attaches the rpc to write progress updates to.
+func withProgressWriter(ctx context.Context, rpc *commonRPC) context.Context {
+	return context.WithValue(ctx, progressWriterCtxKey{}, rpc)
+}
+
+// EmitProgress sends an interim progress update to the caller.
+//
+// ctx must be the context of the call passed to the handler. The update is
+// received by the progress handler of the caller before the response. Callers
+// without a progress handler ignore the update.
+//
+// Returns ErrUnimplemented if ctx is not the context of a call.
+// Returns ErrCompleted if the call already completed.
+func EmitProgress(ctx context.Context, progress []byte) error {
+	rpc, ok := ctx.Value(progressWriterCtxKey{}).(*commonRPC)
+	if !ok {
+		return ErrUnimplemented
+	}
+	return rpc.WriteProgress(progress)
+}
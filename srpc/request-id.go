@@ -0,0 +1,119 @@
+package srpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// RequestIDMetadataKey is the metadata key containing the request ID.
+const RequestIDMetadataKey = "x-request-id"
+
+// requestIDCtxKey is the context key for the request id.
+type requestIDCtxKey struct{}
+
+// WithRequestID attaches a request ID to the context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID attached to the context.
+//
+// Returns an empty string if not set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// IDGenerator generates a new unique ID.
+type IDGenerator = func() string
+
+// RequestIDOption configures a RequestIDInvoker.
+type RequestIDOption func(inv *RequestIDInvoker)
+
+// WithIDGenerator sets the generator for request IDs.
+//
+// For example NewRequestIDv7 generates time-sortable IDs.
+// Defaults to NewRequestID.
+func WithIDGenerator(gen IDGenerator) RequestIDOption {
+	return func(inv *RequestIDInvoker) {
+		inv.gen = gen
+	}
+}
+
+// RequestIDInvoker attaches a request ID to the context of each call.
+//
+// Uses the x-request-id from the call metadata if set.
+// Otherwise generates a new ID, by default a random UUID.
+type RequestIDInvoker struct {
+	// inv is the underlying invoker
+	inv Invoker
+	// gen generates new request IDs
+	gen IDGenerator
+}
+
+// NewRequestIDInvoker constructs a new RequestIDInvoker.
+func NewRequestIDInvoker(inv Invoker, opts ...RequestIDOption) *RequestIDInvoker {
+	i := &RequestIDInvoker{inv: inv}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(i)
+		}
+	}
+	if i.gen == nil {
+		i.gen = NewRequestID
+	}
+	return i
+}
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+// If service string is empty, ignore it.
+func (i *RequestIDInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	ctx := strm.Context()
+	id := MetadataFromContext(ctx).Get(RequestIDMetadataKey)
+	if id == "" {
+		id = i.gen()
+	}
+	return i.inv.InvokeMethod(serviceID, methodID, newStreamWithContext(strm, WithRequestID(ctx, id)))
+}
+
+// NewRequestID generates a new random version 4 UUID.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return formatUUID(b, 4)
+}
+
+// NewRequestIDv7 generates a new time-sortable version 7 UUID.
+//
+// The first 48 bits are the Unix time in milliseconds, the rest is random.
+func NewRequestIDv7() string {
+	var b [16]byte
+	_, _ = rand.Read(b[6:])
+	binary.BigEndian.PutUint64(b[0:8], uint64(time.Now().UnixMilli())<<16|uint64(binary.BigEndian.Uint16(b[6:8])))
+	return formatUUID(b, 7)
+}
+
+// formatUUID sets the version and variant bits and formats the UUID.
+func formatUUID(b [16]byte, version byte) string {
+	b[6] = (b[6] & 0x0f) | version<<4
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+// _ is a type assertion
+var _ Invoker = ((*RequestIDInvoker)(nil))
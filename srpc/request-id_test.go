@@ -0,0 +1,103 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uuidv4Pattern matches a version 4 UUID.
+var uuidv4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// uuidv7Pattern matches a version 7 UUID.
+var uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// callRequestID calls a RequestIDInvoker and returns the request ID seen by the handler.
+func callRequestID(t *testing.T, ctx context.Context, opts ...RequestIDOption) string {
+	ids := make(chan string, 1)
+	inv := InvokerFunc(func(serviceID, methodID string, strm Stream) (bool, error) {
+		ids <- RequestIDFromContext(strm.Context())
+		return true, nil
+	})
+	server := NewServer(NewRequestIDInvoker(inv, opts...))
+	client := NewClient(NewServerPipe(server))
+
+	strm, err := client.NewStream(ctx, "test-service", "test-method", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if err := strm.CloseSend(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.MsgRecv(NewRawMessage(nil, false)); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+	return <-ids
+}
+
+// TestRequestIDInvoker tests attaching the request ID to the handler context.
+func TestRequestIDInvoker(t *testing.T) {
+	ctx := WithOutgoingMetadata(context.Background(), Metadata{RequestIDMetadataKey: "client-request-id"})
+	if id := callRequestID(t, ctx); id != "client-request-id" {
+		t.Fatalf("expected request ID from metadata but got %q", id)
+	}
+
+	id := callRequestID(t, context.Background())
+	if !uuidv4Pattern.MatchString(id) {
+		t.Fatalf("expected generated UUIDv4 but got %q", id)
+	}
+	if other := callRequestID(t, context.Background()); other == id {
+		t.Fatalf("expected unique request IDs but got %q twice", id)
+	}
+}
+
+// TestNewRequestIDv7 tests the format and ordering of version 7 UUIDs.
+func TestNewRequestIDv7(t *testing.T) {
+	before := time.Now().UnixMilli()
+	id := NewRequestIDv7()
+	after := time.Now().UnixMilli()
+	if !uuidv7Pattern.MatchString(id) {
+		t.Fatalf("expected UUIDv7 but got %q", id)
+	}
+	ts, err := strconv.ParseInt(strings.ReplaceAll(id[:13], "-", ""), 16, 64)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ts < before || ts > after {
+		t.Fatalf("expected timestamp between %d and %d but got %d", before, after, ts)
+	}
+
+	// IDs generated in later milliseconds sort after earlier IDs
+	prev := id
+	for i := 0; i < 3; i++ {
+		<-time.After(time.Millisecond * 2)
+		next := NewRequestIDv7()
+		if next <= prev {
+			t.Fatalf("expected %q to sort after %q", next, prev)
+		}
+		prev = next
+	}
+}
+
+// TestWithIDGenerator tests overriding the request ID generator.
+func TestWithIDGenerator(t *testing.T) {
+	gen := WithIDGenerator(func() string { return "generated-id" })
+	if id := callRequestID(t, context.Background(), gen); id != "generated-id" {
+		t.Fatalf("expected ID from generator but got %q", id)
+	}
+
+	// the metadata takes precedence over the generator
+	ctx := WithOutgoingMetadata(context.Background(), Metadata{RequestIDMetadataKey: "client-request-id"})
+	if id := callRequestID(t, ctx, gen); id != "client-request-id" {
+		t.Fatalf("expected request ID from metadata but got %q", id)
+	}
+
+	if id := callRequestID(t, context.Background(), WithIDGenerator(NewRequestIDv7)); !uuidv7Pattern.MatchString(id) {
+		t.Fatalf("expected generated UUIDv7 but got %q", id)
+	}
+}
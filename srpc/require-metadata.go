@@ -0,0 +1,66 @@
+package srpc
+
+import (
+	"sort"
+	"strings"
+)
+
+// RequireMetadataInvoker rejects calls missing required metadata keys.
+//
+// Calls missing any of the required keys fail with a StatusInvalidArgument
+// status before the handler runs.
+type RequireMetadataInvoker struct {
+	// inv is the underlying invoker
+	inv Invoker
+	// keys are the keys required for all methods.
+	keys []string
+	// methodKeys are the additional keys required by service & method ID.
+	methodKeys map[[2]string][]string
+}
+
+// NewRequireMetadataInvoker constructs a new RequireMetadataInvoker.
+//
+// keys are the metadata keys required for all calls.
+func NewRequireMetadataInvoker(inv Invoker, keys ...string) *RequireMetadataInvoker {
+	return &RequireMetadataInvoker{inv: inv, keys: keys}
+}
+
+// RequireMethod adds keys required for calls to the method.
+//
+// The keys are required in addition to the keys required for all calls.
+// Not concurrency safe: call before the invoker is used.
+// Returns the invoker.
+func (i *RequireMetadataInvoker) RequireMethod(serviceID, methodID string, keys ...string) *RequireMetadataInvoker {
+	if i.methodKeys == nil {
+		i.methodKeys = make(map[[2]string][]string)
+	}
+	id := [2]string{serviceID, methodID}
+	i.methodKeys[id] = append(i.methodKeys[id], keys...)
+	return i
+}
+
+// InvokeMethod invokes the method matching the service & method ID.
+// Returns false, nil if not found.
+// If service string is empty, ignore it.
+func (i *RequireMetadataInvoker) InvokeMethod(serviceID, methodID string, strm Stream) (bool, error) {
+	md := MetadataFromContext(strm.Context())
+	var missing []string
+	checkKeys := func(keys []string) {
+		for _, key := range keys {
+			if _, ok := md[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+	}
+	checkKeys(i.keys)
+	checkKeys(i.methodKeys[[2]string{serviceID, methodID}])
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return true, NewStatusError(StatusInvalidArgument, "missing required metadata: "+strings.Join(missing, ", ")).
+			WithDetail("missing", strings.Join(missing, ","))
+	}
+	return i.inv.InvokeMethod(serviceID, methodID, strm)
+}
+
+// _ is a type assertion
+var _ Invoker = ((*RequireMetadataInvoker)(nil))
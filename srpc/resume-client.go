@@ -0,0 +1,170 @@
+package srpc
+
+import "context"
+
+// resumeStartCtxKey is the context key for the resume token of a call.
+type resumeStartCtxKey struct{}
+
+// resumeStart contains the resume token and offset sent with the call start.
+//
+// If the token is empty, the call start requests a new resumable call.
+type resumeStart struct {
+	token  string
+	offset uint64
+}
+
+// withResumeStart attaches the resume token and offset to send with the call start.
+func withResumeStart(ctx context.Context, token string, offset uint64) context.Context {
+	return context.WithValue(ctx, resumeStartCtxKey{}, resumeStart{token: token, offset: offset})
+}
+
+// resumeStartFromContext returns the resume token and offset to send with the call start.
+func resumeStartFromContext(ctx context.Context) (resumeStart, bool) {
+	rs, ok := ctx.Value(resumeStartCtxKey{}).(resumeStart)
+	return rs, ok
+}
+
+// ResumableStream is a server-streaming call which resumes after the transport fails.
+//
+// The server answers the call start with a resume token. When the transport
+// fails before the call completes, the call is started again with the resume
+// token and the number of received messages, and the server continues sending
+// from that offset. The server must enable WithResumableStreams.
+type ResumableStream struct {
+	// ctx is the call context
+	ctx context.Context
+	// getClient returns the client to start the call with
+	getClient func(ctx context.Context) (Client, error)
+	// service and method are the rpc service and method
+	service, method string
+	// req is the request message
+	req Message
+	// token is the resume token sent by the server
+	token string
+	// received is the number of messages received
+	received uint64
+	// strm is the current stream
+	strm Stream
+}
+
+// NewResumableStream starts a resumable server-streaming call.
+//
+// getClient returns the client to start the call with. It is called again to
+// resume the call after the transport fails: for example return the client
+// for the current connection, waiting until it reconnects. getClient must
+// return a client constructed by this package. req is sent again if the
+// call is started again: it must not be modified while the stream is in use.
+func NewResumableStream(
+	ctx context.Context,
+	getClient func(ctx context.Context) (Client, error),
+	service, method string,
+	req Message,
+) (*ResumableStream, error) {
+	s := &ResumableStream{
+		ctx:       ctx,
+		getClient: getClient,
+		service:   service,
+		method:    method,
+		req:       req,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Token returns the resume token of the call.
+//
+// Returns an empty string if the server did not send the token yet.
+func (s *ResumableStream) Token() string {
+	if token := streamResumeToken(s.strm); token != "" {
+		s.token = token
+	}
+	return s.token
+}
+
+// Received returns the number of messages received.
+func (s *ResumableStream) Received() uint64 {
+	return s.received
+}
+
+// MsgRecv receives the next message, resuming the call if the transport failed.
+//
+// Returns io.EOF when the call completed. If resuming the call fails the error
+// is returned: calling MsgRecv again retries resuming the call. If the
+// transport failed before the server sent the resume token, the call is
+// started again only if no messages were received.
+func (s *ResumableStream) MsgRecv(msg Message) error {
+	for {
+		err := s.strm.MsgRecv(msg)
+		if err == nil {
+			s.received++
+			return nil
+		}
+		if s.ctx.Err() != nil || streamRemoteCompleted(s.strm) {
+			return err
+		}
+		if s.Token() == "" && s.received != 0 {
+			return err
+		}
+		_ = s.strm.Close()
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+}
+
+// Close cancels the call.
+func (s *ResumableStream) Close() error {
+	return s.strm.Close()
+}
+
+// open starts the call with the resume token and offset.
+func (s *ResumableStream) open() error {
+	client, err := s.getClient(s.ctx)
+	if err != nil {
+		return err
+	}
+	// the server ignores the request when resuming the call
+	var req Message
+	if s.token == "" {
+		req = s.req
+	}
+	ctx := withResumeStart(s.ctx, s.token, s.received)
+	strm, err := client.NewStream(ctx, s.service, s.method, req)
+	if err != nil {
+		return err
+	}
+	if err := strm.CloseSend(); err != nil {
+		_ = strm.Close()
+		return err
+	}
+	s.strm = strm
+	return nil
+}
+
+// streamResumeToken returns the resume token sent by the server on the stream.
+//
+// Returns an empty string if unknown.
+func streamResumeToken(strm Stream) string {
+	rpc, ok := streamRPCOf(strm)
+	if !ok {
+		return ""
+	}
+	rpc.mtx.Lock()
+	defer rpc.mtx.Unlock()
+	return rpc.recvResumeToken
+}
+
+// streamRemoteCompleted checks if the remote completed the call on the stream.
+//
+// Returns true if unknown.
+func streamRemoteCompleted(strm Stream) bool {
+	rpc, ok := streamRPCOf(strm)
+	if !ok {
+		return true
+	}
+	rpc.mtx.Lock()
+	defer rpc.mtx.Unlock()
+	return rpc.remoteCompleted
+}
@@ -0,0 +1,355 @@
+package srpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/util/broadcast"
+	"github.com/pkg/errors"
+)
+
+// WithResumableStreams enables resuming server-streaming calls after reconnecting.
+//
+// Calls started as resumable (see NewResumableStream) keep running when the
+// transport closes. The server generates a random token for each resumable call
+// and sends it to the client: only the token sent by the server resumes the
+// call. If the connection has an Identity (see WithIdentity) the call can only
+// be resumed by the same subject. The last bufferSize messages sent by the call are kept
+// so that a call started with the same token on a new transport continues from
+// the number of messages the client received. If no call resumes within
+// detachTimeout the call is canceled. Completed calls are also kept for
+// detachTimeout in case the client did not receive the final messages.
+// Resuming from an offset older than the buffered messages fails with
+// ErrResumeExpired. At most maxDetachedResumeSessions calls are kept detached:
+// exceeding the limit cancels the call which was detached the longest.
+//
+// Only the request sent with the call start is read by the handler: messages
+// sent by the client after the call start are not supported.
+// Resumption is best-effort: the buffered messages are held in memory.
+func WithResumableStreams(bufferSize int, detachTimeout time.Duration) ServerOption {
+	reg := newResumeRegistry(bufferSize, detachTimeout)
+	return func(opts *serverOpts) {
+		opts.resume = reg
+	}
+}
+
+// maxDetachedResumeSessions is the max number of calls waiting to be resumed.
+const maxDetachedResumeSessions = 1024
+
+// resumeRegistry contains the resumable calls of a server.
+type resumeRegistry struct {
+	// bufferSize is the max number of buffered messages per call.
+	bufferSize int
+	// detachTimeout is how long to wait for a call to resume.
+	detachTimeout time.Duration
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// sessions contains the resumable calls by token.
+	sessions map[string]*resumeSession
+	// detached contains the time the calls waiting to be resumed were detached.
+	detached map[*resumeSession]time.Time
+}
+
+// newResumeRegistry constructs a new resumeRegistry.
+func newResumeRegistry(bufferSize int, detachTimeout time.Duration) *resumeRegistry {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &resumeRegistry{
+		bufferSize:    bufferSize,
+		detachTimeout: detachTimeout,
+		sessions:      make(map[string]*resumeSession),
+		detached:      make(map[*resumeSession]time.Time),
+	}
+}
+
+// serve invokes or resumes the resumable call and sends its messages to rpc.
+//
+// Answers the call start with the token to resume the call with.
+// Returns when the call completed or rpc was closed.
+func (g *resumeRegistry) serve(ctx context.Context, rpc *ServerRPC, serviceID, methodID string) {
+	token, offset := rpc.resumeToken, rpc.resumeOffset
+	subject := identitySubject(ctx)
+	var sess *resumeSession
+	if token == "" {
+		var err error
+		token, err = newResumeToken()
+		if err != nil {
+			_ = rpc.WriteCallData(nil, true, err)
+			return
+		}
+		sess = newResumeSession(g, token, subject, serviceID, methodID, context.WithoutCancel(ctx))
+		g.mtx.Lock()
+		g.sessions[token] = sess
+		g.mtx.Unlock()
+	} else {
+		g.mtx.Lock()
+		sess = g.sessions[token]
+		g.mtx.Unlock()
+		if sess == nil || sess.subject != subject {
+			_ = rpc.WriteCallData(nil, true, ErrResumeExpired)
+			return
+		}
+		if sess.serviceID != serviceID || sess.methodID != methodID {
+			_ = rpc.WriteCallData(nil, true, errors.Wrap(ErrResumeExpired, "method does not match"))
+			return
+		}
+	}
+
+	if err := rpc.writeCallStartResp(token); err != nil {
+		if rpc.resumeToken == "" {
+			sess.abandon()
+		}
+		return
+	}
+	if rpc.resumeToken == "" {
+		goTracked(func() {
+			var strm Stream = NewMsgStream(sess.ctx, &resumeSessionRw{sess: sess, src: rpc}, sess.ctxCancel)
+			if rpc.codec != nil {
+				strm = NewCodecStream(strm, rpc.codec)
+			}
+			sess.finish(rpc.invokeMethod(serviceID, methodID, strm))
+		})
+	}
+	sess.pump(rpc, offset)
+}
+
+// remove removes the session from the registry.
+func (g *resumeRegistry) remove(sess *resumeSession) {
+	g.mtx.Lock()
+	if g.sessions[sess.token] == sess {
+		delete(g.sessions, sess.token)
+	}
+	delete(g.detached, sess)
+	g.mtx.Unlock()
+}
+
+// addDetached tracks the session waiting to be resumed.
+//
+// Cancels the session detached the longest if there are too many.
+func (g *resumeRegistry) addDetached(sess *resumeSession) {
+	var evict *resumeSession
+	g.mtx.Lock()
+	g.detached[sess] = time.Now()
+	if len(g.detached) > maxDetachedResumeSessions {
+		for s, t := range g.detached {
+			if evict == nil || t.Before(g.detached[evict]) {
+				evict = s
+			}
+		}
+		delete(g.detached, evict)
+	}
+	g.mtx.Unlock()
+	if evict != nil {
+		evict.abandon()
+	}
+}
+
+// removeDetached stops tracking the session after it was resumed.
+func (g *resumeRegistry) removeDetached(sess *resumeSession) {
+	g.mtx.Lock()
+	delete(g.detached, sess)
+	g.mtx.Unlock()
+}
+
+// identitySubject returns the subject of the identity of the remote, if any.
+func identitySubject(ctx context.Context) string {
+	if id, ok := IdentityFromContext(ctx); ok {
+		return id.Subject
+	}
+	return ""
+}
+
+// newResumeToken generates a new random resume token.
+func newResumeToken() (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+// resumeSession is a resumable call.
+type resumeSession struct {
+	reg                        *resumeRegistry
+	token, serviceID, methodID string
+	// subject is the identity subject of the client which started the call.
+	subject string
+	// ctx is the handler context, canceled when the call is abandoned.
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// bcast broadcasts when below fields change
+	bcast broadcast.Broadcast
+	// msgs contains the buffered messages
+	msgs [][]byte
+	// base is the offset of msgs[0]
+	base uint64
+	// done is set when the handler completed.
+	done bool
+	// doneErr is the error returned by the handler.
+	doneErr error
+	// gen is incremented each time a rpc attaches to the session.
+	gen uint64
+	// detachTimer cancels the session if no rpc attaches in time.
+	detachTimer *time.Timer
+}
+
+// newResumeSession constructs a new resumeSession.
+func newResumeSession(reg *resumeRegistry, token, subject, serviceID, methodID string, ctx context.Context) *resumeSession {
+	sess := &resumeSession{reg: reg, token: token, subject: subject, serviceID: serviceID, methodID: methodID}
+	sess.ctx, sess.ctxCancel = context.WithCancel(ctx)
+	return sess
+}
+
+// send buffers a message sent by the handler.
+func (s *resumeSession) send(data []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.done {
+		return ErrCompleted
+	}
+	s.msgs = append(s.msgs, bytes.Clone(data))
+	if len(s.msgs) > s.reg.bufferSize {
+		s.msgs[0] = nil
+		s.msgs = s.msgs[1:]
+		s.base++
+	}
+	s.bcast.Broadcast()
+	return nil
+}
+
+// finish marks the handler as completed with an optional error.
+func (s *resumeSession) finish(err error) {
+	s.mtx.Lock()
+	if !s.done {
+		s.done, s.doneErr = true, err
+	}
+	s.bcast.Broadcast()
+	s.mtx.Unlock()
+}
+
+// abandon cancels the handler and removes the session.
+func (s *resumeSession) abandon() {
+	s.ctxCancel()
+	s.reg.remove(s)
+}
+
+// pump sends the messages starting at offset to rpc until the call completes,
+// rpc is closed, or another rpc resumes the call.
+func (s *resumeSession) pump(rpc *ServerRPC, offset uint64) {
+	s.mtx.Lock()
+	s.gen++
+	gen := s.gen
+	if s.detachTimer != nil {
+		s.detachTimer.Stop()
+		s.detachTimer = nil
+		s.reg.removeDetached(s)
+	}
+	s.mtx.Unlock()
+
+	pos := offset
+	for {
+		s.mtx.Lock()
+		if s.gen != gen {
+			// resumed by another rpc
+			s.mtx.Unlock()
+			return
+		}
+		if pos < s.base {
+			s.mtx.Unlock()
+			_ = rpc.WriteCallData(nil, true, ErrResumeExpired)
+			s.abandon()
+			return
+		}
+		if idx := pos - s.base; idx < uint64(len(s.msgs)) {
+			msg := s.msgs[idx]
+			s.mtx.Unlock()
+			if err := rpc.WriteCallData(msg, false, nil); err != nil {
+				s.detach(gen)
+				return
+			}
+			pos++
+			continue
+		}
+		if s.done {
+			doneErr := s.doneErr
+			s.mtx.Unlock()
+			if err := rpc.WriteCallData(nil, true, doneErr); err == nil {
+				_ = rpc.Flush(rpc.ctx)
+			}
+			// keep the session in case the transport failed before the client
+			// received the final messages.
+			s.detach(gen)
+			return
+		}
+		waitCh := s.bcast.GetWaitCh()
+		s.mtx.Unlock()
+
+		select {
+		case <-rpc.ctx.Done():
+			if errors.Is(context.Cause(rpc.ctx), ErrRemoteCanceled) {
+				// the client canceled the call
+				s.abandon()
+			} else {
+				s.detach(gen)
+			}
+			return
+		case <-waitCh:
+		}
+	}
+}
+
+// detach starts the detach timeout if no other rpc resumed the call.
+func (s *resumeSession) detach(gen uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.gen != gen {
+		return
+	}
+	s.detachTimer = time.AfterFunc(s.reg.detachTimeout, func() {
+		s.mtx.Lock()
+		expired := s.gen == gen
+		s.mtx.Unlock()
+		if expired {
+			s.abandon()
+		}
+	})
+	s.reg.addDetached(s)
+}
+
+// resumeSessionRw is the MsgStreamRw for the handler of a resumable call.
+type resumeSessionRw struct {
+	// sess is the session
+	sess *resumeSession
+	// src is the rpc which started the call
+	src *ServerRPC
+}
+
+// ReadOne reads a message sent by the client which started the call.
+func (r *resumeSessionRw) ReadOne() ([]byte, error) {
+	return r.src.ReadOne()
+}
+
+// WriteCallData buffers the message to be sent to the client.
+func (r *resumeSessionRw) WriteCallData(data []byte, complete bool, err error) error {
+	if len(data) != 0 || (!complete && err == nil) {
+		if serr := r.sess.send(data); serr != nil {
+			return serr
+		}
+	}
+	if complete || err != nil {
+		r.sess.finish(err)
+	}
+	return nil
+}
+
+// _ is a type assertion
+var _ MsgStreamRw = ((*resumeSessionRw)(nil))
@@ -0,0 +1,48 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestResumableStreams_ServerToken tests the server generates the resume token.
+func TestResumableStreams_ServerToken(t *testing.T) {
+	server := NewServer(drainInvoker{}, WithResumableStreams(4, time.Second))
+	client := NewClient(NewServerPipe(server))
+
+	ctx := withResumeStart(context.Background(), "", 0)
+	strm, err := client.NewStream(ctx, "test-service", "test-method", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if err := strm.CloseSend(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.MsgRecv(NewRawMessage(nil, false)); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+	if len(streamResumeToken(strm)) != 32 {
+		t.Fatalf("expected resume token from server but got %q", streamResumeToken(strm))
+	}
+}
+
+// TestResumableStreams_UnknownToken tests a resume token not sent by the server is rejected.
+func TestResumableStreams_UnknownToken(t *testing.T) {
+	server := NewServer(drainInvoker{}, WithResumableStreams(4, time.Second))
+	client := NewClient(NewServerPipe(server))
+
+	ctx := withResumeStart(context.Background(), "client-chosen-token", 0)
+	strm, err := client.NewStream(ctx, "test-service", "test-method", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	err = strm.MsgRecv(NewRawMessage(nil, false))
+	if err == nil || !strings.Contains(err.Error(), ErrResumeExpired.Error()) {
+		t.Fatalf("expected ErrResumeExpired but got %v", err)
+	}
+}
@@ -34,6 +34,14 @@ type Packet struct {
 	//	*Packet_CallStart
 	//	*Packet_CallData
 	//	*Packet_CallCancel
+	//	*Packet_Ping
+	//	*Packet_Pong
+	//	*Packet_CallStartResp
+	//	*Packet_CapabilitiesRequest
+	//	*Packet_Capabilities
+	//	*Packet_CallCancelReason
+	//	*Packet_CallDemand
+	//	*Packet_AttachmentKey
 	Body isPacket_Body `protobuf_oneof:"body"`
 }
 
@@ -97,6 +105,62 @@ func (x *Packet) GetCallCancel() bool {
 	return false
 }
 
+func (x *Packet) GetPing() uint64 {
+	if x, ok := x.GetBody().(*Packet_Ping); ok {
+		return x.Ping
+	}
+	return 0
+}
+
+func (x *Packet) GetPong() uint64 {
+	if x, ok := x.GetBody().(*Packet_Pong); ok {
+		return x.Pong
+	}
+	return 0
+}
+
+func (x *Packet) GetCallStartResp() *CallStartResp {
+	if x, ok := x.GetBody().(*Packet_CallStartResp); ok {
+		return x.CallStartResp
+	}
+	return nil
+}
+
+func (x *Packet) GetCapabilitiesRequest() bool {
+	if x, ok := x.GetBody().(*Packet_CapabilitiesRequest); ok {
+		return x.CapabilitiesRequest
+	}
+	return false
+}
+
+func (x *Packet) GetCapabilities() *Capabilities {
+	if x, ok := x.GetBody().(*Packet_Capabilities); ok {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Packet) GetCallCancelReason() string {
+	if x, ok := x.GetBody().(*Packet_CallCancelReason); ok {
+		return x.CallCancelReason
+	}
+	return ""
+}
+
+func (x *Packet) GetCallDemand() uint32 {
+	if x, ok := x.GetBody().(*Packet_CallDemand); ok {
+		return x.CallDemand
+	}
+	return 0
+}
+
+func (x *Packet) GetAttachmentKey() []byte {
+	if x, ok := x.GetBody().(*Packet_AttachmentKey); ok {
+		return x.AttachmentKey
+	}
+	return nil
+}
+
 type isPacket_Body interface {
 	isPacket_Body()
 }
@@ -116,12 +180,159 @@ type Packet_CallCancel struct {
 	CallCancel bool `protobuf:"varint,3,opt,name=call_cancel,json=callCancel,proto3,oneof"`
 }
 
+type Packet_Ping struct {
+	// Ping requests a Pong with the same value.
+	// Answered by the remote without invoking a handler.
+	Ping uint64 `protobuf:"varint,4,opt,name=ping,proto3,oneof"`
+}
+
+type Packet_Pong struct {
+	// Pong answers a Ping with the value of the Ping.
+	Pong uint64 `protobuf:"varint,5,opt,name=pong,proto3,oneof"`
+}
+
+type Packet_CallStartResp struct {
+	// CallStartResp answers a CallStart which offered codecs.
+	CallStartResp *CallStartResp `protobuf:"bytes,6,opt,name=call_start_resp,json=callStartResp,proto3,oneof"`
+}
+
+type Packet_CapabilitiesRequest struct {
+	// CapabilitiesRequest requests the Capabilities of the remote.
+	// Answered by the remote without invoking a handler.
+	CapabilitiesRequest bool `protobuf:"varint,7,opt,name=capabilities_request,json=capabilitiesRequest,proto3,oneof"`
+}
+
+type Packet_Capabilities struct {
+	// Capabilities answers a CapabilitiesRequest.
+	Capabilities *Capabilities `protobuf:"bytes,8,opt,name=capabilities,proto3,oneof"`
+}
+
+type Packet_CallCancelReason struct {
+	// CallCancelReason cancels the call with a reason.
+	// Equivalent to CallCancel: the reason describes why the call was canceled.
+	CallCancelReason string `protobuf:"bytes,9,opt,name=call_cancel_reason,json=callCancelReason,proto3,oneof"`
+}
+
+type Packet_CallDemand struct {
+	// CallDemand requests the number of additional messages from the server.
+	// Only valid for calls started with initial_demand.
+	CallDemand uint32 `protobuf:"varint,10,opt,name=call_demand,json=callDemand,proto3,oneof"`
+}
+
+type Packet_AttachmentKey struct {
+	// AttachmentKey registers the key of the call for attachment streams.
+	// Sent by the client before opening the first attachment stream of the call.
+	// The key is a random value known only to the client and the server.
+	AttachmentKey []byte `protobuf:"bytes,11,opt,name=attachment_key,json=attachmentKey,proto3,oneof"`
+}
+
 func (*Packet_CallStart) isPacket_Body() {}
 
 func (*Packet_CallData) isPacket_Body() {}
 
 func (*Packet_CallCancel) isPacket_Body() {}
 
+func (*Packet_Ping) isPacket_Body() {}
+
+func (*Packet_Pong) isPacket_Body() {}
+
+func (*Packet_CallStartResp) isPacket_Body() {}
+
+func (*Packet_CapabilitiesRequest) isPacket_Body() {}
+
+func (*Packet_Capabilities) isPacket_Body() {}
+
+func (*Packet_CallCancelReason) isPacket_Body() {}
+
+func (*Packet_CallDemand) isPacket_Body() {}
+
+func (*Packet_AttachmentKey) isPacket_Body() {}
+
+// Capabilities describes the optional features supported by the server.
+type Capabilities struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Compression contains the names of the supported stream compressors.
+	Compression []string `protobuf:"bytes,1,rep,name=compression,proto3" json:"compression,omitempty"`
+	// Codecs contains the names of the codecs supported for negotiation.
+	Codecs []string `protobuf:"bytes,2,rep,name=codecs,proto3" json:"codecs,omitempty"`
+	// MaxMessageSize is the max size of a message in bytes.
+	MaxMessageSize uint32 `protobuf:"varint,3,opt,name=max_message_size,json=maxMessageSize,proto3" json:"max_message_size,omitempty"`
+	// ChecksumsRequired indicates calls must enable packet checksums.
+	ChecksumsRequired bool `protobuf:"varint,4,opt,name=checksums_required,json=checksumsRequired,proto3" json:"checksums_required,omitempty"`
+	// ResumableStreams indicates server-streaming calls can be resumed.
+	ResumableStreams bool `protobuf:"varint,5,opt,name=resumable_streams,json=resumableStreams,proto3" json:"resumable_streams,omitempty"`
+}
+
+func (x *Capabilities) Reset() {
+	*x = Capabilities{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Capabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Capabilities) ProtoMessage() {}
+
+func (x *Capabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Capabilities.ProtoReflect.Descriptor instead.
+func (*Capabilities) Descriptor() ([]byte, []int) {
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Capabilities) GetCompression() []string {
+	if x != nil {
+		return x.Compression
+	}
+	return nil
+}
+
+func (x *Capabilities) GetCodecs() []string {
+	if x != nil {
+		return x.Codecs
+	}
+	return nil
+}
+
+func (x *Capabilities) GetMaxMessageSize() uint32 {
+	if x != nil {
+		return x.MaxMessageSize
+	}
+	return 0
+}
+
+func (x *Capabilities) GetChecksumsRequired() bool {
+	if x != nil {
+		return x.ChecksumsRequired
+	}
+	return false
+}
+
+func (x *Capabilities) GetResumableStreams() bool {
+	if x != nil {
+		return x.ResumableStreams
+	}
+	return false
+}
+
 // CallStart requests starting a new RPC call.
 type CallStart struct {
 	state         protoimpl.MessageState
@@ -139,12 +350,60 @@ type CallStart struct {
 	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
 	// DataIsZero indicates Data is set with an empty message.
 	DataIsZero bool `protobuf:"varint,4,opt,name=data_is_zero,json=dataIsZero,proto3" json:"data_is_zero,omitempty"`
+	// Metadata contains optional key/value pairs sent with the call.
+	Metadata map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// ExtraData contains additional messages in the stream following Data.
+	// Optional: batches several initial messages with the call start.
+	// If set, Data or DataIsZero must also be set.
+	ExtraData [][]byte `protobuf:"bytes,6,rep,name=extra_data,json=extraData,proto3" json:"extra_data,omitempty"`
+	// Compression is the name of the stream compressor applied to the messages.
+	// If set, all messages of the call in both directions are compressed as a
+	// single stream. If empty, messages are not compressed.
+	Compression string `protobuf:"bytes,7,opt,name=compression,proto3" json:"compression,omitempty"`
+	// ResumeToken resumes the call with the token sent by the server.
+	// If a call with the token is in progress the server resumes sending its
+	// messages instead of invoking the method again.
+	// If empty, the call is not resumed.
+	ResumeToken string `protobuf:"bytes,8,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	// ResumeOffset is the number of messages the client already received.
+	// The server sends the messages of the call starting at the offset.
+	ResumeOffset uint64 `protobuf:"varint,9,opt,name=resume_offset,json=resumeOffset,proto3" json:"resume_offset,omitempty"`
+	// Checksum enables CRC-32C checksums of the data of the call.
+	// If set, all CallData packets sent by the client contain the checksum of
+	// their data, verified by the server. The server confirms with the
+	// CallStartResp checksum field: after the confirmation all CallData packets
+	// sent by the server contain the checksum, verified by the client.
+	Checksum bool `protobuf:"varint,10,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// DataChecksum is the CRC-32C checksum of Data followed by ExtraData.
+	// Only set if checksum is set.
+	DataChecksum uint32 `protobuf:"fixed32,11,opt,name=data_checksum,json=dataChecksum,proto3" json:"data_checksum,omitempty"`
+	// Codecs is the ordered list of codecs accepted by the client, preferred first.
+	// If set, the server selects the first codec it supports and answers with
+	// CallStartResp. The messages of the call are encoded with the selected codec.
+	// The client does not send messages before receiving the CallStartResp.
+	// If empty, messages are encoded with protobuf.
+	Codecs []string `protobuf:"bytes,12,rep,name=codecs,proto3" json:"codecs,omitempty"`
+	// InitialDemand enables demand flow control with the initial demand.
+	// The server sends at most the number of messages requested by the client
+	// with initial_demand and CallDemand packets.
+	// If zero, demand flow control is disabled.
+	InitialDemand uint32 `protobuf:"varint,13,opt,name=initial_demand,json=initialDemand,proto3" json:"initial_demand,omitempty"`
+	// Ack requests a CallStartResp when the server accepts the call.
+	// The CallStartResp is sent before any messages of the call.
+	Ack bool `protobuf:"varint,14,opt,name=ack,proto3" json:"ack,omitempty"`
+	// Attachment indicates the stream carries an attachment of another call.
+	// If set, rpc_service and rpc_method must be empty: no method is invoked.
+	Attachment *AttachmentStart `protobuf:"bytes,15,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	// Resumable requests a resumable call.
+	// If supported, the server answers with a CallStartResp containing the token
+	// to resume the call with.
+	Resumable bool `protobuf:"varint,16,opt,name=resumable,proto3" json:"resumable,omitempty"`
 }
 
 func (x *CallStart) Reset() {
 	*x = CallStart{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[1]
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -157,7 +416,7 @@ func (x *CallStart) String() string {
 func (*CallStart) ProtoMessage() {}
 
 func (x *CallStart) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[1]
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -170,7 +429,7 @@ func (x *CallStart) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CallStart.ProtoReflect.Descriptor instead.
 func (*CallStart) Descriptor() ([]byte, []int) {
-	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{1}
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *CallStart) GetRpcService() string {
@@ -201,6 +460,231 @@ func (x *CallStart) GetDataIsZero() bool {
 	return false
 }
 
+func (x *CallStart) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CallStart) GetExtraData() [][]byte {
+	if x != nil {
+		return x.ExtraData
+	}
+	return nil
+}
+
+func (x *CallStart) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+func (x *CallStart) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *CallStart) GetResumeOffset() uint64 {
+	if x != nil {
+		return x.ResumeOffset
+	}
+	return 0
+}
+
+func (x *CallStart) GetChecksum() bool {
+	if x != nil {
+		return x.Checksum
+	}
+	return false
+}
+
+func (x *CallStart) GetDataChecksum() uint32 {
+	if x != nil {
+		return x.DataChecksum
+	}
+	return 0
+}
+
+func (x *CallStart) GetCodecs() []string {
+	if x != nil {
+		return x.Codecs
+	}
+	return nil
+}
+
+func (x *CallStart) GetInitialDemand() uint32 {
+	if x != nil {
+		return x.InitialDemand
+	}
+	return 0
+}
+
+func (x *CallStart) GetAck() bool {
+	if x != nil {
+		return x.Ack
+	}
+	return false
+}
+
+func (x *CallStart) GetAttachment() *AttachmentStart {
+	if x != nil {
+		return x.Attachment
+	}
+	return nil
+}
+
+func (x *CallStart) GetResumable() bool {
+	if x != nil {
+		return x.Resumable
+	}
+	return false
+}
+
+// AttachmentStart opens a stream carrying an attachment of a call.
+type AttachmentStart struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// CallKey is the attachment key registered by the call.
+	CallKey []byte `protobuf:"bytes,1,opt,name=call_key,json=callKey,proto3" json:"call_key,omitempty"`
+	// Id is the ID of the attachment referenced by the messages of the call.
+	Id uint32 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	// Pull requests the attachment opened by the server with the ID.
+	// The server sends the attachment data as CallData packets on the stream.
+	// Otherwise the client sends the attachment data on the stream.
+	Pull bool `protobuf:"varint,3,opt,name=pull,proto3" json:"pull,omitempty"`
+}
+
+func (x *AttachmentStart) Reset() {
+	*x = AttachmentStart{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AttachmentStart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachmentStart) ProtoMessage() {}
+
+func (x *AttachmentStart) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachmentStart.ProtoReflect.Descriptor instead.
+func (*AttachmentStart) Descriptor() ([]byte, []int) {
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AttachmentStart) GetCallKey() []byte {
+	if x != nil {
+		return x.CallKey
+	}
+	return nil
+}
+
+func (x *AttachmentStart) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AttachmentStart) GetPull() bool {
+	if x != nil {
+		return x.Pull
+	}
+	return false
+}
+
+// CallStartResp answers a CallStart which offered codecs, requested an ack,
+// or requested a resumable call.
+type CallStartResp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Codec is the name of the codec selected by the server.
+	// Empty if the call did not offer codecs.
+	Codec string `protobuf:"bytes,1,opt,name=codec,proto3" json:"codec,omitempty"`
+	// ResumeToken is the token to resume the call with.
+	// Empty if the call is not resumable.
+	ResumeToken string `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	// Checksum confirms the server enabled the checksums requested with the
+	// call start. The CallData packets sent by the server after the
+	// CallStartResp contain the checksum of their data.
+	Checksum bool `protobuf:"varint,3,opt,name=checksum,proto3" json:"checksum,omitempty"`
+}
+
+func (x *CallStartResp) Reset() {
+	*x = CallStartResp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CallStartResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallStartResp) ProtoMessage() {}
+
+func (x *CallStartResp) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallStartResp.ProtoReflect.Descriptor instead.
+func (*CallStartResp) Descriptor() ([]byte, []int) {
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CallStartResp) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *CallStartResp) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *CallStartResp) GetChecksum() bool {
+	if x != nil {
+		return x.Checksum
+	}
+	return false
+}
+
 // CallData contains a message in a streaming RPC sequence.
 type CallData struct {
 	state         protoimpl.MessageState
@@ -216,12 +700,41 @@ type CallData struct {
 	// Error contains any error that caused the RPC to fail.
 	// If set, implies complete=true.
 	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Heartbeat indicates this is a keep-alive packet with no data.
+	// Receivers should ignore heartbeat packets.
+	Heartbeat bool `protobuf:"varint,5,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
+	// Fragment indicates Data is a fragment of a message.
+	// The message continues in the next CallData packet.
+	// The last fragment of the message has fragment=false.
+	Fragment bool `protobuf:"varint,6,opt,name=fragment,proto3" json:"fragment,omitempty"`
+	// Seq is the sequence number of the packet in the call, starting at 1.
+	// Incremented for each CallData packet sent, excluding heartbeats.
+	// If zero, sequence numbers are not used.
+	Seq uint32 `protobuf:"varint,7,opt,name=seq,proto3" json:"seq,omitempty"`
+	// Trailer contains metadata sent with the final packet of the call.
+	// Only set if complete or error is set.
+	Trailer map[string]string `protobuf:"bytes,8,rep,name=trailer,proto3" json:"trailer,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Progress indicates Data contains an interim progress update.
+	// Progress updates are not messages of the call.
+	// Receivers which do not handle progress updates should ignore them.
+	Progress bool `protobuf:"varint,9,opt,name=progress,proto3" json:"progress,omitempty"`
+	// Status contains the structured status of the error, if any.
+	// Only set if error is set: error contains the status message.
+	Status *Status `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	// Checksum is the CRC-32C checksum of Data.
+	// Only set if checksums were enabled with the call start.
+	// Only Data is covered: the other fields are not checksummed.
+	Checksum uint32 `protobuf:"fixed32,11,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// DebugLog indicates Data contains an encoded DebugLogEntry.
+	// Debug log entries are not messages of the call.
+	// Receivers which do not handle debug log entries should ignore them.
+	DebugLog bool `protobuf:"varint,13,opt,name=debug_log,json=debugLog,proto3" json:"debug_log,omitempty"`
 }
 
 func (x *CallData) Reset() {
 	*x = CallData{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[2]
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -234,7 +747,7 @@ func (x *CallData) String() string {
 func (*CallData) ProtoMessage() {}
 
 func (x *CallData) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[2]
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -247,7 +760,7 @@ func (x *CallData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CallData.ProtoReflect.Descriptor instead.
 func (*CallData) Descriptor() ([]byte, []int) {
-	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{2}
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CallData) GetData() []byte {
@@ -278,14 +791,223 @@ func (x *CallData) GetError() string {
 	return ""
 }
 
+func (x *CallData) GetHeartbeat() bool {
+	if x != nil {
+		return x.Heartbeat
+	}
+	return false
+}
+
+func (x *CallData) GetFragment() bool {
+	if x != nil {
+		return x.Fragment
+	}
+	return false
+}
+
+func (x *CallData) GetSeq() uint32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *CallData) GetTrailer() map[string]string {
+	if x != nil {
+		return x.Trailer
+	}
+	return nil
+}
+
+func (x *CallData) GetProgress() bool {
+	if x != nil {
+		return x.Progress
+	}
+	return false
+}
+
+func (x *CallData) GetStatus() *Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *CallData) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
+func (x *CallData) GetDebugLog() bool {
+	if x != nil {
+		return x.DebugLog
+	}
+	return false
+}
+
+// DebugLogEntry is a log entry forwarded from the call handler for debugging.
+type DebugLogEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// TimeUnixMs is the time of the entry in milliseconds since the unix epoch.
+	TimeUnixMs int64 `protobuf:"varint,1,opt,name=time_unix_ms,json=timeUnixMs,proto3" json:"time_unix_ms,omitempty"`
+	// Level is the log/slog level of the entry.
+	Level int32 `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	// Message is the log message.
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// Attrs contains the attributes of the entry formatted as strings.
+	Attrs map[string]string `protobuf:"bytes,4,rep,name=attrs,proto3" json:"attrs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *DebugLogEntry) Reset() {
+	*x = DebugLogEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DebugLogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebugLogEntry) ProtoMessage() {}
+
+func (x *DebugLogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebugLogEntry.ProtoReflect.Descriptor instead.
+func (*DebugLogEntry) Descriptor() ([]byte, []int) {
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DebugLogEntry) GetTimeUnixMs() int64 {
+	if x != nil {
+		return x.TimeUnixMs
+	}
+	return 0
+}
+
+func (x *DebugLogEntry) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *DebugLogEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DebugLogEntry) GetAttrs() map[string]string {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+// Status is the structured status of a failed call.
+type Status struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Code is the status code.
+	Code uint32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Message is the error message.
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Details contains additional information about the error.
+	Details map[string]string `protobuf:"bytes,3,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// RetryAfterMs is the minimum time to wait before retrying the call.
+	// Zero if there is no hint.
+	RetryAfterMs uint64 `protobuf:"varint,4,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Status) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Status) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Status) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *Status) GetRetryAfterMs() uint64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
 var File_github_com_aperturerobotics_starpc_srpc_rpcproto_proto protoreflect.FileDescriptor
 
 var file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDesc = []byte{
 	0x0a, 0x36, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x70, 0x65,
 	0x72, 0x74, 0x75, 0x72, 0x65, 0x72, 0x6f, 0x62, 0x6f, 0x74, 0x69, 0x63, 0x73, 0x2f, 0x73, 0x74,
 	0x61, 0x72, 0x70, 0x63, 0x2f, 0x73, 0x72, 0x70, 0x63, 0x2f, 0x72, 0x70, 0x63, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x73, 0x72, 0x70, 0x63, 0x22, 0x94,
-	0x01, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x30, 0x0a, 0x0a, 0x63, 0x61, 0x6c,
+	0x74, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x73, 0x72, 0x70, 0x63, 0x22, 0xea,
+	0x03, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x30, 0x0a, 0x0a, 0x63, 0x61, 0x6c,
 	0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
 	0x73, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x48, 0x00,
 	0x52, 0x09, 0x63, 0x61, 0x6c, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2d, 0x0a, 0x09, 0x63,
@@ -293,24 +1015,147 @@ var file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDesc = []byte
 	0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x44, 0x61, 0x74, 0x61, 0x48, 0x00,
 	0x52, 0x08, 0x63, 0x61, 0x6c, 0x6c, 0x44, 0x61, 0x74, 0x61, 0x12, 0x21, 0x0a, 0x0b, 0x63, 0x61,
 	0x6c, 0x6c, 0x5f, 0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x48,
-	0x00, 0x52, 0x0a, 0x63, 0x61, 0x6c, 0x6c, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x42, 0x06, 0x0a,
-	0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0x81, 0x01, 0x0a, 0x09, 0x43, 0x61, 0x6c, 0x6c, 0x53, 0x74,
-	0x61, 0x72, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x70, 0x63, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x70, 0x63, 0x53, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x70, 0x63, 0x5f, 0x6d, 0x65, 0x74, 0x68,
-	0x6f, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x70, 0x63, 0x4d, 0x65, 0x74,
-	0x68, 0x6f, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x20, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x5f,
-	0x69, 0x73, 0x5f, 0x7a, 0x65, 0x72, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64,
-	0x61, 0x74, 0x61, 0x49, 0x73, 0x5a, 0x65, 0x72, 0x6f, 0x22, 0x72, 0x0a, 0x08, 0x43, 0x61, 0x6c,
-	0x6c, 0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x20, 0x0a, 0x0c, 0x64, 0x61, 0x74,
-	0x61, 0x5f, 0x69, 0x73, 0x5f, 0x7a, 0x65, 0x72, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x0a, 0x64, 0x61, 0x74, 0x61, 0x49, 0x73, 0x5a, 0x65, 0x72, 0x6f, 0x12, 0x1a, 0x0a, 0x08, 0x63,
-	0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x63,
-	0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x00, 0x52, 0x0a, 0x63, 0x61, 0x6c, 0x6c, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x12, 0x14, 0x0a,
+	0x04, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x04, 0x70,
+	0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x04, 0x70, 0x6f, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x00, 0x52, 0x04, 0x70, 0x6f, 0x6e, 0x67, 0x12, 0x3d, 0x0a, 0x0f, 0x63, 0x61, 0x6c,
+	0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x48, 0x00, 0x52, 0x0d, 0x63, 0x61, 0x6c, 0x6c, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x12, 0x33, 0x0a, 0x14, 0x63, 0x61, 0x70, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x13, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69,
+	0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x38, 0x0a,
+	0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x70, 0x61, 0x62,
+	0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x48, 0x00, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62,
+	0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x2e, 0x0a, 0x12, 0x63, 0x61, 0x6c, 0x6c, 0x5f,
+	0x63, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x5f, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x10, 0x63, 0x61, 0x6c, 0x6c, 0x43, 0x61, 0x6e, 0x63, 0x65,
+	0x6c, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0b, 0x63, 0x61, 0x6c, 0x6c, 0x5f,
+	0x64, 0x65, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x0a,
+	0x63, 0x61, 0x6c, 0x6c, 0x44, 0x65, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x27, 0x0a, 0x0e, 0x61, 0x74,
+	0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x0c, 0x48, 0x00, 0x52, 0x0d, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74,
+	0x4b, 0x65, 0x79, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0xce, 0x01, 0x0a, 0x0c,
+	0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b,
+	0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16,
+	0x0a, 0x06, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
+	0x63, 0x6f, 0x64, 0x65, 0x63, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0e, 0x6d, 0x61, 0x78, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65,
+	0x12, 0x2d, 0x0a, 0x12, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x73, 0x5f, 0x72, 0x65,
+	0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x63, 0x68,
+	0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x12,
+	0x2b, 0x0a, 0x11, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x72, 0x65, 0x73, 0x75,
+	0x6d, 0x61, 0x62, 0x6c, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x22, 0xe9, 0x04, 0x0a,
+	0x09, 0x43, 0x61, 0x6c, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x70,
+	0x63, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x72, 0x70, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x72,
+	0x70, 0x63, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x72, 0x70, 0x63, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x20,
+	0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x69, 0x73, 0x5f, 0x7a, 0x65, 0x72, 0x6f, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x49, 0x73, 0x5a, 0x65, 0x72, 0x6f,
+	0x12, 0x39, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1d, 0x0a, 0x0a, 0x65,
+	0x78, 0x74, 0x72, 0x61, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0c, 0x52,
+	0x09, 0x65, 0x78, 0x74, 0x72, 0x61, 0x44, 0x61, 0x74, 0x61, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f,
+	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c,
+	0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12,
+	0x23, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x4f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x12, 0x23, 0x0a, 0x0d, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75,
+	0x6d, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x43, 0x68, 0x65,
+	0x63, 0x6b, 0x73, 0x75, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x73, 0x18,
+	0x0c, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x63, 0x6f, 0x64, 0x65, 0x63, 0x73, 0x12, 0x25, 0x0a,
+	0x0e, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x6d, 0x61, 0x6e, 0x64, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x44, 0x65,
+	0x6d, 0x61, 0x6e, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x03, 0x61, 0x63, 0x6b, 0x12, 0x35, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68,
+	0x6d, 0x65, 0x6e, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x72, 0x70,
+	0x63, 0x2e, 0x41, 0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a,
+	0x09, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x09, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x61, 0x62, 0x6c, 0x65, 0x1a, 0x3b, 0x0a, 0x0d, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x50, 0x0a, 0x0f, 0x41, 0x74, 0x74, 0x61,
+	0x63, 0x68, 0x6d, 0x65, 0x6e, 0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63,
+	0x61, 0x6c, 0x6c, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63,
+	0x61, 0x6c, 0x6c, 0x4b, 0x65, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x75, 0x6c, 0x6c, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x70, 0x75, 0x6c, 0x6c, 0x22, 0x64, 0x0a, 0x0d, 0x43, 0x61,
+	0x6c, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x63,
+	0x6f, 0x64, 0x65, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x64, 0x65,
+	0x63, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54,
+	0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d,
+	0x22, 0xb2, 0x03, 0x0a, 0x08, 0x43, 0x61, 0x6c, 0x6c, 0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x12, 0x20, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x69, 0x73, 0x5f, 0x7a, 0x65, 0x72,
+	0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x49, 0x73, 0x5a,
+	0x65, 0x72, 0x6f, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x74, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65,
+	0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62,
+	0x65, 0x61, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x72, 0x61, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x66, 0x72, 0x61, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x73, 0x65,
+	0x71, 0x12, 0x35, 0x0a, 0x07, 0x74, 0x72, 0x61, 0x69, 0x6c, 0x65, 0x72, 0x18, 0x08, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x44, 0x61,
+	0x74, 0x61, 0x2e, 0x54, 0x72, 0x61, 0x69, 0x6c, 0x65, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x07, 0x74, 0x72, 0x61, 0x69, 0x6c, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67,
+	0x72, 0x65, 0x73, 0x73, 0x12, 0x24, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x68,
+	0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x07, 0x52, 0x08, 0x63, 0x68,
+	0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x65, 0x62, 0x75, 0x67, 0x5f,
+	0x6c, 0x6f, 0x67, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x65, 0x62, 0x75, 0x67,
+	0x4c, 0x6f, 0x67, 0x1a, 0x3a, 0x0a, 0x0c, 0x54, 0x72, 0x61, 0x69, 0x6c, 0x65, 0x72, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x4a,
+	0x04, 0x08, 0x0c, 0x10, 0x0d, 0x22, 0xd1, 0x01, 0x0a, 0x0d, 0x44, 0x65, 0x62, 0x75, 0x67, 0x4c,
+	0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x20, 0x0a, 0x0c, 0x74, 0x69, 0x6d, 0x65, 0x5f,
+	0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74,
+	0x69, 0x6d, 0x65, 0x55, 0x6e, 0x69, 0x78, 0x4d, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x34, 0x0a, 0x05, 0x61, 0x74, 0x74,
+	0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e,
+	0x44, 0x65, 0x62, 0x75, 0x67, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x2e, 0x41, 0x74,
+	0x74, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x1a,
+	0x38, 0x0a, 0x0a, 0x41, 0x74, 0x74, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xcd, 0x01, 0x0a, 0x06, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x33, 0x0a, 0x07, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x73, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x2e, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x72, 0x65, 0x74, 0x72, 0x79,
+	0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0c, 0x72, 0x65, 0x74, 0x72, 0x79, 0x41, 0x66, 0x74, 0x65, 0x72, 0x4d, 0x73, 0x1a, 0x3a, 0x0a,
+	0x0c, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -325,20 +1170,37 @@ func file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescGZIP() [
 	return file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDescData
 }
 
-var file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_goTypes = []interface{}{
-	(*Packet)(nil),    // 0: srpc.Packet
-	(*CallStart)(nil), // 1: srpc.CallStart
-	(*CallData)(nil),  // 2: srpc.CallData
+	(*Packet)(nil),          // 0: srpc.Packet
+	(*Capabilities)(nil),    // 1: srpc.Capabilities
+	(*CallStart)(nil),       // 2: srpc.CallStart
+	(*AttachmentStart)(nil), // 3: srpc.AttachmentStart
+	(*CallStartResp)(nil),   // 4: srpc.CallStartResp
+	(*CallData)(nil),        // 5: srpc.CallData
+	(*DebugLogEntry)(nil),   // 6: srpc.DebugLogEntry
+	(*Status)(nil),          // 7: srpc.Status
+	nil,                     // 8: srpc.CallStart.MetadataEntry
+	nil,                     // 9: srpc.CallData.TrailerEntry
+	nil,                     // 10: srpc.DebugLogEntry.AttrsEntry
+	nil,                     // 11: srpc.Status.DetailsEntry
 }
 var file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_depIdxs = []int32{
-	1, // 0: srpc.Packet.call_start:type_name -> srpc.CallStart
-	2, // 1: srpc.Packet.call_data:type_name -> srpc.CallData
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2,  // 0: srpc.Packet.call_start:type_name -> srpc.CallStart
+	5,  // 1: srpc.Packet.call_data:type_name -> srpc.CallData
+	4,  // 2: srpc.Packet.call_start_resp:type_name -> srpc.CallStartResp
+	1,  // 3: srpc.Packet.capabilities:type_name -> srpc.Capabilities
+	8,  // 4: srpc.CallStart.metadata:type_name -> srpc.CallStart.MetadataEntry
+	3,  // 5: srpc.CallStart.attachment:type_name -> srpc.AttachmentStart
+	9,  // 6: srpc.CallData.trailer:type_name -> srpc.CallData.TrailerEntry
+	7,  // 7: srpc.CallData.status:type_name -> srpc.Status
+	10, // 8: srpc.DebugLogEntry.attrs:type_name -> srpc.DebugLogEntry.AttrsEntry
+	11, // 9: srpc.Status.details:type_name -> srpc.Status.DetailsEntry
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_init() }
@@ -360,7 +1222,7 @@ func file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_init() {
 			}
 		}
 		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CallStart); i {
+			switch v := v.(*Capabilities); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -372,6 +1234,42 @@ func file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_init() {
 			}
 		}
 		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallStart); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AttachmentStart); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallStartResp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*CallData); i {
 			case 0:
 				return &v.state
@@ -383,11 +1281,43 @@ func file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_init() {
 				return nil
 			}
 		}
+		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DebugLogEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Status); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_msgTypes[0].OneofWrappers = []interface{}{
 		(*Packet_CallStart)(nil),
 		(*Packet_CallData)(nil),
 		(*Packet_CallCancel)(nil),
+		(*Packet_Ping)(nil),
+		(*Packet_Pong)(nil),
+		(*Packet_CallStartResp)(nil),
+		(*Packet_CapabilitiesRequest)(nil),
+		(*Packet_Capabilities)(nil),
+		(*Packet_CallCancelReason)(nil),
+		(*Packet_CallDemand)(nil),
+		(*Packet_AttachmentKey)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -395,7 +1325,7 @@ func file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_github_com_aperturerobotics_starpc_srpc_rpcproto_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
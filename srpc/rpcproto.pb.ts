@@ -13,6 +13,46 @@ export interface Packet {
         $case: 'callCancel'
         callCancel: boolean
       }
+    | {
+        $case: 'ping'
+        ping: Long
+      }
+    | {
+        $case: 'pong'
+        pong: Long
+      }
+    | { $case: 'callStartResp'; callStartResp: CallStartResp }
+    | {
+        $case: 'capabilitiesRequest'
+        capabilitiesRequest: boolean
+      }
+    | { $case: 'capabilities'; capabilities: Capabilities }
+    | {
+        $case: 'callCancelReason'
+        callCancelReason: string
+      }
+    | {
+        $case: 'callDemand'
+        callDemand: number
+      }
+    | {
+        $case: 'attachmentKey'
+        attachmentKey: Uint8Array
+      }
+}
+
+/** Capabilities describes the optional features supported by the server. */
+export interface Capabilities {
+  /** Compression contains the names of the supported stream compressors. */
+  compression: string[]
+  /** Codecs contains the names of the codecs supported for negotiation. */
+  codecs: string[]
+  /** MaxMessageSize is the max size of a message in bytes. */
+  maxMessageSize: number
+  /** ChecksumsRequired indicates calls must enable packet checksums. */
+  checksumsRequired: boolean
+  /** ResumableStreams indicates server-streaming calls can be resumed. */
+  resumableStreams: boolean
 }
 
 /** CallStart requests starting a new RPC call. */
@@ -34,6 +74,118 @@ export interface CallStart {
   data: Uint8Array
   /** DataIsZero indicates Data is set with an empty message. */
   dataIsZero: boolean
+  /** Metadata contains optional key/value pairs sent with the call. */
+  metadata: { [key: string]: string }
+  /**
+   * ExtraData contains additional messages in the stream following Data.
+   * Optional: batches several initial messages with the call start.
+   * If set, Data or DataIsZero must also be set.
+   */
+  extraData: Uint8Array[]
+  /**
+   * Compression is the name of the stream compressor applied to the messages.
+   * If set, all messages of the call in both directions are compressed as a
+   * single stream. If empty, messages are not compressed.
+   */
+  compression: string
+  /**
+   * ResumeToken resumes the call with the token sent by the server.
+   * If a call with the token is in progress the server resumes sending its
+   * messages instead of invoking the method again.
+   * If empty, the call is not resumed.
+   */
+  resumeToken: string
+  /**
+   * ResumeOffset is the number of messages the client already received.
+   * The server sends the messages of the call starting at the offset.
+   */
+  resumeOffset: Long
+  /**
+   * Checksum enables CRC-32C checksums of the data of the call.
+   * If set, all CallData packets sent by the client contain the checksum of
+   * their data, verified by the server. The server confirms with the
+   * CallStartResp checksum field: after the confirmation all CallData packets
+   * sent by the server contain the checksum, verified by the client.
+   */
+  checksum: boolean
+  /**
+   * DataChecksum is the CRC-32C checksum of Data followed by ExtraData.
+   * Only set if checksum is set.
+   */
+  dataChecksum: number
+  /**
+   * Codecs is the ordered list of codecs accepted by the client, preferred first.
+   * If set, the server selects the first codec it supports and answers with
+   * CallStartResp. The messages of the call are encoded with the selected codec.
+   * The client does not send messages before receiving the CallStartResp.
+   * If empty, messages are encoded with protobuf.
+   */
+  codecs: string[]
+  /**
+   * InitialDemand enables demand flow control with the initial demand.
+   * The server sends at most the number of messages requested by the client
+   * with initial_demand and CallDemand packets.
+   * If zero, demand flow control is disabled.
+   */
+  initialDemand: number
+  /**
+   * Ack requests a CallStartResp when the server accepts the call.
+   * The CallStartResp is sent before any messages of the call.
+   */
+  ack: boolean
+  /**
+   * Attachment indicates the stream carries an attachment of another call.
+   * If set, rpc_service and rpc_method must be empty: no method is invoked.
+   */
+  attachment: AttachmentStart | undefined
+  /**
+   * Resumable requests a resumable call.
+   * If supported, the server answers with a CallStartResp containing the token
+   * to resume the call with.
+   */
+  resumable: boolean
+}
+
+export interface CallStart_MetadataEntry {
+  key: string
+  value: string
+}
+
+/** AttachmentStart opens a stream carrying an attachment of a call. */
+export interface AttachmentStart {
+  /** CallKey is the attachment key registered by the call. */
+  callKey: Uint8Array
+  /** Id is the ID of the attachment referenced by the messages of the call. */
+  id: number
+  /**
+   * Pull requests the attachment opened by the server with the ID.
+   * The server sends the attachment data as CallData packets on the stream.
+   * Otherwise the client sends the attachment data on the stream.
+   */
+  pull: boolean
+}
+
+/**
+ * CallStartResp answers a CallStart which offered codecs, requested an ack,
+ * or requested a resumable call.
+ */
+export interface CallStartResp {
+  /**
+   * Codec is the name of the codec selected by the server.
+   * Empty if the call did not offer codecs.
+   */
+  codec: string
+  /**
+   * ResumeToken is the token to resume the call with.
+   * Empty if the call is not resumable.
+   */
+  resumeToken: string
+  /**
+   * Checksum confirms the server enabled the checksums requested with the
+   * call start. The CallData packets sent by the server after the
+   * CallStartResp contain the checksum of their data.
+   */
+  checksum: boolean
 }
 
 /** CallData contains a message in a streaming RPC sequence. */
@@ -49,6 +201,93 @@ export interface CallData {
    * If set, implies complete=true.
    */
   error: string
+  /**
+   * Heartbeat indicates this is a keep-alive packet with no data.
+   * Receivers should ignore heartbeat packets.
+   */
+  heartbeat: boolean
+  /**
+   * Fragment indicates Data is a fragment of a message.
+   * The message continues in the next CallData packet.
+   * The last fragment of the message has fragment=false.
+   */
+  fragment: boolean
+  /**
+   * Seq is the sequence number of the packet in the call, starting at 1.
+   * Incremented for each CallData packet sent, excluding heartbeats.
+   * If zero, sequence numbers are not used.
+   */
+  seq: number
+  /**
+   * Trailer contains metadata sent with the final packet of the call.
+   * Only set if complete or error is set.
+   */
+  trailer: { [key: string]: string }
+  /**
+   * Progress indicates Data contains an interim progress update.
+   * Progress updates are not messages of the call.
+   * Receivers which do not handle progress updates should ignore them.
+   */
+  progress: boolean
+  /**
+   * Status contains the structured status of the error, if any.
+   * Only set if error is set: error contains the status message.
+   */
+  status: Status | undefined
+  /**
+   * Checksum is the CRC-32C checksum of Data.
+   * Only set if checksums were enabled with the call start.
+   * Only Data is covered: the other fields are not checksummed.
+   */
+  checksum: number
+  /**
+   * DebugLog indicates Data contains an encoded DebugLogEntry.
+   * Debug log entries are not messages of the call.
+   * Receivers which do not handle debug log entries should ignore them.
+   */
+  debugLog: boolean
+}
+
+export interface CallData_TrailerEntry {
+  key: string
+  value: string
+}
+
+/** DebugLogEntry is a log entry forwarded from the call handler for debugging. */
+export interface DebugLogEntry {
+  /** TimeUnixMs is the time of the entry in milliseconds since the unix epoch. */
+  timeUnixMs: Long
+  /** Level is the log/slog level of the entry. */
+  level: number
+  /** Message is the log message. */
+  message: string
+  /** Attrs contains the attributes of the entry formatted as strings. */
+  attrs: { [key: string]: string }
+}
+
+export interface DebugLogEntry_AttrsEntry {
+  key: string
+  value: string
+}
+
+/** Status is the structured status of a failed call. */
+export interface Status {
+  /** Code is the status code. */
+  code: number
+  /** Message is the error message. */
+  message: string
+  /** Details contains additional information about the error. */
+  details: { [key: string]: string }
+  /**
+   * RetryAfterMs is the minimum time to wait before retrying the call.
+   * Zero if there is no hint.
+   */
+  retryAfterMs: Long
+}
+
+export interface Status_DetailsEntry {
+  key: string
+  value: string
 }
 
 function createBasePacket(): Packet {
@@ -72,6 +311,36 @@ export const Packet = {
     if (message.body?.$case === 'callCancel') {
       writer.uint32(24).bool(message.body.callCancel)
     }
+    if (message.body?.$case === 'ping') {
+      writer.uint32(32).uint64(message.body.ping)
+    }
+    if (message.body?.$case === 'pong') {
+      writer.uint32(40).uint64(message.body.pong)
+    }
+    if (message.body?.$case === 'callStartResp') {
+      CallStartResp.encode(
+        message.body.callStartResp,
+        writer.uint32(50).fork()
+      ).ldelim()
+    }
+    if (message.body?.$case === 'capabilitiesRequest') {
+      writer.uint32(56).bool(message.body.capabilitiesRequest)
+    }
+    if (message.body?.$case === 'capabilities') {
+      Capabilities.encode(
+        message.body.capabilities,
+        writer.uint32(66).fork()
+      ).ldelim()
+    }
+    if (message.body?.$case === 'callCancelReason') {
+      writer.uint32(74).string(message.body.callCancelReason)
+    }
+    if (message.body?.$case === 'callDemand') {
+      writer.uint32(80).uint32(message.body.callDemand)
+    }
+    if (message.body?.$case === 'attachmentKey') {
+      writer.uint32(90).bytes(message.body.attachmentKey)
+    }
     return writer
   },
 
@@ -97,6 +366,45 @@ export const Packet = {
         case 3:
           message.body = { $case: 'callCancel', callCancel: reader.bool() }
           break
+        case 4:
+          message.body = { $case: 'ping', ping: reader.uint64() as Long }
+          break
+        case 5:
+          message.body = { $case: 'pong', pong: reader.uint64() as Long }
+          break
+        case 6:
+          message.body = {
+            $case: 'callStartResp',
+            callStartResp: CallStartResp.decode(reader, reader.uint32()),
+          }
+          break
+        case 7:
+          message.body = {
+            $case: 'capabilitiesRequest',
+            capabilitiesRequest: reader.bool(),
+          }
+          break
+        case 8:
+          message.body = {
+            $case: 'capabilities',
+            capabilities: Capabilities.decode(reader, reader.uint32()),
+          }
+          break
+        case 9:
+          message.body = {
+            $case: 'callCancelReason',
+            callCancelReason: reader.string(),
+          }
+          break
+        case 10:
+          message.body = { $case: 'callDemand', callDemand: reader.uint32() }
+          break
+        case 11:
+          message.body = {
+            $case: 'attachmentKey',
+            attachmentKey: reader.bytes(),
+          }
+          break
         default:
           reader.skipType(tag & 7)
           break
@@ -150,6 +458,37 @@ export const Packet = {
         ? { $case: 'callData', callData: CallData.fromJSON(object.callData) }
         : isSet(object.callCancel)
         ? { $case: 'callCancel', callCancel: Boolean(object.callCancel) }
+        : isSet(object.ping)
+        ? { $case: 'ping', ping: Long.fromValue(object.ping) }
+        : isSet(object.pong)
+        ? { $case: 'pong', pong: Long.fromValue(object.pong) }
+        : isSet(object.callStartResp)
+        ? {
+            $case: 'callStartResp',
+            callStartResp: CallStartResp.fromJSON(object.callStartResp),
+          }
+        : isSet(object.capabilitiesRequest)
+        ? {
+            $case: 'capabilitiesRequest',
+            capabilitiesRequest: Boolean(object.capabilitiesRequest),
+          }
+        : isSet(object.capabilities)
+        ? {
+            $case: 'capabilities',
+            capabilities: Capabilities.fromJSON(object.capabilities),
+          }
+        : isSet(object.callCancelReason)
+        ? {
+            $case: 'callCancelReason',
+            callCancelReason: String(object.callCancelReason),
+          }
+        : isSet(object.callDemand)
+        ? { $case: 'callDemand', callDemand: Number(object.callDemand) }
+        : isSet(object.attachmentKey)
+        ? {
+            $case: 'attachmentKey',
+            attachmentKey: bytesFromBase64(object.attachmentKey),
+          }
         : undefined,
     }
   },
@@ -166,6 +505,28 @@ export const Packet = {
         : undefined)
     message.body?.$case === 'callCancel' &&
       (obj.callCancel = message.body?.callCancel)
+    message.body?.$case === 'ping' &&
+      (obj.ping = (message.body?.ping || Long.UZERO).toString())
+    message.body?.$case === 'pong' &&
+      (obj.pong = (message.body?.pong || Long.UZERO).toString())
+    message.body?.$case === 'callStartResp' &&
+      (obj.callStartResp = message.body?.callStartResp
+        ? CallStartResp.toJSON(message.body?.callStartResp)
+        : undefined)
+    message.body?.$case === 'capabilitiesRequest' &&
+      (obj.capabilitiesRequest = message.body?.capabilitiesRequest)
+    message.body?.$case === 'capabilities' &&
+      (obj.capabilities = message.body?.capabilities
+        ? Capabilities.toJSON(message.body?.capabilities)
+        : undefined)
+    message.body?.$case === 'callCancelReason' &&
+      (obj.callCancelReason = message.body?.callCancelReason)
+    message.body?.$case === 'callDemand' &&
+      (obj.callDemand = Math.round(message.body?.callDemand))
+    message.body?.$case === 'attachmentKey' &&
+      (obj.attachmentKey = base64FromBytes(
+        message.body?.attachmentKey !== undefined ? message.body?.attachmentKey : new Uint8Array()
+      ))
     return obj
   },
 
@@ -202,57 +563,135 @@ export const Packet = {
     ) {
       message.body = { $case: 'callCancel', callCancel: object.body.callCancel }
     }
+    if (
+      object.body?.$case === 'ping' &&
+      object.body?.ping !== undefined &&
+      object.body?.ping !== null
+    ) {
+      message.body = { $case: 'ping', ping: Long.fromValue(object.body.ping) }
+    }
+    if (
+      object.body?.$case === 'pong' &&
+      object.body?.pong !== undefined &&
+      object.body?.pong !== null
+    ) {
+      message.body = { $case: 'pong', pong: Long.fromValue(object.body.pong) }
+    }
+    if (
+      object.body?.$case === 'callStartResp' &&
+      object.body?.callStartResp !== undefined &&
+      object.body?.callStartResp !== null
+    ) {
+      message.body = {
+        $case: 'callStartResp',
+        callStartResp: CallStartResp.fromPartial(object.body.callStartResp),
+      }
+    }
+    if (
+      object.body?.$case === 'capabilitiesRequest' &&
+      object.body?.capabilitiesRequest !== undefined &&
+      object.body?.capabilitiesRequest !== null
+    ) {
+      message.body = {
+        $case: 'capabilitiesRequest',
+        capabilitiesRequest: object.body.capabilitiesRequest,
+      }
+    }
+    if (
+      object.body?.$case === 'capabilities' &&
+      object.body?.capabilities !== undefined &&
+      object.body?.capabilities !== null
+    ) {
+      message.body = {
+        $case: 'capabilities',
+        capabilities: Capabilities.fromPartial(object.body.capabilities),
+      }
+    }
+    if (
+      object.body?.$case === 'callCancelReason' &&
+      object.body?.callCancelReason !== undefined &&
+      object.body?.callCancelReason !== null
+    ) {
+      message.body = {
+        $case: 'callCancelReason',
+        callCancelReason: object.body.callCancelReason,
+      }
+    }
+    if (
+      object.body?.$case === 'callDemand' &&
+      object.body?.callDemand !== undefined &&
+      object.body?.callDemand !== null
+    ) {
+      message.body = { $case: 'callDemand', callDemand: object.body.callDemand }
+    }
+    if (
+      object.body?.$case === 'attachmentKey' &&
+      object.body?.attachmentKey !== undefined &&
+      object.body?.attachmentKey !== null
+    ) {
+      message.body = {
+        $case: 'attachmentKey',
+        attachmentKey: object.body.attachmentKey,
+      }
+    }
     return message
   },
 }
 
-function createBaseCallStart(): CallStart {
+function createBaseCapabilities(): Capabilities {
   return {
-    rpcService: '',
-    rpcMethod: '',
-    data: new Uint8Array(),
-    dataIsZero: false,
+    compression: [],
+    codecs: [],
+    maxMessageSize: 0,
+    checksumsRequired: false,
+    resumableStreams: false,
   }
 }
 
-export const CallStart = {
+export const Capabilities = {
   encode(
-    message: CallStart,
+    message: Capabilities,
     writer: _m0.Writer = _m0.Writer.create()
   ): _m0.Writer {
-    if (message.rpcService !== '') {
-      writer.uint32(10).string(message.rpcService)
+    for (const v of message.compression) {
+      writer.uint32(10).string(v!)
     }
-    if (message.rpcMethod !== '') {
-      writer.uint32(18).string(message.rpcMethod)
+    for (const v of message.codecs) {
+      writer.uint32(18).string(v!)
     }
-    if (message.data.length !== 0) {
-      writer.uint32(26).bytes(message.data)
+    if (message.maxMessageSize !== 0) {
+      writer.uint32(24).uint32(message.maxMessageSize)
     }
-    if (message.dataIsZero === true) {
-      writer.uint32(32).bool(message.dataIsZero)
+    if (message.checksumsRequired === true) {
+      writer.uint32(32).bool(message.checksumsRequired)
+    }
+    if (message.resumableStreams === true) {
+      writer.uint32(40).bool(message.resumableStreams)
     }
     return writer
   },
 
-  decode(input: _m0.Reader | Uint8Array, length?: number): CallStart {
+  decode(input: _m0.Reader | Uint8Array, length?: number): Capabilities {
     const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
     let end = length === undefined ? reader.len : reader.pos + length
-    const message = createBaseCallStart()
+    const message = createBaseCapabilities()
     while (reader.pos < end) {
       const tag = reader.uint32()
       switch (tag >>> 3) {
         case 1:
-          message.rpcService = reader.string()
+          message.compression.push(reader.string())
           break
         case 2:
-          message.rpcMethod = reader.string()
+          message.codecs.push(reader.string())
           break
         case 3:
-          message.data = reader.bytes()
+          message.maxMessageSize = reader.uint32()
           break
         case 4:
-          message.dataIsZero = reader.bool()
+          message.checksumsRequired = reader.bool()
+          break
+        case 5:
+          message.resumableStreams = reader.bool()
           break
         default:
           reader.skipType(tag & 7)
@@ -263,127 +702,1155 @@ export const CallStart = {
   },
 
   // encodeTransform encodes a source of message objects.
-  // Transform<CallStart, Uint8Array>
+  // Transform<Capabilities, Uint8Array>
   async *encodeTransform(
     source:
-      | AsyncIterable<CallStart | CallStart[]>
-      | Iterable<CallStart | CallStart[]>
+      | AsyncIterable<Capabilities | Capabilities[]>
+      | Iterable<Capabilities | Capabilities[]>
   ): AsyncIterable<Uint8Array> {
     for await (const pkt of source) {
       if (Array.isArray(pkt)) {
         for (const p of pkt) {
-          yield* [CallStart.encode(p).finish()]
+          yield* [Capabilities.encode(p).finish()]
         }
       } else {
-        yield* [CallStart.encode(pkt).finish()]
+        yield* [Capabilities.encode(pkt).finish()]
       }
     }
   },
 
   // decodeTransform decodes a source of encoded messages.
-  // Transform<Uint8Array, CallStart>
+  // Transform<Uint8Array, Capabilities>
   async *decodeTransform(
     source:
       | AsyncIterable<Uint8Array | Uint8Array[]>
       | Iterable<Uint8Array | Uint8Array[]>
-  ): AsyncIterable<CallStart> {
+  ): AsyncIterable<Capabilities> {
     for await (const pkt of source) {
       if (Array.isArray(pkt)) {
         for (const p of pkt) {
-          yield* [CallStart.decode(p)]
+          yield* [Capabilities.decode(p)]
         }
       } else {
-        yield* [CallStart.decode(pkt)]
+        yield* [Capabilities.decode(pkt)]
       }
     }
   },
 
-  fromJSON(object: any): CallStart {
+  fromJSON(object: any): Capabilities {
     return {
-      rpcService: isSet(object.rpcService) ? String(object.rpcService) : '',
-      rpcMethod: isSet(object.rpcMethod) ? String(object.rpcMethod) : '',
-      data: isSet(object.data)
-        ? bytesFromBase64(object.data)
-        : new Uint8Array(),
-      dataIsZero: isSet(object.dataIsZero) ? Boolean(object.dataIsZero) : false,
+      compression: Array.isArray(object?.compression)
+        ? object.compression.map((e: any) => String(e))
+        : [],
+      codecs: Array.isArray(object?.codecs)
+        ? object.codecs.map((e: any) => String(e))
+        : [],
+      maxMessageSize: isSet(object.maxMessageSize)
+        ? Number(object.maxMessageSize)
+        : 0,
+      checksumsRequired: isSet(object.checksumsRequired)
+        ? Boolean(object.checksumsRequired)
+        : false,
+      resumableStreams: isSet(object.resumableStreams)
+        ? Boolean(object.resumableStreams)
+        : false,
     }
   },
 
-  toJSON(message: CallStart): unknown {
+  toJSON(message: Capabilities): unknown {
     const obj: any = {}
-    message.rpcService !== undefined && (obj.rpcService = message.rpcService)
-    message.rpcMethod !== undefined && (obj.rpcMethod = message.rpcMethod)
-    message.data !== undefined &&
-      (obj.data = base64FromBytes(
-        message.data !== undefined ? message.data : new Uint8Array()
-      ))
-    message.dataIsZero !== undefined && (obj.dataIsZero = message.dataIsZero)
+    if (message.compression) {
+      obj.compression = message.compression.map((e) => e)
+    } else {
+      obj.compression = []
+    }
+    if (message.codecs) {
+      obj.codecs = message.codecs.map((e) => e)
+    } else {
+      obj.codecs = []
+    }
+    message.maxMessageSize !== undefined &&
+      (obj.maxMessageSize = Math.round(message.maxMessageSize))
+    message.checksumsRequired !== undefined &&
+      (obj.checksumsRequired = message.checksumsRequired)
+    message.resumableStreams !== undefined &&
+      (obj.resumableStreams = message.resumableStreams)
     return obj
   },
 
-  create<I extends Exact<DeepPartial<CallStart>, I>>(base?: I): CallStart {
-    return CallStart.fromPartial(base ?? {})
+  create<I extends Exact<DeepPartial<Capabilities>, I>>(
+    base?: I
+  ): Capabilities {
+    return Capabilities.fromPartial(base ?? {})
   },
 
-  fromPartial<I extends Exact<DeepPartial<CallStart>, I>>(
+  fromPartial<I extends Exact<DeepPartial<Capabilities>, I>>(
     object: I
-  ): CallStart {
-    const message = createBaseCallStart()
-    message.rpcService = object.rpcService ?? ''
-    message.rpcMethod = object.rpcMethod ?? ''
-    message.data = object.data ?? new Uint8Array()
-    message.dataIsZero = object.dataIsZero ?? false
+  ): Capabilities {
+    const message = createBaseCapabilities()
+    message.compression = object.compression?.map((e) => e) || []
+    message.codecs = object.codecs?.map((e) => e) || []
+    message.maxMessageSize = object.maxMessageSize ?? 0
+    message.checksumsRequired = object.checksumsRequired ?? false
+    message.resumableStreams = object.resumableStreams ?? false
     return message
   },
 }
 
-function createBaseCallData(): CallData {
+function createBaseCallStart(): CallStart {
   return {
+    rpcService: '',
+    rpcMethod: '',
     data: new Uint8Array(),
     dataIsZero: false,
-    complete: false,
-    error: '',
+    metadata: {},
+    extraData: [],
+    compression: '',
+    resumeToken: '',
+    resumeOffset: Long.UZERO,
+    checksum: false,
+    dataChecksum: 0,
+    codecs: [],
+    initialDemand: 0,
+    ack: false,
+    attachment: undefined,
+    resumable: false,
   }
 }
 
-export const CallData = {
+export const CallStart = {
   encode(
-    message: CallData,
+    message: CallStart,
     writer: _m0.Writer = _m0.Writer.create()
   ): _m0.Writer {
+    if (message.rpcService !== '') {
+      writer.uint32(10).string(message.rpcService)
+    }
+    if (message.rpcMethod !== '') {
+      writer.uint32(18).string(message.rpcMethod)
+    }
     if (message.data.length !== 0) {
-      writer.uint32(10).bytes(message.data)
+      writer.uint32(26).bytes(message.data)
     }
     if (message.dataIsZero === true) {
-      writer.uint32(16).bool(message.dataIsZero)
+      writer.uint32(32).bool(message.dataIsZero)
     }
-    if (message.complete === true) {
-      writer.uint32(24).bool(message.complete)
+    Object.entries(message.metadata).forEach(([key, value]) => {
+      CallStart_MetadataEntry.encode(
+        { key: key as any, value },
+        writer.uint32(42).fork()
+      ).ldelim()
+    })
+    for (const v of message.extraData) {
+      writer.uint32(50).bytes(v!)
     }
-    if (message.error !== '') {
-      writer.uint32(34).string(message.error)
+    if (message.compression !== '') {
+      writer.uint32(58).string(message.compression)
+    }
+    if (message.resumeToken !== '') {
+      writer.uint32(66).string(message.resumeToken)
+    }
+    if (!message.resumeOffset.isZero()) {
+      writer.uint32(72).uint64(message.resumeOffset)
+    }
+    if (message.checksum === true) {
+      writer.uint32(80).bool(message.checksum)
+    }
+    if (message.dataChecksum !== 0) {
+      writer.uint32(93).fixed32(message.dataChecksum)
+    }
+    for (const v of message.codecs) {
+      writer.uint32(98).string(v!)
+    }
+    if (message.initialDemand !== 0) {
+      writer.uint32(104).uint32(message.initialDemand)
+    }
+    if (message.ack === true) {
+      writer.uint32(112).bool(message.ack)
+    }
+    if (message.attachment !== undefined) {
+      AttachmentStart.encode(
+        message.attachment,
+        writer.uint32(122).fork()
+      ).ldelim()
+    }
+    if (message.resumable === true) {
+      writer.uint32(128).bool(message.resumable)
     }
     return writer
   },
 
-  decode(input: _m0.Reader | Uint8Array, length?: number): CallData {
+  decode(input: _m0.Reader | Uint8Array, length?: number): CallStart {
     const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
     let end = length === undefined ? reader.len : reader.pos + length
-    const message = createBaseCallData()
+    const message = createBaseCallStart()
     while (reader.pos < end) {
       const tag = reader.uint32()
       switch (tag >>> 3) {
         case 1:
-          message.data = reader.bytes()
+          message.rpcService = reader.string()
           break
         case 2:
-          message.dataIsZero = reader.bool()
+          message.rpcMethod = reader.string()
+          break
+        case 3:
+          message.data = reader.bytes()
+          break
+        case 4:
+          message.dataIsZero = reader.bool()
+          break
+        case 5:
+          const entry5 = CallStart_MetadataEntry.decode(reader, reader.uint32())
+          if (entry5.value !== undefined) {
+            message.metadata[entry5.key] = entry5.value
+          }
+          break
+        case 6:
+          message.extraData.push(reader.bytes())
+          break
+        case 7:
+          message.compression = reader.string()
+          break
+        case 8:
+          message.resumeToken = reader.string()
+          break
+        case 9:
+          message.resumeOffset = reader.uint64() as Long
+          break
+        case 10:
+          message.checksum = reader.bool()
+          break
+        case 11:
+          message.dataChecksum = reader.fixed32()
+          break
+        case 12:
+          message.codecs.push(reader.string())
+          break
+        case 13:
+          message.initialDemand = reader.uint32()
+          break
+        case 14:
+          message.ack = reader.bool()
+          break
+        case 15:
+          message.attachment = AttachmentStart.decode(reader, reader.uint32())
+          break
+        case 16:
+          message.resumable = reader.bool()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<CallStart, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<CallStart | CallStart[]>
+      | Iterable<CallStart | CallStart[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallStart.encode(p).finish()]
+        }
+      } else {
+        yield* [CallStart.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, CallStart>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<CallStart> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallStart.decode(p)]
+        }
+      } else {
+        yield* [CallStart.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): CallStart {
+    return {
+      rpcService: isSet(object.rpcService) ? String(object.rpcService) : '',
+      rpcMethod: isSet(object.rpcMethod) ? String(object.rpcMethod) : '',
+      data: isSet(object.data)
+        ? bytesFromBase64(object.data)
+        : new Uint8Array(),
+      dataIsZero: isSet(object.dataIsZero) ? Boolean(object.dataIsZero) : false,
+      metadata: isObject(object.metadata)
+        ? Object.entries(object.metadata).reduce<{ [key: string]: string }>(
+            (acc, [key, value]) => {
+              acc[key] = String(value)
+              return acc
+            },
+            {}
+          )
+        : {},
+      extraData: Array.isArray(object?.extraData)
+        ? object.extraData.map((e: any) => bytesFromBase64(e))
+        : [],
+      compression: isSet(object.compression) ? String(object.compression) : '',
+      resumeToken: isSet(object.resumeToken) ? String(object.resumeToken) : '',
+      resumeOffset: isSet(object.resumeOffset)
+        ? Long.fromValue(object.resumeOffset)
+        : Long.UZERO,
+      checksum: isSet(object.checksum) ? Boolean(object.checksum) : false,
+      dataChecksum: isSet(object.dataChecksum)
+        ? Number(object.dataChecksum)
+        : 0,
+      codecs: Array.isArray(object?.codecs)
+        ? object.codecs.map((e: any) => String(e))
+        : [],
+      initialDemand: isSet(object.initialDemand)
+        ? Number(object.initialDemand)
+        : 0,
+      ack: isSet(object.ack) ? Boolean(object.ack) : false,
+      attachment: isSet(object.attachment)
+        ? AttachmentStart.fromJSON(object.attachment)
+        : undefined,
+      resumable: isSet(object.resumable) ? Boolean(object.resumable) : false,
+    }
+  },
+
+  toJSON(message: CallStart): unknown {
+    const obj: any = {}
+    message.rpcService !== undefined && (obj.rpcService = message.rpcService)
+    message.rpcMethod !== undefined && (obj.rpcMethod = message.rpcMethod)
+    message.data !== undefined &&
+      (obj.data = base64FromBytes(
+        message.data !== undefined ? message.data : new Uint8Array()
+      ))
+    message.dataIsZero !== undefined && (obj.dataIsZero = message.dataIsZero)
+    obj.metadata = {}
+    if (message.metadata) {
+      Object.entries(message.metadata).forEach(([k, v]) => {
+        obj.metadata[k] = v
+      })
+    }
+    if (message.extraData) {
+      obj.extraData = message.extraData.map((e) =>
+        base64FromBytes(e !== undefined ? e : new Uint8Array())
+      )
+    } else {
+      obj.extraData = []
+    }
+    message.compression !== undefined && (obj.compression = message.compression)
+    message.resumeToken !== undefined && (obj.resumeToken = message.resumeToken)
+    message.resumeOffset !== undefined &&
+      (obj.resumeOffset = (message.resumeOffset || Long.UZERO).toString())
+    message.checksum !== undefined && (obj.checksum = message.checksum)
+    message.dataChecksum !== undefined &&
+      (obj.dataChecksum = Math.round(message.dataChecksum))
+    if (message.codecs) {
+      obj.codecs = message.codecs.map((e) => e)
+    } else {
+      obj.codecs = []
+    }
+    message.initialDemand !== undefined &&
+      (obj.initialDemand = Math.round(message.initialDemand))
+    message.ack !== undefined && (obj.ack = message.ack)
+    message.attachment !== undefined &&
+      (obj.attachment = message.attachment
+        ? AttachmentStart.toJSON(message.attachment)
+        : undefined)
+    message.resumable !== undefined && (obj.resumable = message.resumable)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<CallStart>, I>>(base?: I): CallStart {
+    return CallStart.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<CallStart>, I>>(
+    object: I
+  ): CallStart {
+    const message = createBaseCallStart()
+    message.rpcService = object.rpcService ?? ''
+    message.rpcMethod = object.rpcMethod ?? ''
+    message.data = object.data ?? new Uint8Array()
+    message.dataIsZero = object.dataIsZero ?? false
+    message.metadata = Object.entries(object.metadata ?? {}).reduce<{
+      [key: string]: string
+    }>((acc, [key, value]) => {
+      if (value !== undefined) {
+        acc[key] = String(value)
+      }
+      return acc
+    }, {})
+    message.extraData = object.extraData?.map((e) => e) || []
+    message.compression = object.compression ?? ''
+    message.resumeToken = object.resumeToken ?? ''
+    message.resumeOffset =
+      object.resumeOffset !== undefined && object.resumeOffset !== null
+        ? Long.fromValue(object.resumeOffset)
+        : Long.UZERO
+    message.checksum = object.checksum ?? false
+    message.dataChecksum = object.dataChecksum ?? 0
+    message.codecs = object.codecs?.map((e) => e) || []
+    message.initialDemand = object.initialDemand ?? 0
+    message.ack = object.ack ?? false
+    message.attachment =
+      object.attachment !== undefined && object.attachment !== null
+        ? AttachmentStart.fromPartial(object.attachment)
+        : undefined
+    message.resumable = object.resumable ?? false
+    return message
+  },
+}
+
+function createBaseCallStart_MetadataEntry(): CallStart_MetadataEntry {
+  return { key: '', value: '' }
+}
+
+export const CallStart_MetadataEntry = {
+  encode(
+    message: CallStart_MetadataEntry,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.key !== '') {
+      writer.uint32(10).string(message.key)
+    }
+    if (message.value !== '') {
+      writer.uint32(18).string(message.value)
+    }
+    return writer
+  },
+
+  decode(
+    input: _m0.Reader | Uint8Array,
+    length?: number
+  ): CallStart_MetadataEntry {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseCallStart_MetadataEntry()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.key = reader.string()
+          break
+        case 2:
+          message.value = reader.string()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<CallStart_MetadataEntry, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<CallStart_MetadataEntry | CallStart_MetadataEntry[]>
+      | Iterable<CallStart_MetadataEntry | CallStart_MetadataEntry[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallStart_MetadataEntry.encode(p).finish()]
+        }
+      } else {
+        yield* [CallStart_MetadataEntry.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, CallStart_MetadataEntry>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<CallStart_MetadataEntry> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallStart_MetadataEntry.decode(p)]
+        }
+      } else {
+        yield* [CallStart_MetadataEntry.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): CallStart_MetadataEntry {
+    return {
+      key: isSet(object.key) ? String(object.key) : '',
+      value: isSet(object.value) ? String(object.value) : '',
+    }
+  },
+
+  toJSON(message: CallStart_MetadataEntry): unknown {
+    const obj: any = {}
+    message.key !== undefined && (obj.key = message.key)
+    message.value !== undefined && (obj.value = message.value)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<CallStart_MetadataEntry>, I>>(
+    base?: I
+  ): CallStart_MetadataEntry {
+    return CallStart_MetadataEntry.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<CallStart_MetadataEntry>, I>>(
+    object: I
+  ): CallStart_MetadataEntry {
+    const message = createBaseCallStart_MetadataEntry()
+    message.key = object.key ?? ''
+    message.value = object.value ?? ''
+    return message
+  },
+}
+
+function createBaseAttachmentStart(): AttachmentStart {
+  return { callKey: new Uint8Array(), id: 0, pull: false }
+}
+
+export const AttachmentStart = {
+  encode(
+    message: AttachmentStart,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.callKey.length !== 0) {
+      writer.uint32(10).bytes(message.callKey)
+    }
+    if (message.id !== 0) {
+      writer.uint32(16).uint32(message.id)
+    }
+    if (message.pull === true) {
+      writer.uint32(24).bool(message.pull)
+    }
+    return writer
+  },
+
+  decode(input: _m0.Reader | Uint8Array, length?: number): AttachmentStart {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseAttachmentStart()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.callKey = reader.bytes()
+          break
+        case 2:
+          message.id = reader.uint32()
+          break
+        case 3:
+          message.pull = reader.bool()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<AttachmentStart, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<AttachmentStart | AttachmentStart[]>
+      | Iterable<AttachmentStart | AttachmentStart[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [AttachmentStart.encode(p).finish()]
+        }
+      } else {
+        yield* [AttachmentStart.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, AttachmentStart>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<AttachmentStart> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [AttachmentStart.decode(p)]
+        }
+      } else {
+        yield* [AttachmentStart.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): AttachmentStart {
+    return {
+      callKey: isSet(object.callKey)
+        ? bytesFromBase64(object.callKey)
+        : new Uint8Array(),
+      id: isSet(object.id) ? Number(object.id) : 0,
+      pull: isSet(object.pull) ? Boolean(object.pull) : false,
+    }
+  },
+
+  toJSON(message: AttachmentStart): unknown {
+    const obj: any = {}
+    message.callKey !== undefined &&
+      (obj.callKey = base64FromBytes(
+        message.callKey !== undefined ? message.callKey : new Uint8Array()
+      ))
+    message.id !== undefined && (obj.id = Math.round(message.id))
+    message.pull !== undefined && (obj.pull = message.pull)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<AttachmentStart>, I>>(
+    base?: I
+  ): AttachmentStart {
+    return AttachmentStart.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<AttachmentStart>, I>>(
+    object: I
+  ): AttachmentStart {
+    const message = createBaseAttachmentStart()
+    message.callKey = object.callKey ?? new Uint8Array()
+    message.id = object.id ?? 0
+    message.pull = object.pull ?? false
+    return message
+  },
+}
+
+function createBaseCallStartResp(): CallStartResp {
+  return { codec: '', resumeToken: '', checksum: false }
+}
+
+export const CallStartResp = {
+  encode(
+    message: CallStartResp,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.codec !== '') {
+      writer.uint32(10).string(message.codec)
+    }
+    if (message.resumeToken !== '') {
+      writer.uint32(18).string(message.resumeToken)
+    }
+    if (message.checksum === true) {
+      writer.uint32(24).bool(message.checksum)
+    }
+    return writer
+  },
+
+  decode(input: _m0.Reader | Uint8Array, length?: number): CallStartResp {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseCallStartResp()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.codec = reader.string()
+          break
+        case 2:
+          message.resumeToken = reader.string()
+          break
+        case 3:
+          message.checksum = reader.bool()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<CallStartResp, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<CallStartResp | CallStartResp[]>
+      | Iterable<CallStartResp | CallStartResp[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallStartResp.encode(p).finish()]
+        }
+      } else {
+        yield* [CallStartResp.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, CallStartResp>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<CallStartResp> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallStartResp.decode(p)]
+        }
+      } else {
+        yield* [CallStartResp.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): CallStartResp {
+    return {
+      codec: isSet(object.codec) ? String(object.codec) : '',
+      resumeToken: isSet(object.resumeToken) ? String(object.resumeToken) : '',
+      checksum: isSet(object.checksum) ? Boolean(object.checksum) : false,
+    }
+  },
+
+  toJSON(message: CallStartResp): unknown {
+    const obj: any = {}
+    message.codec !== undefined && (obj.codec = message.codec)
+    message.resumeToken !== undefined && (obj.resumeToken = message.resumeToken)
+    message.checksum !== undefined && (obj.checksum = message.checksum)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<CallStartResp>, I>>(
+    base?: I
+  ): CallStartResp {
+    return CallStartResp.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<CallStartResp>, I>>(
+    object: I
+  ): CallStartResp {
+    const message = createBaseCallStartResp()
+    message.codec = object.codec ?? ''
+    message.resumeToken = object.resumeToken ?? ''
+    message.checksum = object.checksum ?? false
+    return message
+  },
+}
+
+function createBaseCallData(): CallData {
+  return {
+    data: new Uint8Array(),
+    dataIsZero: false,
+    complete: false,
+    error: '',
+    heartbeat: false,
+    fragment: false,
+    seq: 0,
+    trailer: {},
+    progress: false,
+    status: undefined,
+    checksum: 0,
+    debugLog: false,
+  }
+}
+
+export const CallData = {
+  encode(
+    message: CallData,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.data.length !== 0) {
+      writer.uint32(10).bytes(message.data)
+    }
+    if (message.dataIsZero === true) {
+      writer.uint32(16).bool(message.dataIsZero)
+    }
+    if (message.complete === true) {
+      writer.uint32(24).bool(message.complete)
+    }
+    if (message.error !== '') {
+      writer.uint32(34).string(message.error)
+    }
+    if (message.heartbeat === true) {
+      writer.uint32(40).bool(message.heartbeat)
+    }
+    if (message.fragment === true) {
+      writer.uint32(48).bool(message.fragment)
+    }
+    if (message.seq !== 0) {
+      writer.uint32(56).uint32(message.seq)
+    }
+    Object.entries(message.trailer).forEach(([key, value]) => {
+      CallData_TrailerEntry.encode(
+        { key: key as any, value },
+        writer.uint32(66).fork()
+      ).ldelim()
+    })
+    if (message.progress === true) {
+      writer.uint32(72).bool(message.progress)
+    }
+    if (message.status !== undefined) {
+      Status.encode(message.status, writer.uint32(82).fork()).ldelim()
+    }
+    if (message.checksum !== 0) {
+      writer.uint32(93).fixed32(message.checksum)
+    }
+    if (message.debugLog === true) {
+      writer.uint32(104).bool(message.debugLog)
+    }
+    return writer
+  },
+
+  decode(input: _m0.Reader | Uint8Array, length?: number): CallData {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseCallData()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.data = reader.bytes()
+          break
+        case 2:
+          message.dataIsZero = reader.bool()
           break
         case 3:
           message.complete = reader.bool()
           break
         case 4:
-          message.error = reader.string()
+          message.error = reader.string()
+          break
+        case 5:
+          message.heartbeat = reader.bool()
+          break
+        case 6:
+          message.fragment = reader.bool()
+          break
+        case 7:
+          message.seq = reader.uint32()
+          break
+        case 8:
+          const entry8 = CallData_TrailerEntry.decode(reader, reader.uint32())
+          if (entry8.value !== undefined) {
+            message.trailer[entry8.key] = entry8.value
+          }
+          break
+        case 9:
+          message.progress = reader.bool()
+          break
+        case 10:
+          message.status = Status.decode(reader, reader.uint32())
+          break
+        case 11:
+          message.checksum = reader.fixed32()
+          break
+        case 13:
+          message.debugLog = reader.bool()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<CallData, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<CallData | CallData[]>
+      | Iterable<CallData | CallData[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallData.encode(p).finish()]
+        }
+      } else {
+        yield* [CallData.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, CallData>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<CallData> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallData.decode(p)]
+        }
+      } else {
+        yield* [CallData.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): CallData {
+    return {
+      data: isSet(object.data)
+        ? bytesFromBase64(object.data)
+        : new Uint8Array(),
+      dataIsZero: isSet(object.dataIsZero) ? Boolean(object.dataIsZero) : false,
+      complete: isSet(object.complete) ? Boolean(object.complete) : false,
+      error: isSet(object.error) ? String(object.error) : '',
+      heartbeat: isSet(object.heartbeat) ? Boolean(object.heartbeat) : false,
+      fragment: isSet(object.fragment) ? Boolean(object.fragment) : false,
+      seq: isSet(object.seq) ? Number(object.seq) : 0,
+      trailer: isObject(object.trailer)
+        ? Object.entries(object.trailer).reduce<{ [key: string]: string }>(
+            (acc, [key, value]) => {
+              acc[key] = String(value)
+              return acc
+            },
+            {}
+          )
+        : {},
+      progress: isSet(object.progress) ? Boolean(object.progress) : false,
+      status: isSet(object.status) ? Status.fromJSON(object.status) : undefined,
+      checksum: isSet(object.checksum) ? Number(object.checksum) : 0,
+      debugLog: isSet(object.debugLog) ? Boolean(object.debugLog) : false,
+    }
+  },
+
+  toJSON(message: CallData): unknown {
+    const obj: any = {}
+    message.data !== undefined &&
+      (obj.data = base64FromBytes(
+        message.data !== undefined ? message.data : new Uint8Array()
+      ))
+    message.dataIsZero !== undefined && (obj.dataIsZero = message.dataIsZero)
+    message.complete !== undefined && (obj.complete = message.complete)
+    message.error !== undefined && (obj.error = message.error)
+    message.heartbeat !== undefined && (obj.heartbeat = message.heartbeat)
+    message.fragment !== undefined && (obj.fragment = message.fragment)
+    message.seq !== undefined && (obj.seq = Math.round(message.seq))
+    obj.trailer = {}
+    if (message.trailer) {
+      Object.entries(message.trailer).forEach(([k, v]) => {
+        obj.trailer[k] = v
+      })
+    }
+    message.progress !== undefined && (obj.progress = message.progress)
+    message.status !== undefined &&
+      (obj.status = message.status ? Status.toJSON(message.status) : undefined)
+    message.checksum !== undefined &&
+      (obj.checksum = Math.round(message.checksum))
+    message.debugLog !== undefined && (obj.debugLog = message.debugLog)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<CallData>, I>>(base?: I): CallData {
+    return CallData.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<CallData>, I>>(object: I): CallData {
+    const message = createBaseCallData()
+    message.data = object.data ?? new Uint8Array()
+    message.dataIsZero = object.dataIsZero ?? false
+    message.complete = object.complete ?? false
+    message.error = object.error ?? ''
+    message.heartbeat = object.heartbeat ?? false
+    message.fragment = object.fragment ?? false
+    message.seq = object.seq ?? 0
+    message.trailer = Object.entries(object.trailer ?? {}).reduce<{
+      [key: string]: string
+    }>((acc, [key, value]) => {
+      if (value !== undefined) {
+        acc[key] = String(value)
+      }
+      return acc
+    }, {})
+    message.progress = object.progress ?? false
+    message.status =
+      object.status !== undefined && object.status !== null
+        ? Status.fromPartial(object.status)
+        : undefined
+    message.checksum = object.checksum ?? 0
+    message.debugLog = object.debugLog ?? false
+    return message
+  },
+}
+
+function createBaseCallData_TrailerEntry(): CallData_TrailerEntry {
+  return { key: '', value: '' }
+}
+
+export const CallData_TrailerEntry = {
+  encode(
+    message: CallData_TrailerEntry,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.key !== '') {
+      writer.uint32(10).string(message.key)
+    }
+    if (message.value !== '') {
+      writer.uint32(18).string(message.value)
+    }
+    return writer
+  },
+
+  decode(
+    input: _m0.Reader | Uint8Array,
+    length?: number
+  ): CallData_TrailerEntry {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseCallData_TrailerEntry()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.key = reader.string()
+          break
+        case 2:
+          message.value = reader.string()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<CallData_TrailerEntry, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<CallData_TrailerEntry | CallData_TrailerEntry[]>
+      | Iterable<CallData_TrailerEntry | CallData_TrailerEntry[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallData_TrailerEntry.encode(p).finish()]
+        }
+      } else {
+        yield* [CallData_TrailerEntry.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, CallData_TrailerEntry>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<CallData_TrailerEntry> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [CallData_TrailerEntry.decode(p)]
+        }
+      } else {
+        yield* [CallData_TrailerEntry.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): CallData_TrailerEntry {
+    return {
+      key: isSet(object.key) ? String(object.key) : '',
+      value: isSet(object.value) ? String(object.value) : '',
+    }
+  },
+
+  toJSON(message: CallData_TrailerEntry): unknown {
+    const obj: any = {}
+    message.key !== undefined && (obj.key = message.key)
+    message.value !== undefined && (obj.value = message.value)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<CallData_TrailerEntry>, I>>(
+    base?: I
+  ): CallData_TrailerEntry {
+    return CallData_TrailerEntry.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<CallData_TrailerEntry>, I>>(
+    object: I
+  ): CallData_TrailerEntry {
+    const message = createBaseCallData_TrailerEntry()
+    message.key = object.key ?? ''
+    message.value = object.value ?? ''
+    return message
+  },
+}
+
+function createBaseDebugLogEntry(): DebugLogEntry {
+  return { timeUnixMs: Long.ZERO, level: 0, message: '', attrs: {} }
+}
+
+export const DebugLogEntry = {
+  encode(
+    message: DebugLogEntry,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (!message.timeUnixMs.isZero()) {
+      writer.uint32(8).int64(message.timeUnixMs)
+    }
+    if (message.level !== 0) {
+      writer.uint32(16).int32(message.level)
+    }
+    if (message.message !== '') {
+      writer.uint32(26).string(message.message)
+    }
+    Object.entries(message.attrs).forEach(([key, value]) => {
+      DebugLogEntry_AttrsEntry.encode(
+        { key: key as any, value },
+        writer.uint32(34).fork()
+      ).ldelim()
+    })
+    return writer
+  },
+
+  decode(input: _m0.Reader | Uint8Array, length?: number): DebugLogEntry {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseDebugLogEntry()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.timeUnixMs = reader.int64() as Long
+          break
+        case 2:
+          message.level = reader.int32()
+          break
+        case 3:
+          message.message = reader.string()
+          break
+        case 4:
+          const entry4 = DebugLogEntry_AttrsEntry.decode(
+            reader,
+            reader.uint32()
+          )
+          if (entry4.value !== undefined) {
+            message.attrs[entry4.key] = entry4.value
+          }
           break
         default:
           reader.skipType(tag & 7)
@@ -394,74 +1861,461 @@ export const CallData = {
   },
 
   // encodeTransform encodes a source of message objects.
-  // Transform<CallData, Uint8Array>
+  // Transform<DebugLogEntry, Uint8Array>
   async *encodeTransform(
     source:
-      | AsyncIterable<CallData | CallData[]>
-      | Iterable<CallData | CallData[]>
+      | AsyncIterable<DebugLogEntry | DebugLogEntry[]>
+      | Iterable<DebugLogEntry | DebugLogEntry[]>
   ): AsyncIterable<Uint8Array> {
     for await (const pkt of source) {
       if (Array.isArray(pkt)) {
         for (const p of pkt) {
-          yield* [CallData.encode(p).finish()]
+          yield* [DebugLogEntry.encode(p).finish()]
         }
       } else {
-        yield* [CallData.encode(pkt).finish()]
+        yield* [DebugLogEntry.encode(pkt).finish()]
       }
     }
   },
 
   // decodeTransform decodes a source of encoded messages.
-  // Transform<Uint8Array, CallData>
+  // Transform<Uint8Array, DebugLogEntry>
   async *decodeTransform(
     source:
       | AsyncIterable<Uint8Array | Uint8Array[]>
       | Iterable<Uint8Array | Uint8Array[]>
-  ): AsyncIterable<CallData> {
+  ): AsyncIterable<DebugLogEntry> {
     for await (const pkt of source) {
       if (Array.isArray(pkt)) {
         for (const p of pkt) {
-          yield* [CallData.decode(p)]
+          yield* [DebugLogEntry.decode(p)]
         }
       } else {
-        yield* [CallData.decode(pkt)]
+        yield* [DebugLogEntry.decode(pkt)]
       }
     }
   },
 
-  fromJSON(object: any): CallData {
+  fromJSON(object: any): DebugLogEntry {
     return {
-      data: isSet(object.data)
-        ? bytesFromBase64(object.data)
-        : new Uint8Array(),
-      dataIsZero: isSet(object.dataIsZero) ? Boolean(object.dataIsZero) : false,
-      complete: isSet(object.complete) ? Boolean(object.complete) : false,
-      error: isSet(object.error) ? String(object.error) : '',
+      timeUnixMs: isSet(object.timeUnixMs)
+        ? Long.fromValue(object.timeUnixMs)
+        : Long.ZERO,
+      level: isSet(object.level) ? Number(object.level) : 0,
+      message: isSet(object.message) ? String(object.message) : '',
+      attrs: isObject(object.attrs)
+        ? Object.entries(object.attrs).reduce<{ [key: string]: string }>(
+            (acc, [key, value]) => {
+              acc[key] = String(value)
+              return acc
+            },
+            {}
+          )
+        : {},
     }
   },
 
-  toJSON(message: CallData): unknown {
+  toJSON(message: DebugLogEntry): unknown {
     const obj: any = {}
-    message.data !== undefined &&
-      (obj.data = base64FromBytes(
-        message.data !== undefined ? message.data : new Uint8Array()
-      ))
-    message.dataIsZero !== undefined && (obj.dataIsZero = message.dataIsZero)
-    message.complete !== undefined && (obj.complete = message.complete)
-    message.error !== undefined && (obj.error = message.error)
+    message.timeUnixMs !== undefined &&
+      (obj.timeUnixMs = (message.timeUnixMs || Long.ZERO).toString())
+    message.level !== undefined && (obj.level = Math.round(message.level))
+    message.message !== undefined && (obj.message = message.message)
+    obj.attrs = {}
+    if (message.attrs) {
+      Object.entries(message.attrs).forEach(([k, v]) => {
+        obj.attrs[k] = v
+      })
+    }
     return obj
   },
 
-  create<I extends Exact<DeepPartial<CallData>, I>>(base?: I): CallData {
-    return CallData.fromPartial(base ?? {})
+  create<I extends Exact<DeepPartial<DebugLogEntry>, I>>(
+    base?: I
+  ): DebugLogEntry {
+    return DebugLogEntry.fromPartial(base ?? {})
   },
 
-  fromPartial<I extends Exact<DeepPartial<CallData>, I>>(object: I): CallData {
-    const message = createBaseCallData()
-    message.data = object.data ?? new Uint8Array()
-    message.dataIsZero = object.dataIsZero ?? false
-    message.complete = object.complete ?? false
-    message.error = object.error ?? ''
+  fromPartial<I extends Exact<DeepPartial<DebugLogEntry>, I>>(
+    object: I
+  ): DebugLogEntry {
+    const message = createBaseDebugLogEntry()
+    message.timeUnixMs =
+      object.timeUnixMs !== undefined && object.timeUnixMs !== null
+        ? Long.fromValue(object.timeUnixMs)
+        : Long.ZERO
+    message.level = object.level ?? 0
+    message.message = object.message ?? ''
+    message.attrs = Object.entries(object.attrs ?? {}).reduce<{
+      [key: string]: string
+    }>((acc, [key, value]) => {
+      if (value !== undefined) {
+        acc[key] = String(value)
+      }
+      return acc
+    }, {})
+    return message
+  },
+}
+
+function createBaseDebugLogEntry_AttrsEntry(): DebugLogEntry_AttrsEntry {
+  return { key: '', value: '' }
+}
+
+export const DebugLogEntry_AttrsEntry = {
+  encode(
+    message: DebugLogEntry_AttrsEntry,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.key !== '') {
+      writer.uint32(10).string(message.key)
+    }
+    if (message.value !== '') {
+      writer.uint32(18).string(message.value)
+    }
+    return writer
+  },
+
+  decode(
+    input: _m0.Reader | Uint8Array,
+    length?: number
+  ): DebugLogEntry_AttrsEntry {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseDebugLogEntry_AttrsEntry()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.key = reader.string()
+          break
+        case 2:
+          message.value = reader.string()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<DebugLogEntry_AttrsEntry, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<DebugLogEntry_AttrsEntry | DebugLogEntry_AttrsEntry[]>
+      | Iterable<DebugLogEntry_AttrsEntry | DebugLogEntry_AttrsEntry[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [DebugLogEntry_AttrsEntry.encode(p).finish()]
+        }
+      } else {
+        yield* [DebugLogEntry_AttrsEntry.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, DebugLogEntry_AttrsEntry>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<DebugLogEntry_AttrsEntry> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [DebugLogEntry_AttrsEntry.decode(p)]
+        }
+      } else {
+        yield* [DebugLogEntry_AttrsEntry.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): DebugLogEntry_AttrsEntry {
+    return {
+      key: isSet(object.key) ? String(object.key) : '',
+      value: isSet(object.value) ? String(object.value) : '',
+    }
+  },
+
+  toJSON(message: DebugLogEntry_AttrsEntry): unknown {
+    const obj: any = {}
+    message.key !== undefined && (obj.key = message.key)
+    message.value !== undefined && (obj.value = message.value)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<DebugLogEntry_AttrsEntry>, I>>(
+    base?: I
+  ): DebugLogEntry_AttrsEntry {
+    return DebugLogEntry_AttrsEntry.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<DebugLogEntry_AttrsEntry>, I>>(
+    object: I
+  ): DebugLogEntry_AttrsEntry {
+    const message = createBaseDebugLogEntry_AttrsEntry()
+    message.key = object.key ?? ''
+    message.value = object.value ?? ''
+    return message
+  },
+}
+
+function createBaseStatus(): Status {
+  return { code: 0, message: '', details: {}, retryAfterMs: Long.UZERO }
+}
+
+export const Status = {
+  encode(
+    message: Status,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.code !== 0) {
+      writer.uint32(8).uint32(message.code)
+    }
+    if (message.message !== '') {
+      writer.uint32(18).string(message.message)
+    }
+    Object.entries(message.details).forEach(([key, value]) => {
+      Status_DetailsEntry.encode(
+        { key: key as any, value },
+        writer.uint32(26).fork()
+      ).ldelim()
+    })
+    if (!message.retryAfterMs.isZero()) {
+      writer.uint32(32).uint64(message.retryAfterMs)
+    }
+    return writer
+  },
+
+  decode(input: _m0.Reader | Uint8Array, length?: number): Status {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseStatus()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.code = reader.uint32()
+          break
+        case 2:
+          message.message = reader.string()
+          break
+        case 3:
+          const entry3 = Status_DetailsEntry.decode(reader, reader.uint32())
+          if (entry3.value !== undefined) {
+            message.details[entry3.key] = entry3.value
+          }
+          break
+        case 4:
+          message.retryAfterMs = reader.uint64() as Long
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<Status, Uint8Array>
+  async *encodeTransform(
+    source: AsyncIterable<Status | Status[]> | Iterable<Status | Status[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [Status.encode(p).finish()]
+        }
+      } else {
+        yield* [Status.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, Status>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<Status> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [Status.decode(p)]
+        }
+      } else {
+        yield* [Status.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): Status {
+    return {
+      code: isSet(object.code) ? Number(object.code) : 0,
+      message: isSet(object.message) ? String(object.message) : '',
+      details: isObject(object.details)
+        ? Object.entries(object.details).reduce<{ [key: string]: string }>(
+            (acc, [key, value]) => {
+              acc[key] = String(value)
+              return acc
+            },
+            {}
+          )
+        : {},
+      retryAfterMs: isSet(object.retryAfterMs)
+        ? Long.fromValue(object.retryAfterMs)
+        : Long.UZERO,
+    }
+  },
+
+  toJSON(message: Status): unknown {
+    const obj: any = {}
+    message.code !== undefined && (obj.code = Math.round(message.code))
+    message.message !== undefined && (obj.message = message.message)
+    obj.details = {}
+    if (message.details) {
+      Object.entries(message.details).forEach(([k, v]) => {
+        obj.details[k] = v
+      })
+    }
+    message.retryAfterMs !== undefined &&
+      (obj.retryAfterMs = (message.retryAfterMs || Long.UZERO).toString())
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<Status>, I>>(base?: I): Status {
+    return Status.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<Status>, I>>(object: I): Status {
+    const message = createBaseStatus()
+    message.code = object.code ?? 0
+    message.message = object.message ?? ''
+    message.details = Object.entries(object.details ?? {}).reduce<{
+      [key: string]: string
+    }>((acc, [key, value]) => {
+      if (value !== undefined) {
+        acc[key] = String(value)
+      }
+      return acc
+    }, {})
+    message.retryAfterMs =
+      object.retryAfterMs !== undefined && object.retryAfterMs !== null
+        ? Long.fromValue(object.retryAfterMs)
+        : Long.UZERO
+    return message
+  },
+}
+
+function createBaseStatus_DetailsEntry(): Status_DetailsEntry {
+  return { key: '', value: '' }
+}
+
+export const Status_DetailsEntry = {
+  encode(
+    message: Status_DetailsEntry,
+    writer: _m0.Writer = _m0.Writer.create()
+  ): _m0.Writer {
+    if (message.key !== '') {
+      writer.uint32(10).string(message.key)
+    }
+    if (message.value !== '') {
+      writer.uint32(18).string(message.value)
+    }
+    return writer
+  },
+
+  decode(input: _m0.Reader | Uint8Array, length?: number): Status_DetailsEntry {
+    const reader = input instanceof _m0.Reader ? input : new _m0.Reader(input)
+    let end = length === undefined ? reader.len : reader.pos + length
+    const message = createBaseStatus_DetailsEntry()
+    while (reader.pos < end) {
+      const tag = reader.uint32()
+      switch (tag >>> 3) {
+        case 1:
+          message.key = reader.string()
+          break
+        case 2:
+          message.value = reader.string()
+          break
+        default:
+          reader.skipType(tag & 7)
+          break
+      }
+    }
+    return message
+  },
+
+  // encodeTransform encodes a source of message objects.
+  // Transform<Status_DetailsEntry, Uint8Array>
+  async *encodeTransform(
+    source:
+      | AsyncIterable<Status_DetailsEntry | Status_DetailsEntry[]>
+      | Iterable<Status_DetailsEntry | Status_DetailsEntry[]>
+  ): AsyncIterable<Uint8Array> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [Status_DetailsEntry.encode(p).finish()]
+        }
+      } else {
+        yield* [Status_DetailsEntry.encode(pkt).finish()]
+      }
+    }
+  },
+
+  // decodeTransform decodes a source of encoded messages.
+  // Transform<Uint8Array, Status_DetailsEntry>
+  async *decodeTransform(
+    source:
+      | AsyncIterable<Uint8Array | Uint8Array[]>
+      | Iterable<Uint8Array | Uint8Array[]>
+  ): AsyncIterable<Status_DetailsEntry> {
+    for await (const pkt of source) {
+      if (Array.isArray(pkt)) {
+        for (const p of pkt) {
+          yield* [Status_DetailsEntry.decode(p)]
+        }
+      } else {
+        yield* [Status_DetailsEntry.decode(pkt)]
+      }
+    }
+  },
+
+  fromJSON(object: any): Status_DetailsEntry {
+    return {
+      key: isSet(object.key) ? String(object.key) : '',
+      value: isSet(object.value) ? String(object.value) : '',
+    }
+  },
+
+  toJSON(message: Status_DetailsEntry): unknown {
+    const obj: any = {}
+    message.key !== undefined && (obj.key = message.key)
+    message.value !== undefined && (obj.value = message.value)
+    return obj
+  },
+
+  create<I extends Exact<DeepPartial<Status_DetailsEntry>, I>>(
+    base?: I
+  ): Status_DetailsEntry {
+    return Status_DetailsEntry.fromPartial(base ?? {})
+  },
+
+  fromPartial<I extends Exact<DeepPartial<Status_DetailsEntry>, I>>(
+    object: I
+  ): Status_DetailsEntry {
+    const message = createBaseStatus_DetailsEntry()
+    message.key = object.key ?? ''
+    message.value = object.value ?? ''
     return message
   },
 }
@@ -547,6 +2401,10 @@ if (_m0.util.Long !== Long) {
   _m0.configure()
 }
 
+function isObject(value: any): boolean {
+  return typeof value === 'object' && value !== null
+}
+
 function isSet(value: any): boolean {
   return value !== null && value !== undefined
 }
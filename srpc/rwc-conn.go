@@ -30,6 +30,8 @@ type RwcConn struct {
 	wd       time.Time   // write deadline
 	packetCh chan []byte // packet ch
 	closeErr error
+	// doneCh is closed when the rx pump exits.
+	doneCh chan struct{}
 }
 
 // NewRwcConn constructs a new packet conn and starts the rx pump.
@@ -51,10 +53,11 @@ func NewRwcConn(
 		laddr:     laddr,
 		raddr:     raddr,
 		packetCh:  make(chan []byte, bufferPacketN),
+		doneCh:    make(chan struct{}),
 	}
-	go func() {
+	goTracked(func() {
 		_ = c.rxPump()
-	}()
+	})
 	return c
 }
 
@@ -169,6 +172,14 @@ func (p *RwcConn) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+// Done returns a channel which is closed when the connection ends.
+//
+// Closed when reading from the ReadWriteCloser fails or the conn is closed.
+// Packets received before the connection ended may still be read.
+func (p *RwcConn) Done() <-chan struct{} {
+	return p.doneCh
+}
+
 // Close closes the connection.
 // Any blocked ReadFrom or WriteTo operations will be unblocked and return errors.
 func (p *RwcConn) Close() error {
@@ -199,6 +210,7 @@ func (p *RwcConn) rxPump() (rerr error) {
 	defer func() {
 		p.closeErr = rerr
 		close(p.packetCh)
+		close(p.doneCh)
 	}()
 
 	for {
@@ -0,0 +1,144 @@
+package srpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// CallInfo contains information about an active call.
+type CallInfo struct {
+	// ID identifies the call on the server: use with Server.CancelCall.
+	ID uint64 `json:"id"`
+	// ServiceID is the service ID of the call.
+	ServiceID string `json:"serviceId"`
+	// MethodID is the method ID of the call.
+	MethodID string `json:"methodId"`
+	// StartTime is the time the call started.
+	StartTime time.Time `json:"startTime"`
+	// Peer is the address of the remote, if known.
+	Peer string `json:"peer,omitempty"`
+	// StreamID is the multiplexing stream ID on the connection, if known.
+	// Unique per connection but not globally unique.
+	StreamID uint64 `json:"streamId,omitempty"`
+}
+
+// activeCall is an entry in the Server active calls registry.
+type activeCall struct {
+	// id is the unique id of the call
+	id uint64
+	// rpc is the server rpc
+	rpc *ServerRPC
+	// peer is the address of the remote, if known.
+	peer string
+}
+
+// peerAddrCtxKey is the context key for the remote address.
+type peerAddrCtxKey struct{}
+
+// withPeerAddr attaches the remote address to the context.
+func withPeerAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, peerAddrCtxKey{}, addr)
+}
+
+// peerAddrFromStream returns the remote address from the context or stream.
+func peerAddrFromStream(ctx context.Context, rwc any) string {
+	if addr, ok := ctx.Value(peerAddrCtxKey{}).(string); ok {
+		return addr
+	}
+	if ra, ok := rwc.(interface{ RemoteAddr() net.Addr }); ok {
+		if addr := ra.RemoteAddr(); addr != nil {
+			return addr.String()
+		}
+	}
+	return ""
+}
+
+// ActiveCalls returns information about the calls currently being handled.
+//
+// Streams which have not yet received a call start are not included.
+// The list is sorted by start time.
+func (s *Server) ActiveCalls() []CallInfo {
+	s.callsMtx.Lock()
+	calls := make([]*activeCall, 0, len(s.calls))
+	for _, call := range s.calls {
+		calls = append(calls, call)
+	}
+	s.callsMtx.Unlock()
+
+	infos := make([]CallInfo, 0, len(calls))
+	for _, call := range calls {
+		call.rpc.mtx.Lock()
+		info := CallInfo{
+			ID:        call.id,
+			ServiceID: call.rpc.service,
+			MethodID:  call.rpc.method,
+			StartTime: call.rpc.startTime,
+			Peer:      call.peer,
+			StreamID:  call.rpc.streamID,
+		}
+		call.rpc.mtx.Unlock()
+		if info.ServiceID == "" && info.MethodID == "" {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.Before(infos[j].StartTime)
+	})
+	return infos
+}
+
+// CancelCall cancels the active call with the ID from ActiveCalls.
+//
+// Cancels the context of the call handler with the cause and sends the cause
+// to the client as the call error. If cause is nil, uses ErrCallCanceled. The
+// connection carrying the call is not closed. Returns false if the call was
+// not found, for example if it already completed.
+func (s *Server) CancelCall(id uint64, cause error) bool {
+	var rpc *ServerRPC
+	s.callsMtx.Lock()
+	for _, call := range s.calls {
+		if call.id == id {
+			rpc = call.rpc
+			break
+		}
+	}
+	s.callsMtx.Unlock()
+	if rpc == nil {
+		return false
+	}
+	if cause == nil {
+		cause = ErrCallCanceled
+	}
+	rpc.cancel(cause)
+	return true
+}
+
+// DebugHandler returns a http handler which writes ActiveCalls as JSON.
+func (s *Server) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.ActiveCalls())
+	})
+}
+
+// trackCall adds the rpc to the active calls until the rpc context is canceled.
+func (s *Server) trackCall(rpc *ServerRPC, peer string) {
+	s.callsMtx.Lock()
+	if s.calls == nil {
+		s.calls = make(map[*ServerRPC]*activeCall)
+	}
+	s.lastCallID++
+	s.calls[rpc] = &activeCall{id: s.lastCallID, rpc: rpc, peer: peer}
+	s.callsMtx.Unlock()
+
+	context.AfterFunc(rpc.Context(), func() {
+		s.callsMtx.Lock()
+		delete(s.calls, rpc)
+		s.callsMtx.Unlock()
+	})
+}
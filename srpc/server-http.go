@@ -4,7 +4,11 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
 
+	"github.com/pkg/errors"
 	"nhooyr.io/websocket"
 )
 
@@ -13,15 +17,19 @@ type HTTPServer struct {
 	mux  Mux
 	srpc *Server
 	path string
+	opts serverOpts
+	// conns is the number of open WebSocket conns
+	conns atomic.Int64
 }
 
 // NewHTTPServer builds a http server / handler.
 // if path is empty, serves on all routes.
-func NewHTTPServer(mux Mux, path string) (*HTTPServer, error) {
+func NewHTTPServer(mux Mux, path string, opts ...ServerOption) (*HTTPServer, error) {
 	return &HTTPServer{
 		mux:  mux,
-		srpc: NewServer(mux),
+		srpc: NewServer(mux, opts...),
 		path: path,
+		opts: newServerOpts(opts),
 	}, nil
 }
 
@@ -30,6 +38,37 @@ func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx := r.Context()
+	if s.opts.preUpgradeFn != nil {
+		upgradeCtx, err := s.opts.preUpgradeFn(r)
+		if err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrUnauthenticated) {
+				status = http.StatusUnauthorized
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		if upgradeCtx != nil {
+			ctx = upgradeCtx
+		}
+	}
+	ctx = withPeerAddr(ctx, r.RemoteAddr)
+	ctx = withPeerCerts(ctx, r.TLS)
+
+	if max := int64(s.opts.maxConnections); max > 0 {
+		if s.conns.Add(1) > max {
+			s.conns.Add(-1)
+			if retryAfter := s.opts.busyRetryAfter; retryAfter > 0 {
+				secs := (retryAfter + time.Second - 1) / time.Second
+				w.Header().Set("Retry-After", strconv.FormatInt(int64(secs), 10))
+			}
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		defer s.conns.Add(-1)
+	}
+
 	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{})
 	if err != nil {
 		w.WriteHeader(500)
@@ -38,23 +77,27 @@ func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer c.Close(websocket.StatusInternalError, "closed")
 
-	ctx := r.Context()
-	wsConn, err := NewWebSocketConn(ctx, c, true, nil)
+	wsConn, err := NewWebSocketConn(ctx, c, true, nil, s.opts.webSocketOpts...)
 	if err != nil {
 		c.Close(websocket.StatusInternalError, err.Error())
 		return
 	}
 
-	// handle incoming streams
+	// handle incoming streams until the conn closes or ctx is canceled
+	ctx = withWebSocketConn(ctx, wsConn)
 	for {
-		strm, err := wsConn.AcceptStream()
+		strm, err := wsConn.AcceptStreamContext(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				c.Close(wsConn.requestedCloseStatus(websocket.StatusGoingAway, "server shutting down"))
+				return
+			}
 			if err != io.EOF && err != context.Canceled {
 				// TODO: handle / log error?
-				c.Close(websocket.StatusInternalError, err.Error())
+				c.Close(wsConn.requestedCloseStatus(websocket.StatusInternalError, err.Error()))
 			}
 			return
 		}
-		go s.srpc.HandleStream(ctx, strm)
+		s.srpc.dispatchStream(ctx, strm)
 	}
 }
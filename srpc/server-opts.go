@@ -0,0 +1,280 @@
+package srpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ServerOption configures a Server or ServerRPC.
+type ServerOption func(opts *serverOpts)
+
+// serverOpts contains the server options.
+type serverOpts struct {
+	// heartbeatInterval is the interval to send heartbeats on streams.
+	heartbeatInterval time.Duration
+	// webSocketOpts are options for incoming WebSocket conns.
+	webSocketOpts []WebSocketOption
+	// preUpgradeFn is called before upgrading incoming HTTP requests.
+	preUpgradeFn PreUpgradeFunc
+	// fragmentSize is the max size of data in a CallData packet.
+	fragmentSize int
+	// maxRecvQueue is the max number of queued incoming messages per stream.
+	maxRecvQueue int
+	// sequence enables sending and verifying CallData sequence numbers.
+	sequence bool
+	// requireChecksums fails calls which did not enable checksums.
+	requireChecksums bool
+	// muxSelector selects the mux for each call.
+	muxSelector MuxSelector
+	// streamWorkers is the number of workers handling incoming streams.
+	// if zero, each stream is handled in a new goroutine.
+	streamWorkers int
+	// streamQueueDepth is the number of streams waiting for a worker.
+	streamQueueDepth int
+	// streamQueuePolicy is the policy when the stream queue is full.
+	streamQueuePolicy StreamQueuePolicy
+	// resume contains the resumable calls, if enabled.
+	resume *resumeRegistry
+	// attachments contains the calls accepting attachment streams.
+	// set by the Server: attachments are not supported without a Server.
+	attachments *attachmentRegistry
+	// debugStacks includes the stack trace of handler panics in the error.
+	debugStacks bool
+	// sendQueueDepth is the max number of queued outgoing packets per stream.
+	// if zero, packets are written directly.
+	sendQueueDepth int
+	// maxConnections is the max number of concurrent WebSocket conns.
+	// if zero, the number of conns is unlimited.
+	maxConnections int
+	// busyRetryAfter is the retry-after hint sent when rejecting due to load.
+	busyRetryAfter time.Duration
+	// goroutineLimiter limits the goroutines started for streams and calls.
+	// if nil, the number of goroutines is unlimited.
+	goroutineLimiter *GoroutineLimiter
+	// unknownPacketPolicy is the policy for unknown packet types.
+	unknownPacketPolicy UnknownPacketPolicy
+	// debugLogs forwards debug logs of handlers to clients requesting them.
+	debugLogs bool
+	// deprecationWarnings sends warnings to callers of deprecated methods.
+	deprecationWarnings bool
+}
+
+// newServerOpts applies the list of options.
+func newServerOpts(opts []ServerOption) serverOpts {
+	var o serverOpts
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithDebugStacks includes the panic value and stack trace in the error returned for a handler panic.
+//
+// Panics in call handlers are recovered and the call fails with a
+// StatusInternal status matching ErrHandlerPanic. If enabled, the panic value
+// and the stack trace are sent to the caller in the status details with the
+// PanicValueDetail and PanicStackDetail keys. Disabled by default: the caller
+// only receives a generic message. Do not enable in production.
+func WithDebugStacks(enable bool) ServerOption {
+	return func(opts *serverOpts) {
+		opts.debugStacks = enable
+	}
+}
+
+// WithSendQueueDepth queues up to depth outgoing packets per stream.
+//
+// Packets sent by the handler are queued and written to the transport in the
+// background, so MsgSend does not wait for each network write. If the queue is
+// full MsgSend blocks until there is space (backpressure). Messages are written
+// in order. A write error is returned by the next MsgSend. Sent messages must
+// not be modified after MsgSend returns: the data may not be written yet.
+// If zero or negative, packets are written directly (the default).
+func WithSendQueueDepth(depth int) ServerOption {
+	return func(opts *serverOpts) {
+		opts.sendQueueDepth = depth
+	}
+}
+
+// WithHeartbeatInterval sends a heartbeat packet on each stream at the interval.
+//
+// Heartbeats keep long-lived streams with sparse data from being closed by
+// intermediaries with idle timeouts. Clients silently drop heartbeats.
+// If zero or negative, heartbeats are disabled (the default).
+func WithHeartbeatInterval(interval time.Duration) ServerOption {
+	return func(opts *serverOpts) {
+		opts.heartbeatInterval = interval
+	}
+}
+
+// WithWebSocketOptions sets the options used for incoming WebSocket conns.
+//
+// Used by HTTPServer.
+func WithWebSocketOptions(opts ...WebSocketOption) ServerOption {
+	return func(o *serverOpts) {
+		o.webSocketOpts = append(o.webSocketOpts, opts...)
+	}
+}
+
+// WithFragmentSize fragments outgoing messages larger than size bytes.
+//
+// Large messages are split into multiple CallData packets of at most size
+// bytes which are reassembled by the remote. Use this if a transport or
+// intermediary limits the frame size. If zero or negative, messages are not
+// fragmented (the default). The remote must support fragmented messages.
+func WithFragmentSize(size int) ServerOption {
+	return func(opts *serverOpts) {
+		opts.fragmentSize = size
+	}
+}
+
+// WithMaxRecvQueue limits the number of incoming messages queued per stream.
+//
+// Incoming messages are queued until the handler calls MsgRecv. If the remote
+// sends more than max messages which were not yet received by the handler, the
+// stream is closed with ErrRecvQueueFull instead of buffering without bound.
+// The read pump never blocks on a slow handler. If zero or negative, the
+// queue is unbounded (the default).
+func WithMaxRecvQueue(max int) ServerOption {
+	return func(opts *serverOpts) {
+		opts.maxRecvQueue = max
+	}
+}
+
+// WithSequenceNumbers enables sequence numbers on CallData packets.
+//
+// Outgoing CallData packets are numbered and incoming packets are verified to
+// be numbered consecutively: gaps or reordering close the stream with
+// ErrSequenceMismatch. Incoming packets are not verified if the remote does
+// not send sequence numbers. Intended for debugging transports which may lose
+// or reorder packets, such as nested RpcStreams.
+func WithSequenceNumbers() ServerOption {
+	return func(opts *serverOpts) {
+		opts.sequence = true
+	}
+}
+
+// MuxSelector selects the Mux to handle a call.
+//
+// Called with the service ID and the metadata sent with the call start.
+// If nil is returned, the call is handled by the Server invoker.
+type MuxSelector func(serviceID string, md Metadata) Mux
+
+// WithMuxSelector selects the Mux to handle each call with a function.
+//
+// Allows serving multiple muxes on the same endpoint, for example selecting a
+// per-tenant mux with a tenant ID sent in the call metadata. To avoid falling
+// back to a shared set of services, construct the Server with an empty Mux.
+func WithMuxSelector(sel MuxSelector) ServerOption {
+	return func(opts *serverOpts) {
+		opts.muxSelector = sel
+	}
+}
+
+// WithStreamWorkers handles incoming streams with a bounded pool of workers.
+//
+// Accepted streams are queued to be handled by one of workers goroutines. Up
+// to queueDepth streams wait for a worker. If the queue is full, policy
+// determines if accepting streams blocks or if the stream is rejected with
+// ErrServerBusy. Each worker is busy until the stream is closed. Call
+// Server.Close to stop the workers when the server is no longer used.
+//
+// Used by AcceptMuxedConn and HTTPServer. If workers is zero or negative, each
+// stream is handled in a new goroutine (the default).
+func WithStreamWorkers(workers, queueDepth int, policy StreamQueuePolicy) ServerOption {
+	return func(opts *serverOpts) {
+		opts.streamWorkers = workers
+		opts.streamQueueDepth = queueDepth
+		opts.streamQueuePolicy = policy
+	}
+}
+
+// WithMaxConnections limits the number of concurrent WebSocket connections.
+//
+// Used by HTTPServer. Requests to upgrade while the limit is reached are
+// rejected with HTTP 503 Service Unavailable. A connection is counted until
+// it closes. Independent of the number of streams per connection. If max is
+// zero or negative, the number of connections is unlimited (the default).
+func WithMaxConnections(max int) ServerOption {
+	return func(o *serverOpts) {
+		o.maxConnections = max
+	}
+}
+
+// WithRequiredChecksums fails calls which did not enable packet checksums.
+//
+// Clients enable checksums with WithClientChecksums. Calls which did not
+// enable them are failed with ErrChecksumRequired without invoking the
+// handler. Calls which enabled checksums are supported regardless of this
+// option.
+func WithRequiredChecksums() ServerOption {
+	return func(opts *serverOpts) {
+		opts.requireChecksums = true
+	}
+}
+
+// WithBusyRetryAfter sets the retry-after hint sent when rejecting due to load.
+//
+// Streams rejected by the stream worker pool (see WithStreamWorkers) fail with
+// a StatusUnavailable status matching ErrServerBusy with the RetryAfter hint.
+// Connections rejected by WithMaxConnections include a Retry-After header.
+// Clients retrying the call wait at least d before the next attempt.
+func WithBusyRetryAfter(d time.Duration) ServerOption {
+	return func(o *serverOpts) {
+		o.busyRetryAfter = d
+	}
+}
+
+// WithGoroutineLimiter limits the goroutines started for incoming streams and calls.
+//
+// Incoming streams and calls which would exceed the limit are rejected with a
+// StatusUnavailable status matching ErrServerBusy. Streams handled by the
+// stream worker pool (see WithStreamWorkers) do not start a goroutine for
+// reading the stream. If nil, the number of goroutines is unlimited (the default).
+func WithGoroutineLimiter(l *GoroutineLimiter) ServerOption {
+	return func(o *serverOpts) {
+		o.goroutineLimiter = l
+	}
+}
+
+// UnknownPacketPolicy is the policy for packets with an unknown type.
+type UnknownPacketPolicy int
+
+const (
+	// UnknownPacketIgnore ignores unknown packets.
+	//
+	// Tolerates packets added by newer versions of the protocol.
+	UnknownPacketIgnore UnknownPacketPolicy = iota
+	// UnknownPacketError fails the call with ErrUnrecognizedPacket.
+	UnknownPacketError
+)
+
+// WithUnknownPacketPolicy sets the policy for packets with an unknown type.
+//
+// Applies to packets with a type not known to this version of the protocol
+// and to packets which are not expected by the server, like CallStartResp.
+// Defaults to UnknownPacketIgnore.
+func WithUnknownPacketPolicy(policy UnknownPacketPolicy) ServerOption {
+	return func(o *serverOpts) {
+		o.unknownPacketPolicy = policy
+	}
+}
+
+// PreUpgradeFunc is called with the HTTP request before the WebSocket upgrade.
+//
+// Returns the base context for the connection, which should be derived from
+// the request context. If the returned context is nil, uses the request context.
+// If an error is returned the request is rejected: errors wrapping
+// ErrUnauthenticated are returned as HTTP 401, all others as HTTP 403.
+type PreUpgradeFunc func(r *http.Request) (context.Context, error)
+
+// WithPreUpgradeFunc sets a function to authorize HTTP requests before upgrade.
+//
+// Used by HTTPServer.
+func WithPreUpgradeFunc(fn PreUpgradeFunc) ServerOption {
+	return func(o *serverOpts) {
+		o.preUpgradeFn = fn
+	}
+}
@@ -9,11 +9,16 @@ import (
 // Stream with the given Server. Starts read pumps for both. Starts the
 // HandleStream function on the server in a separate goroutine.
 func NewServerPipe(server *Server) OpenStreamFunc {
-	return func(ctx context.Context, msgHandler PacketHandler, closeHandler CloseHandler) (Writer, error) {
+	return NewDialerOpenStream(NewServerPipeDialer(server))
+}
+
+// NewServerPipeDialer constructs a Dialer which creates an in-memory Pipe with
+// the given Server. Starts the HandleStream function on the server in a
+// separate goroutine for each dialed connection.
+func NewServerPipeDialer(server *Server) Dialer {
+	return DialerFunc(func(ctx context.Context) (net.Conn, error) {
 		srvPipe, clientPipe := net.Pipe()
-		go server.HandleStream(ctx, srvPipe)
-		clientPrw := NewPacketReadWriter(clientPipe)
-		go clientPrw.ReadPump(msgHandler, closeHandler)
-		return clientPrw, nil
-	}
+		goTracked(func() { server.HandleStream(ctx, srvPipe) })
+		return clientPipe, nil
+	})
 }
@@ -0,0 +1,163 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// StreamQueuePolicy is the policy when the stream worker queue is full.
+type StreamQueuePolicy int
+
+const (
+	// StreamQueueBlock waits to accept more streams until the queue has space.
+	StreamQueueBlock StreamQueuePolicy = iota
+	// StreamQueueReject rejects incoming streams with ErrServerBusy.
+	StreamQueueReject
+)
+
+// streamPool dispatches incoming streams to a bounded set of workers.
+type streamPool struct {
+	// workers is the number of worker goroutines
+	workers int
+	// policy is the policy when the queue is full
+	policy StreamQueuePolicy
+	// slots limits the number of running and queued streams
+	slots chan struct{}
+	// queue contains streams waiting for a worker
+	queue chan func()
+	// startOnce starts the workers
+	startOnce sync.Once
+	// wg waits for the workers to exit
+	wg sync.WaitGroup
+
+	// mtx guards sending to and closing queue
+	mtx sync.Mutex
+	// closed is closed when the pool is closed
+	closed chan struct{}
+}
+
+// newStreamPool constructs a new streamPool.
+func newStreamPool(workers, queueDepth int, policy StreamQueuePolicy) *streamPool {
+	if queueDepth < 0 {
+		queueDepth = 0
+	}
+	return &streamPool{
+		workers: workers,
+		policy:  policy,
+		slots:   make(chan struct{}, workers+queueDepth),
+		queue:   make(chan func(), workers+queueDepth),
+		closed:  make(chan struct{}),
+	}
+}
+
+// dispatch queues the handler to run on a worker.
+//
+// Returns false if the handler was not queued or the pool is closed.
+func (p *streamPool) dispatch(ctx context.Context, handler func()) bool {
+	select {
+	case <-p.closed:
+		return false
+	default:
+	}
+
+	p.startOnce.Do(func() {
+		p.wg.Add(p.workers)
+		for i := 0; i < p.workers; i++ {
+			goTracked(p.work)
+		}
+	})
+
+	if p.policy == StreamQueueReject {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			return false
+		}
+	} else {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-p.closed:
+			return false
+		case p.slots <- struct{}{}:
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	select {
+	case <-p.closed:
+		<-p.slots
+		return false
+	default:
+	}
+	// the queue has capacity for every slot: does not block.
+	p.queue <- handler
+	return true
+}
+
+// close stops the workers once the queued handlers have run.
+func (p *streamPool) close() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	select {
+	case <-p.closed:
+	default:
+		close(p.closed)
+		close(p.queue)
+	}
+}
+
+// work runs handlers from the queue until the pool is closed.
+func (p *streamPool) work() {
+	defer p.wg.Done()
+	for handler := range p.queue {
+		handler()
+		<-p.slots
+	}
+}
+
+// dispatchStream handles the incoming stream in a separate goroutine.
+//
+// If the stream worker pool is enabled, queues the stream to the pool.
+// Streams which cannot be queued or would exceed the goroutine limit are
+// rejected with ErrServerBusy and a StatusUnavailable status with the
+// retry-after hint.
+func (s *Server) dispatchStream(ctx context.Context, rwc io.ReadWriteCloser) {
+	handler := func() { s.HandleStream(ctx, rwc) }
+	var dispatched bool
+	if s.pool == nil {
+		dispatched = s.goroutineLimiter.TryGo(handler)
+	} else {
+		dispatched = s.pool.dispatch(ctx, handler)
+	}
+	if !dispatched {
+		rejectStream(rwc, newServerBusyError(s.busyRetryAfter))
+	}
+}
+
+// newServerBusyError constructs the error for a call rejected due to load.
+func newServerBusyError(retryAfter time.Duration) error {
+	return NewStatusError(StatusUnavailable, ErrServerBusy.Error()).WithRetryAfter(retryAfter)
+}
+
+// rejectWriteTimeout is the max time to wait for the remote to read a rejection.
+const rejectWriteTimeout = time.Second
+
+// rejectStream writes an error to the stream and closes it.
+//
+// The stream is closed if the remote does not read the error within
+// rejectWriteTimeout so that the caller is not blocked by the remote.
+func rejectStream(rwc io.ReadWriteCloser, err error) {
+	prw := NewPacketReadWriter(rwc)
+	pkt := NewCallDataPacket(nil, false, true, err)
+	if st, ok := StatusFromError(err); ok {
+		pkt.GetCallData().Status = st.toProto()
+	}
+	tmr := time.AfterFunc(rejectWriteTimeout, func() { _ = prw.Close() })
+	_ = prw.WritePacket(pkt)
+	tmr.Stop()
+	_ = prw.Close()
+}
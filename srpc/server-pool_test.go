@@ -0,0 +1,34 @@
+package srpc
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStreamPool_Close tests the workers exit after the queued handlers ran.
+func TestStreamPool_Close(t *testing.T) {
+	ctx := context.Background()
+	p := newStreamPool(2, 2, StreamQueueBlock)
+
+	releaseCh := make(chan struct{})
+	ranCh := make(chan struct{}, 4)
+	for i := 0; i < 4; i++ {
+		if !p.dispatch(ctx, func() {
+			<-releaseCh
+			ranCh <- struct{}{}
+		}) {
+			t.Fatal("expected handler to be queued")
+		}
+	}
+
+	p.close()
+	if p.dispatch(ctx, func() {}) {
+		t.Fatal("expected handler to be rejected after close")
+	}
+
+	close(releaseCh)
+	p.wg.Wait()
+	if len(ranCh) != 4 {
+		t.Fatalf("expected 4 handlers to run but got %d", len(ranCh))
+	}
+}
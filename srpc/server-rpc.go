@@ -2,6 +2,9 @@ package srpc
 
 import (
 	"context"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -11,14 +14,51 @@ type ServerRPC struct {
 	commonRPC
 	// invoker is the rpc call invoker
 	invoker Invoker
+	// metadata is the metadata sent with the call start
+	metadata Metadata
+	// firstMsg is the first message sent with the call start
+	firstMsg []byte
+	// hasFirstMsg indicates the call start contained a message
+	hasFirstMsg bool
+	// opts are the server options
+	opts serverOpts
+	// startTime is the time the call start was received
+	startTime time.Time
+	// startErr is an error to return to the caller without invoking the rpc.
+	startErr error
+	// resumable indicates the client requested a resumable call.
+	resumable bool
+	// resumeToken identifies the resumable call to resume, if set.
+	resumeToken string
+	// resumeOffset is the number of messages the client already received.
+	resumeOffset uint64
+	// cancelErr is the error sent to the client if the call was canceled locally.
+	cancelErr error
+	// codec is the codec selected from the codecs offered by the client, if any.
+	codec Codec
+	// ack indicates the client requested a CallStartResp.
+	ack bool
+	// attachmentStream indicates the stream carries an attachment of another call.
+	attachmentStream bool
+	// attachmentIn receives the attachment sent on the stream, if any.
+	attachmentIn *attachmentPipe
 }
 
 // NewServerRPC constructs a new ServerRPC session.
 // note: call SetWriter before handling any incoming messages.
-func NewServerRPC(ctx context.Context, invoker Invoker, writer Writer) *ServerRPC {
-	rpc := &ServerRPC{invoker: invoker}
+func NewServerRPC(ctx context.Context, invoker Invoker, writer Writer, opts ...ServerOption) *ServerRPC {
+	rpc := &ServerRPC{invoker: invoker, opts: newServerOpts(opts)}
 	initCommonRPC(ctx, &rpc.commonRPC)
+	rpc.fragmentSize = rpc.opts.fragmentSize
+	rpc.maxRecvQueue = rpc.opts.maxRecvQueue
+	rpc.sequence = rpc.opts.sequence
+	rpc.trailer = &trailerHolder{}
 	rpc.writer = writer
+	if depth := rpc.opts.sendQueueDepth; depth > 0 && writer != nil {
+		rpc.writer = newQueuedWriter(rpc.ctx, writer, depth)
+	}
+	rpc.streamID = streamIDOf(writer)
+	rpc.attacher = rpc
 	return rpc
 }
 
@@ -27,6 +67,10 @@ func (r *ServerRPC) HandlePacket(msg *Packet) error {
 	if msg == nil {
 		return nil
 	}
+	if msg.GetBody() == nil && len(msg.ProtoReflect().GetUnknown()) != 0 {
+		// the packet type was added by a newer version of the protocol
+		return r.handleUnknownPacket()
+	}
 	if err := msg.Validate(); err != nil {
 		return err
 	}
@@ -41,45 +85,371 @@ func (r *ServerRPC) HandlePacket(msg *Packet) error {
 			return r.HandleCallCancel()
 		}
 		return nil
+	case *Packet_CallCancelReason:
+		return r.HandleCallCancelReason(b.CallCancelReason)
+	case *Packet_CallDemand:
+		return r.HandleCallDemand(b.CallDemand)
+	case *Packet_Ping:
+		return r.HandlePing(b.Ping)
+	case *Packet_CapabilitiesRequest:
+		return r.HandleCapabilitiesRequest()
+	case *Packet_AttachmentKey:
+		return r.HandleAttachmentKey(b.AttachmentKey)
 	default:
+		return r.handleUnknownPacket()
+	}
+}
+
+// handleUnknownPacket handles a packet with an unknown or unexpected type.
+//
+// If the policy is UnknownPacketError, the error is written to the remote and
+// the stream is closed.
+func (r *ServerRPC) handleUnknownPacket() error {
+	if r.opts.unknownPacketPolicy != UnknownPacketError {
 		return nil
 	}
+	_ = r.WriteCallData(nil, true, ErrUnrecognizedPacket)
+	return ErrUnrecognizedPacket
+}
+
+// HandleCallData handles the call data packet.
+//
+// Returns ErrCallNotStarted if the call start packet was not received yet: the
+// error is written to the remote and the stream is closed.
+func (r *ServerRPC) HandleCallData(pkt *CallData) error {
+	r.mtx.Lock()
+	started := r.service != "" || r.method != ""
+	attachmentIn := r.attachmentIn
+	r.mtx.Unlock()
+	if attachmentIn != nil {
+		return r.handleAttachmentData(attachmentIn, pkt)
+	}
+	if !started {
+		_ = r.WriteCallData(nil, true, ErrCallNotStarted)
+		return ErrCallNotStarted
+	}
+	if r.ctx.Err() != nil {
+		// the call finished: discard the remaining data
+		return nil
+	}
+	return r.commonRPC.HandleCallData(pkt)
+}
+
+// HandlePing answers the ping packet with a pong.
+func (r *ServerRPC) HandlePing(value uint64) error {
+	if r.writer == nil {
+		return nil
+	}
+	if err := r.writer.WritePacket(NewPongPacket(value)); err != nil {
+		return err
+	}
+	return r.Flush(r.ctx)
+}
+
+// HandleCapabilitiesRequest answers the capabilities request with the server capabilities.
+func (r *ServerRPC) HandleCapabilitiesRequest() error {
+	if r.writer == nil {
+		return nil
+	}
+	if err := r.writer.WritePacket(NewCapabilitiesPacket(newServerCapabilities(r.opts))); err != nil {
+		return err
+	}
+	return r.Flush(r.ctx)
+}
+
+// HandleStreamClose handles the incoming stream closing w/ optional error.
+func (r *ServerRPC) HandleStreamClose(closeErr error) {
+	r.mtx.Lock()
+	attachmentIn := r.attachmentIn
+	r.mtx.Unlock()
+	if attachmentIn != nil {
+		attachmentIn.finish(io.ErrUnexpectedEOF)
+	}
+	r.commonRPC.HandleStreamClose(closeErr)
 }
 
 // HandleCallStart handles the call start packet.
+//
+// If the packet opens an attachment stream, connects the stream to the call.
 func (r *ServerRPC) HandleCallStart(pkt *CallStart) error {
+	if att := pkt.GetAttachment(); att != nil {
+		return r.handleAttachmentStart(att)
+	}
 	r.mtx.Lock()
-	defer r.mtx.Unlock()
+	invoke, err := r.handleCallStartLocked(pkt)
+	r.mtx.Unlock()
+	if invoke {
+		r.startInvoke()
+	}
+	return err
+}
+
+// handleCallStartLocked processes the call start packet.
+//
+// Returns true if the rpc should be invoked.
+// r.mtx must be locked by the caller.
+func (r *ServerRPC) handleCallStartLocked(pkt *CallStart) (bool, error) {
 	// process start: method and service
-	if r.method != "" || r.service != "" {
-		return errors.New("call start must be sent only once")
+	if r.method != "" || r.service != "" || r.attachmentStream {
+		return false, errors.New("call start must be sent only once")
 	}
 	if r.dataClosed {
-		return ErrCompleted
+		return false, ErrCompleted
 	}
 	service, method := pkt.GetRpcService(), pkt.GetRpcMethod()
 	r.service, r.method = service, method
+	r.startTime = time.Now()
+	r.metadata = pkt.GetMetadata()
+	r.resumable = pkt.GetResumable()
+	r.resumeToken, r.resumeOffset = pkt.GetResumeToken(), pkt.GetResumeOffset()
+	r.ack, r.established = pkt.GetAck(), true
+	if n := pkt.GetInitialDemand(); n != 0 {
+		r.demandEnabled, r.demand = true, uint64(n)
+	}
+
+	r.checksum, r.verifyChecksums = pkt.GetChecksum(), pkt.GetChecksum()
+	if r.checksum {
+		if err := verifyChecksum(pkt.GetDataChecksum(), checksumCallStart(pkt)); err != nil {
+			return false, err
+		}
+	} else if r.opts.requireChecksums {
+		r.failStartLocked(ErrChecksumRequired)
+		return true, nil
+	}
+
+	if name := pkt.GetCompression(); name != "" {
+		compressor, ok := LookupStreamCompressor(name)
+		if !ok {
+			r.failStartLocked(errors.Wrap(ErrUnsupportedCompression, name))
+			return true, nil
+		}
+		r.compression = newStreamCompression(compressor)
+	}
+
+	if offered := pkt.GetCodecs(); len(offered) != 0 {
+		codec, ok := selectCodec(offered)
+		if !ok {
+			msg := errors.Wrap(ErrUnsupportedCodec, strings.Join(offered, ", ")).Error()
+			r.failStartLocked(NewStatusError(StatusUnimplemented, msg))
+			return true, nil
+		}
+		r.codec = codec
+	}
 
 	// process first data packet, if included
 	if data := pkt.GetData(); len(data) != 0 || pkt.GetDataIsZero() {
+		r.bytesReceived += uint64(len(data))
+		data, err := r.decompressStartMsg(data)
+		if err != nil {
+			return false, err
+		}
+		r.dataQueue = append(r.dataQueue, data)
+		r.firstMsg, r.hasFirstMsg = data, true
+	}
+	for _, data := range pkt.GetExtraData() {
+		r.bytesReceived += uint64(len(data))
+		data, err := r.decompressStartMsg(data)
+		if err != nil {
+			return false, err
+		}
 		r.dataQueue = append(r.dataQueue, data)
 	}
 
 	// invoke the rpc
 	r.bcast.Broadcast()
-	go r.invokeRPC(service, method)
-	return nil
+	return true, nil
+}
+
+// startInvoke starts invokeRPC in a new goroutine.
+//
+// If the goroutine limit was reached, rejects the call with ErrServerBusy
+// without invoking the method. The rejection is written on the read pump: the
+// stream is closed if the remote does not read it within rejectWriteTimeout.
+func (r *ServerRPC) startInvoke() {
+	if r.opts.goroutineLimiter.TryGo(func() { r.invokeRPC(r.service, r.method) }) {
+		return
+	}
+	tmr := time.AfterFunc(rejectWriteTimeout, func() { _ = r.writer.Close() })
+	_ = r.WriteCallData(nil, true, newServerBusyError(r.opts.busyRetryAfter))
+	tmr.Stop()
+	_ = r.writer.Close()
+	r.ctxCancelCause(ErrCallCompleted)
+}
+
+// failStartLocked fails the call with the error without invoking the method.
+//
+// The rpc must still be invoked to write the error.
+// r.mtx must be locked by the caller.
+func (r *ServerRPC) failStartLocked(err error) {
+	r.startErr = err
+	r.bcast.Broadcast()
+}
+
+// decompressStartMsg decompresses a message sent with the call start.
+//
+// Returns the data unchanged if compression is not enabled.
+// r.mtx must be locked by the caller.
+func (r *ServerRPC) decompressStartMsg(data []byte) ([]byte, error) {
+	if r.compression == nil || len(data) == 0 {
+		return data, nil
+	}
+	return r.compression.decompress(data)
+}
+
+// PeekFirstMessage returns the raw data of the first message sent with CallStart.
+// Returns nil, false if the call start did not include a message.
+func (r *ServerRPC) PeekFirstMessage() ([]byte, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.firstMsg, r.hasFirstMsg
 }
 
 // invokeRPC invokes the RPC after CallStart is received.
 func (r *ServerRPC) invokeRPC(serviceID, methodID string) {
-	strm := NewMsgStream(r.ctx, r, r.ctxCancel)
-	ok, err := r.invoker.InvokeMethod(serviceID, methodID, strm)
-	if err == nil && !ok {
-		err = ErrUnimplemented
+	ctx := withMethod(r.ctx, serviceID, methodID)
+	ctx = withTrailer(ctx, r.trailer)
+	if len(r.metadata) != 0 {
+		ctx = withIncomingMetadata(ctx, r.metadata)
+	}
+	if r.startErr != nil {
+		_ = r.WriteCallData(nil, true, r.startErr)
+		_ = r.writer.Close()
+		r.ctxCancelCause(ErrCallCompleted)
+		return
+	}
+	if interval := r.opts.heartbeatInterval; interval > 0 {
+		hbCtx, hbCtxCancel := context.WithCancel(r.ctx)
+		defer hbCtxCancel()
+		goTracked(func() { r.runHeartbeats(hbCtx, interval) })
+	}
+	if (r.resumable || r.resumeToken != "") && r.opts.resume != nil {
+		r.opts.resume.serve(ctx, r, serviceID, methodID)
+		_ = r.writer.Close()
+		r.ctxCancelCause(ErrCallCompleted)
+		return
+	}
+	if r.codec != nil || r.checksum || (r.ack && r.hasMethod(serviceID, methodID)) {
+		if err := r.writeCallStartResp(""); err != nil {
+			_ = r.writer.Close()
+			r.ctxCancelCause(err)
+			return
+		}
+	}
+	ctx = withProgressWriter(ctx, &r.commonRPC)
+	if r.opts.deprecationWarnings {
+		ctx = withDeprecationWarnings(ctx)
+	}
+	if r.opts.debugLogs {
+		if _, ok := r.metadata[DebugLogMetadataKey]; ok {
+			ctx = withDebugLogger(ctx, &r.commonRPC)
+		}
+	}
+	ctx = withStreamRPC(ctx, &r.commonRPC)
+	var strm Stream = NewMsgStream(ctx, r, r.ctxCancel)
+	if r.codec != nil {
+		strm = NewCodecStream(strm, r.codec)
+	}
+	err := r.invokeMethod(serviceID, methodID, strm)
+	r.mtx.Lock()
+	if r.cancelErr != nil {
+		err = r.cancelErr
+	}
+	r.mtx.Unlock()
+	if werr := r.WriteCallData(nil, true, err); werr == nil {
+		_ = r.Flush(r.ctx)
 	}
-	outPkt := NewCallDataPacket(nil, false, true, err)
-	_ = r.writer.WritePacket(outPkt)
 	_ = r.writer.Close()
-	r.ctxCancel()
+	r.ctxCancelCause(ErrCallCompleted)
+}
+
+// writeCallStartResp accepts the call with the codec selected for the call, if any.
+//
+// Confirms the checksums if the call enabled them.
+// resumeToken is the token to resume the call with, if resumable.
+func (r *ServerRPC) writeCallStartResp(resumeToken string) error {
+	var codecName string
+	if r.codec != nil {
+		codecName = r.codec.Name()
+	}
+	pkt := NewCallStartRespPacket(codecName)
+	pkt.GetCallStartResp().ResumeToken = resumeToken
+	pkt.GetCallStartResp().Checksum = r.checksum
+	r.sendSeqMtx.Lock()
+	err := r.writer.WritePacket(pkt)
+	r.sendSeqMtx.Unlock()
+	if err != nil {
+		return err
+	}
+	return r.Flush(r.ctx)
+}
+
+// cancel cancels the call context with the cause.
+//
+// The cause is sent to the client when the handler returns.
+func (r *ServerRPC) cancel(cause error) {
+	r.mtx.Lock()
+	if r.cancelErr == nil {
+		r.cancelErr = cause
+	}
+	r.mtx.Unlock()
+	r.ctxCancelCause(cause)
+}
+
+// invokeMethod invokes the method with the invoker selected for the call.
+//
+// Returns an UnimplementedError if the method was not found.
+// Returns a StatusInternal status if the handler panicked.
+func (r *ServerRPC) invokeMethod(serviceID, methodID string, strm Stream) (rerr error) {
+	defer func() {
+		if val := recover(); val != nil {
+			rerr = newPanicError(val, r.opts.debugStacks).toStatus(r.opts.debugStacks)
+		}
+	}()
+	ok, err := r.selectInvoker(serviceID).InvokeMethod(serviceID, methodID, strm)
+	if (err == nil && !ok) || err == ErrUnimplemented {
+		err = NewUnimplementedError(serviceID, methodID)
+	}
+	return err
+}
+
+// selectInvoker returns the invoker for the service.
+func (r *ServerRPC) selectInvoker(serviceID string) Invoker {
+	if sel := r.opts.muxSelector; sel != nil {
+		if mux := sel(serviceID, r.metadata); mux != nil {
+			return mux
+		}
+	}
+	return r.invoker
+}
+
+// hasMethod checks if the invoker has a handler for the method.
+//
+// Returns true if the invoker is not a Mux: other invokers cannot be checked.
+// Returns false for the methods of the fallback invokers of a Mux.
+func (r *ServerRPC) hasMethod(serviceID, methodID string) bool {
+	mux, ok := r.selectInvoker(serviceID).(Mux)
+	return !ok || mux.HasServiceMethod(serviceID, methodID)
+}
+
+// runHeartbeats writes heartbeat packets at the interval until ctx is canceled.
+func (r *ServerRPC) runHeartbeats(ctx context.Context, interval time.Duration) {
+	tkr := time.NewTicker(interval)
+	defer tkr.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tkr.C:
+		}
+
+		r.mtx.Lock()
+		completed := r.localCompleted
+		r.mtx.Unlock()
+		if completed {
+			return
+		}
+		if err := r.writer.WritePacket(NewCallDataHeartbeatPacket()); err != nil {
+			return
+		}
+	}
 }
@@ -3,6 +3,10 @@ package srpc
 import (
 	"context"
 	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 )
@@ -11,12 +15,48 @@ import (
 type Server struct {
 	// invoker is the method invoker
 	invoker Invoker
+	// opts are the server options
+	opts []ServerOption
+	// callsMtx guards calls and lastCallID
+	callsMtx sync.Mutex
+	// calls contains the active calls
+	calls map[*ServerRPC]*activeCall
+	// lastCallID is the id of the last tracked call
+	lastCallID uint64
+	// pool is the stream worker pool, if enabled
+	pool *streamPool
+	// busyRetryAfter is the retry-after hint when rejecting streams
+	busyRetryAfter time.Duration
+	// goroutineLimiter limits the goroutines handling streams, if set
+	goroutineLimiter *GoroutineLimiter
 }
 
 // NewServer constructs a new SRPC server.
-func NewServer(invoker Invoker) *Server {
-	return &Server{
+func NewServer(invoker Invoker, opts ...ServerOption) *Server {
+	attachments := newAttachmentRegistry()
+	srv := &Server{
 		invoker: invoker,
+		opts: append(slices.Clip(opts), func(opts *serverOpts) {
+			opts.attachments = attachments
+		}),
+	}
+	o := newServerOpts(opts)
+	if o.streamWorkers > 0 {
+		srv.pool = newStreamPool(o.streamWorkers, o.streamQueueDepth, o.streamQueuePolicy)
+	}
+	srv.busyRetryAfter = o.busyRetryAfter
+	srv.goroutineLimiter = o.goroutineLimiter
+	return srv
+}
+
+// Close stops the stream workers started for WithStreamWorkers.
+//
+// The workers exit once the queued streams have been handled. Streams accepted
+// after Close are rejected with ErrServerBusy. Does not close the streams
+// being handled. Does nothing if WithStreamWorkers is not set.
+func (s *Server) Close() {
+	if s.pool != nil {
+		s.pool.close()
 	}
 }
 
@@ -30,29 +70,66 @@ func (s *Server) HandleStream(ctx context.Context, rwc io.ReadWriteCloser) {
 	subCtx, subCtxCancel := context.WithCancel(ctx)
 	defer subCtxCancel()
 	prw := NewPacketReadWriter(rwc)
-	serverRPC := NewServerRPC(subCtx, s.invoker, prw)
+	serverRPC := NewServerRPC(subCtx, s.invoker, prw, s.opts...)
+	s.trackCall(serverRPC, peerAddrFromStream(ctx, rwc))
 	prw.ReadPump(serverRPC.HandlePacket, serverRPC.HandleStreamClose)
 }
 
+// Serve accepts connections from the listener and handles each as a stream.
+//
+// Each connection carries a single RPC stream: use with NewDialerClient.
+// To accept multiplexed connections use AcceptMuxedListener instead.
+// If WithStreamWorkers is set, the stream is queued to the worker pool.
+// Returns the error from Accept when the listener is closed.
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+		s.dispatchStream(ctx, conn)
+	}
+}
+
 // AcceptMuxedConn runs a loop which calls Accept on a muxer to handle streams.
 //
 // Starts HandleStream in a separate goroutine to handle the stream.
+// The calls can observe the conn closing with ConnDoneFromContext.
+// If WithStreamWorkers is set, the stream is queued to the worker pool.
 // Returns context.Canceled or io.EOF when the loop is complete / closed.
 func (s *Server) AcceptMuxedConn(ctx context.Context, mc network.MuxedConn) error {
+	return s.ServeTransport(ctx, NewTransport(mc))
+}
+
+// ServeTransport runs a loop which accepts and handles streams from the transport.
+//
+// Starts HandleStream in a separate goroutine to handle the stream.
+// The calls can observe the transport closing with ConnDoneFromContext.
+// If WithStreamWorkers is set, the stream is queued to the worker pool.
+// Returns context.Canceled or io.EOF when the loop is complete / closed.
+func (s *Server) ServeTransport(ctx context.Context, t Transport) error {
+	done := t.Done()
+	if done != nil {
+		ctx = withConnDone(ctx, done)
+	}
+	acceptor := newStreamAcceptor(t)
+	defer acceptor.close()
 	for {
 		select {
 		case <-ctx.Done():
 			return context.Canceled
+		case <-done:
+			return io.EOF
 		default:
-			if mc.IsClosed() {
-				return io.EOF
-			}
 		}
 
-		muxedStream, err := mc.AcceptStream()
+		muxedStream, err := acceptor.accept(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return context.Canceled
+			}
 			return err
 		}
-		go s.HandleStream(ctx, muxedStream)
+		s.dispatchStream(ctx, muxedStream)
 	}
 }
@@ -0,0 +1,205 @@
+package srpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StatusCode is the code of a call status.
+//
+// The codes match the gRPC status codes.
+type StatusCode uint32
+
+// Status codes.
+const (
+	// StatusOK indicates the call succeeded.
+	StatusOK StatusCode = 0
+	// StatusCanceled indicates the call was canceled.
+	StatusCanceled StatusCode = 1
+	// StatusUnknown indicates an unknown error.
+	StatusUnknown StatusCode = 2
+	// StatusInvalidArgument indicates the request is invalid.
+	StatusInvalidArgument StatusCode = 3
+	// StatusDeadlineExceeded indicates the deadline passed before the call completed.
+	StatusDeadlineExceeded StatusCode = 4
+	// StatusNotFound indicates a requested entity was not found.
+	StatusNotFound StatusCode = 5
+	// StatusAlreadyExists indicates an entity already exists.
+	StatusAlreadyExists StatusCode = 6
+	// StatusPermissionDenied indicates the caller is not permitted to make the call.
+	StatusPermissionDenied StatusCode = 7
+	// StatusResourceExhausted indicates a resource or quota was exhausted.
+	StatusResourceExhausted StatusCode = 8
+	// StatusFailedPrecondition indicates the system is not in the required state.
+	StatusFailedPrecondition StatusCode = 9
+	// StatusAborted indicates the operation was aborted.
+	StatusAborted StatusCode = 10
+	// StatusOutOfRange indicates the operation was attempted past the valid range.
+	StatusOutOfRange StatusCode = 11
+	// StatusUnimplemented indicates the method is not implemented.
+	StatusUnimplemented StatusCode = 12
+	// StatusInternal indicates an internal error.
+	StatusInternal StatusCode = 13
+	// StatusUnavailable indicates the service is unavailable: the call can be retried.
+	StatusUnavailable StatusCode = 14
+	// StatusDataLoss indicates unrecoverable data loss or corruption.
+	StatusDataLoss StatusCode = 15
+	// StatusUnauthenticated indicates the caller does not have valid credentials.
+	StatusUnauthenticated StatusCode = 16
+)
+
+// statusCodeNames contains the names of the status codes.
+var statusCodeNames = []string{
+	"OK",
+	"Canceled",
+	"Unknown",
+	"InvalidArgument",
+	"DeadlineExceeded",
+	"NotFound",
+	"AlreadyExists",
+	"PermissionDenied",
+	"ResourceExhausted",
+	"FailedPrecondition",
+	"Aborted",
+	"OutOfRange",
+	"Unimplemented",
+	"Internal",
+	"Unavailable",
+	"DataLoss",
+	"Unauthenticated",
+}
+
+// String returns the name of the status code.
+func (c StatusCode) String() string {
+	if int(c) < len(statusCodeNames) {
+		return statusCodeNames[c]
+	}
+	return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// StatusError is an error with a status code and details.
+//
+// Returned by handlers to send a structured status to the caller: the caller
+// receives a RemoteError which unwraps to a StatusError with the same code,
+// message, and details. Use errors.As or StatusFromError to read it.
+type StatusError struct {
+	// Code is the status code.
+	Code StatusCode
+	// Message is the error message.
+	Message string
+	// Details contains additional information about the error.
+	Details map[string]string
+	// RetryAfter is the minimum time to wait before retrying the call.
+	// Zero if there is no hint. Sent with millisecond precision.
+	RetryAfter time.Duration
+}
+
+// NewStatusError constructs a new StatusError.
+func NewStatusError(code StatusCode, msg string) *StatusError {
+	return &StatusError{Code: code, Message: msg}
+}
+
+// NewStatusErrorf constructs a new StatusError with a formatted message.
+func NewStatusErrorf(code StatusCode, format string, args ...any) *StatusError {
+	return NewStatusError(code, errors.Errorf(format, args...).Error())
+}
+
+// WithDetail sets a detail on the status and returns the status.
+func (e *StatusError) WithDetail(key, value string) *StatusError {
+	if e.Details == nil {
+		e.Details = make(map[string]string)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// WithRetryAfter sets the retry-after hint on the status and returns the status.
+//
+// Clients retrying the call wait at least d before the next attempt.
+func (e *StatusError) WithRetryAfter(d time.Duration) *StatusError {
+	e.RetryAfter = d
+	return e
+}
+
+// Error returns the error message.
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return e.Code.String()
+	}
+	return e.Message
+}
+
+// toProto converts the status to the wire format.
+func (e *StatusError) toProto() *Status {
+	return &Status{
+		Code:         uint32(e.Code),
+		Message:      e.Message,
+		Details:      e.Details,
+		RetryAfterMs: uint64(e.RetryAfter / time.Millisecond),
+	}
+}
+
+// newStatusErrorFromProto constructs a StatusError from the wire format.
+func newStatusErrorFromProto(st *Status) *StatusError {
+	return &StatusError{
+		Code:       StatusCode(st.GetCode()),
+		Message:    st.GetMessage(),
+		Details:    st.GetDetails(),
+		RetryAfter: time.Duration(st.GetRetryAfterMs()) * time.Millisecond,
+	}
+}
+
+// StatusFromError returns the StatusError in the error chain of err, if any.
+func StatusFromError(err error) (*StatusError, bool) {
+	var st *StatusError
+	if errors.As(err, &st) {
+		return st, true
+	}
+	return nil, false
+}
+
+// RetryAfterOf returns the retry-after hint of the status in the error chain.
+//
+// Returns 0 if there is no hint.
+func RetryAfterOf(err error) time.Duration {
+	if st, ok := StatusFromError(err); ok {
+		return st.RetryAfter
+	}
+	return 0
+}
+
+// StatusCodeOf returns the status code of the error.
+//
+// Returns the code of a StatusError in the chain of err. Otherwise maps the
+// known errors of this package and context to a code. Returns StatusOK if err
+// is nil and StatusUnknown for other errors.
+func StatusCodeOf(err error) StatusCode {
+	if err == nil {
+		return StatusOK
+	}
+	if st, ok := StatusFromError(err); ok {
+		return st.Code
+	}
+	switch {
+	case errors.Is(err, ErrUnimplemented):
+		return StatusUnimplemented
+	case errors.Is(err, ErrServerBusy):
+		return StatusUnavailable
+	case errors.Is(err, ErrSlowConsumer), errors.Is(err, ErrGoroutineLimit):
+		return StatusResourceExhausted
+	case errors.Is(err, ErrUnauthenticated):
+		return StatusUnauthenticated
+	case errors.Is(err, context.Canceled):
+		return StatusCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusDeadlineExceeded
+	default:
+		return StatusUnknown
+	}
+}
+
+// _ is a type assertion
+var _ error = ((*StatusError)(nil))
@@ -0,0 +1,50 @@
+package srpc
+
+import (
+	"context"
+	"io"
+)
+
+// SendAll sends all messages from the channel to the remote.
+//
+// Returns when ch is closed, calling CloseSend to signal completion.
+// Returns context.Canceled if the stream context is canceled.
+func SendAll[T Message](strm Stream, ch <-chan T) error {
+	ctx := strm.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case msg, ok := <-ch:
+			if !ok {
+				return strm.CloseSend()
+			}
+			if err := strm.MsgSend(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RecvAll receives all messages from the remote and calls cb for each.
+//
+// Constructs a new message for each call to cb.
+// Returns nil when the remote closes the stream (io.EOF).
+// If cb returns an error, returns that error.
+func RecvAll[T any, P interface {
+	*T
+	Message
+}](strm Stream, cb func(msg P) error) error {
+	for {
+		msg := P(new(T))
+		if err := strm.MsgRecv(msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := cb(msg); err != nil {
+			return err
+		}
+	}
+}
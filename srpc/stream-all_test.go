@@ -0,0 +1,53 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestSendAll tests sending all messages from a channel.
+func TestSendAll(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	s1, s2 := NewPipeStream(ctx)
+	expected := []string{"hello", "world"}
+	ch := make(chan *RawMessage, len(expected))
+	for _, body := range expected {
+		ch <- NewRawMessage([]byte(body), true)
+	}
+	close(ch)
+	if err := SendAll(s1, ch); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got []string
+	err := RecvAll(s2, func(msg *RawMessage) error {
+		got = append(got, string(msg.GetData()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+
+	// SendAll returns when the stream is canceled
+	s1, _ = NewPipeStream(ctx)
+	_ = s1.Close()
+	if err := SendAll(s1, make(chan *RawMessage)); err != context.Canceled {
+		t.Fatalf("expected context.Canceled but got %v", err)
+	}
+
+	// RecvAll returns the callback error
+	s1, s2 = NewPipeStream(ctx)
+	if err := s2.MsgSend(NewRawMessage([]byte("hello"), true)); err != nil {
+		t.Fatal(err.Error())
+	}
+	errCb := errors.New("callback failed")
+	if err := RecvAll(s1, func(msg *RawMessage) error { return errCb }); err != errCb {
+		t.Fatalf("expected callback error but got %v", err)
+	}
+}
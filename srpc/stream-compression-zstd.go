@@ -0,0 +1,144 @@
+package srpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// ZstdStreamCompressor compresses messages with zstd (RFC 8878).
+//
+// Each message is compressed as an independent zstd frame: the zstd decoder
+// cannot decode a frame incrementally as the flushed blocks arrive. Unlike
+// deflate the compression window is not shared across messages.
+type ZstdStreamCompressor struct {
+	// Level is the zstd compression level from 1 to 22.
+	// Uses zstd.SpeedDefault if zero or out of range.
+	Level int
+}
+
+// NewZstdStreamCompressor constructs a zstd compressor with a level.
+//
+// level is a zstd compression level from 1 (fastest) to 22 (best
+// compression). The levels are mapped to the closest supported encoder
+// level. Uses the default level if level is out of range.
+func NewZstdStreamCompressor(level int) *ZstdStreamCompressor {
+	if level < 1 || level > 22 {
+		level = 0
+	}
+	return &ZstdStreamCompressor{Level: level}
+}
+
+// Name returns the name of the compressor.
+func (c *ZstdStreamCompressor) Name() string {
+	return "zstd"
+}
+
+// NewWriter constructs a compressing writer which writes to w.
+func (c *ZstdStreamCompressor) NewWriter(w io.Writer) (StreamCompressWriter, error) {
+	level := zstd.SpeedDefault
+	if c.Level >= 1 && c.Level <= 22 {
+		level = zstd.EncoderLevelFromZstd(c.Level)
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdFrameWriter{w: w, enc: enc}, nil
+}
+
+// NewReader constructs a decompressing reader which reads from r.
+//
+// The decoded size of each chunk is limited to the max message size.
+func (c *ZstdStreamCompressor) NewReader(r io.Reader) (io.Reader, error) {
+	dec, err := zstd.NewReader(
+		nil,
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxMemory(zstdMaxChunkSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdFrameReader{src: r, dec: dec}, nil
+}
+
+// zstdMaxChunkSize is the max size of a compressed or decompressed chunk.
+//
+// Each chunk contains one message with a varint length prefix.
+var zstdMaxChunkSize = uint64(maxMessageSize) + binary.MaxVarintLen64
+
+// zstdFrameWriter writes the data written before each Flush as a zstd frame.
+type zstdFrameWriter struct {
+	w   io.Writer
+	enc *zstd.Encoder
+	buf bytes.Buffer
+}
+
+// Write buffers data to compress with the next Flush.
+func (w *zstdFrameWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Flush compresses the buffered data and writes the frame.
+func (w *zstdFrameWriter) Flush() error {
+	frame := w.enc.EncodeAll(w.buf.Bytes(), nil)
+	w.buf.Reset()
+	_, err := w.w.Write(frame)
+	return err
+}
+
+// Close releases the encoder.
+func (w *zstdFrameWriter) Close() error {
+	return w.enc.Close()
+}
+
+// zstdFrameReader decodes the zstd frames read from src.
+//
+// When more data is needed, decodes all of the data available from src: each
+// flushed chunk contains complete frames.
+type zstdFrameReader struct {
+	src io.Reader
+	dec *zstd.Decoder
+	out []byte
+}
+
+// Read reads decompressed data.
+func (r *zstdFrameReader) Read(p []byte) (int, error) {
+	if len(r.out) == 0 {
+		data, err := io.ReadAll(io.LimitReader(r.src, int64(zstdMaxChunkSize)+1))
+		if err != nil {
+			return 0, err
+		}
+		if len(data) == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if uint64(len(data)) > zstdMaxChunkSize {
+			return 0, errors.Errorf("compressed chunk greater than maximum %v", zstdMaxChunkSize)
+		}
+		r.out, err = r.dec.DecodeAll(data, r.out[:0])
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// Close releases the decoder.
+func (r *zstdFrameReader) Close() error {
+	r.dec.Close()
+	r.out = nil
+	return nil
+}
+
+// _ is a type assertion
+var (
+	_ StreamCompressor     = ((*ZstdStreamCompressor)(nil))
+	_ StreamCompressWriter = ((*zstdFrameWriter)(nil))
+	_ io.Closer            = ((*zstdFrameWriter)(nil))
+	_ io.ReadCloser        = ((*zstdFrameReader)(nil))
+)
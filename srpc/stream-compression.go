@@ -0,0 +1,310 @@
+package srpc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// StreamCompressor compresses all of the messages of a call as a single stream.
+//
+// Compressing the messages as one stream shares the compression window across
+// messages: many small similar messages compress much better than with
+// per-message compression. The tradeoff is that each call holds compressor
+// state for its lifetime (several hundred KB for deflate) and the messages
+// cannot be decoded independently of the preceding messages.
+//
+// Each message is flushed when it is sent: compression does not delay messages.
+type StreamCompressor interface {
+	// Name returns the name of the compressor sent with the call start.
+	Name() string
+	// NewWriter constructs a compressing writer which writes to w.
+	//
+	// If the writer implements io.Closer it is closed when the call ends.
+	NewWriter(w io.Writer) (StreamCompressWriter, error)
+	// NewReader constructs a decompressing reader which reads from r.
+	//
+	// r implements io.ByteReader. The reader must not read past the end of
+	// the data flushed by the writer before returning the flushed data.
+	// If the reader implements io.Closer it is closed when the call ends.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+// StreamCompressWriter is a compressing writer which can be flushed.
+type StreamCompressWriter interface {
+	io.Writer
+	// Flush writes any pending data to the underlying writer.
+	//
+	// The data written before Flush must be decodable from the output.
+	Flush() error
+}
+
+// DeflateStreamCompressor compresses streams with deflate (RFC 1951).
+type DeflateStreamCompressor struct {
+	// Level is the compression level.
+	// Uses flate.DefaultCompression if zero or out of range.
+	Level int
+}
+
+// NewDeflateStreamCompressor constructs a deflate compressor with a level.
+//
+// level is from flate.HuffmanOnly (-2) to flate.BestCompression (9): lower
+// levels are faster, higher levels compress better. Uses the default level
+// if level is out of range.
+func NewDeflateStreamCompressor(level int) *DeflateStreamCompressor {
+	return &DeflateStreamCompressor{Level: validFlateLevel(level)}
+}
+
+// Name returns the name of the compressor.
+func (c *DeflateStreamCompressor) Name() string {
+	return "deflate"
+}
+
+// NewWriter constructs a compressing writer which writes to w.
+func (c *DeflateStreamCompressor) NewWriter(w io.Writer) (StreamCompressWriter, error) {
+	return flate.NewWriter(w, validFlateLevel(c.Level))
+}
+
+// NewReader constructs a decompressing reader which reads from r.
+func (c *DeflateStreamCompressor) NewReader(r io.Reader) (io.Reader, error) {
+	return flate.NewReader(r), nil
+}
+
+// GzipStreamCompressor compresses streams with gzip (RFC 1952).
+//
+// Gzip is deflate with a header: prefer deflate unless the remote requires gzip.
+type GzipStreamCompressor struct {
+	// Level is the compression level.
+	// Uses gzip.DefaultCompression if zero or out of range.
+	Level int
+}
+
+// NewGzipStreamCompressor constructs a gzip compressor with a level.
+//
+// level is from gzip.HuffmanOnly (-2) to gzip.BestCompression (9). Uses the
+// default level if level is out of range.
+func NewGzipStreamCompressor(level int) *GzipStreamCompressor {
+	return &GzipStreamCompressor{Level: validFlateLevel(level)}
+}
+
+// Name returns the name of the compressor.
+func (c *GzipStreamCompressor) Name() string {
+	return "gzip"
+}
+
+// NewWriter constructs a compressing writer which writes to w.
+func (c *GzipStreamCompressor) NewWriter(w io.Writer) (StreamCompressWriter, error) {
+	return gzip.NewWriterLevel(w, validFlateLevel(c.Level))
+}
+
+// NewReader constructs a decompressing reader which reads from r.
+//
+// The gzip header is read on the first Read call.
+func (c *GzipStreamCompressor) NewReader(r io.Reader) (io.Reader, error) {
+	return &lazyGzipReader{src: r}, nil
+}
+
+// lazyGzipReader reads the gzip header when the first data is read.
+//
+// gzip.NewReader reads the header immediately: the header is not available
+// until the first message is received.
+type lazyGzipReader struct {
+	src io.Reader
+	r   *gzip.Reader
+}
+
+// Read reads decompressed data.
+func (r *lazyGzipReader) Read(p []byte) (int, error) {
+	if r.r == nil {
+		gr, err := gzip.NewReader(r.src)
+		if err != nil {
+			return 0, err
+		}
+		r.r = gr
+	}
+	return r.r.Read(p)
+}
+
+// validFlateLevel returns the level if it is a valid flate level or the default.
+func validFlateLevel(level int) int {
+	if level == 0 || level < flate.HuffmanOnly || level > flate.BestCompression {
+		return flate.DefaultCompression
+	}
+	return level
+}
+
+var (
+	// streamCompressorsMtx guards streamCompressors
+	streamCompressorsMtx sync.RWMutex
+	// streamCompressors contains the registered stream compressors by name.
+	streamCompressors = map[string]StreamCompressor{
+		"deflate": &DeflateStreamCompressor{},
+	}
+)
+
+// RegisterStreamCompressor registers a stream compressor by name.
+//
+// Replaces any compressor with the same name. The deflate compressor is
+// registered by default. Both the client and server must register the
+// compressor for it to be used. The compression level only affects the
+// sender: for example RegisterStreamCompressor(NewZstdStreamCompressor(19)).
+func RegisterStreamCompressor(c StreamCompressor) {
+	streamCompressorsMtx.Lock()
+	streamCompressors[c.Name()] = c
+	streamCompressorsMtx.Unlock()
+}
+
+// LookupStreamCompressor returns the registered stream compressor with the name.
+func LookupStreamCompressor(name string) (StreamCompressor, bool) {
+	streamCompressorsMtx.RLock()
+	defer streamCompressorsMtx.RUnlock()
+	c, ok := streamCompressors[name]
+	return c, ok
+}
+
+// streamCompressorNames returns the sorted names of the registered stream compressors.
+func streamCompressorNames() []string {
+	streamCompressorsMtx.RLock()
+	names := make([]string, 0, len(streamCompressors))
+	for name := range streamCompressors {
+		names = append(names, name)
+	}
+	streamCompressorsMtx.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// streamCompression contains the compression state of a call.
+//
+// Messages are written to the compressor with a varint length prefix and
+// flushed. Each flushed chunk is sent as the data of one message.
+type streamCompression struct {
+	// compressor is the stream compressor
+	compressor StreamCompressor
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// closed is set when the call ended and the compressors were released
+	closed bool
+	// w is the compressing writer, created on first use
+	w StreamCompressWriter
+	// out receives the output of w
+	out bytes.Buffer
+	// r is the decompressing reader, created on first use
+	r io.Reader
+	// in contains the data to decompress
+	in bytes.Buffer
+	// lenBuf is used to encode and decode the length prefix
+	lenBuf [binary.MaxVarintLen64]byte
+}
+
+// newStreamCompression constructs the compression state for a call.
+func newStreamCompression(compressor StreamCompressor) *streamCompression {
+	return &streamCompression{compressor: compressor}
+}
+
+// compress compresses a message and returns the flushed chunk.
+//
+// Calls must be serialized in the order the chunks are sent.
+func (s *streamCompression) compress(msg []byte) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return nil, ErrCompleted
+	}
+	if s.w == nil {
+		w, err := s.compressor.NewWriter(&s.out)
+		if err != nil {
+			return nil, err
+		}
+		s.w = w
+	}
+	n := binary.PutUvarint(s.lenBuf[:], uint64(len(msg)))
+	if _, err := s.w.Write(s.lenBuf[:n]); err != nil {
+		return nil, err
+	}
+	if _, err := s.w.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := s.w.Flush(); err != nil {
+		return nil, err
+	}
+	chunk := bytes.Clone(s.out.Bytes())
+	s.out.Reset()
+	return chunk, nil
+}
+
+// decompress decompresses a chunk and returns the message.
+//
+// Calls must be serialized in the order the chunks were received.
+func (s *streamCompression) decompress(chunk []byte) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return nil, ErrCompleted
+	}
+	_, _ = s.in.Write(chunk)
+	if s.r == nil {
+		r, err := s.compressor.NewReader(&s.in)
+		if err != nil {
+			return nil, err
+		}
+		s.r = r
+	}
+	size, err := binary.ReadUvarint(s)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+	if size > uint64(maxMessageSize) {
+		return nil, errors.Wrapf(ErrInvalidMessage, "decompressed message size %v greater than maximum %v", size, maxMessageSize)
+	}
+	// grow the message as it is decoded: the size is claimed by the remote.
+	var msg bytes.Buffer
+	if _, err := io.CopyN(&msg, s.r, int64(size)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, errors.Wrap(ErrInvalidMessage, err.Error())
+	}
+	return msg.Bytes(), nil
+}
+
+// close releases the compressor and decompressor.
+func (s *streamCompression) close() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if c, ok := s.w.(io.Closer); ok {
+		_ = c.Close()
+	}
+	if c, ok := s.r.(io.Closer); ok {
+		_ = c.Close()
+	}
+	s.w, s.r = nil, nil
+	s.in.Reset()
+	s.out.Reset()
+}
+
+// ReadByte reads a single decompressed byte.
+func (s *streamCompression) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(s.r, s.lenBuf[:1]); err != nil {
+		return 0, err
+	}
+	return s.lenBuf[0], nil
+}
+
+// _ is a type assertion
+var (
+	_ StreamCompressor = ((*DeflateStreamCompressor)(nil))
+	_ StreamCompressor = ((*GzipStreamCompressor)(nil))
+	_ io.ByteReader    = ((*streamCompression)(nil))
+)
@@ -0,0 +1,191 @@
+package srpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// TestStreamCompression tests compressing messages as a single stream.
+func TestStreamCompression(t *testing.T) {
+	compressor, ok := LookupStreamCompressor("deflate")
+	if !ok {
+		t.Fatal("expected deflate compressor to be registered")
+	}
+	testStreamCompression(t, compressor)
+}
+
+// TestStreamCompressionLevels tests the compressors with levels.
+func TestStreamCompressionLevels(t *testing.T) {
+	for _, level := range []int{-100, 1, 9, 100} {
+		for _, compressor := range []StreamCompressor{
+			NewDeflateStreamCompressor(level),
+			NewGzipStreamCompressor(level),
+			NewZstdStreamCompressor(level),
+		} {
+			t.Run(compressor.Name()+"-"+strconv.Itoa(level), func(t *testing.T) {
+				testStreamCompression(t, compressor)
+			})
+		}
+	}
+}
+
+// TestGzipStreamCompressorLevels tests validating the gzip compression levels.
+func TestGzipStreamCompressorLevels(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		level int
+		want  int
+	}{
+		{"default", gzip.DefaultCompression, gzip.DefaultCompression},
+		{"best-speed", gzip.BestSpeed, gzip.BestSpeed},
+		{"middle", 5, 5},
+		{"best-compression", gzip.BestCompression, gzip.BestCompression},
+		{"zero", 0, gzip.DefaultCompression},
+		{"too-low", -3, gzip.DefaultCompression},
+		{"too-high", 10, gzip.DefaultCompression},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			compressor := NewGzipStreamCompressor(tc.level)
+			if compressor.Level != tc.want {
+				t.Fatalf("expected level %d but got %d", tc.want, compressor.Level)
+			}
+			testStreamCompression(t, compressor)
+		})
+	}
+}
+
+// TestZstdStreamCompressorLevels tests validating the zstd compression levels.
+func TestZstdStreamCompressorLevels(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		level int
+		want  int
+	}{
+		{"fastest", 1, 1},
+		{"default", 3, 3},
+		{"best", 22, 22},
+		{"zero", 0, 0},
+		{"too-low", -1, 0},
+		{"too-high", 23, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			compressor := NewZstdStreamCompressor(tc.level)
+			if compressor.Level != tc.want {
+				t.Fatalf("expected level %d but got %d", tc.want, compressor.Level)
+			}
+			testStreamCompression(t, compressor)
+		})
+	}
+}
+
+// testStreamCompression tests compressing messages with the compressor.
+func testStreamCompression(t *testing.T, compressor StreamCompressor) {
+	enc, dec := newStreamCompression(compressor), newStreamCompression(compressor)
+
+	var msgs [][]byte
+	for i := 0; i < 100; i++ {
+		msgs = append(msgs, []byte("message number "+strconv.Itoa(i)+" with some repeated content"))
+	}
+	msgs = append(msgs, nil, bytes.Repeat([]byte("large"), 64*1024))
+
+	var rawSize, compressedSize int
+	for _, msg := range msgs {
+		chunk, err := enc.compress(msg)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		rawSize += len(msg)
+		compressedSize += len(chunk)
+
+		// each chunk decodes to the message without the following chunks
+		out, err := dec.decompress(chunk)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !bytes.Equal(out, msg) {
+			t.Fatalf("expected %q but got %q", msg, out)
+		}
+	}
+	if compressedSize >= rawSize/4 {
+		t.Fatalf("expected compressed size %d to be less than a quarter of %d", compressedSize, rawSize)
+	}
+}
+
+// TestStreamCompressionClaimedSize tests that the claimed message size is not allocated up front.
+func TestStreamCompressionClaimedSize(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewDeflateStreamCompressor(0).NewWriter(&out)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(maxMessageSize))
+	_, _ = w.Write(lenBuf[:n])
+	_, _ = w.Write([]byte("short"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dec := newStreamCompression(NewDeflateStreamCompressor(0))
+	defer dec.close()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := dec.decompress(out.Bytes()); err == nil {
+		t.Fatal("expected error decoding truncated message")
+	}
+	runtime.ReadMemStats(&after)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc >= uint64(maxMessageSize)/2 {
+		t.Fatalf("expected the message to grow as decoded but allocated %d bytes", alloc)
+	}
+}
+
+// TestZstdStreamCompressionMaxSize tests rejecting a chunk which decodes to more than the max message size.
+func TestZstdStreamCompressionMaxSize(t *testing.T) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer enc.Close()
+	// claims a small message followed by a large amount of compressible data
+	raw := make([]byte, int(maxMessageSize)*2)
+	binary.PutUvarint(raw, 1)
+	chunk := enc.EncodeAll(raw, nil)
+
+	dec := newStreamCompression(NewZstdStreamCompressor(0))
+	defer dec.close()
+	if _, err := dec.decompress(chunk); err == nil {
+		t.Fatal("expected error decoding oversized chunk")
+	}
+}
+
+// TestServerRPC_UnsupportedCompression tests rejecting an unknown compressor.
+func TestServerRPC_UnsupportedCompression(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	writer := &recordWriter{}
+	serverRPC := NewServerRPC(ctx, responseInvoker{}, writer)
+	pkt := NewCallStartPacket("test-service", "test-method", []byte("hello"), false)
+	pkt.GetCallStart().Compression = "unknown"
+	if err := serverRPC.HandlePacket(pkt); err != nil {
+		t.Fatal(err.Error())
+	}
+	<-serverRPC.Context().Done()
+
+	writer.mtx.Lock()
+	defer writer.mtx.Unlock()
+	if len(writer.pkts) != 1 {
+		t.Fatalf("expected 1 packet but got %d", len(writer.pkts))
+	}
+	if errStr := writer.pkts[0].GetCallData().GetError(); !strings.Contains(errStr, ErrUnsupportedCompression.Error()) {
+		t.Fatalf("expected unsupported compression error but got %q", errStr)
+	}
+}
@@ -0,0 +1,182 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// streamAddrNetwork is the network name of stream addresses.
+const streamAddrNetwork = "srpc"
+
+// streamAddr is the address of one end of a stream.
+type streamAddr struct {
+	// addr is the address string
+	addr string
+}
+
+// Network returns the name of the network.
+func (a *streamAddr) Network() string {
+	return streamAddrNetwork
+}
+
+// String returns the string form of the address.
+func (a *streamAddr) String() string {
+	return a.addr
+}
+
+// StreamConn implements net.Conn on top of a bidirectional Stream.
+//
+// Each Write is sent as one RawMessage. Read returns the data of the received
+// messages in order: data which does not fit is returned by subsequent calls.
+type StreamConn struct {
+	// strm is the underlying stream
+	strm Stream
+	// laddr is the local address
+	laddr net.Addr
+	// raddr is the remote address
+	raddr net.Addr
+	// closed is set when Close is called
+	closed atomic.Bool
+
+	// readMtx guards below fields
+	readMtx sync.Mutex
+	// recvMsg is the message used to receive data
+	recvMsg *RawMessage
+	// pending is the unread remainder of the last received message.
+	pending []byte
+}
+
+// NewConnFromStream constructs a new net.Conn with a bidirectional stream.
+//
+// Both ends of the stream must use the same framing: for example both wrapped
+// with NewConnFromStream. The remote address is the peer address of incoming
+// calls, if known, otherwise an address with the stream ID.
+func NewConnFromStream(strm Stream) *StreamConn {
+	streamID, _ := StreamID(strm)
+	id := strconv.FormatUint(streamID, 10)
+	raddr := peerAddrFromStream(strm.Context(), nil)
+	if raddr == "" {
+		raddr = "remote/" + id
+	}
+	return &StreamConn{
+		strm:    strm,
+		laddr:   &streamAddr{addr: "local/" + id},
+		raddr:   &streamAddr{addr: raddr},
+		recvMsg: NewRawMessage(nil, false),
+	}
+}
+
+// Stream returns the underlying stream.
+func (c *StreamConn) Stream() Stream {
+	return c.strm
+}
+
+// LocalAddr returns the local network address.
+func (c *StreamConn) LocalAddr() net.Addr {
+	return c.laddr
+}
+
+// RemoteAddr returns the remote network address.
+func (c *StreamConn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+// Read reads data from the connection.
+//
+// Returns io.EOF after the remote closes the stream for sending.
+func (c *StreamConn) Read(b []byte) (int, error) {
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
+	for len(c.pending) == 0 {
+		if err := c.strm.MsgRecv(c.recvMsg); err != nil {
+			return 0, c.mapErr(err)
+		}
+		c.pending = c.recvMsg.GetData()
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write writes data to the connection as a single message.
+func (c *StreamConn) Write(b []byte) (int, error) {
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if err := c.strm.MsgSend(NewRawMessage(b, true)); err != nil {
+		return 0, c.mapErr(err)
+	}
+	return len(b), nil
+}
+
+// CloseWrite closes the stream for sending.
+//
+// The remote receives io.EOF after reading the remaining data.
+func (c *StreamConn) CloseWrite() error {
+	return c.strm.CloseSend()
+}
+
+// Close closes the connection and the underlying stream.
+func (c *StreamConn) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	return c.strm.Close()
+}
+
+// SetDeadline sets the read and write deadlines.
+//
+// Deadlines apply to subsequent Read and Write calls. Exceeding a deadline
+// returns an error wrapping os.ErrDeadlineExceeded. Returns ErrUnimplemented
+// if the stream does not support deadlines.
+func (c *StreamConn) SetDeadline(t time.Time) error {
+	return SetStreamDeadline(c.strm, t)
+}
+
+// SetReadDeadline sets the deadline for subsequent Read calls.
+func (c *StreamConn) SetReadDeadline(t time.Time) error {
+	return SetStreamReadDeadline(c.strm, t)
+}
+
+// SetWriteDeadline sets the deadline for subsequent Write calls.
+func (c *StreamConn) SetWriteDeadline(t time.Time) error {
+	return SetStreamWriteDeadline(c.strm, t)
+}
+
+// mapErr maps a stream error to the equivalent net.Conn error.
+func (c *StreamConn) mapErr(err error) error {
+	switch {
+	case err == io.EOF:
+		return io.EOF
+	case errors.Is(err, context.DeadlineExceeded):
+		return os.ErrDeadlineExceeded
+	case c.closed.Load():
+		return net.ErrClosed
+	default:
+		return err
+	}
+}
+
+// _ is a type assertion
+var (
+	_ net.Conn = ((*StreamConn)(nil))
+	_ net.Addr = ((*streamAddr)(nil))
+)
@@ -0,0 +1,65 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestStreamConn tests reading and writing a net.Conn over a stream pipe.
+func TestStreamConn(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	strmA, strmB := NewPipeStream(ctx)
+	connA, connB := NewConnFromStream(strmA), NewConnFromStream(strmB)
+
+	// Write must not retain the buffer.
+	buf := []byte("hello world")
+	if _, err := connA.Write(buf); err != nil {
+		t.Fatal(err.Error())
+	}
+	copy(buf, "xxxxx")
+
+	// read with a small buffer to split the message
+	out := make([]byte, 5)
+	var data []byte
+	for len(data) < len("hello world") {
+		n, err := connB.Read(out)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		data = append(data, out[:n]...)
+	}
+	if string(data) != "hello world" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+
+	if err := connB.SetReadDeadline(time.Now().Add(time.Millisecond * 10)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := connB.Read(out); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded but got %v", err)
+	}
+	if err := connB.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := connA.CloseWrite(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := connB.Read(out); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+
+	if err := connB.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := connB.Write(buf); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed but got %v", err)
+	}
+}
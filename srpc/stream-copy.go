@@ -0,0 +1,68 @@
+package srpc
+
+import "io"
+
+// DefaultCopyChunkSize is the default chunk size for CopyToStream.
+const DefaultCopyChunkSize = 32 * 1024
+
+// CopyToStream reads from r and sends the data to the remote in chunks.
+//
+// Each message contains up to chunkSize bytes of raw data. Partial reads are
+// buffered until a full chunk is read or r returns io.EOF. If chunkSize is
+// zero or negative, uses DefaultCopyChunkSize. Backpressure is applied by
+// MsgSend blocking until the transport accepts the chunk.
+//
+// Calls CloseSend when r returns io.EOF. Returns the number of bytes sent.
+func CopyToStream(strm Stream, r io.Reader, chunkSize int) (int64, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultCopyChunkSize
+	}
+
+	var written int64
+	for {
+		// allocate a new buffer for each chunk: the stream may retain the data.
+		buf := make([]byte, chunkSize)
+		n, err := io.ReadFull(r, buf)
+		if n != 0 {
+			if serr := strm.MsgSend(NewRawMessage(buf[:n], false)); serr != nil {
+				return written, serr
+			}
+			written += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return written, strm.CloseSend()
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// CopyFromStream receives raw data chunks from the remote and writes them to w.
+//
+// Returns nil when the remote closes the stream (io.EOF).
+// Returns the number of bytes written.
+func CopyFromStream(w io.Writer, strm Stream) (int64, error) {
+	var written int64
+	msg := NewRawMessage(nil, false)
+	for {
+		if err := strm.MsgRecv(msg); err != nil {
+			if err == io.EOF {
+				return written, nil
+			}
+			return written, err
+		}
+		data := msg.GetData()
+		if len(data) == 0 {
+			continue
+		}
+		n, err := w.Write(data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		if n != len(data) {
+			return written, io.ErrShortWrite
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package srpc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/iotest"
+)
+
+// TestCopyStream tests copying data through a stream in chunks.
+func TestCopyStream(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	data := make([]byte, 64*1024+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	s1, s2 := NewPipeStream(ctx)
+	type sendResult struct {
+		n   int64
+		err error
+	}
+	sentCh := make(chan sendResult, 1)
+	go func() {
+		// HalfReader returns partial reads which must be buffered into chunks
+		n, err := CopyToStream(s1, iotest.HalfReader(bytes.NewReader(data)), 1000)
+		sentCh <- sendResult{n: n, err: err}
+	}()
+
+	var chunks int
+	var out bytes.Buffer
+	err := RecvAll(s2, func(msg *RawMessage) error {
+		chunks++
+		if len(msg.GetData()) > 1000 {
+			t.Errorf("chunk %d larger than chunk size: %d bytes", chunks, len(msg.GetData()))
+		}
+		_, err := out.Write(msg.GetData())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sent := <-sentCh
+	if sent.err != nil {
+		t.Fatal(sent.err.Error())
+	}
+	if sent.n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("expected %d bytes but sent %d and received %d", len(data), sent.n, out.Len())
+	}
+	if expected := (len(data) + 999) / 1000; chunks != expected {
+		t.Fatalf("expected %d chunks but got %d", expected, chunks)
+	}
+
+	// CopyFromStream writes the received chunks
+	s1, s2 = NewPipeStream(ctx)
+	go func() {
+		n, err := CopyToStream(s1, bytes.NewReader(data), 0)
+		sentCh <- sendResult{n: n, err: err}
+	}()
+	out.Reset()
+	n, err := CopyFromStream(&out, s2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if sent := <-sentCh; sent.err != nil {
+		t.Fatal(sent.err.Error())
+	}
+	if n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("expected %d bytes but received %d", len(data), n)
+	}
+}
@@ -0,0 +1,33 @@
+package srpc
+
+import "context"
+
+// streamWithContext overrides the Context of a Stream.
+type streamWithContext struct {
+	Stream
+	// ctx is the overridden context
+	ctx context.Context
+}
+
+// newStreamWithContext wraps a Stream with a different Context.
+//
+// ctx should be derived from the stream context.
+func newStreamWithContext(strm Stream, ctx context.Context) Stream {
+	return &streamWithContext{Stream: strm, ctx: ctx}
+}
+
+// Context is canceled when the Stream is no longer valid.
+func (s *streamWithContext) Context() context.Context {
+	return s.ctx
+}
+
+// PeekFirstMessage returns the raw first message sent with the call, if any.
+func (s *streamWithContext) PeekFirstMessage() ([]byte, bool) {
+	return PeekFirstMessage(s.Stream)
+}
+
+// _ is a type assertion
+var (
+	_ Stream             = ((*streamWithContext)(nil))
+	_ FirstMessagePeeker = ((*streamWithContext)(nil))
+)
@@ -0,0 +1,34 @@
+package srpc
+
+import (
+	"reflect"
+
+	"github.com/libp2p/go-yamux/v4"
+)
+
+// yamuxStreamType is the type of a yamux stream.
+var yamuxStreamType = reflect.TypeOf((*yamux.Stream)(nil))
+
+// streamIDOf returns the multiplexing stream ID of a transport stream.
+//
+// Supports values with a StreamID function and yamux streams wrapped by
+// libp2p. Returns 0 if the stream ID is unknown.
+func streamIDOf(strm any) uint64 {
+	switch s := strm.(type) {
+	case nil:
+		return 0
+	case interface{ StreamID() uint64 }:
+		return s.StreamID()
+	case interface{ StreamID() uint32 }:
+		return uint64(s.StreamID())
+	}
+
+	// the libp2p yamux muxer wraps *yamux.Stream with a named type.
+	v := reflect.ValueOf(strm)
+	if v.Kind() == reflect.Pointer && !v.IsNil() && v.Type().ConvertibleTo(yamuxStreamType) {
+		if ys, ok := v.Convert(yamuxStreamType).Interface().(*yamux.Stream); ok {
+			return uint64(ys.StreamID())
+		}
+	}
+	return 0
+}
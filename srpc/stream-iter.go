@@ -0,0 +1,56 @@
+//go:build go1.23
+
+package srpc
+
+import (
+	"io"
+	"iter"
+)
+
+// RecvSeq returns an iterator over the messages received from the remote.
+//
+// Constructs a new message for each iteration. Stops when the remote closes
+// the stream (io.EOF). Any other error is yielded once with a nil message,
+// after which the iteration stops.
+//
+//	for msg, err := range srpc.RecvSeq[echo.EchoMsg](strm) {
+func RecvSeq[T any, P interface {
+	*T
+	Message
+}](strm Stream) iter.Seq2[P, error] {
+	return func(yield func(P, error) bool) {
+		for {
+			msg := P(new(T))
+			if err := strm.MsgRecv(msg); err != nil {
+				if err != io.EOF {
+					yield(nil, err)
+				}
+				return
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the messages received from the remote.
+//
+// Stops when the remote closes the stream (io.EOF). Any other error is yielded
+// once with an empty message, after which the iteration stops.
+func (s *TypedStream[Req, Resp]) All() iter.Seq2[Resp, error] {
+	return func(yield func(Resp, error) bool) {
+		for {
+			msg, err := s.Recv()
+			if err != nil {
+				if err != io.EOF {
+					yield(msg, err)
+				}
+				return
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build go1.23
+
+package srpc
+
+import (
+	"context"
+	"testing"
+)
+
+// TestRecvSeq tests iterating over received messages.
+func TestRecvSeq(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	s1, s2 := NewPipeStream(ctx)
+	expected := []string{"hello", "world"}
+	for _, body := range expected {
+		if err := s1.MsgSend(NewRawMessage([]byte(body), true)); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if err := s1.CloseSend(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got []string
+	for msg, err := range RecvSeq[RawMessage](s2) {
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		got = append(got, string(msg.GetData()))
+	}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Fatalf("expected %v got %v", expected, got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // pipeStream implements an in-memory stream.
@@ -17,6 +19,14 @@ type pipeStream struct {
 	closeOnce sync.Once
 	// dataCh is the data channel
 	dataCh chan []byte
+	// bytesReceived is the number of message bytes received.
+	bytesReceived atomic.Uint64
+	// deadlineMtx guards below fields
+	deadlineMtx sync.Mutex
+	// readDeadline is the deadline for MsgRecv calls
+	readDeadline time.Time
+	// writeDeadline is the deadline for MsgSend calls
+	writeDeadline time.Time
 }
 
 // NewPipeStream constructs a new in-memory stream.
@@ -40,9 +50,15 @@ func (p *pipeStream) MsgSend(msg Message) error {
 	if err != nil {
 		return err
 	}
+	p.deadlineMtx.Lock()
+	deadlineCh, stopDeadline := newDeadlineCh(p.writeDeadline)
+	p.deadlineMtx.Unlock()
+	defer stopDeadline()
 	select {
 	case <-p.ctx.Done():
 		return context.Canceled
+	case <-deadlineCh:
+		return context.DeadlineExceeded
 	case p.other.dataCh <- data:
 		return nil
 	}
@@ -51,13 +67,20 @@ func (p *pipeStream) MsgSend(msg Message) error {
 // MsgRecv receives an incoming message from the remote.
 // Parses the message into the object at msg.
 func (p *pipeStream) MsgRecv(msg Message) error {
+	p.deadlineMtx.Lock()
+	deadlineCh, stopDeadline := newDeadlineCh(p.readDeadline)
+	p.deadlineMtx.Unlock()
+	defer stopDeadline()
 	select {
 	case <-p.ctx.Done():
 		return context.Canceled
+	case <-deadlineCh:
+		return context.DeadlineExceeded
 	case data, ok := <-p.dataCh:
 		if !ok {
 			return io.EOF
 		}
+		p.bytesReceived.Add(uint64(len(data)))
 		return msg.UnmarshalVT(data)
 	}
 }
@@ -75,6 +98,49 @@ func (p *pipeStream) Close() error {
 	return nil
 }
 
+// SetDeadline sets the read and write deadlines.
+func (p *pipeStream) SetDeadline(t time.Time) error {
+	p.deadlineMtx.Lock()
+	p.readDeadline, p.writeDeadline = t, t
+	p.deadlineMtx.Unlock()
+	return nil
+}
+
+// SetReadDeadline sets the deadline for subsequent MsgRecv calls.
+func (p *pipeStream) SetReadDeadline(t time.Time) error {
+	p.deadlineMtx.Lock()
+	p.readDeadline = t
+	p.deadlineMtx.Unlock()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for subsequent MsgSend calls.
+func (p *pipeStream) SetWriteDeadline(t time.Time) error {
+	p.deadlineMtx.Lock()
+	p.writeDeadline = t
+	p.deadlineMtx.Unlock()
+	return nil
+}
+
+// ID returns the multiplexing stream ID of the stream.
+//
+// In-memory pipe streams do not have stream IDs: always returns 0.
+func (p *pipeStream) ID() uint64 {
+	return 0
+}
+
+// Trailer returns the trailer metadata sent by the remote.
+//
+// In-memory pipe streams do not carry trailers: always returns nil.
+func (p *pipeStream) Trailer() Metadata {
+	return nil
+}
+
+// BytesReceived returns the number of message bytes received from the remote.
+func (p *pipeStream) BytesReceived() uint64 {
+	return p.bytesReceived.Load()
+}
+
 // closeRemote closes the remote data channel.
 func (p *pipeStream) closeRemote() {
 	p.closeOnce.Do(func() {
@@ -82,5 +148,22 @@ func (p *pipeStream) closeRemote() {
 	})
 }
 
+// newDeadlineCh returns a channel which fires when the deadline passes.
+//
+// If the deadline is zero, returns a nil channel.
+func newDeadlineCh(deadline time.Time) (<-chan time.Time, func()) {
+	if deadline.IsZero() {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	return timer.C, func() { timer.Stop() }
+}
+
 // _ is a type assertion
-var _ Stream = ((*pipeStream)(nil))
+var (
+	_ Stream          = ((*pipeStream)(nil))
+	_ TrailerReceiver = ((*pipeStream)(nil))
+	_ ByteCounter     = ((*pipeStream)(nil))
+	_ DeadlineStream  = ((*pipeStream)(nil))
+	_ StreamIDer      = ((*pipeStream)(nil))
+)
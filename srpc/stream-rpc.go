@@ -0,0 +1,22 @@
+package srpc
+
+import "context"
+
+// streamRPCCtxKey is the context key for the rpc of a stream.
+type streamRPCCtxKey struct{}
+
+// withStreamRPC attaches the rpc carrying the stream to the stream context.
+//
+// Used by the helpers which must reach the rpc through wrapped streams (such
+// as the generated stream types).
+func withStreamRPC(ctx context.Context, rpc *commonRPC) context.Context {
+	return context.WithValue(ctx, streamRPCCtxKey{}, rpc)
+}
+
+// streamRPCOf returns the rpc carrying the stream.
+//
+// Returns nil, false if the stream context does not contain the rpc.
+func streamRPCOf(strm Stream) (*commonRPC, bool) {
+	rpc, ok := strm.Context().Value(streamRPCCtxKey{}).(*commonRPC)
+	return rpc, ok
+}
@@ -0,0 +1,134 @@
+package srpc
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// StreamPipe splits a bidirectional stream into separate read and write halves.
+//
+// Each Write is sent as one RawMessage. Read returns the data of the received
+// messages in order: data which does not fit is returned by subsequent calls.
+// Both ends of the stream must use the same framing: for example StreamPipe,
+// NewConnFromStream, or CopyToStream and CopyFromStream.
+//
+// Closing the writer closes the stream for sending: the remote reads io.EOF.
+// The stream is closed when both halves are closed.
+func StreamPipe(strm Stream) (io.ReadCloser, io.WriteCloser) {
+	sp := &streamPipe{strm: strm, recvMsg: NewRawMessage(nil, false)}
+	return &streamPipeReader{sp: sp}, &streamPipeWriter{sp: sp}
+}
+
+// streamPipe contains the state shared by the halves of a StreamPipe.
+type streamPipe struct {
+	// strm is the underlying stream
+	strm Stream
+	// readClosed is set when the reader is closed
+	readClosed atomic.Bool
+	// writeClosed is set when the writer is closed
+	writeClosed atomic.Bool
+	// closeOnce guards closing strm
+	closeOnce sync.Once
+
+	// readMtx guards below fields
+	readMtx sync.Mutex
+	// recvMsg is the message used to receive data
+	recvMsg *RawMessage
+	// pending is the unread remainder of the last received message.
+	pending []byte
+}
+
+// closeIfDone closes the stream if both halves are closed.
+func (s *streamPipe) closeIfDone() error {
+	if !s.readClosed.Load() || !s.writeClosed.Load() {
+		return nil
+	}
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.strm.Close()
+	})
+	return err
+}
+
+// streamPipeReader is the read half of a StreamPipe.
+type streamPipeReader struct {
+	sp *streamPipe
+}
+
+// Read reads data received from the remote.
+//
+// Returns io.EOF after the remote closes the stream for sending.
+func (r *streamPipeReader) Read(b []byte) (int, error) {
+	sp := r.sp
+	if sp.readClosed.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	sp.readMtx.Lock()
+	defer sp.readMtx.Unlock()
+	for len(sp.pending) == 0 {
+		if err := sp.strm.MsgRecv(sp.recvMsg); err != nil {
+			if err != io.EOF && sp.readClosed.Load() {
+				return 0, io.ErrClosedPipe
+			}
+			return 0, err
+		}
+		sp.pending = sp.recvMsg.GetData()
+	}
+	n := copy(b, sp.pending)
+	sp.pending = sp.pending[n:]
+	return n, nil
+}
+
+// Close closes the read half.
+//
+// Closes the stream if the write half was also closed.
+func (r *streamPipeReader) Close() error {
+	if r.sp.readClosed.Swap(true) {
+		return nil
+	}
+	return r.sp.closeIfDone()
+}
+
+// streamPipeWriter is the write half of a StreamPipe.
+type streamPipeWriter struct {
+	sp *streamPipe
+}
+
+// Write sends data to the remote as a single message.
+func (w *streamPipeWriter) Write(b []byte) (int, error) {
+	if w.sp.writeClosed.Load() {
+		return 0, io.ErrClosedPipe
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if err := w.sp.strm.MsgSend(NewRawMessage(b, true)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes the stream for sending.
+//
+// Closes the stream if the read half was also closed.
+func (w *streamPipeWriter) Close() error {
+	if w.sp.writeClosed.Swap(true) {
+		return nil
+	}
+	err := w.sp.strm.CloseSend()
+	if cerr := w.sp.closeIfDone(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// _ is a type assertion
+var (
+	_ io.ReadCloser  = ((*streamPipeReader)(nil))
+	_ io.WriteCloser = ((*streamPipeWriter)(nil))
+)
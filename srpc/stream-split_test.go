@@ -0,0 +1,58 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// TestStreamPipe tests reading and writing the halves of a split stream.
+func TestStreamPipe(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	strmA, strmB := NewPipeStream(ctx)
+	readA, writeA := StreamPipe(strmA)
+	readB, writeB := StreamPipe(strmB)
+
+	errCh := make(chan error, 1)
+	go func() {
+		// echo the data back to A
+		_, err := io.Copy(writeB, readB)
+		if err == nil {
+			err = writeB.Close()
+		}
+		errCh <- err
+	}()
+
+	if _, err := io.WriteString(writeA, "hello "); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := io.WriteString(writeA, "world"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := writeA.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := writeA.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe but got %v", err)
+	}
+
+	data, err := io.ReadAll(readA)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(data) != "hello world" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := readA.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if strmA.Context().Err() == nil {
+		t.Fatal("expected stream to be closed after closing both halves")
+	}
+}
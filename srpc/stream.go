@@ -2,6 +2,7 @@ package srpc
 
 import (
 	"context"
+	"time"
 )
 
 // Stream is a handle to an on-going bi-directional or one-directional stream RPC handle.
@@ -10,10 +11,20 @@ type Stream interface {
 	Context() context.Context
 
 	// MsgSend sends the message to the remote.
+	//
+	// Messages are received by the remote in the order they were sent.
+	// Concurrent calls are written one at a time without interleaving.
+	// Returns io.EOF if the remote finished the call and no longer reads
+	// messages: for example a client-streaming handler which returned early.
+	// Call MsgRecv to read the response or error.
 	MsgSend(msg Message) error
 
 	// MsgRecv receives an incoming message from the remote.
 	// Parses the message into the object at msg.
+	//
+	// Returns exactly io.EOF after the last message if the remote completed
+	// the call without an error. Returns a different error if the call failed
+	// or the transport closed before the remote completed the call.
 	MsgRecv(msg Message) error
 
 	// CloseSend signals to the remote that we will no longer send any messages.
@@ -22,3 +33,219 @@ type Stream interface {
 	// Close closes the stream for reading and writing.
 	Close() error
 }
+
+// FirstMessagePeeker is implemented by streams which can return the raw first
+// message sent with the call start before it is decoded.
+type FirstMessagePeeker interface {
+	// PeekFirstMessage returns the raw data of the first message sent with CallStart.
+	// Does not consume the message: it is still returned by MsgRecv.
+	// Returns nil, false if the call start did not include a message.
+	// The returned data must not be modified.
+	PeekFirstMessage() ([]byte, bool)
+}
+
+// PeekFirstMessage returns the raw first message sent with the call, if any.
+//
+// Returns nil, false if the stream does not implement FirstMessagePeeker.
+func PeekFirstMessage(strm Stream) ([]byte, bool) {
+	p, ok := strm.(FirstMessagePeeker)
+	if !ok {
+		return nil, false
+	}
+	return p.PeekFirstMessage()
+}
+
+// TrailerReceiver is implemented by streams which receive trailer metadata.
+type TrailerReceiver interface {
+	// Trailer returns the trailer metadata sent by the remote with the final packet.
+	//
+	// Valid after MsgRecv returns io.EOF or an error: the trailer is also sent
+	// when the call fails. Returns nil if the remote did not send a trailer.
+	Trailer() Metadata
+}
+
+// StreamTrailer returns the trailer metadata sent by the remote with the final packet.
+//
+// Supports streams implementing TrailerReceiver and the streams of srpc calls,
+// including wrapped streams. Returns nil if the remote did not send a trailer
+// or the stream does not receive trailers.
+func StreamTrailer(strm Stream) Metadata {
+	if t, ok := strm.(TrailerReceiver); ok {
+		return t.Trailer()
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.Trailer()
+	}
+	return nil
+}
+
+// ByteCounter is implemented by streams which count the received message bytes.
+type ByteCounter interface {
+	// BytesReceived returns the number of message bytes received from the remote.
+	//
+	// Counts the encoded message data as received from the transport.
+	BytesReceived() uint64
+}
+
+// StreamBytesReceived returns the number of message bytes received by the stream.
+//
+// Supports streams implementing ByteCounter and the streams of srpc calls,
+// including wrapped streams. Returns 0 if the stream does not count bytes.
+func StreamBytesReceived(strm Stream) uint64 {
+	if c, ok := strm.(ByteCounter); ok {
+		return c.BytesReceived()
+	}
+	if rpc, ok := streamRPCOf(strm); ok {
+		return rpc.BytesReceived()
+	}
+	return 0
+}
+
+// StreamIDer is implemented by streams which have a multiplexing stream ID.
+type StreamIDer interface {
+	// ID returns the multiplexing stream ID of the stream on the connection.
+	//
+	// IDs are unique per connection but not globally unique.
+	// Returns 0 if the transport does not have stream IDs.
+	ID() uint64
+}
+
+// StreamID returns the multiplexing stream ID of the stream on the connection.
+//
+// Supports streams implementing StreamIDer and the streams of srpc calls,
+// including wrapped streams. Returns 0, false if the stream does not have a
+// stream ID.
+func StreamID(strm Stream) (uint64, bool) {
+	var id uint64
+	if s, ok := strm.(StreamIDer); ok {
+		id = s.ID()
+	} else if rpc, ok := streamRPCOf(strm); ok {
+		id = rpc.StreamID()
+	}
+	return id, id != 0
+}
+
+// DeadlineStream is implemented by streams which support deadlines.
+type DeadlineStream interface {
+	// SetDeadline sets the read and write deadlines.
+	//
+	// A deadline is an absolute time after which MsgRecv or MsgSend fail with
+	// context.DeadlineExceeded instead of blocking. Deadlines apply to
+	// subsequent calls. A zero value for t means calls will not time out.
+	// Exceeding a deadline does not close the stream: the deadline can be
+	// extended and the call retried.
+	SetDeadline(t time.Time) error
+	// SetReadDeadline sets the deadline for subsequent MsgRecv calls.
+	SetReadDeadline(t time.Time) error
+	// SetWriteDeadline sets the deadline for subsequent MsgSend calls.
+	SetWriteDeadline(t time.Time) error
+}
+
+// deadlineStreamCtxKey is the context key for the DeadlineStream of a stream.
+type deadlineStreamCtxKey struct{}
+
+// withDeadlineStream attaches the stream handling deadlines to the stream context.
+//
+// Used by the deadline helpers to reach the stream through wrapped streams
+// (such as the generated stream types).
+func withDeadlineStream(ctx context.Context, strm DeadlineStream) context.Context {
+	return context.WithValue(ctx, deadlineStreamCtxKey{}, strm)
+}
+
+// deadlineStreamOf returns the DeadlineStream handling the deadlines of the stream.
+func deadlineStreamOf(strm Stream) (DeadlineStream, bool) {
+	if ds, ok := strm.(DeadlineStream); ok {
+		return ds, true
+	}
+	ds, ok := strm.Context().Value(deadlineStreamCtxKey{}).(DeadlineStream)
+	return ds, ok
+}
+
+// SetStreamDeadline sets the read and write deadlines of the stream.
+//
+// Supports streams implementing DeadlineStream and the streams of srpc calls,
+// including wrapped streams. See DeadlineStream.SetDeadline.
+// Returns ErrUnimplemented if the stream does not support deadlines.
+func SetStreamDeadline(strm Stream, t time.Time) error {
+	ds, ok := deadlineStreamOf(strm)
+	if !ok {
+		return ErrUnimplemented
+	}
+	return ds.SetDeadline(t)
+}
+
+// SetStreamReadDeadline sets the deadline for subsequent MsgRecv calls.
+//
+// Returns ErrUnimplemented if the stream does not support deadlines.
+func SetStreamReadDeadline(strm Stream, t time.Time) error {
+	ds, ok := deadlineStreamOf(strm)
+	if !ok {
+		return ErrUnimplemented
+	}
+	return ds.SetReadDeadline(t)
+}
+
+// SetStreamWriteDeadline sets the deadline for subsequent MsgSend calls.
+//
+// Returns ErrUnimplemented if the stream does not support deadlines.
+func SetStreamWriteDeadline(strm Stream, t time.Time) error {
+	ds, ok := deadlineStreamOf(strm)
+	if !ok {
+		return ErrUnimplemented
+	}
+	return ds.SetWriteDeadline(t)
+}
+
+// CloseAndMsgRecv signals the end of sending and receives the response message.
+//
+// Used by client-streaming calls which expect a single response. The stream is
+// closed before returning. If CloseSend fails, returns a *CloseSendError. If
+// the remote returned an error, returns a *RemoteError (or *UnimplementedError).
+//
+// If the remote finished the call before CloseSend, for example if the handler
+// returned early and closed the transport, returns the response or the remote
+// error instead of the CloseSend error.
+func CloseAndMsgRecv(strm Stream, msg Message) error {
+	defer strm.Close()
+	if err := strm.CloseSend(); err != nil {
+		if rerr := strm.MsgRecv(msg); rerr == nil || isRemoteError(rerr) {
+			return rerr
+		}
+		return &CloseSendError{Err: err}
+	}
+	return strm.MsgRecv(msg)
+}
+
+// SendCloser is a Stream which can send a final message and close the send
+// side of the stream with a single packet.
+type SendCloser interface {
+	// SendClose sends the message and signals to the remote that we will no
+	// longer send any messages.
+	SendClose(msg Message) error
+}
+
+// SendClose sends the final message and signals the end of sending.
+//
+// If strm implements SendCloser, the message and the completion are sent in a
+// single packet. Otherwise calls MsgSend and CloseSend.
+func SendClose(strm Stream, msg Message) error {
+	if sc, ok := strm.(SendCloser); ok {
+		return sc.SendClose(msg)
+	}
+	if err := strm.MsgSend(msg); err != nil {
+		return err
+	}
+	return strm.CloseSend()
+}
+
+// SendResponse sends the single response message of a call.
+//
+// Used for unary and client-streaming calls, which have a single response. If
+// supported by strm, the response and the completion are coalesced into a
+// single packet. Otherwise calls MsgSend. No more messages can be sent after.
+func SendResponse(strm Stream, msg Message) error {
+	if sc, ok := strm.(SendCloser); ok {
+		return sc.SendClose(msg)
+	}
+	return strm.MsgSend(msg)
+}
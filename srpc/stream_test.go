@@ -0,0 +1,69 @@
+package srpc
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// peekResult is the result of PeekFirstMessage in a handler.
+type peekResult struct {
+	data []byte
+	ok   bool
+	// recv is the data received with MsgRecv after peeking, if any.
+	recv []byte
+}
+
+// callPeekFirstMessage starts a call with firstMsg and returns what the handler peeked.
+func callPeekFirstMessage(t *testing.T, firstMsg Message) peekResult {
+	results := make(chan peekResult, 1)
+	server := NewServer(InvokerFunc(func(serviceID, methodID string, strm Stream) (bool, error) {
+		var res peekResult
+		res.data, res.ok = PeekFirstMessage(strm)
+		// the peeked message is still received by MsgRecv
+		msg := NewRawMessage(nil, false)
+		if err := strm.MsgRecv(msg); err == nil {
+			res.recv = msg.GetData()
+		} else if err != io.EOF {
+			return true, err
+		}
+		results <- res
+		return true, nil
+	}))
+	client := NewClient(NewServerPipe(server))
+
+	strm, err := client.NewStream(context.Background(), "test-service", "test-method", firstMsg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if err := strm.CloseSend(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := strm.MsgRecv(NewRawMessage(nil, false)); err != io.EOF {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+	return <-results
+}
+
+// TestPeekFirstMessage tests peeking the first message without consuming it.
+func TestPeekFirstMessage(t *testing.T) {
+	res := callPeekFirstMessage(t, NewRawMessage([]byte("hello world"), false))
+	if !res.ok || string(res.data) != "hello world" {
+		t.Fatalf("expected to peek the first message but got %q, %v", res.data, res.ok)
+	}
+	if string(res.recv) != "hello world" {
+		t.Fatalf("expected MsgRecv to return the peeked message but got %q", res.recv)
+	}
+}
+
+// TestPeekFirstMessage_None tests peeking when the call start did not include a message.
+func TestPeekFirstMessage_None(t *testing.T) {
+	res := callPeekFirstMessage(t, nil)
+	if res.ok || res.data != nil {
+		t.Fatalf("expected no first message but got %q, %v", res.data, res.ok)
+	}
+	if res.recv != nil {
+		t.Fatalf("expected no message to be received but got %q", res.recv)
+	}
+}
@@ -0,0 +1,129 @@
+package srpc
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// TCPOption configures the socket options of a TCP connection.
+type TCPOption func(opts *tcpOpts)
+
+// tcpOpts contains the TCP socket options.
+type tcpOpts struct {
+	// noDelay disables Nagle's algorithm.
+	noDelay bool
+	// keepAlive is the keep-alive period.
+	// if zero, uses the system defaults.
+	// if negative, keep-alives are disabled.
+	keepAlive time.Duration
+	// readBuffer is the size of the receive buffer.
+	// if zero, uses the system defaults.
+	readBuffer int
+	// writeBuffer is the size of the send buffer.
+	// if zero, uses the system defaults.
+	writeBuffer int
+}
+
+// newTCPOpts builds the TCP options with the defaults.
+func newTCPOpts(opts []TCPOption) tcpOpts {
+	o := tcpOpts{noDelay: true}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithTCPNoDelay sets TCP_NODELAY which disables Nagle's algorithm.
+//
+// Nagle's algorithm delays small writes to batch them, adding latency to RPCs
+// with small messages. Defaults to true.
+func WithTCPNoDelay(noDelay bool) TCPOption {
+	return func(opts *tcpOpts) {
+		opts.noDelay = noDelay
+	}
+}
+
+// WithTCPKeepAlive sets the TCP keep-alive period.
+//
+// If zero, uses the system defaults (the default).
+// If negative, keep-alives are disabled.
+func WithTCPKeepAlive(period time.Duration) TCPOption {
+	return func(opts *tcpOpts) {
+		opts.keepAlive = period
+	}
+}
+
+// WithTCPBufferSizes sets the sizes of the socket receive and send buffers.
+//
+// If a size is zero, uses the system default for that buffer (the default).
+func WithTCPBufferSizes(readBuffer, writeBuffer int) TCPOption {
+	return func(opts *tcpOpts) {
+		opts.readBuffer = readBuffer
+		opts.writeBuffer = writeBuffer
+	}
+}
+
+// ConfigureTCPConn applies the socket options to a TCP connection.
+//
+// Unwraps connections implementing NetConn() net.Conn (such as *tls.Conn).
+// Does nothing if the connection is not a TCP connection.
+func ConfigureTCPConn(conn net.Conn, opts ...TCPOption) error {
+	for {
+		inner, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = inner.NetConn()
+	}
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	o := newTCPOpts(opts)
+	if err := tc.SetNoDelay(o.noDelay); err != nil {
+		return err
+	}
+	if o.keepAlive < 0 {
+		if err := tc.SetKeepAlive(false); err != nil {
+			return err
+		}
+	} else if o.keepAlive > 0 {
+		if err := tc.SetKeepAlive(true); err != nil {
+			return err
+		}
+		if err := tc.SetKeepAlivePeriod(o.keepAlive); err != nil {
+			return err
+		}
+	}
+	if o.readBuffer > 0 {
+		if err := tc.SetReadBuffer(o.readBuffer); err != nil {
+			return err
+		}
+	}
+	if o.writeBuffer > 0 {
+		if err := tc.SetWriteBuffer(o.writeBuffer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DialTCP dials a TCP connection and applies the socket options.
+//
+// Use with NewClientWithConn to construct a client.
+func DialTCP(ctx context.Context, addr string, opts ...TCPOption) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := ConfigureTCPConn(conn, opts...); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
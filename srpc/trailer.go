@@ -0,0 +1,58 @@
+package srpc
+
+import (
+	"context"
+	"sync"
+)
+
+// trailerCtxKey is the context key for the trailer of the call being handled.
+type trailerCtxKey struct{}
+
+// trailerHolder contains the trailer to send with the final packet of a call.
+type trailerHolder struct {
+	// mtx guards below fields
+	mtx sync.Mutex
+	// md is the trailer metadata
+	md Metadata
+	// sent is set after the trailer was taken for sending
+	sent bool
+}
+
+// withTrailer attaches the trailer holder to the context.
+func withTrailer(ctx context.Context, h *trailerHolder) context.Context {
+	return context.WithValue(ctx, trailerCtxKey{}, h)
+}
+
+// take returns the trailer and marks it as sent.
+func (h *trailerHolder) take() Metadata {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.sent = true
+	return h.md
+}
+
+// SetTrailer sets metadata to send to the client with the final packet of the call.
+//
+// The trailer is sent even if the call returns an error. Keys set by previous
+// calls are kept unless overwritten. The trailer must be set before the call
+// completes: SendResponse and SendClose complete the call.
+// Returns false if the context does not belong to an incoming call or the
+// trailer was already sent.
+func SetTrailer(ctx context.Context, md Metadata) bool {
+	h, ok := ctx.Value(trailerCtxKey{}).(*trailerHolder)
+	if !ok {
+		return false
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.sent {
+		return false
+	}
+	if h.md == nil {
+		h.md = make(Metadata, len(md))
+	}
+	for k, v := range md {
+		h.md[k] = v
+	}
+	return true
+}
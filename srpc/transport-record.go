@@ -0,0 +1,424 @@
+package srpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/pkg/errors"
+)
+
+// recordingMagic is written at the start of a recording.
+var recordingMagic = []byte("SRPCREC1")
+
+// recordKind is the kind of a recorded event.
+type recordKind uint8
+
+const (
+	// recordOpen is a stream opened by the local side.
+	recordOpen recordKind = iota + 1
+	// recordAccept is a stream opened by the remote.
+	recordAccept
+	// recordRead is data received from the remote.
+	recordRead
+	// recordWrite is data sent to the remote.
+	recordWrite
+	// recordReadEOF is the remote closing the stream for writing.
+	recordReadEOF
+	// recordCloseWrite is the local side closing the stream for writing.
+	recordCloseWrite
+)
+
+// recordHeaderSize is the size of the header of a recorded event.
+//
+// timestamp (8) + stream (4) + kind (1) + data length (4)
+const recordHeaderSize = 17
+
+// RecordingTransport is a Transport which records the data of its streams.
+//
+// Each event is written with a timestamp, the index of the stream and the
+// data sent or received: the recording contains the raw packet stream of each
+// stream on the transport. Use NewReplayTransport to replay the recording
+// against a server.
+type RecordingTransport struct {
+	Transport
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// w is the recording writer
+	w io.Writer
+	// started is set after writing the magic.
+	started bool
+	// nextStream is the index of the next stream.
+	nextStream uint32
+	// err is the error writing the recording, if any.
+	err error
+}
+
+// NewRecordingTransport constructs a new RecordingTransport.
+//
+// Writes the recording to w. Writes are serialized: w does not need to be
+// safe for concurrent use. Recording errors do not affect the streams: use
+// Err to check if the recording is complete.
+func NewRecordingTransport(inner Transport, w io.Writer) *RecordingTransport {
+	return &RecordingTransport{Transport: inner, w: w}
+}
+
+// OpenStream opens a new stream to the remote.
+func (t *RecordingTransport) OpenStream(ctx context.Context) (network.MuxedStream, error) {
+	strm, err := t.Transport.OpenStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.newStream(strm, recordOpen), nil
+}
+
+// AcceptStream accepts a stream opened by the remote.
+func (t *RecordingTransport) AcceptStream() (network.MuxedStream, error) {
+	strm, err := t.Transport.AcceptStream()
+	if err != nil {
+		return nil, err
+	}
+	return t.newStream(strm, recordAccept), nil
+}
+
+// Err returns the error writing the recording, if any.
+func (t *RecordingTransport) Err() error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.err
+}
+
+// newStream records opening the stream and wraps it.
+func (t *RecordingTransport) newStream(strm network.MuxedStream, kind recordKind) *recordingStream {
+	t.mtx.Lock()
+	id := t.nextStream
+	t.nextStream++
+	t.mtx.Unlock()
+	t.record(id, kind, nil)
+	return &recordingStream{MuxedStream: strm, t: t, id: id}
+}
+
+// record writes an event to the recording.
+func (t *RecordingTransport) record(stream uint32, kind recordKind, data []byte) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.err != nil {
+		return
+	}
+	if !t.started {
+		t.started = true
+		if _, t.err = t.w.Write(recordingMagic); t.err != nil {
+			return
+		}
+	}
+	buf := make([]byte, recordHeaderSize+len(data))
+	binary.LittleEndian.PutUint64(buf, uint64(time.Now().UnixNano()))
+	binary.LittleEndian.PutUint32(buf[8:], stream)
+	buf[12] = byte(kind)
+	binary.LittleEndian.PutUint32(buf[13:], uint32(len(data)))
+	copy(buf[recordHeaderSize:], data)
+	_, t.err = t.w.Write(buf)
+}
+
+// recordingStream is a stream which records its data.
+type recordingStream struct {
+	network.MuxedStream
+	// t is the recording transport
+	t *RecordingTransport
+	// id is the index of the stream in the recording
+	id uint32
+	// closeWriteOnce records closing for writing once
+	closeWriteOnce sync.Once
+}
+
+// Read reads data from the stream.
+func (s *recordingStream) Read(p []byte) (int, error) {
+	n, err := s.MuxedStream.Read(p)
+	if n > 0 {
+		s.t.record(s.id, recordRead, p[:n])
+	}
+	if err == io.EOF {
+		s.t.record(s.id, recordReadEOF, nil)
+	}
+	return n, err
+}
+
+// Write writes data to the stream.
+func (s *recordingStream) Write(p []byte) (int, error) {
+	n, err := s.MuxedStream.Write(p)
+	if n > 0 {
+		s.t.record(s.id, recordWrite, p[:n])
+	}
+	return n, err
+}
+
+// CloseWrite closes the stream for writing.
+func (s *recordingStream) CloseWrite() error {
+	s.recordCloseWrite()
+	return s.MuxedStream.CloseWrite()
+}
+
+// Close closes the stream.
+func (s *recordingStream) Close() error {
+	s.recordCloseWrite()
+	return s.MuxedStream.Close()
+}
+
+// recordCloseWrite records closing the stream for writing once.
+func (s *recordingStream) recordCloseWrite() {
+	s.closeWriteOnce.Do(func() {
+		s.t.record(s.id, recordCloseWrite, nil)
+	})
+}
+
+// StreamID returns the multiplexing stream ID of the stream.
+func (s *recordingStream) StreamID() uint64 {
+	return streamIDOf(s.MuxedStream)
+}
+
+// ReplayTransport is a Transport which replays a recording to a server.
+//
+// Each stream of the recording is accepted as an incoming stream. Reading the
+// stream returns the data the server received from the client when recording:
+// the data read by the recording side of accepted streams, or the data
+// written by the recording side of opened streams. The data is replayed
+// without delay. Data written to the streams is discarded. If the client did
+// not close the stream for writing, reading blocks after the recorded data
+// until the stream is closed, as if the client was still connected.
+type ReplayTransport struct {
+	// r is the recording reader
+	r io.Reader
+	// done is closed when the transport is closed
+	done chan struct{}
+	// closeOnce guards closing done
+	closeOnce sync.Once
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// parsed is set after parsing the recording.
+	parsed bool
+	// streams contains the streams which were not accepted yet in order.
+	streams []*replayStream
+	// accepted contains the accepted streams.
+	accepted []*replayStream
+	// err is the error parsing the recording.
+	err error
+}
+
+// NewReplayTransport constructs a new ReplayTransport reading the recording from r.
+//
+// The recording is read by the first call to AcceptStream.
+func NewReplayTransport(r io.Reader) *ReplayTransport {
+	return &ReplayTransport{r: r, done: make(chan struct{})}
+}
+
+// OpenStream returns ErrUnimplemented: replayed streams are accepted only.
+func (t *ReplayTransport) OpenStream(ctx context.Context) (network.MuxedStream, error) {
+	return nil, ErrUnimplemented
+}
+
+// AcceptStream accepts the next stream of the recording.
+//
+// Returns io.EOF after all streams were accepted or the transport was closed.
+func (t *ReplayTransport) AcceptStream() (network.MuxedStream, error) {
+	select {
+	case <-t.done:
+		return nil, io.EOF
+	default:
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if !t.parsed {
+		t.parsed = true
+		t.streams, t.err = parseRecording(t.r)
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	if len(t.streams) == 0 {
+		return nil, io.EOF
+	}
+	strm := t.streams[0]
+	t.streams[0] = nil
+	t.streams = t.streams[1:]
+	t.accepted = append(t.accepted, strm)
+	return strm, nil
+}
+
+// Close closes the transport and all of its streams.
+func (t *ReplayTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+	t.mtx.Lock()
+	accepted := t.accepted
+	t.accepted = nil
+	t.mtx.Unlock()
+	for _, strm := range accepted {
+		_ = strm.Close()
+	}
+	return nil
+}
+
+// Done returns a channel which is closed when the transport is closed.
+func (t *ReplayTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// parseRecording reads the recording and returns the streams to replay in order.
+func parseRecording(r io.Reader) ([]*replayStream, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		if err == io.EOF {
+			// empty recording
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !bytes.Equal(magic, recordingMagic) {
+		return nil, errors.New("invalid recording header")
+	}
+
+	var streams []*replayStream
+	byID := make(map[uint32]*replayStream)
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return streams, nil
+			}
+			return nil, err
+		}
+		id := binary.LittleEndian.Uint32(header[8:])
+		kind := recordKind(header[12])
+		size := binary.LittleEndian.Uint32(header[13:])
+		if size > uint32(maxMessageSize) {
+			return nil, errors.Wrapf(ErrFrameTooLarge, "recorded data of %d bytes", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, err
+		}
+
+		if kind == recordOpen || kind == recordAccept {
+			strm := newReplayStream(kind == recordOpen)
+			streams = append(streams, strm)
+			byID[id] = strm
+			continue
+		}
+		strm := byID[id]
+		if strm == nil {
+			return nil, errors.Errorf("recorded event for unknown stream %d", id)
+		}
+		switch {
+		case kind == recordRead && !strm.opened, kind == recordWrite && strm.opened:
+			strm.data = append(strm.data, data...)
+		case kind == recordReadEOF && !strm.opened, kind == recordCloseWrite && strm.opened:
+			strm.eof = true
+		}
+	}
+}
+
+// replayStream is a stream replaying the recorded data.
+type replayStream struct {
+	// opened indicates the recording side opened the stream.
+	opened bool
+	// data is the data received by the server
+	data []byte
+	// eof indicates the client closed the stream for writing
+	eof bool
+	// closed is closed when the stream is closed for reading
+	closed chan struct{}
+	// closeOnce guards closing closed
+	closeOnce sync.Once
+
+	// mtx guards pos
+	mtx sync.Mutex
+	// pos is the read position in data
+	pos int
+}
+
+// newReplayStream constructs a new replayStream.
+func newReplayStream(opened bool) *replayStream {
+	return &replayStream{opened: opened, closed: make(chan struct{})}
+}
+
+// Read reads the recorded data.
+func (s *replayStream) Read(p []byte) (int, error) {
+	s.mtx.Lock()
+	if s.pos < len(s.data) {
+		n := copy(p, s.data[s.pos:])
+		s.pos += n
+		s.mtx.Unlock()
+		return n, nil
+	}
+	s.mtx.Unlock()
+	if !s.eof {
+		<-s.closed
+	}
+	return 0, io.EOF
+}
+
+// Write discards the data.
+func (s *replayStream) Write(p []byte) (int, error) {
+	select {
+	case <-s.closed:
+		return 0, io.ErrClosedPipe
+	default:
+		return len(p), nil
+	}
+}
+
+// Close closes the stream.
+func (s *replayStream) Close() error {
+	return s.CloseRead()
+}
+
+// CloseWrite closes the stream for writing.
+func (s *replayStream) CloseWrite() error {
+	return nil
+}
+
+// CloseRead closes the stream for reading.
+func (s *replayStream) CloseRead() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+	return nil
+}
+
+// Reset closes the stream.
+func (s *replayStream) Reset() error {
+	return s.Close()
+}
+
+// SetDeadline does nothing.
+func (s *replayStream) SetDeadline(t time.Time) error {
+	return nil
+}
+
+// SetReadDeadline does nothing.
+func (s *replayStream) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline does nothing.
+func (s *replayStream) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// _ is a type assertion
+var (
+	_ Transport           = ((*RecordingTransport)(nil))
+	_ Transport           = ((*ReplayTransport)(nil))
+	_ network.MuxedStream = ((*recordingStream)(nil))
+	_ network.MuxedStream = ((*replayStream)(nil))
+)
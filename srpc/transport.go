@@ -0,0 +1,100 @@
+package srpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	yamux "github.com/libp2p/go-yamux/v4"
+)
+
+// Transport is a connection which carries multiplexed RPC streams.
+//
+// The Server accepts streams from a Transport with ServeTransport and the
+// Client opens streams with NewClientWithTransport. WebSocketConn implements
+// Transport; use NewTransport to wrap any other MuxedConn (such as a yamux
+// session over TCP) and NewPipeTransports for in-memory transports.
+type Transport interface {
+	// OpenStream opens a new stream to the remote.
+	OpenStream(ctx context.Context) (network.MuxedStream, error)
+	// AcceptStream accepts a stream opened by the remote.
+	AcceptStream() (network.MuxedStream, error)
+	// Close closes the transport and all of its streams.
+	Close() error
+	// Done returns a channel which is closed when the transport is closed.
+	//
+	// Returns nil if the transport cannot report closing.
+	Done() <-chan struct{}
+}
+
+// NewTransport constructs a Transport with a MuxedConn.
+//
+// Returns the conn if it already implements Transport.
+// Done is implemented with MuxedConnDone.
+func NewTransport(mc network.MuxedConn) Transport {
+	if t, ok := mc.(Transport); ok {
+		return t
+	}
+	return &muxedConnTransport{MuxedConn: mc, done: MuxedConnDone(mc)}
+}
+
+// NewPipeTransports constructs a pair of connected in-memory transports.
+//
+// Streams opened on one transport are accepted by the other.
+// If yamuxConf is nil, uses defaults.
+func NewPipeTransports(yamuxConf *yamux.Config) (Transport, Transport, error) {
+	outPipe, inPipe := net.Pipe()
+	outConn, err := NewMuxedConn(outPipe, true, yamuxConf)
+	if err != nil {
+		_ = outPipe.Close()
+		_ = inPipe.Close()
+		return nil, nil, err
+	}
+	inConn, err := NewMuxedConn(inPipe, false, yamuxConf)
+	if err != nil {
+		_ = outConn.Close()
+		_ = inPipe.Close()
+		return nil, nil, err
+	}
+	return NewTransport(outConn), NewTransport(inConn), nil
+}
+
+// NewClientWithTransport constructs a new client with a Transport.
+//
+// Closing the client closes the Transport.
+func NewClientWithTransport(t Transport, opts ...ClientOption) Client {
+	return NewClientWithClose(NewOpenStreamWithTransport(t), t.Close, opts...)
+}
+
+// NewOpenStreamWithTransport constructs a OpenStream func with a Transport.
+func NewOpenStreamWithTransport(t Transport) OpenStreamFunc {
+	return func(ctx context.Context, msgHandler PacketHandler, closeHandler CloseHandler) (Writer, error) {
+		mstrm, err := t.OpenStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rw := NewPacketReadWriter(mstrm)
+		if err := startReadPump(ctx, rw, msgHandler, closeHandler); err != nil {
+			return nil, err
+		}
+		return rw, nil
+	}
+}
+
+// muxedConnTransport implements Transport with a MuxedConn.
+type muxedConnTransport struct {
+	network.MuxedConn
+	// done is closed when the conn is closed
+	done <-chan struct{}
+}
+
+// Done returns a channel which is closed when the transport is closed.
+func (t *muxedConnTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// _ is a type assertion
+var (
+	_ Transport = ((*muxedConnTransport)(nil))
+	_ Transport = ((*WebSocketConn)(nil))
+)
@@ -0,0 +1,57 @@
+package srpc
+
+import "context"
+
+// TypedStream wraps a Stream with typed Send and Recv functions.
+//
+// Useful for constructing streams without generated code.
+type TypedStream[Req, Resp Message] struct {
+	Stream
+	// newResp constructs a new response message.
+	newResp func() Resp
+}
+
+// NewTypedStream wraps a Stream with a TypedStream.
+//
+// newResp constructs a new empty response message for Recv.
+func NewTypedStream[Req, Resp Message](strm Stream, newResp func() Resp) *TypedStream[Req, Resp] {
+	return &TypedStream[Req, Resp]{Stream: strm, newResp: newResp}
+}
+
+// OpenTypedStream starts a streaming RPC with the client & wraps it with a TypedStream.
+//
+// firstMsg is optional.
+// newResp constructs a new empty response message for Recv.
+func OpenTypedStream[Req, Resp Message](
+	ctx context.Context,
+	client Client,
+	service, method string,
+	firstMsg Message,
+	newResp func() Resp,
+) (*TypedStream[Req, Resp], error) {
+	strm, err := client.NewStream(ctx, service, method, firstMsg)
+	if err != nil {
+		return nil, err
+	}
+	return NewTypedStream[Req](strm, newResp), nil
+}
+
+// Send sends the message to the remote.
+func (s *TypedStream[Req, Resp]) Send(msg Req) error {
+	return s.MsgSend(msg)
+}
+
+// Recv receives a message from the remote.
+func (s *TypedStream[Req, Resp]) Recv() (Resp, error) {
+	msg := s.newResp()
+	if err := s.MsgRecv(msg); err != nil {
+		var empty Resp
+		return empty, err
+	}
+	return msg, nil
+}
+
+// RecvTo receives a message from the remote into msg.
+func (s *TypedStream[Req, Resp]) RecvTo(msg Resp) error {
+	return s.MsgRecv(msg)
+}
@@ -0,0 +1,71 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestTypedStream tests sending and receiving typed messages over a server pipe.
+func TestTypedStream(t *testing.T) {
+	newCallData := func() *CallData { return &CallData{} }
+	server := NewServer(InvokerFunc(func(serviceID, methodID string, strm Stream) (bool, error) {
+		typed := NewTypedStream[*CallData](strm, newCallData)
+		for {
+			msg, err := typed.Recv()
+			if err == io.EOF {
+				return true, nil
+			}
+			if err != nil {
+				return true, err
+			}
+			if string(msg.GetData()) == "fail" {
+				return true, errors.New("handler failed")
+			}
+			if err := typed.Send(&CallData{Data: append([]byte("echo "), msg.GetData()...)}); err != nil {
+				return true, err
+			}
+		}
+	}))
+	client := NewClient(NewServerPipe(server))
+	ctx := context.Background()
+
+	strm, err := OpenTypedStream[*CallData](ctx, client, "test-service", "test-method", &CallData{Data: []byte("first")}, newCallData)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+
+	msg, err := strm.Recv()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(msg.GetData()) != "echo first" {
+		t.Fatalf("expected echo of the first message but got %q", msg.GetData())
+	}
+
+	if err := strm.Send(&CallData{Data: []byte("second")}); err != nil {
+		t.Fatal(err.Error())
+	}
+	out := &CallData{}
+	if err := strm.RecvTo(out); err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(out.GetData()) != "echo second" {
+		t.Fatalf("expected echo of the second message but got %q", out.GetData())
+	}
+
+	// the handler error is returned by Recv
+	if err := strm.Send(&CallData{Data: []byte("fail")}); err != nil {
+		t.Fatal(err.Error())
+	}
+	msg, err = strm.Recv()
+	if err == nil || !strings.Contains(err.Error(), "handler failed") {
+		t.Fatalf("expected the handler error but got %v", err)
+	}
+	if msg != nil {
+		t.Fatalf("expected no message with the error but got %v", msg)
+	}
+}
@@ -2,12 +2,174 @@ package srpc
 
 import (
 	"context"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-yamux/v4"
 	"nhooyr.io/websocket"
 )
 
+// WebSocketOption configures a WebSocket conn.
+type WebSocketOption func(opts *webSocketOpts)
+
+// webSocketOpts contains the WebSocket conn options.
+type webSocketOpts struct {
+	// readTimeout is the timeout for each read operation.
+	readTimeout time.Duration
+	// writeTimeout is the timeout for each write operation.
+	writeTimeout time.Duration
+	// coalesceMaxBytes is the max size of coalesced writes.
+	coalesceMaxBytes int
+	// coalesceMaxDelay is the max time to wait to coalesce writes.
+	coalesceMaxDelay time.Duration
+}
+
+// WithReadTimeout sets the timeout for each read from the WebSocket.
+//
+// If a read does not complete within the timeout the connection is closed,
+// erroring any pending streams. Note that this also closes idle connections.
+// If zero, reads do not time out (the default).
+func WithReadTimeout(timeout time.Duration) WebSocketOption {
+	return func(opts *webSocketOpts) {
+		opts.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the timeout for each write to the WebSocket.
+//
+// If a write does not complete within the timeout the connection is closed,
+// erroring any pending streams.
+// If zero, writes do not time out (the default).
+func WithWriteTimeout(timeout time.Duration) WebSocketOption {
+	return func(opts *webSocketOpts) {
+		opts.writeTimeout = timeout
+	}
+}
+
+// WithWriteCoalesce coalesces writes into fewer WebSocket frames.
+//
+// Writes are buffered until maxBytes are pending or maxDelay has passed since
+// the first pending write, then sent as a single frame. This reduces the
+// number of frames when many small packets are sent in quick succession at
+// the cost of up to maxDelay of added latency. Errors writing the buffered
+// data are returned by the next call to Write. Completing a stream with
+// CloseSend or Close flushes the pending data without waiting for maxDelay.
+// If maxBytes or maxDelay is zero, writes are not coalesced (the default).
+func WithWriteCoalesce(maxBytes int, maxDelay time.Duration) WebSocketOption {
+	return func(opts *webSocketOpts) {
+		opts.coalesceMaxBytes = maxBytes
+		opts.coalesceMaxDelay = maxDelay
+	}
+}
+
+// WebSocketConn is a MuxedConn over a WebSocket.
+type WebSocketConn struct {
+	network.MuxedConn
+	acceptor *streamAcceptor
+	// coalesce is the coalescing conn, if enabled.
+	coalesce *coalesceConn
+	// ws is the underlying websocket
+	ws *websocket.Conn
+
+	// closeMtx guards below fields
+	closeMtx sync.Mutex
+	// closeStatus is the close status requested with CloseWithStatus.
+	closeStatus websocket.StatusCode
+	// closeReason is the close reason requested with CloseWithStatus.
+	closeReason string
+}
+
+// OpenStream opens a new stream to the remote.
+func (c *WebSocketConn) OpenStream(ctx context.Context) (network.MuxedStream, error) {
+	strm, err := c.MuxedConn.OpenStream(ctx)
+	return c.wrapStream(strm), err
+}
+
+// AcceptStream accepts a stream opened by the remote.
+func (c *WebSocketConn) AcceptStream() (network.MuxedStream, error) {
+	return c.AcceptStreamContext(context.Background())
+}
+
+// AcceptStreamContext accepts a stream from the remote until ctx is canceled.
+//
+// Returns ctx.Err() if ctx is canceled before a stream is accepted. A stream
+// arriving after ctx was canceled is returned by the next call. Must not be
+// called concurrently with itself or AcceptStream.
+func (c *WebSocketConn) AcceptStreamContext(ctx context.Context) (network.MuxedStream, error) {
+	strm, err := c.acceptor.accept(ctx)
+	return c.wrapStream(strm), err
+}
+
+// wrapStream wraps the stream to flush coalesced writes, if enabled.
+func (c *WebSocketConn) wrapStream(strm network.MuxedStream) network.MuxedStream {
+	if strm == nil || c.coalesce == nil {
+		return strm
+	}
+	return &coalesceStream{MuxedStream: strm, conn: c.coalesce}
+}
+
+// Done returns a channel which is closed when the conn is closed.
+//
+// Closing the conn ends all of its streams.
+func (c *WebSocketConn) Done() <-chan struct{} {
+	return MuxedConnDone(c.MuxedConn)
+}
+
+// Close closes the conn.
+func (c *WebSocketConn) Close() error {
+	c.acceptor.close()
+	return c.MuxedConn.Close()
+}
+
+// CloseWithStatus closes the WebSocket with the close status code and reason.
+//
+// Use to terminate the whole connection with an application-defined code,
+// for example from a call handler with WebSocketConnFromContext. All streams
+// of the conn are ended. The status is only sent by the first call to
+// CloseWithStatus or Close. Blocks until the remote acknowledges the close
+// or a timeout.
+func (c *WebSocketConn) CloseWithStatus(code websocket.StatusCode, reason string) error {
+	c.closeMtx.Lock()
+	if c.closeStatus == 0 {
+		c.closeStatus, c.closeReason = code, reason
+	}
+	c.closeMtx.Unlock()
+	c.acceptor.close()
+	err := c.ws.Close(code, reason)
+	_ = c.MuxedConn.Close()
+	return err
+}
+
+// requestedCloseStatus returns the close status requested with CloseWithStatus.
+//
+// Returns the defaults if CloseWithStatus was not called.
+func (c *WebSocketConn) requestedCloseStatus(code websocket.StatusCode, reason string) (websocket.StatusCode, string) {
+	c.closeMtx.Lock()
+	defer c.closeMtx.Unlock()
+	if c.closeStatus != 0 {
+		return c.closeStatus, c.closeReason
+	}
+	return code, reason
+}
+
+// webSocketConnCtxKey is the context key for the WebSocket conn.
+type webSocketConnCtxKey struct{}
+
+// withWebSocketConn attaches the WebSocket conn to the context.
+func withWebSocketConn(ctx context.Context, conn *WebSocketConn) context.Context {
+	return context.WithValue(ctx, webSocketConnCtxKey{}, conn)
+}
+
+// WebSocketConnFromContext returns the WebSocket conn carrying the call.
+//
+// Set for calls accepted by the HTTP server. Returns nil, false otherwise.
+func WebSocketConnFromContext(ctx context.Context) (*WebSocketConn, bool) {
+	conn, ok := ctx.Value(webSocketConnCtxKey{}).(*WebSocketConn)
+	return conn, ok
+}
+
 // NewWebSocketConn wraps a websocket into a MuxedConn.
 // if yamuxConf is unset, uses the defaults.
 func NewWebSocketConn(
@@ -15,7 +177,209 @@ func NewWebSocketConn(
 	conn *websocket.Conn,
 	isServer bool,
 	yamuxConf *yamux.Config,
-) (network.MuxedConn, error) {
-	nc := websocket.NetConn(ctx, conn, websocket.MessageBinary)
-	return NewMuxedConn(nc, !isServer, yamuxConf)
+	opts ...WebSocketOption,
+) (*WebSocketConn, error) {
+	var wsOpts webSocketOpts
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&wsOpts)
+		}
+	}
+
+	var nc net.Conn = websocket.NetConn(ctx, conn, websocket.MessageBinary)
+	if wsOpts.readTimeout > 0 || wsOpts.writeTimeout > 0 {
+		nc = &timeoutConn{
+			Conn:         nc,
+			readTimeout:  wsOpts.readTimeout,
+			writeTimeout: wsOpts.writeTimeout,
+		}
+	}
+	var cc *coalesceConn
+	if wsOpts.coalesceMaxBytes > 0 && wsOpts.coalesceMaxDelay > 0 {
+		cc = newCoalesceConn(nc, wsOpts.coalesceMaxBytes, wsOpts.coalesceMaxDelay)
+		nc = cc
+	}
+	mc, err := NewMuxedConn(nc, !isServer, yamuxConf)
+	if err != nil {
+		return nil, err
+	}
+	return &WebSocketConn{MuxedConn: mc, acceptor: newStreamAcceptor(mc), coalesce: cc, ws: conn}, nil
+}
+
+// timeoutConn sets a deadline before each Read and Write call.
+//
+// The WebSocket net.Conn closes the connection when a deadline is exceeded.
+type timeoutConn struct {
+	net.Conn
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// Read reads data from the connection.
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if c.readTimeout > 0 {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Read(b)
+}
+
+// Write writes data to the connection.
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	if c.writeTimeout > 0 {
+		if err := c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Write(b)
 }
+
+// coalesceConn buffers writes and writes them to the conn in batches.
+type coalesceConn struct {
+	net.Conn
+	maxBytes int
+	maxDelay time.Duration
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// buf contains the pending data
+	buf []byte
+	// timer flushes buf after maxDelay
+	timer *time.Timer
+	// err is the error from a previous write
+	err error
+	// flushUntil is the time until which writes are not delayed.
+	flushUntil time.Time
+}
+
+// newCoalesceConn constructs a new coalesceConn.
+func newCoalesceConn(conn net.Conn, maxBytes int, maxDelay time.Duration) *coalesceConn {
+	return &coalesceConn{Conn: conn, maxBytes: maxBytes, maxDelay: maxDelay}
+}
+
+// Write buffers data to be written to the connection.
+func (c *coalesceConn) Write(b []byte) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	if len(c.buf) != 0 && len(c.buf)+len(b) > c.maxBytes {
+		if err := c.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if len(b) >= c.maxBytes {
+		n, err := c.Conn.Write(b)
+		if err != nil {
+			c.err = err
+		}
+		return n, err
+	}
+	c.buf = append(c.buf, b...)
+	if len(c.buf) >= c.maxBytes || time.Now().Before(c.flushUntil) {
+		if err := c.flushLocked(); err != nil {
+			return 0, err
+		}
+	} else if c.timer == nil {
+		c.timer = time.AfterFunc(c.maxDelay, c.flushTimer)
+	}
+	return len(b), nil
+}
+
+// Flush writes any pending data to the connection.
+//
+// The muxer writes frames to the connection asynchronously: frames queued
+// before Flush was called may not have been written yet. Writes within maxDelay
+// after Flush are not delayed so that the queued frames are written promptly.
+// If ctx has a deadline it is used as the write deadline.
+func (c *coalesceConn) Flush(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.flushUntil = time.Now().Add(c.maxDelay)
+	if len(c.buf) == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.Conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+		defer func() {
+			_ = c.Conn.SetWriteDeadline(time.Time{})
+		}()
+	}
+	return c.flushLocked()
+}
+
+// Close flushes any pending data and closes the connection.
+func (c *coalesceConn) Close() error {
+	c.mtx.Lock()
+	if c.err == nil {
+		_ = c.flushLocked()
+	}
+	c.mtx.Unlock()
+	return c.Conn.Close()
+}
+
+// flushTimer flushes the pending data after the delay.
+func (c *coalesceConn) flushTimer() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.timer = nil
+	if c.err != nil {
+		return
+	}
+	if err := c.flushLocked(); err != nil {
+		_ = c.Conn.Close()
+	}
+}
+
+// flushLocked writes the pending data to the connection.
+// c.mtx must be locked.
+func (c *coalesceConn) flushLocked() error {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if len(c.buf) == 0 {
+		return nil
+	}
+	_, err := c.Conn.Write(c.buf)
+	c.buf = c.buf[:0]
+	if err != nil {
+		c.err = err
+	}
+	return err
+}
+
+// coalesceStream is a stream over a coalesceConn which can be flushed.
+type coalesceStream struct {
+	network.MuxedStream
+	conn *coalesceConn
+}
+
+// StreamID returns the multiplexing stream ID of the stream.
+func (s *coalesceStream) StreamID() uint64 {
+	return streamIDOf(s.MuxedStream)
+}
+
+// Flush writes any pending coalesced data to the connection.
+func (s *coalesceStream) Flush(ctx context.Context) error {
+	return s.conn.Flush(ctx)
+}
+
+// _ is a type assertion
+var (
+	_ network.MuxedConn = ((*WebSocketConn)(nil))
+	_ Flusher           = ((*coalesceStream)(nil))
+	_ Flusher           = ((*coalesceConn)(nil))
+	_ net.Conn          = ((*timeoutConn)(nil))
+	_ net.Conn          = ((*coalesceConn)(nil))
+)
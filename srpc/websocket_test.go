@@ -0,0 +1,194 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// recordConn is a net.Conn which records the writes.
+type recordConn struct {
+	net.Conn
+
+	mtx    sync.Mutex
+	writes [][]byte
+}
+
+// Write records the data.
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.mtx.Lock()
+	c.writes = append(c.writes, append([]byte(nil), b...))
+	c.mtx.Unlock()
+	return len(b), nil
+}
+
+// Close does nothing.
+func (c *recordConn) Close() error { return nil }
+
+// getWrites returns the recorded writes.
+func (c *recordConn) getWrites() [][]byte {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([][]byte(nil), c.writes...)
+}
+
+// TestCoalesceConn tests coalescing writes to a conn.
+func TestCoalesceConn(t *testing.T) {
+	rc := &recordConn{}
+	conn := newCoalesceConn(rc, 8, time.Millisecond*10)
+
+	// small writes are coalesced until the delay
+	for _, data := range []string{"ab", "cd", "ef"} {
+		if _, err := conn.Write([]byte(data)); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if writes := rc.getWrites(); len(writes) != 0 {
+		t.Fatalf("expected no writes but got %d", len(writes))
+	}
+	<-time.After(time.Millisecond * 50)
+	writes := rc.getWrites()
+	if len(writes) != 1 || string(writes[0]) != "abcdef" {
+		t.Fatalf("expected one coalesced write but got %q", writes)
+	}
+
+	// reaching maxBytes flushes immediately
+	if _, err := conn.Write([]byte("0123")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := conn.Write([]byte("4567")); err != nil {
+		t.Fatal(err.Error())
+	}
+	writes = rc.getWrites()
+	if len(writes) != 2 || string(writes[1]) != "01234567" {
+		t.Fatalf("expected flush at max bytes but got %q", writes)
+	}
+
+	// close flushes pending data
+	if _, err := conn.Write([]byte("xy")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	writes = rc.getWrites()
+	if len(writes) != 3 || string(writes[2]) != "xy" {
+		t.Fatalf("expected flush on close but got %q", writes)
+	}
+}
+
+// TestCoalesceConn_Flush tests flushing pending writes to a conn.
+func TestCoalesceConn_Flush(t *testing.T) {
+	rc := &recordConn{}
+	conn := newCoalesceConn(rc, 1024, time.Minute)
+
+	if _, err := conn.Write([]byte("ab")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := conn.Flush(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	writes := rc.getWrites()
+	if len(writes) != 1 || string(writes[0]) != "ab" {
+		t.Fatalf("expected flushed write but got %q", writes)
+	}
+
+	// writes queued in the muxer before the flush are not delayed
+	if _, err := conn.Write([]byte("cd")); err != nil {
+		t.Fatal(err.Error())
+	}
+	writes = rc.getWrites()
+	if len(writes) != 2 || string(writes[1]) != "cd" {
+		t.Fatalf("expected write after flush but got %q", writes)
+	}
+}
+
+// TestTimeoutConn tests the read and write timeouts of a conn.
+func TestTimeoutConn(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := &timeoutConn{Conn: c1, readTimeout: time.Millisecond * 10, writeTimeout: time.Millisecond * 10}
+	defer conn.Close()
+
+	// nothing is written to or read from the other end
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected read deadline exceeded but got %v", err)
+	}
+	if _, err := conn.Write([]byte("hello")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected write deadline exceeded but got %v", err)
+	}
+}
+
+// TestWebSocketConn_AcceptStreamContext tests canceling a blocked accept.
+func TestWebSocketConn_AcceptStreamContext(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	defer ctxCancel()
+
+	serverConns := make(chan *WebSocketConn, 1)
+	hsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		wsConn, err := NewWebSocketConn(ctx, c, true, nil)
+		if err != nil {
+			return
+		}
+		serverConns <- wsConn
+		<-ctx.Done()
+	}))
+	defer hsrv.Close()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hsrv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	clientConn, err := NewWebSocketConn(ctx, conn, false, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer clientConn.Close()
+	serverConn := <-serverConns
+	defer serverConn.Close()
+
+	// no streams are opened: accept blocks until acceptCtx is canceled
+	acceptCtx, acceptCtxCancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := serverConn.AcceptStreamContext(acceptCtx)
+		errCh <- err
+	}()
+	acceptCtxCancel()
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled but got %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected AcceptStreamContext to return after ctx was canceled")
+	}
+
+	// the conn accepts streams after the canceled call
+	strm, err := clientConn.OpenStream(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer strm.Close()
+	if _, err := strm.Write([]byte("hello")); err != nil {
+		t.Fatal(err.Error())
+	}
+	accepted, err := serverConn.AcceptStreamContext(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer accepted.Close()
+}
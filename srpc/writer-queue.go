@@ -0,0 +1,184 @@
+package srpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aperturerobotics/util/broadcast"
+	"github.com/pkg/errors"
+)
+
+// queuedWriter is a Writer which writes packets to the underlying Writer in
+// the background with a bounded queue.
+//
+// WritePacket returns once the packet is queued and blocks if the queue is
+// full. Packets are written in order. Close closes the underlying Writer after
+// the queued packets were written. When ctx is canceled the queued packets are
+// dropped.
+type queuedWriter struct {
+	// ctx is the context of the stream
+	ctx context.Context
+	// w is the underlying writer
+	w Writer
+	// depth is the max number of queued packets
+	depth int
+	// done is closed when the write loop exits
+	done chan struct{}
+
+	// mtx guards below fields
+	mtx sync.Mutex
+	// bcast broadcasts when below fields change
+	bcast broadcast.Broadcast
+	// queue contains the packets waiting to be written.
+	// the packet being written remains at the head of the queue.
+	queue []*Packet
+	// err is the error writing a packet, if any.
+	err error
+	// closed is set when Close is called.
+	closed bool
+	// dropped is the number of queued packets which were not written.
+	dropped int
+}
+
+// newQueuedWriter constructs a new queuedWriter and starts the write loop.
+func newQueuedWriter(ctx context.Context, w Writer, depth int) *queuedWriter {
+	if depth <= 0 {
+		depth = 1
+	}
+	qw := &queuedWriter{ctx: ctx, w: w, depth: depth, done: make(chan struct{})}
+	goTracked(qw.writeLoop)
+	return qw
+}
+
+// WritePacket queues a packet to be written to the remote.
+//
+// Blocks until there is space in the queue.
+// Returns context.Canceled if ctx is canceled while waiting.
+// Returns the error from a previous write if it failed.
+func (w *queuedWriter) WritePacket(p *Packet) error {
+	w.mtx.Lock()
+	for {
+		if w.err != nil {
+			err := w.err
+			w.mtx.Unlock()
+			return err
+		}
+		if w.closed {
+			w.mtx.Unlock()
+			return ErrStreamClosed
+		}
+		if len(w.queue) < w.depth {
+			w.queue = append(w.queue, p)
+			w.bcast.Broadcast()
+			w.mtx.Unlock()
+			return nil
+		}
+		waitCh := w.bcast.GetWaitCh()
+		w.mtx.Unlock()
+		select {
+		case <-w.ctx.Done():
+			return context.Canceled
+		case <-waitCh:
+		}
+		w.mtx.Lock()
+	}
+}
+
+// Flush waits for the queued packets to be written and flushes the underlying Writer.
+func (w *queuedWriter) Flush(ctx context.Context) error {
+	for {
+		w.mtx.Lock()
+		err, queued := w.err, len(w.queue)
+		waitCh := w.bcast.GetWaitCh()
+		w.mtx.Unlock()
+		if err != nil {
+			return err
+		}
+		if queued == 0 {
+			return flushWriter(ctx, w.w)
+		}
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case <-waitCh:
+		}
+	}
+}
+
+// Close closes the underlying Writer after the queued packets were written.
+//
+// Waits for the queued packets to be written. If ctx is canceled first the
+// remaining packets are dropped. Returns an error wrapping ErrStreamClosed if
+// any queued packets were not written.
+func (w *queuedWriter) Close() error {
+	w.mtx.Lock()
+	w.closed = true
+	w.bcast.Broadcast()
+	w.mtx.Unlock()
+
+	select {
+	case <-w.done:
+	case <-w.ctx.Done():
+		// unblock the pending write, if any
+		_ = w.w.Close()
+		<-w.done
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.dropped != 0 {
+		return errors.Wrapf(ErrStreamClosed, "%d queued packets dropped", w.dropped)
+	}
+	return nil
+}
+
+// StreamID returns the stream ID of the underlying Writer.
+func (w *queuedWriter) StreamID() uint64 {
+	return streamIDOf(w.w)
+}
+
+// writeLoop writes the queued packets until the writer is closed.
+//
+// Drops the queued packets when ctx is canceled.
+func (w *queuedWriter) writeLoop() {
+	defer close(w.done)
+	w.mtx.Lock()
+	for {
+		if len(w.queue) != 0 && w.ctx.Err() != nil {
+			w.dropped += len(w.queue)
+			w.queue = nil
+			w.bcast.Broadcast()
+		}
+		if len(w.queue) == 0 || w.err != nil {
+			if w.closed {
+				w.mtx.Unlock()
+				_ = w.w.Close()
+				return
+			}
+			waitCh := w.bcast.GetWaitCh()
+			w.mtx.Unlock()
+			<-waitCh
+			w.mtx.Lock()
+			continue
+		}
+
+		pkt := w.queue[0]
+		w.mtx.Unlock()
+		err := w.w.WritePacket(pkt)
+		w.mtx.Lock()
+		w.queue[0] = nil
+		w.queue = w.queue[1:]
+		if err != nil {
+			w.err = err
+			w.dropped += len(w.queue) + 1
+			w.queue = nil
+		}
+		w.bcast.Broadcast()
+	}
+}
+
+// _ is a type assertion
+var (
+	_ Writer  = ((*queuedWriter)(nil))
+	_ Flusher = ((*queuedWriter)(nil))
+)
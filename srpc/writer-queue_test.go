@@ -0,0 +1,102 @@
+package srpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter is a Writer which blocks writes until released.
+type gatedWriter struct {
+	release chan struct{}
+	mtx     sync.Mutex
+	written []string
+	closed  bool
+}
+
+// WritePacket waits for release and records the packet data.
+func (w *gatedWriter) WritePacket(p *Packet) error {
+	<-w.release
+	w.mtx.Lock()
+	w.written = append(w.written, string(p.GetCallData().GetData()))
+	w.mtx.Unlock()
+	return nil
+}
+
+// Close records that the writer was closed.
+func (w *gatedWriter) Close() error {
+	w.mtx.Lock()
+	w.closed = true
+	w.mtx.Unlock()
+	return nil
+}
+
+// TestQueuedWriter tests queueing packets with backpressure.
+func TestQueuedWriter(t *testing.T) {
+	gw := &gatedWriter{release: make(chan struct{})}
+	qw := newQueuedWriter(context.Background(), gw, 2)
+
+	// the queue accepts packets while the transport is blocked
+	for _, data := range []string{"1", "2"} {
+		if err := qw.WritePacket(NewCallDataPacket([]byte(data), false, false, nil)); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// the queue is full: the next write blocks
+	writtenCh := make(chan error, 1)
+	go func() {
+		writtenCh <- qw.WritePacket(NewCallDataPacket([]byte("3"), false, false, nil))
+	}()
+	select {
+	case <-writtenCh:
+		t.Fatal("expected write to block when the queue is full")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(gw.release)
+	if err := <-writtenCh; err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := qw.Flush(context.Background()); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := qw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	gw.mtx.Lock()
+	defer gw.mtx.Unlock()
+	if !gw.closed {
+		t.Fatal("expected Close to close the underlying writer")
+	}
+	if len(gw.written) != 3 || gw.written[0] != "1" || gw.written[1] != "2" || gw.written[2] != "3" {
+		t.Fatalf("unexpected written packets: %v", gw.written)
+	}
+}
+
+// TestQueuedWriter_Canceled tests canceling the context while packets are queued.
+func TestQueuedWriter_Canceled(t *testing.T) {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	gw := &gatedWriter{release: make(chan struct{})}
+	qw := newQueuedWriter(ctx, gw, 2)
+
+	for _, data := range []string{"1", "2"} {
+		if err := qw.WritePacket(NewCallDataPacket([]byte(data), false, false, nil)); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	ctxCancel()
+	err := qw.WritePacket(NewCallDataPacket([]byte("3"), false, false, nil))
+	if err != nil && err != context.Canceled {
+		t.Fatalf("expected context.Canceled but got %v", err)
+	}
+
+	close(gw.release)
+	err = qw.Close()
+	if !errors.Is(err, ErrStreamClosed) {
+		t.Fatalf("expected dropped packets error but got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package srpc
+
+import "sync/atomic"
+
+// TeeWriter is a Writer which mirrors all packets to a secondary Writer.
+//
+// Packets are written to the primary first and then to the secondary if the
+// primary write succeeded. By default errors from the secondary are ignored.
+type TeeWriter struct {
+	// primary is the writer to the remote
+	primary Writer
+	// secondary is the writer to mirror packets to
+	secondary Writer
+	// failOnSecondaryErr returns errors from the secondary if set
+	failOnSecondaryErr atomic.Bool
+}
+
+// NewTeeWriter constructs a new TeeWriter.
+func NewTeeWriter(primary, secondary Writer) *TeeWriter {
+	return &TeeWriter{primary: primary, secondary: secondary}
+}
+
+// SetFailOnSecondaryError sets if errors from the secondary should be returned.
+//
+// If false (the default), errors from the secondary are ignored.
+func (w *TeeWriter) SetFailOnSecondaryError(fail bool) {
+	w.failOnSecondaryErr.Store(fail)
+}
+
+// WritePacket writes a packet to the primary and the secondary.
+//
+// The secondary receives a copy of the packet.
+func (w *TeeWriter) WritePacket(p *Packet) error {
+	if err := w.primary.WritePacket(p); err != nil {
+		return err
+	}
+	if err := w.secondary.WritePacket(p.CloneVT()); err != nil && w.failOnSecondaryErr.Load() {
+		return err
+	}
+	return nil
+}
+
+// Close closes the primary and the secondary.
+func (w *TeeWriter) Close() error {
+	err := w.primary.Close()
+	if serr := w.secondary.Close(); serr != nil && err == nil && w.failOnSecondaryErr.Load() {
+		err = serr
+	}
+	return err
+}
+
+// _ is a type assertion
+var _ Writer = ((*TeeWriter)(nil))
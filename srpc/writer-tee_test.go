@@ -0,0 +1,72 @@
+package srpc
+
+import (
+	"errors"
+	"testing"
+)
+
+// failWriter is a Writer which records the written packets or fails with err.
+type failWriter struct {
+	packets []*Packet
+	closed  bool
+	err     error
+}
+
+// WritePacket records the packet and returns err.
+func (w *failWriter) WritePacket(p *Packet) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.packets = append(w.packets, p)
+	return nil
+}
+
+// Close records that the writer was closed and returns err.
+func (w *failWriter) Close() error {
+	w.closed = true
+	return w.err
+}
+
+// TestTeeWriter tests mirroring packets to a secondary writer.
+func TestTeeWriter(t *testing.T) {
+	primary, secondary := &failWriter{}, &failWriter{}
+	tw := NewTeeWriter(primary, secondary)
+	pkt := NewCallDataPacket([]byte("hello"), false, false, nil)
+	if err := tw.WritePacket(pkt); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(primary.packets) != 1 || primary.packets[0] != pkt {
+		t.Fatalf("expected packet written to primary: %v", primary.packets)
+	}
+	if len(secondary.packets) != 1 || secondary.packets[0] == pkt || !secondary.packets[0].EqualVT(pkt) {
+		t.Fatalf("expected copy of packet written to secondary: %v", secondary.packets)
+	}
+
+	// secondary errors are ignored by default
+	errSecondary := errors.New("secondary failed")
+	secondary.err = errSecondary
+	if err := tw.WritePacket(pkt); err != nil {
+		t.Fatalf("expected secondary error to be ignored but got %v", err)
+	}
+	tw.SetFailOnSecondaryError(true)
+	if err := tw.WritePacket(pkt); err != errSecondary {
+		t.Fatalf("expected secondary error but got %v", err)
+	}
+	if err := tw.Close(); err != errSecondary {
+		t.Fatalf("expected secondary close error but got %v", err)
+	}
+	if !primary.closed || !secondary.closed {
+		t.Fatal("expected primary and secondary to be closed")
+	}
+
+	// the secondary is not written if the primary fails
+	errPrimary := errors.New("primary failed")
+	primary.err, secondary.err = errPrimary, nil
+	secondary.packets = nil
+	if err := tw.WritePacket(pkt); err != errPrimary {
+		t.Fatalf("expected primary error but got %v", err)
+	}
+	if len(secondary.packets) != 0 {
+		t.Fatalf("expected no packets written to secondary: %v", secondary.packets)
+	}
+}
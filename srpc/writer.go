@@ -1,5 +1,7 @@
 package srpc
 
+import "context"
+
 // Writer is the interface used to write messages to the remote.
 type Writer interface {
 	// WritePacket writes a packet to the remote.
@@ -7,3 +9,19 @@ type Writer interface {
 	// Close closes the writer.
 	Close() error
 }
+
+// Flusher is implemented by writers which buffer outgoing data.
+type Flusher interface {
+	// Flush writes any buffered data to the transport.
+	//
+	// Returns an error if ctx is canceled or its deadline passes first.
+	Flush(ctx context.Context) error
+}
+
+// flushWriter flushes the writer if it implements Flusher.
+func flushWriter(ctx context.Context, w any) error {
+	if f, ok := w.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package srpctest contains helpers for testing srpc services.
+package srpctest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aperturerobotics/starpc/srpc"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// Direction is the direction of a message in a transcript.
+type Direction int
+
+const (
+	// DirectionSent is a message sent to the remote.
+	DirectionSent Direction = iota
+	// DirectionReceived is a message received from the remote.
+	DirectionReceived
+)
+
+// String returns the name of the direction.
+func (d Direction) String() string {
+	switch d {
+	case DirectionSent:
+		return "sent"
+	case DirectionReceived:
+		return "received"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+// Entry is a message in a stream transcript.
+type Entry struct {
+	// Direction is the direction of the message.
+	Direction Direction
+	// Msg is the message.
+	Msg srpc.Message
+}
+
+// Sent constructs an expected entry for a message sent to the remote.
+func Sent(msg srpc.Message) Entry {
+	return Entry{Direction: DirectionSent, Msg: msg}
+}
+
+// Received constructs an expected entry for a message received from the remote.
+func Received(msg srpc.Message) Entry {
+	return Entry{Direction: DirectionReceived, Msg: msg}
+}
+
+// String formats the entry for test output.
+func (e Entry) String() string {
+	return fmt.Sprintf("%s: %v", e.Direction, e.Msg)
+}
+
+// Recorder is a Stream which records the transcript of the messages.
+//
+// Messages are recorded in order after they were successfully sent or
+// received. The recorded messages are copies: the caller may reuse the
+// messages passed to MsgSend and MsgRecv.
+type Recorder struct {
+	srpc.Stream
+
+	// mtx guards entries
+	mtx sync.Mutex
+	// entries is the transcript
+	entries []Entry
+}
+
+// RecordStream wraps a stream to record the transcript of the messages.
+func RecordStream(strm srpc.Stream) *Recorder {
+	return &Recorder{Stream: strm}
+}
+
+// MsgSend sends the message to the remote and records it.
+func (r *Recorder) MsgSend(msg srpc.Message) error {
+	if err := r.Stream.MsgSend(msg); err != nil {
+		return err
+	}
+	return r.record(DirectionSent, msg)
+}
+
+// MsgRecv receives a message from the remote and records it.
+func (r *Recorder) MsgRecv(msg srpc.Message) error {
+	if err := r.Stream.MsgRecv(msg); err != nil {
+		return err
+	}
+	return r.record(DirectionReceived, msg)
+}
+
+// Transcript returns a snapshot of the recorded messages in order.
+func (r *Recorder) Transcript() []Entry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// Reset clears the recorded messages.
+func (r *Recorder) Reset() {
+	r.mtx.Lock()
+	r.entries = nil
+	r.mtx.Unlock()
+}
+
+// AssertTranscript fails the test if the transcript does not match expected.
+func (r *Recorder) AssertTranscript(t testing.TB, expected ...Entry) {
+	t.Helper()
+	if err := CompareTranscript(r.Transcript(), expected); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// record appends a copy of the message to the transcript.
+func (r *Recorder) record(dir Direction, msg srpc.Message) error {
+	cloned, err := cloneMessage(msg)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	r.entries = append(r.entries, Entry{Direction: dir, Msg: cloned})
+	r.mtx.Unlock()
+	return nil
+}
+
+// CompareTranscript compares the transcript with the expected entries.
+//
+// Messages are compared with proto.Equal if they implement proto.Message.
+// Otherwise compares the encoded messages. Returns an error describing the
+// first difference or nil if the transcripts match.
+func CompareTranscript(actual, expected []Entry) error {
+	for i := 0; i < len(actual) || i < len(expected); i++ {
+		switch {
+		case i >= len(actual):
+			return errors.Errorf("transcript[%d]: missing entry: expected %v", i, expected[i])
+		case i >= len(expected):
+			return errors.Errorf("transcript[%d]: unexpected entry %v", i, actual[i])
+		}
+		act, exp := actual[i], expected[i]
+		if act.Direction != exp.Direction {
+			return errors.Errorf("transcript[%d]: expected %v but got %v", i, exp, act)
+		}
+		equal, err := messagesEqual(act.Msg, exp.Msg)
+		if err != nil {
+			return errors.Wrapf(err, "transcript[%d]", i)
+		}
+		if !equal {
+			return errors.Errorf("transcript[%d]: expected %v but got %v", i, exp, act)
+		}
+	}
+	return nil
+}
+
+// messagesEqual checks if the messages are equal.
+func messagesEqual(a, b srpc.Message) (bool, error) {
+	if a == nil || b == nil {
+		return a == nil && b == nil, nil
+	}
+	pa, aok := a.(proto.Message)
+	pb, bok := b.(proto.Message)
+	if aok && bok {
+		return proto.Equal(pa, pb), nil
+	}
+	aData, err := a.MarshalVT()
+	if err != nil {
+		return false, err
+	}
+	bData, err := b.MarshalVT()
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aData, bData), nil
+}
+
+// cloneMessage copies the message by encoding it to a new message of the same type.
+func cloneMessage(msg srpc.Message) (srpc.Message, error) {
+	data, err := msg.MarshalVT()
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeOf(msg)
+	if typ.Kind() != reflect.Pointer {
+		return nil, errors.Errorf("cannot copy message of type %T", msg)
+	}
+	out, ok := reflect.New(typ.Elem()).Interface().(srpc.Message)
+	if !ok {
+		return nil, errors.Errorf("cannot copy message of type %T", msg)
+	}
+	if err := out.UnmarshalVT(data); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// _ is a type assertion
+var _ srpc.Stream = ((*Recorder)(nil))
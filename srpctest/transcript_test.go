@@ -0,0 +1,58 @@
+package srpctest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aperturerobotics/starpc/echo"
+	"github.com/aperturerobotics/starpc/srpc"
+)
+
+// TestRecordStream tests recording and comparing the transcript of a stream.
+func TestRecordStream(t *testing.T) {
+	ctx := context.Background()
+	mux := srpc.NewMux()
+	if err := echo.SRPCRegisterEchoer(mux, echo.NewEchoServer(mux)); err != nil {
+		t.Fatal(err.Error())
+	}
+	client := srpc.NewClient(srpc.NewServerPipe(srpc.NewServer(mux)))
+
+	strm, err := client.NewStream(ctx, echo.SRPCEchoerServiceID, "EchoBidiStream", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rec := RecordStream(strm)
+	defer rec.Close()
+
+	// the server sends an initial message
+	msg := &echo.EchoMsg{}
+	if err := rec.MsgRecv(msg); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, body := range []string{"hello", "world"} {
+		if err := rec.MsgSend(&echo.EchoMsg{Body: body}); err != nil {
+			t.Fatal(err.Error())
+		}
+		// reuses msg: the transcript contains copies
+		if err := rec.MsgRecv(msg); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	rec.AssertTranscript(t,
+		Received(&echo.EchoMsg{Body: "hello from server"}),
+		Sent(&echo.EchoMsg{Body: "hello"}),
+		Received(&echo.EchoMsg{Body: "hello"}),
+		Sent(&echo.EchoMsg{Body: "world"}),
+		Received(&echo.EchoMsg{Body: "world"}),
+	)
+
+	err = CompareTranscript(rec.Transcript(), []Entry{
+		Received(&echo.EchoMsg{Body: "hello from server"}),
+		Sent(&echo.EchoMsg{Body: "hello"}),
+		Received(&echo.EchoMsg{Body: "other"}),
+	})
+	if err == nil {
+		t.Fatal("expected transcript mismatch")
+	}
+}